@@ -269,6 +269,7 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	m.Handle("/diff", s.middleware(dsh.DiffHandler))
 	m.Handle("/body/", s.middleware(dsh.BodyHandler))
 	m.Handle("/stats/", s.middleware(dsh.StatsHandler))
+	m.Handle("/stats/diff", s.middleware(dsh.StatsDiffHandler))
 	m.Handle("/unpack/", s.middleware(dsh.UnpackHandler))
 
 	remClientH := NewRemoteClientHandlers(s.Instance, cfg.API.ReadOnly)
@@ -301,6 +302,9 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	sqlh := NewSQLHandlers(s.Instance, cfg.API.ReadOnly)
 	m.Handle("/sql", s.middleware(sqlh.QueryHandler("/sql")))
 
+	th := NewTransformHandlers(s.Instance, cfg.API.ReadOnly)
+	m.Handle("/apply", s.middleware(th.ApplyHandler("/apply")))
+
 	rh := NewRootHandler(dsh, ph)
 	m.Handle("/", s.datasetRefMiddleware(s.middleware(rh.Handler)))
 
@@ -176,6 +176,18 @@ func (h *DatasetHandlers) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// StatsDiffHandler compares the stats of two dataset versions
+func (h *DatasetHandlers) StatsDiffHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.statsDiffHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // UnpackHandler unpacks a zip file and sends it back as json
 func (h *DatasetHandlers) UnpackHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -257,6 +269,10 @@ func extensionToMimeType(ext string) string {
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	case ".zip":
 		return "application/zip"
+	case ".parquet":
+		return "application/vnd.apache.parquet"
+	case ".arrow":
+		return "application/vnd.apache.arrow.stream"
 	default:
 		return ""
 	}
@@ -353,6 +369,8 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 			LeftSide:  r.FormValue("left_path"),
 			RightSide: r.FormValue("right_path"),
 			Selector:  r.FormValue("selector"),
+			Offset:    util.ReqParamInt(r, "offset", 0),
+			Limit:     util.ReqParamInt(r, "limit", 0),
 		}
 	}
 
@@ -597,6 +615,8 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 		Limit:    listParams.Limit,
 		Offset:   listParams.Offset,
 		All:      r.FormValue("all") == "true" && !readOnly,
+
+		ExperimentalFormats: r.FormValue("experimental_formats") == "true",
 	}
 
 	if !readOnly {
@@ -686,6 +706,25 @@ func (h DatasetHandlers) statsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h DatasetHandlers) statsDiffHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.StatsDiffParams{
+		LeftRef:  r.FormValue("left_path"),
+		RightRef: r.FormValue("right_path"),
+	}
+	res := &lib.StatsDiffResponse{}
+	if err := h.StatsDiff(p, res); err != nil {
+		if err == repo.ErrNoHistory {
+			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := util.WriteResponse(w, res.Diff); err != nil {
+		log.Infof("error writing response: %s", err.Error())
+	}
+}
+
 func (h DatasetHandlers) unpackHandler(w http.ResponseWriter, r *http.Request, postData []byte) {
 	contents, err := archive.UnzipGetContents(postData)
 	if err != nil {
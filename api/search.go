@@ -38,6 +38,8 @@ func (h *SearchHandlers) searchHandler(w http.ResponseWriter, r *http.Request) {
 		QueryString: r.FormValue("q"),
 		Limit:       listParams.Limit,
 		Offset:      listParams.Offset,
+		Filters:     r.URL.Query()["filter"],
+		Local:       r.FormValue("local") == "true",
 	}
 
 	if r.Header.Get("Content-Type") == "application/json" {
@@ -2,10 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/base/columnar"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/sql"
 )
 
 // SQLHandlers connects HTTP requests to the FSI subsystem
@@ -60,11 +63,19 @@ func (h *SQLHandlers) queryHandler(routePrefix string) http.HandlerFunc {
 			}
 		default:
 			p.Query = r.FormValue("query")
+			p.Save = r.FormValue("save")
+			p.ExperimentalFormats = r.FormValue("experimental_formats") == "true"
 			if format := r.FormValue("output_format"); format != "" {
 				p.OutputFormat = format
 			}
 		}
 
+		// saving adds a version to a dataset, which GET requests must never do
+		if _, _, create := sql.ParseCreateDataset(p.Query); r.Method != http.MethodPost && (p.Save != "" || create) {
+			util.WriteErrResponse(w, http.StatusMethodNotAllowed, fmt.Errorf("saving query results requires a POST request"))
+			return
+		}
+
 		var res []byte
 		if err := h.Exec(p, &res); err != nil {
 			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
@@ -76,7 +87,10 @@ func (h *SQLHandlers) queryHandler(routePrefix string) http.HandlerFunc {
 			return
 		}
 
-		// TODO (b5) - set Content-Type header based on output format
+		if _, _, create := sql.ParseCreateDataset(p.Query); columnar.IsFormat(p.OutputFormat) && p.Save == "" && !create {
+			w.Header().Set("Content-Type", extensionToMimeType("."+p.OutputFormat))
+		}
+		// TODO (b5) - set Content-Type header for other output formats
 		w.Write(res)
 	}
 }
@@ -2,6 +2,10 @@ package api
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -27,3 +31,48 @@ func TestSQLHandler(t *testing.T) {
 	}
 	runHandlerTestCases(t, "sql", h.QueryHandler("/sql"), jsonCases, true)
 }
+
+func TestSQLHandlerSaveRequiresPost(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inst := newTestInstanceWithProfileFromNode(ctx, node)
+	h := NewSQLHandlers(inst, false)
+
+	query := "select * from me/movies m order by m.title limit 1"
+	bad := []string{
+		"/sql?" + url.Values{"query": {query}, "save": {"me/top_movie"}}.Encode(),
+		"/sql?" + url.Values{"query": {"CREATE DATASET me/top_movie AS " + query}}.Encode(),
+	}
+	for _, u := range bad {
+		w := httptest.NewRecorder()
+		h.QueryHandler("/sql")(w, httptest.NewRequest("GET", u, nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("GET %s: expected status %d, got %d: %s", u, http.StatusMethodNotAllowed, w.Code, w.Body.String())
+		}
+	}
+
+	jsonBody := `{"query":"select * from me/movies m order by m.title limit 1","save":"me/top_movie"}`
+	req := httptest.NewRequest("GET", "/sql", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.QueryHandler("/sql")(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET with json body: expected status %d, got %d: %s", http.StatusMethodNotAllowed, w.Code, w.Body.String())
+	}
+
+	form := url.Values{"query": {query}, "save": {"me/top_movie"}}
+	req = httptest.NewRequest("POST", "/sql", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.QueryHandler("/sql")(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST save: expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "top_movie") {
+		t.Errorf("expected response to describe the saved dataset, got: %s", w.Body.String())
+	}
+}
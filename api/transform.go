@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// TransformHandlers connects HTTP requests to transform methods
+type TransformHandlers struct {
+	lib.TransformMethods
+	ReadOnly bool
+}
+
+// NewTransformHandlers creates TransformHandlers from a qri Instance
+func NewTransformHandlers(inst *lib.Instance, readOnly bool) TransformHandlers {
+	return TransformHandlers{
+		TransformMethods: *lib.NewTransformMethods(inst),
+		ReadOnly:         readOnly,
+	}
+}
+
+// ApplyHandler runs a transform script against a dataset without saving
+func (h *TransformHandlers) ApplyHandler(routePrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.ReadOnly {
+			readOnlyResponse(w, routePrefix)
+			return
+		}
+
+		switch r.Method {
+		case "OPTIONS":
+			util.EmptyOkHandler(w, r)
+		case "POST":
+			h.applyHandler(w, r)
+		default:
+			util.NotFoundHandler(w, r)
+		}
+	}
+}
+
+func (h *TransformHandlers) applyHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.ApplyParams{}
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		p.Refstr = r.FormValue("ref")
+		p.Script = r.FormValue("script")
+		if size := r.FormValue("preview_size"); size != "" {
+			n, err := strconv.Atoi(size)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			p.PreviewSize = n
+		}
+		if f, _, err := r.FormFile("file"); err == nil {
+			data, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			p.Script = string(data)
+		}
+	}
+	// scripts over HTTP are sent as source text, never read from the server's
+	// filesystem
+	p.ScriptPath = ""
+
+	res := &lib.ApplyResult{}
+	if err := h.Apply(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestApplyHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inst := newTestInstanceWithProfileFromNode(ctx, node)
+	h := NewTransformHandlers(inst, false)
+	handler := h.ApplyHandler("/apply")
+
+	script := `
+def transform(ds, ctx):
+	ds.set_meta("title", "applied")
+`
+	form := url.Values{"ref": {"me/movies"}, "script": {script}, "preview_size": {"2"}}
+	req := httptest.NewRequest("POST", "/apply", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	res := struct {
+		Data struct {
+			Data struct {
+				Meta struct {
+					Title string `json:"title"`
+				} `json:"meta"`
+				Body []interface{} `json:"body"`
+			} `json:"data"`
+			Diff struct {
+				Diff []interface{} `json:"diff"`
+			} `json:"diff"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Data.Meta.Title != "applied" {
+		t.Errorf("expected meta title %q, got %q", "applied", res.Data.Data.Meta.Title)
+	}
+	if len(res.Data.Data.Body) != 2 {
+		t.Errorf("expected body preview of 2 entries, got %d", len(res.Data.Data.Body))
+	}
+	if len(res.Data.Diff.Diff) == 0 {
+		t.Error("expected a diff against head")
+	}
+
+	req = httptest.NewRequest("POST", "/apply", bytes.NewBufferString(`{"Refstr":"me/movies"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected applying without a script to fail with status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	ro := NewTransformHandlers(inst, true)
+	w = httptest.NewRecorder()
+	ro.ApplyHandler("/apply")(w, httptest.NewRequest("POST", "/apply", nil))
+	if w.Code == http.StatusOK {
+		t.Error("expected read-only handler to refuse apply")
+	}
+}
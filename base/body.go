@@ -9,6 +9,7 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/columnar"
 )
 
 // ReadBody grabs some or all of a dataset's body, writing an output in the desired format
@@ -42,6 +43,46 @@ func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.Forma
 	return data, nil
 }
 
+// ReadColumnarBody grabs some or all of a tabular dataset's body, writing an
+// output in a columnar format like parquet or arrow. The dataset structure
+// must have a tabular schema, which sets the type of each column
+func ReadColumnarBody(ds *dataset.Dataset, format string, limit, offset int, all bool) ([]byte, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("can't load body from a nil dataset")
+	}
+
+	file := ds.BodyFile()
+	if file == nil {
+		return nil, fmt.Errorf("no body file to read")
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := columnar.NewEntryWriter(format, ds.Structure, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	rr, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err)
+	}
+	if !all {
+		rr = &dsio.PagedReader{
+			Reader: rr,
+			Limit:  limit,
+			Offset: offset,
+		}
+	}
+
+	if err := dsio.Copy(rr, w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error closing %s writer: %s", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
 // ReadEntries reads entries and returns them as a native go array or map
 func ReadEntries(reader dsio.EntryReader) (interface{}, error) {
 	obj := make(map[string]interface{})
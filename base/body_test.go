@@ -38,6 +38,47 @@ func TestReadBody(t *testing.T) {
 	}
 }
 
+func TestReadColumnarBody(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+
+	ds, err := ReadDataset(ctx, r, ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = OpenDataset(ctx, r.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadColumnarBody(ds, "parquet", 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Errorf("expected parquet output to start & end with magic bytes")
+	}
+	if !bytes.Contains(data, []byte("new york")) {
+		t.Errorf("expected parquet output to contain the selected row")
+	}
+
+	if err = OpenDataset(ctx, r.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ReadColumnarBody(ds, "arrow", 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eos := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	if !bytes.HasPrefix(data, eos[:4]) || !bytes.HasSuffix(data, eos) {
+		t.Errorf("expected arrow output to be a stream of messages ending in an end of stream marker")
+	}
+
+	if _, err := ReadColumnarBody(ds, "csv", 0, 0, true); err == nil {
+		t.Error("expected non-columnar format to error")
+	}
+}
+
 func TestConvertBodyFormat(t *testing.T) {
 	jsonStructure := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
 	csvStructure := &dataset.Structure{Format: "csv", Schema: dsfs.BaseTabularSchema}
@@ -0,0 +1,273 @@
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// arrow IPC metadata values, as defined in the Schema.fbs & Message.fbs
+// flatbuffer schemas of the arrow format specification
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+
+	arrowPrecisionDouble = 2
+)
+
+// arrowContinuation prefixes every message in an arrow IPC stream
+const arrowContinuation = 0xFFFFFFFF
+
+// arrowWriter writes an arrow IPC stream: a schema message followed by one
+// record batch message per batch of rows, and an end of stream marker
+type arrowWriter struct {
+	cols        []Column
+	w           io.Writer
+	batch       *batch
+	wroteSchema bool
+}
+
+func newArrowWriter(cols []Column, w io.Writer) *arrowWriter {
+	return &arrowWriter{
+		cols:  cols,
+		w:     w,
+		batch: newBatch(cols),
+	}
+}
+
+// WriteRow adds a row to the stream
+func (aw *arrowWriter) WriteRow(row []interface{}) error {
+	aw.batch.add(row)
+	if aw.batch.full() {
+		return aw.flush()
+	}
+	return nil
+}
+
+// Close writes any buffered rows & the end of stream marker
+func (aw *arrowWriter) Close() error {
+	if err := aw.flush(); err != nil {
+		return err
+	}
+	eos := make([]byte, 8)
+	binary.LittleEndian.PutUint32(eos, arrowContinuation)
+	_, err := aw.w.Write(eos)
+	return err
+}
+
+func (aw *arrowWriter) flush() error {
+	if !aw.wroteSchema {
+		if err := writeArrowMessage(aw.w, aw.schemaMessage(), nil); err != nil {
+			return err
+		}
+		aw.wroteSchema = true
+	}
+	if aw.batch.rows == 0 {
+		return nil
+	}
+
+	meta, body, err := aw.recordBatchMessage()
+	if err != nil {
+		return err
+	}
+	if err := writeArrowMessage(aw.w, meta, body); err != nil {
+		return err
+	}
+	aw.batch.reset()
+	return nil
+}
+
+// schemaMessage encodes the column definitions as a schema message
+func (aw *arrowWriter) schemaMessage() []byte {
+	b := flatbuffers.NewBuilder(1024)
+
+	fields := make([]flatbuffers.UOffsetT, len(aw.cols))
+	for i, col := range aw.cols {
+		name := b.CreateString(col.Title)
+		typeType, typ := arrowType(b, col.Type)
+		// readers expect a children vector, even for types without children
+		b.StartVector(4, 0, 4)
+		children := b.EndVector(0)
+
+		b.StartObject(7)
+		b.PrependUOffsetTSlot(0, name, 0)
+		b.PrependBoolSlot(1, true, false)
+		b.PrependByteSlot(2, typeType, 0)
+		b.PrependUOffsetTSlot(3, typ, 0)
+		b.PrependUOffsetTSlot(5, children, 0)
+		fields[i] = b.EndObject()
+	}
+
+	b.StartVector(4, len(fields), 4)
+	for i := len(fields) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(fields[i])
+	}
+	fieldsVec := b.EndVector(len(fields))
+
+	b.StartObject(4)
+	b.PrependUOffsetTSlot(1, fieldsVec, 0)
+	schema := b.EndObject()
+
+	return finishArrowMessage(b, arrowHeaderSchema, schema, 0)
+}
+
+// arrowType builds the type table for a column type, returning the type union
+// tag & offset
+func arrowType(b *flatbuffers.Builder, t Type) (byte, flatbuffers.UOffsetT) {
+	switch t {
+	case Int:
+		b.StartObject(2)
+		b.PrependInt32Slot(0, 64, 0)
+		b.PrependBoolSlot(1, true, false)
+		return arrowTypeInt, b.EndObject()
+	case Float:
+		b.StartObject(1)
+		b.PrependInt16Slot(0, arrowPrecisionDouble, 0)
+		return arrowTypeFloatingPoint, b.EndObject()
+	case Bool:
+		b.StartObject(0)
+		return arrowTypeBool, b.EndObject()
+	}
+	b.StartObject(0)
+	return arrowTypeUtf8, b.EndObject()
+}
+
+// recordBatchMessage encodes the buffered batch as record batch metadata &
+// a message body holding the column buffers
+func (aw *arrowWriter) recordBatchMessage() ([]byte, []byte, error) {
+	var (
+		body    = &bytes.Buffer{}
+		nodes   [][2]int64
+		buffers [][2]int64
+	)
+
+	// buffers are aligned to 8 byte boundaries within the body
+	addBuffer := func(data []byte) {
+		offset := int64(body.Len())
+		body.Write(data)
+		body.Write(make([]byte, padding(len(data), 8)))
+		buffers = append(buffers, [2]int64{offset, int64(len(data))})
+	}
+
+	for i, c := range aw.batch.cols {
+		nodes = append(nodes, [2]int64{int64(c.len()), int64(c.nulls)})
+
+		// the validity buffer may be omitted when a column has no nulls
+		if c.nulls > 0 {
+			addBuffer(bitmap(c.valid))
+		} else {
+			addBuffer(nil)
+		}
+
+		switch c.typ {
+		case Int:
+			data := make([]byte, 8*len(c.ints))
+			for j, v := range c.ints {
+				binary.LittleEndian.PutUint64(data[8*j:], uint64(v))
+			}
+			addBuffer(data)
+		case Float:
+			data := make([]byte, 8*len(c.floats))
+			for j, v := range c.floats {
+				binary.LittleEndian.PutUint64(data[8*j:], math.Float64bits(v))
+			}
+			addBuffer(data)
+		case Bool:
+			addBuffer(bitmap(c.bools))
+		default:
+			offsets := make([]byte, 4*(len(c.strs)+1))
+			data := &bytes.Buffer{}
+			for j, s := range c.strs {
+				data.WriteString(s)
+				if data.Len() > math.MaxInt32 {
+					return nil, nil, fmt.Errorf("column %q: too much string data for one record batch", aw.cols[i].Title)
+				}
+				binary.LittleEndian.PutUint32(offsets[4*(j+1):], uint32(data.Len()))
+			}
+			addBuffer(offsets)
+			addBuffer(data.Bytes())
+		}
+	}
+
+	b := flatbuffers.NewBuilder(1024)
+
+	b.StartVector(16, len(buffers), 8)
+	for i := len(buffers) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(buffers[i][1])
+		b.PrependInt64(buffers[i][0])
+	}
+	buffersVec := b.EndVector(len(buffers))
+
+	b.StartVector(16, len(nodes), 8)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(nodes[i][1])
+		b.PrependInt64(nodes[i][0])
+	}
+	nodesVec := b.EndVector(len(nodes))
+
+	b.StartObject(4)
+	b.PrependInt64Slot(0, int64(aw.batch.rows), 0)
+	b.PrependUOffsetTSlot(1, nodesVec, 0)
+	b.PrependUOffsetTSlot(2, buffersVec, 0)
+	recordBatch := b.EndObject()
+
+	return finishArrowMessage(b, arrowHeaderRecordBatch, recordBatch, int64(body.Len())), body.Bytes(), nil
+}
+
+// finishArrowMessage wraps a message header in a message table
+func finishArrowMessage(b *flatbuffers.Builder, headerType byte, header flatbuffers.UOffsetT, bodyLength int64) []byte {
+	b.StartObject(5)
+	b.PrependInt16Slot(0, arrowMetadataV5, 0)
+	b.PrependByteSlot(1, headerType, 0)
+	b.PrependUOffsetTSlot(2, header, 0)
+	b.PrependInt64Slot(3, bodyLength, 0)
+	b.Finish(b.EndObject())
+	return b.FinishedBytes()
+}
+
+// writeArrowMessage writes an encapsulated message: the continuation marker,
+// the length of the metadata, the metadata padded to an 8 byte boundary, and
+// the message body
+func writeArrowMessage(w io.Writer, meta, body []byte) error {
+	pad := padding(len(meta), 8)
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, arrowContinuation)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)+pad))
+
+	for _, p := range [][]byte{prefix, meta, make([]byte, pad), body} {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// padding gives the number of bytes needed to pad n bytes to a multiple of
+// align
+func padding(n, align int) int {
+	return (align - n%align) % align
+}
+
+// bitmap packs booleans into bits, least significant bit first
+func bitmap(bits []bool) []byte {
+	data := make([]byte, (len(bits)+7)/8)
+	for i, set := range bits {
+		if set {
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return data
+}
@@ -0,0 +1,216 @@
+package columnar
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestArrowWriter(t *testing.T) {
+	data := writeRows(t, ArrowFormat, testColumns, testRows)
+	cols, rows, batches := readArrow(t, data)
+	if diff := cmp.Diff(testColumns, cols); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(testRowsExpect, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+	if batches != 1 {
+		t.Errorf("expected 1 record batch, got %d", batches)
+	}
+}
+
+func TestArrowWriterEmpty(t *testing.T) {
+	data := writeRows(t, ArrowFormat, testColumns, nil)
+	cols, rows, batches := readArrow(t, data)
+	if diff := cmp.Diff(testColumns, cols); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	if len(rows) != 0 || batches != 0 {
+		t.Errorf("expected no rows or record batches, got %d rows in %d batches", len(rows), batches)
+	}
+}
+
+func TestArrowWriterBatches(t *testing.T) {
+	cols := []Column{{Title: "n", Type: Int}}
+	expect := manyRows()
+	_, rows, batches := readArrow(t, writeRows(t, ArrowFormat, cols, expect))
+	if batches != 2 {
+		t.Errorf("expected 2 record batches, got %d", batches)
+	}
+	if diff := cmp.Diff(expect, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// fbTable wraps a flatbuffer table with accessors by field index
+type fbTable struct {
+	flatbuffers.Table
+}
+
+func (t fbTable) offset(field int) flatbuffers.UOffsetT {
+	return flatbuffers.UOffsetT(t.Offset(flatbuffers.VOffsetT(4 + 2*field)))
+}
+
+func (t fbTable) table(field int) fbTable {
+	sub := fbTable{}
+	t.Union(&sub.Table, t.offset(field))
+	return sub
+}
+
+func (t fbTable) vectorLen(field int) int {
+	if o := t.offset(field); o != 0 {
+		return t.VectorLen(o)
+	}
+	return -1
+}
+
+func (t fbTable) vectorTable(field, i int) fbTable {
+	x := t.Vector(t.offset(field)) + flatbuffers.UOffsetT(i*4)
+	return fbTable{flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(x)}}
+}
+
+// vectorStruct gives the two int64 fields of a 16 byte struct in a vector
+func (t fbTable) vectorStruct(field, i int) (int64, int64) {
+	x := t.Vector(t.offset(field)) + flatbuffers.UOffsetT(i*16)
+	return t.GetInt64(x), t.GetInt64(x + 8)
+}
+
+// readArrow decodes an arrow IPC stream written by arrowWriter, returning the
+// columns, rows of values & number of record batches
+func readArrow(t *testing.T, data []byte) ([]Column, [][]interface{}, int) {
+	t.Helper()
+	var (
+		cols    []Column
+		rows    [][]interface{}
+		batches int
+	)
+
+	for {
+		if len(data) < 8 || binary.LittleEndian.Uint32(data) != arrowContinuation {
+			t.Fatal("expected message to start with a continuation marker")
+		}
+		metaLen := int(binary.LittleEndian.Uint32(data[4:]))
+		if metaLen == 0 {
+			if len(data) != 8 {
+				t.Errorf("expected stream to end after end of stream marker, %d bytes remain", len(data)-8)
+			}
+			break
+		}
+		if (8+metaLen)%8 != 0 {
+			t.Errorf("expected metadata to be padded to 8 bytes, got length %d", metaLen)
+		}
+		meta := data[8 : 8+metaLen]
+		msg := fbTable{flatbuffers.Table{Bytes: meta, Pos: flatbuffers.GetUOffsetT(meta)}}
+		if v := msg.GetInt16Slot(4, 0); v != arrowMetadataV5 {
+			t.Errorf("expected metadata version %d, got %d", arrowMetadataV5, v)
+		}
+		bodyLen := int(msg.GetInt64Slot(10, 0))
+		body := data[8+metaLen : 8+metaLen+bodyLen]
+		data = data[8+metaLen+bodyLen:]
+		header := msg.table(2)
+
+		switch msg.GetByteSlot(6, 0) {
+		case arrowHeaderSchema:
+			if cols != nil {
+				t.Fatal("unexpected second schema message")
+			}
+			cols = []Column{}
+			for i := 0; i < header.vectorLen(1); i++ {
+				field := header.vectorTable(1, i)
+				col := Column{Title: string(field.ByteVector(field.offset(0) + field.Pos))}
+				if !field.GetBoolSlot(6, false) {
+					t.Errorf("expected field %q to be nullable", col.Title)
+				}
+				if field.vectorLen(5) != 0 {
+					t.Errorf("expected field %q to have an empty children vector", col.Title)
+				}
+				typ := field.table(3)
+				switch field.GetByteSlot(8, 0) {
+				case arrowTypeInt:
+					if typ.GetInt32Slot(4, 0) != 64 || !typ.GetBoolSlot(6, false) {
+						t.Errorf("expected field %q to be a signed 64 bit integer", col.Title)
+					}
+					col.Type = Int
+				case arrowTypeFloatingPoint:
+					if typ.GetInt16Slot(4, 0) != arrowPrecisionDouble {
+						t.Errorf("expected field %q to have double precision", col.Title)
+					}
+					col.Type = Float
+				case arrowTypeBool:
+					col.Type = Bool
+				case arrowTypeUtf8:
+					col.Type = String
+				default:
+					t.Fatalf("unexpected type for field %q", col.Title)
+				}
+				cols = append(cols, col)
+			}
+
+		case arrowHeaderRecordBatch:
+			batches++
+			n := int(header.GetInt64Slot(4, 0))
+			batchRows := make([][]interface{}, n)
+			for i := range batchRows {
+				batchRows[i] = make([]interface{}, len(cols))
+			}
+
+			buffer := 0
+			nextBuffer := func() []byte {
+				offset, length := header.vectorStruct(2, buffer)
+				buffer++
+				if offset%8 != 0 {
+					t.Errorf("expected buffer %d to be aligned to 8 bytes", buffer)
+				}
+				return body[offset : offset+length]
+			}
+			isSet := func(bits []byte, i int) bool {
+				return bits[i/8]&(1<<uint(i%8)) != 0
+			}
+
+			for i, col := range cols {
+				length, nulls := header.vectorStruct(1, i)
+				if int(length) != n {
+					t.Errorf("expected column %q to have length %d, got %d", col.Title, n, length)
+				}
+				validity := nextBuffer()
+				if nulls == 0 && len(validity) != 0 {
+					t.Errorf("expected column %q without nulls to omit the validity buffer", col.Title)
+				}
+
+				values := nextBuffer()
+				var strData []byte
+				if col.Type == String {
+					strData = nextBuffer()
+				}
+
+				for j := range batchRows {
+					if nulls > 0 && !isSet(validity, j) {
+						continue
+					}
+					switch col.Type {
+					case Int:
+						batchRows[j][i] = int64(binary.LittleEndian.Uint64(values[8*j:]))
+					case Float:
+						batchRows[j][i] = math.Float64frombits(binary.LittleEndian.Uint64(values[8*j:]))
+					case Bool:
+						batchRows[j][i] = isSet(values, j)
+					default:
+						start := binary.LittleEndian.Uint32(values[4*j:])
+						end := binary.LittleEndian.Uint32(values[4*(j+1):])
+						batchRows[j][i] = string(strData[start:end])
+					}
+				}
+			}
+			rows = append(rows, batchRows...)
+
+		default:
+			t.Fatal("unexpected message type")
+		}
+	}
+
+	return cols, rows, batches
+}
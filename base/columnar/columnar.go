@@ -0,0 +1,372 @@
+// Package columnar writes tabular data in column-oriented formats used by
+// analytics tooling: Apache Parquet files & Apache Arrow IPC streams. Unlike
+// CSV, both formats carry column types, so typed values survive the trip into
+// tools like pandas & Spark. Both formats are experimental, callers must opt in
+// before writing them
+package columnar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const (
+	// ParquetFormat is the format name for Apache Parquet files
+	ParquetFormat = "parquet"
+	// ArrowFormat is the format name for Apache Arrow IPC streams
+	ArrowFormat = "arrow"
+)
+
+// batchSize is the number of rows buffered before they're written as a
+// parquet row group or arrow record batch
+const batchSize = 64 * 1024
+
+// ErrExperimental is returned when a columnar format is used without enabling
+// experimental formats. The encoders here are written for qri, and haven't
+// been checked against other parquet & arrow readers
+var ErrExperimental = fmt.Errorf("parquet & arrow formats are experimental and must be enabled to use")
+
+// IsFormat returns true if format names a columnar format
+func IsFormat(format string) bool {
+	return format == ParquetFormat || format == ArrowFormat
+}
+
+// Type enumerates the column types columnar writers support
+type Type int
+
+const (
+	// String is a column of UTF-8 text. Values of any type can be written to
+	// string columns
+	String Type = iota
+	// Int is a column of 64 bit signed integers
+	Int
+	// Float is a column of 64 bit floating point numbers
+	Float
+	// Bool is a column of booleans
+	Bool
+)
+
+// String implements the stringer interface for Type
+func (t Type) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	}
+	return "string"
+}
+
+// Column describes a column in a table
+type Column struct {
+	Title string
+	Type  Type
+}
+
+// ColumnsFromSchema creates columns from a tabular JSON schema. Columns with
+// a single JSON schema type (ignoring "null") map to int, float & bool
+// columns. All other columns are strings
+func ColumnsFromSchema(sch map[string]interface{}) ([]Column, error) {
+	tcols, _, err := tabular.ColumnsFromJSONSchema(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]Column, len(tcols))
+	for i, tc := range tcols {
+		cols[i].Title = tc.Title
+		if tc.Type == nil {
+			continue
+		}
+
+		var types []string
+		for _, t := range *tc.Type {
+			if t != "null" {
+				types = append(types, t)
+			}
+		}
+		if len(types) != 1 {
+			continue
+		}
+		switch types[0] {
+		case "integer":
+			cols[i].Type = Int
+		case "number":
+			cols[i].Type = Float
+		case "boolean":
+			cols[i].Type = Bool
+		}
+	}
+	return cols, nil
+}
+
+// Writer writes rows of values to a columnar output. Rows are buffered &
+// written in batches, Close must be called to write any remaining rows
+type Writer interface {
+	// WriteRow adds a row to the output. Values are converted to the type of
+	// their column, values that can't be converted are written as nulls
+	WriteRow(row []interface{}) error
+	// Close flushes all buffered rows & finalizes the output
+	Close() error
+}
+
+// NewWriter creates a writer for the named columnar format
+func NewWriter(format string, cols []Column, w io.Writer) (Writer, error) {
+	switch format {
+	case ParquetFormat:
+		return newParquetWriter(cols, w), nil
+	case ArrowFormat:
+		return newArrowWriter(cols, w), nil
+	}
+	return nil, fmt.Errorf("unsupported columnar format %q", format)
+}
+
+// EntryWriter adapts a columnar writer to the dsio.EntryWriter interface,
+// for writing the body of a tabular dataset
+type EntryWriter struct {
+	st  *dataset.Structure
+	w   Writer
+	row []interface{}
+}
+
+var _ dsio.EntryWriter = (*EntryWriter)(nil)
+
+// NewEntryWriter creates an entry writer for the named columnar format. The
+// columns are read from the schema of st, which must describe a table
+func NewEntryWriter(format string, st *dataset.Structure, w io.Writer) (*EntryWriter, error) {
+	if st == nil || st.Schema == nil {
+		return nil, fmt.Errorf("%s output requires a structure with a schema", format)
+	}
+	cols, err := ColumnsFromSchema(st.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s output requires a tabular body: %w", format, err)
+	}
+	cw, err := NewWriter(format, cols, w)
+	if err != nil {
+		return nil, err
+	}
+	return &EntryWriter{st: st, w: cw, row: make([]interface{}, len(cols))}, nil
+}
+
+// Structure gives the structure being written
+func (w *EntryWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one row of the body. Entry values must be arrays
+func (w *EntryWriter) WriteEntry(ent dsio.Entry) error {
+	vals, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("entry %d: expected an array value, got %T", ent.Index, ent.Value)
+	}
+	// pad or truncate rows that don't match the number of columns
+	for i := range w.row {
+		w.row[i] = nil
+		if i < len(vals) {
+			w.row[i] = vals[i]
+		}
+	}
+	return w.w.WriteRow(w.row)
+}
+
+// Close finalizes the writer
+func (w *EntryWriter) Close() error {
+	return w.w.Close()
+}
+
+// columnData buffers the values of a column for a batch of rows. Only values
+// for the column's type are populated. Null entries hold zero values
+type columnData struct {
+	typ    Type
+	valid  []bool
+	nulls  int
+	ints   []int64
+	floats []float64
+	bools  []bool
+	strs   []string
+}
+
+func newColumnData(typ Type) *columnData {
+	return &columnData{typ: typ}
+}
+
+// len gives the number of values in the column, including nulls
+func (c *columnData) len() int {
+	return len(c.valid)
+}
+
+// reset empties the column, keeping allocated space
+func (c *columnData) reset() {
+	c.valid = c.valid[:0]
+	c.nulls = 0
+	c.ints = c.ints[:0]
+	c.floats = c.floats[:0]
+	c.bools = c.bools[:0]
+	c.strs = c.strs[:0]
+}
+
+// append adds a value to the column, converting it to the column type
+func (c *columnData) append(v interface{}) {
+	ok := v != nil
+	switch c.typ {
+	case Int:
+		var i int64
+		if ok {
+			i, ok = toInt(v)
+		}
+		c.ints = append(c.ints, i)
+	case Float:
+		var f float64
+		if ok {
+			f, ok = toFloat(v)
+		}
+		c.floats = append(c.floats, f)
+	case Bool:
+		var b bool
+		if ok {
+			b, ok = toBool(v)
+		}
+		c.bools = append(c.bools, b)
+	default:
+		var s string
+		if ok {
+			s, ok = toString(v)
+			// both formats require string columns to be valid UTF-8
+			s = strings.ToValidUTF8(s, "\uFFFD")
+		}
+		c.strs = append(c.strs, s)
+	}
+	c.valid = append(c.valid, ok)
+	if !ok {
+		c.nulls++
+	}
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int64:
+		return x, true
+	case int32:
+		return int64(x), true
+	case uint64:
+		if x <= math.MaxInt64 {
+			return int64(x), true
+		}
+	case float64:
+		if x == math.Trunc(x) && x >= math.MinInt64 && x < math.MaxInt64 {
+			return int64(x), true
+		}
+	case string:
+		i, err := strconv.ParseInt(x, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case string:
+		b, err := strconv.ParseBool(x)
+		return b, err == nil
+	}
+	return false, false
+}
+
+func toString(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	case map[string]interface{}, []interface{}:
+		// nested values are written as JSON text
+		data, err := json.Marshal(x)
+		return string(data), err == nil
+	}
+	return fmt.Sprint(v), true
+}
+
+// batch buffers rows as columns
+type batch struct {
+	cols []*columnData
+	rows int
+}
+
+func newBatch(cols []Column) *batch {
+	b := &batch{cols: make([]*columnData, len(cols))}
+	for i, col := range cols {
+		b.cols[i] = newColumnData(col.Type)
+	}
+	return b
+}
+
+// add appends a row to the batch. Missing values are nulls & extra values are
+// ignored
+func (b *batch) add(row []interface{}) {
+	for i, c := range b.cols {
+		var v interface{}
+		if i < len(row) {
+			v = row[i]
+		}
+		c.append(v)
+	}
+	b.rows++
+}
+
+func (b *batch) full() bool {
+	return b.rows >= batchSize
+}
+
+func (b *batch) reset() {
+	for _, c := range b.cols {
+		c.reset()
+	}
+	b.rows = 0
+}
+
+// countWriter tracks the number of bytes written to a writer
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,169 @@
+package columnar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+var testColumns = []Column{
+	{Title: "name", Type: String},
+	{Title: "count", Type: Int},
+	{Title: "score", Type: Float},
+	{Title: "ok", Type: Bool},
+}
+
+var testRows = [][]interface{}{
+	{"a", 1, 1.5, true},
+	{"b", nil, "2.5", false},
+	{nil, "3", 3, "true"},
+	{"d", "not a number", nil, nil},
+	{"é", int64(-5), -0.5, true},
+	{map[string]interface{}{"a": 1}, float64(7), int64(2)},
+}
+
+// testRowsExpect is testRows after conversion to column types
+var testRowsExpect = [][]interface{}{
+	{"a", int64(1), 1.5, true},
+	{"b", nil, 2.5, false},
+	{nil, int64(3), 3.0, true},
+	{"d", nil, nil, nil},
+	{"é", int64(-5), -0.5, true},
+	{`{"a":1}`, int64(7), 2.0, nil},
+}
+
+func TestColumnsFromSchema(t *testing.T) {
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "a", "type": "string"},
+				map[string]interface{}{"title": "b", "type": "integer"},
+				map[string]interface{}{"title": "c", "type": "number"},
+				map[string]interface{}{"title": "d", "type": "boolean"},
+				map[string]interface{}{"title": "e", "type": []interface{}{"integer", "null"}},
+				map[string]interface{}{"title": "f", "type": []interface{}{"integer", "string"}},
+				map[string]interface{}{"title": "g", "type": "object"},
+			},
+		},
+	}
+
+	got, err := ColumnsFromSchema(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Column{
+		{"a", String},
+		{"b", Int},
+		{"c", Float},
+		{"d", Bool},
+		{"e", Int},
+		{"f", String},
+		{"g", String},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ColumnsFromSchema(map[string]interface{}{"type": "string"}); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	if _, err := NewWriter("csv", testColumns, &bytes.Buffer{}); err == nil {
+		t.Error("expected unsupported format to error")
+	}
+}
+
+func TestEntryWriter(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "csv",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "count", "type": "integer"},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(ParquetFormat, st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Structure() != st {
+		t.Error("expected writer to return the structure it was created with")
+	}
+
+	entries := []interface{}{
+		[]interface{}{"a", 1},
+		[]interface{}{"b"},
+		[]interface{}{"c", 3, "extra"},
+	}
+	for i, ent := range entries {
+		if err := w.WriteEntry(dsio.Entry{Index: i, Value: ent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteEntry(dsio.Entry{Index: 3, Value: "not a row"}); err == nil {
+		t.Error("expected writing a non-array entry to error")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cols, rows := readParquet(t, buf.Bytes())
+	if diff := cmp.Diff(testColumns[:2], cols); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	expect := [][]interface{}{
+		{"a", int64(1)},
+		{"b", nil},
+		{"c", int64(3)},
+	}
+	if diff := cmp.Diff(expect, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewEntryWriter(ArrowFormat, &dataset.Structure{Format: "json"}, buf); err == nil {
+		t.Error("expected structure without a schema to error")
+	}
+}
+
+// writeRows writes rows to a new columnar writer, returning the output
+func writeRows(t *testing.T, format string, cols []Column, rows [][]interface{}) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(format, cols, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// manyRows creates enough single-column integer rows to fill more than one
+// batch
+func manyRows() [][]interface{} {
+	rows := make([][]interface{}, batchSize+3)
+	for i := range rows {
+		rows[i] = []interface{}{int64(i)}
+	}
+	return rows
+}
@@ -0,0 +1,280 @@
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// parquetMagic starts & ends every parquet file
+const parquetMagic = "PAR1"
+
+// parquet metadata enum values, as defined in the parquet.thrift file of the
+// parquet format specification
+const (
+	parquetBoolean   int32 = 0
+	parquetInt64     int32 = 2
+	parquetDouble    int32 = 5
+	parquetByteArray int32 = 6
+
+	parquetOptional int32 = 1
+
+	parquetConvertedUTF8 int32 = 0
+
+	parquetEncodingPlain int32 = 0
+	parquetEncodingRLE   int32 = 3
+
+	parquetUncompressed int32 = 0
+
+	parquetDataPage int32 = 0
+)
+
+// parquetWriter writes an uncompressed parquet file with one row group per
+// batch of rows. Each column chunk is a single PLAIN encoded data page. All
+// columns are optional, with nulls recorded as definition levels
+type parquetWriter struct {
+	cols      []Column
+	w         *countWriter
+	batch     *batch
+	rowGroups []tstruct
+	rows      int64
+}
+
+func newParquetWriter(cols []Column, w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		cols:  cols,
+		w:     &countWriter{w: w},
+		batch: newBatch(cols),
+	}
+}
+
+// WriteRow adds a row to the file
+func (pw *parquetWriter) WriteRow(row []interface{}) error {
+	pw.batch.add(row)
+	if pw.batch.full() {
+		return pw.writeRowGroup()
+	}
+	return nil
+}
+
+// Close writes any buffered rows & the file footer
+func (pw *parquetWriter) Close() error {
+	if err := pw.writeMagic(); err != nil {
+		return err
+	}
+	if pw.batch.rows > 0 {
+		if err := pw.writeRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	meta, err := encodeThrift(pw.fileMetaData())
+	if err != nil {
+		return err
+	}
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, uint32(len(meta)))
+	for _, p := range [][]byte{meta, footer, []byte(parquetMagic)} {
+		if _, err := pw.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMagic writes the leading magic bytes if nothing has been written yet
+func (pw *parquetWriter) writeMagic() error {
+	if pw.w.n > 0 {
+		return nil
+	}
+	_, err := pw.w.Write([]byte(parquetMagic))
+	return err
+}
+
+func (pw *parquetWriter) writeRowGroup() error {
+	if err := pw.writeMagic(); err != nil {
+		return err
+	}
+
+	var (
+		chunks = make([]tstruct, len(pw.cols))
+		size   int64
+		rows   = int64(pw.batch.rows)
+	)
+	for i, c := range pw.batch.cols {
+		page, err := parquetPage(c)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", pw.cols[i].Title, err)
+		}
+		header, err := encodeThrift(tstruct{
+			{1, parquetDataPage},
+			{2, int32(len(page))},
+			{3, int32(len(page))},
+			{5, tstruct{
+				{1, int32(c.len())},
+				{2, parquetEncodingPlain},
+				{3, parquetEncodingRLE},
+				{4, parquetEncodingRLE},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+
+		offset := pw.w.n
+		if _, err := pw.w.Write(header); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(page); err != nil {
+			return err
+		}
+		chunkSize := pw.w.n - offset
+		size += chunkSize
+
+		chunks[i] = tstruct{
+			{2, offset},
+			{3, tstruct{
+				{1, parquetType(c.typ)},
+				{2, []int32{parquetEncodingPlain, parquetEncodingRLE}},
+				{3, []string{pw.cols[i].Title}},
+				{4, parquetUncompressed},
+				{5, int64(c.len())},
+				{6, chunkSize},
+				{7, chunkSize},
+				{9, offset},
+			}},
+		}
+	}
+
+	pw.rowGroups = append(pw.rowGroups, tstruct{
+		{1, chunks},
+		{2, size},
+		{3, rows},
+	})
+	pw.rows += rows
+	pw.batch.reset()
+	return nil
+}
+
+// fileMetaData builds the file footer describing the schema & row groups
+func (pw *parquetWriter) fileMetaData() tstruct {
+	schema := []tstruct{{
+		{4, "schema"},
+		{5, int32(len(pw.cols))},
+	}}
+	for _, col := range pw.cols {
+		el := tstruct{
+			{1, parquetType(col.Type)},
+			{3, parquetOptional},
+			{4, col.Title},
+		}
+		if col.Type == String {
+			// annotate byte arrays as UTF-8 strings, using both the legacy
+			// converted type & the STRING logical type
+			el = append(el, tfield{6, parquetConvertedUTF8}, tfield{10, tstruct{{1, tstruct{}}}})
+		}
+		schema = append(schema, el)
+	}
+
+	rowGroups := pw.rowGroups
+	if rowGroups == nil {
+		rowGroups = []tstruct{}
+	}
+
+	return tstruct{
+		{1, int32(1)},
+		{2, schema},
+		{3, pw.rows},
+		{4, rowGroups},
+		{6, "qri"},
+	}
+}
+
+// parquetType gives the physical parquet type for a column type
+func parquetType(t Type) int32 {
+	switch t {
+	case Int:
+		return parquetInt64
+	case Float:
+		return parquetDouble
+	case Bool:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+// parquetPage encodes the contents of a data page for a column: definition
+// levels marking which values are null, followed by the PLAIN encoding of all
+// non-null values
+func parquetPage(c *columnData) ([]byte, error) {
+	page := &bytes.Buffer{}
+
+	levels := definitionLevels(c.valid)
+	lenPrefix := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenPrefix, uint32(len(levels)))
+	page.Write(lenPrefix)
+	page.Write(levels)
+
+	var val [8]byte
+	switch c.typ {
+	case Int:
+		for i, v := range c.ints {
+			if c.valid[i] {
+				binary.LittleEndian.PutUint64(val[:], uint64(v))
+				page.Write(val[:])
+			}
+		}
+	case Float:
+		for i, v := range c.floats {
+			if c.valid[i] {
+				binary.LittleEndian.PutUint64(val[:], math.Float64bits(v))
+				page.Write(val[:])
+			}
+		}
+	case Bool:
+		bools := make([]bool, 0, len(c.bools))
+		for i, v := range c.bools {
+			if c.valid[i] {
+				bools = append(bools, v)
+			}
+		}
+		page.Write(bitmap(bools))
+	default:
+		for i, s := range c.strs {
+			if c.valid[i] {
+				binary.LittleEndian.PutUint32(val[:4], uint32(len(s)))
+				page.Write(val[:4])
+				page.WriteString(s)
+			}
+		}
+	}
+
+	if page.Len() > math.MaxInt32 {
+		return nil, fmt.Errorf("too much data for one page")
+	}
+	return page.Bytes(), nil
+}
+
+// definitionLevels encodes the nullness of each value as definition levels
+// with the RLE/bit-packing hybrid encoding, using only run-length encoded
+// runs. With a bit width of one, each run is a varint header holding the run
+// length, followed by a single byte for the repeated level
+func definitionLevels(valid []bool) []byte {
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(valid); {
+		j := i + 1
+		for j < len(valid) && valid[j] == valid[i] {
+			j++
+		}
+		writeVarint(buf, uint64(j-i)<<1)
+		if valid[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
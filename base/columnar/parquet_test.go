@@ -0,0 +1,265 @@
+package columnar
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParquetWriter(t *testing.T) {
+	data := writeRows(t, ParquetFormat, testColumns, testRows)
+	cols, rows := readParquet(t, data)
+	if diff := cmp.Diff(testColumns, cols); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(testRowsExpect, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	data := writeRows(t, ParquetFormat, testColumns, nil)
+	cols, rows := readParquet(t, data)
+	if diff := cmp.Diff(testColumns, cols); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %d", len(rows))
+	}
+}
+
+func TestParquetWriterRowGroups(t *testing.T) {
+	cols := []Column{{Title: "n", Type: Int}}
+	expect := manyRows()
+	data := writeRows(t, ParquetFormat, cols, expect)
+
+	meta := parquetFooter(t, data)
+	if groups := len(meta[4].([]interface{})); groups != 2 {
+		t.Errorf("expected 2 row groups, got %d", groups)
+	}
+	_, rows := readParquet(t, data)
+	if diff := cmp.Diff(expect, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestThriftEncoding(t *testing.T) {
+	ints := make([]int32, 20)
+	for i := range ints {
+		ints[i] = int32(i - 10)
+	}
+	data, err := encodeThrift(tstruct{
+		{1, true},
+		{2, false},
+		{3, int32(-1)},
+		{20, int64(math.MaxInt64)},
+		{21, "hello"},
+		{40, ints},
+		{41, []string{"a", "b"}},
+		{42, tstruct{{1, int32(1)}}},
+		{43, []tstruct{{{1, "x"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectInts := make([]interface{}, len(ints))
+	for i, v := range ints {
+		expectInts[i] = int64(v)
+	}
+	expect := map[int16]interface{}{
+		1:  true,
+		2:  false,
+		3:  int64(-1),
+		20: int64(math.MaxInt64),
+		21: "hello",
+		40: expectInts,
+		41: []interface{}{"a", "b"},
+		42: map[int16]interface{}{1: int64(1)},
+		43: []interface{}{map[int16]interface{}{1: "x"}},
+	}
+	d := &thriftDecoder{data: data}
+	if diff := cmp.Diff(expect, d.readStruct()); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := encodeThrift(tstruct{{1, 1.5}}); err == nil {
+		t.Error("expected unsupported value type to error")
+	}
+}
+
+// readParquet decodes a parquet file written by parquetWriter, returning
+// columns & rows of values
+func readParquet(t *testing.T, data []byte) ([]Column, [][]interface{}) {
+	t.Helper()
+	meta := parquetFooter(t, data)
+
+	var cols []Column
+	for _, el := range meta[2].([]interface{})[1:] {
+		el := el.(map[int16]interface{})
+		if el[3] != int64(parquetOptional) {
+			t.Errorf("expected column %q to be optional", el[4])
+		}
+		col := Column{Title: el[4].(string)}
+		switch int32(el[1].(int64)) {
+		case parquetInt64:
+			col.Type = Int
+		case parquetDouble:
+			col.Type = Float
+		case parquetBoolean:
+			col.Type = Bool
+		}
+		cols = append(cols, col)
+	}
+
+	var rows [][]interface{}
+	for _, rg := range meta[4].([]interface{}) {
+		rg := rg.(map[int16]interface{})
+		numRows := int(rg[3].(int64))
+		groupRows := make([][]interface{}, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(cols))
+		}
+
+		for i, chunk := range rg[1].([]interface{}) {
+			md := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			d := &thriftDecoder{data: data, pos: int(md[9].(int64))}
+			header := d.readStruct()
+			page := data[d.pos : d.pos+int(header[3].(int64))]
+
+			levelsLen := int(binary.LittleEndian.Uint32(page))
+			valid := readDefinitionLevels(t, page[4:4+levelsLen], numRows)
+			values := page[4+levelsLen:]
+
+			bit := 0
+			for j := range groupRows {
+				if !valid[j] {
+					continue
+				}
+				switch cols[i].Type {
+				case Int:
+					groupRows[j][i] = int64(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case Float:
+					groupRows[j][i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case Bool:
+					groupRows[j][i] = values[bit/8]&(1<<uint(bit%8)) != 0
+					bit++
+				default:
+					l := binary.LittleEndian.Uint32(values)
+					groupRows[j][i] = string(values[4 : 4+l])
+					values = values[4+l:]
+				}
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+
+	if int64(len(rows)) != meta[3].(int64) {
+		t.Errorf("expected file metadata to count %d rows, got %d", len(rows), meta[3])
+	}
+	return cols, rows
+}
+
+// parquetFooter checks the magic bytes of a parquet file & decodes the file
+// metadata
+func parquetFooter(t *testing.T, data []byte) map[int16]interface{} {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatalf("invalid parquet magic bytes")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	start := len(data) - 8 - metaLen
+	d := &thriftDecoder{data: data[start : len(data)-8]}
+	return d.readStruct()
+}
+
+// readDefinitionLevels decodes run-length encoded definition levels
+func readDefinitionLevels(t *testing.T, data []byte, n int) []bool {
+	t.Helper()
+	var valid []bool
+	for len(data) > 0 {
+		header, l := binary.Uvarint(data)
+		if header&1 != 0 {
+			t.Fatal("unexpected bit-packed run")
+		}
+		for i := uint64(0); i < header>>1; i++ {
+			valid = append(valid, data[l] == 1)
+		}
+		data = data[l+1:]
+	}
+	if len(valid) != n {
+		t.Fatalf("expected %d definition levels, got %d", n, len(valid))
+	}
+	return valid
+}
+
+// thriftDecoder decodes the thrift compact protocol into go values. structs
+// decode to maps keyed by field id, integers to int64 & lists to slices
+type thriftDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *thriftDecoder) readStruct() map[int16]interface{} {
+	res := map[int16]interface{}{}
+	var last int16
+	for {
+		b := d.data[d.pos]
+		d.pos++
+		if b == 0 {
+			return res
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(d.zigzag())
+		}
+		last = id
+		res[id] = d.readValue(b & 0x0f)
+	}
+}
+
+func (d *thriftDecoder) readValue(typ byte) interface{} {
+	switch typ {
+	case thriftBoolTrue:
+		return true
+	case thriftBoolFalse:
+		return false
+	case thriftI32, thriftI64:
+		return d.zigzag()
+	case thriftBinary:
+		l := int(d.varint())
+		s := string(d.data[d.pos : d.pos+l])
+		d.pos += l
+		return s
+	case thriftList:
+		b := d.data[d.pos]
+		d.pos++
+		size := int(b >> 4)
+		if size == 15 {
+			size = int(d.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = d.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return d.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (d *thriftDecoder) varint() uint64 {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	d.pos += n
+	return v
+}
+
+func (d *thriftDecoder) zigzag() int64 {
+	v := d.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
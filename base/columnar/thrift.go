@@ -0,0 +1,141 @@
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// thrift compact protocol type ids
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+// tstruct is a thrift struct, for encoding parquet metadata with the thrift
+// compact protocol. Fields must be listed in ascending order of id
+type tstruct []tfield
+
+// tfield is a field in a thrift struct. Values must be one of bool, int32,
+// int64, string, tstruct, []int32, []string or []tstruct
+type tfield struct {
+	id    int16
+	value interface{}
+}
+
+// encodeThrift encodes a struct with the thrift compact protocol
+func encodeThrift(s tstruct) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeThriftStruct(buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeThriftStruct(buf *bytes.Buffer, s tstruct) error {
+	var last int16
+	for _, f := range s {
+		typ, err := thriftType(f.value)
+		if err != nil {
+			return fmt.Errorf("field %d: %w", f.id, err)
+		}
+
+		// field headers hold the difference from the previous field id when
+		// it's small enough, otherwise the full id follows the type
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeVarint(buf, zigzag(int64(f.id)))
+		}
+		last = f.id
+
+		if err := writeThriftValue(buf, f.value); err != nil {
+			return fmt.Errorf("field %d: %w", f.id, err)
+		}
+	}
+	buf.WriteByte(0)
+	return nil
+}
+
+func thriftType(v interface{}) (byte, error) {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return thriftBoolTrue, nil
+		}
+		return thriftBoolFalse, nil
+	case int32:
+		return thriftI32, nil
+	case int64:
+		return thriftI64, nil
+	case string:
+		return thriftBinary, nil
+	case tstruct:
+		return thriftStruct, nil
+	case []int32, []string, []tstruct:
+		return thriftList, nil
+	}
+	return 0, fmt.Errorf("unsupported thrift value type %T", v)
+}
+
+func writeThriftValue(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case bool:
+		// booleans are encoded in the field header
+	case int32:
+		writeVarint(buf, zigzag(int64(x)))
+	case int64:
+		writeVarint(buf, zigzag(x))
+	case string:
+		writeVarint(buf, uint64(len(x)))
+		buf.WriteString(x)
+	case tstruct:
+		return writeThriftStruct(buf, x)
+	case []int32:
+		writeThriftListHeader(buf, len(x), thriftI32)
+		for _, i := range x {
+			writeVarint(buf, zigzag(int64(i)))
+		}
+	case []string:
+		writeThriftListHeader(buf, len(x), thriftBinary)
+		for _, s := range x {
+			writeVarint(buf, uint64(len(s)))
+			buf.WriteString(s)
+		}
+	case []tstruct:
+		writeThriftListHeader(buf, len(x), thriftStruct)
+		for _, s := range x {
+			if err := writeThriftStruct(buf, s); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported thrift value type %T", v)
+	}
+	return nil
+}
+
+func writeThriftListHeader(buf *bytes.Buffer, size int, elemType byte) {
+	if size < 15 {
+		buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	buf.WriteByte(0xf0 | elemType)
+	writeVarint(buf, uint64(size))
+}
+
+func writeVarint(buf *bytes.Buffer, v uint64) {
+	var data [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(data[:], v)
+	buf.Write(data[:n])
+}
+
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
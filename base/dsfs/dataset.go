@@ -25,6 +25,8 @@ import (
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qfs/localfs"
 	"github.com/qri-io/qri/base/friendly"
+	"github.com/qri-io/qri/base/rowdiff"
+	"github.com/qri-io/qri/base/rowkey"
 	"github.com/qri-io/qri/base/toqtype"
 )
 
@@ -38,6 +40,9 @@ var (
 	// files unresolved.
 	// TODO (b5) - allow -1 duration as a sentinel value for no timeout
 	OpenFileTimeoutDuration = time.Millisecond * 700
+	// ErrNoChanges indicates a save failed because the dataset is the same as
+	// the version before it
+	ErrNoChanges = fmt.Errorf("no changes")
 )
 
 // If a user has a dataset larger than the above limit, then instead of diffing we compare the
@@ -417,7 +422,7 @@ func generateCommit(store cafs.Filestore, prev, ds *dataset.Dataset, privKey cry
 	shortTitle, longMessage, err := generateCommitDescriptions(store, prev, ds, bodyAct, forceIfNoChanges)
 	if err != nil {
 		log.Debug(fmt.Errorf("error saving: %s", err))
-		return fmt.Errorf("error saving: %s", err)
+		return fmt.Errorf("error saving: %w", err)
 	}
 
 	if shortTitle == defaultCreatedDescription && fileHint != "" {
@@ -749,19 +754,37 @@ func generateCommitDescriptions(store cafs.Filestore, prev, ds *dataset.Dataset,
 	if err != nil {
 		return "", "", err
 	}
-	if prevBody != nil && nextBody != nil {
-		bodyDiff, bodyStat, err = deepdiff.New().StatDiff(ctx, prevBody, nextBody)
-		if err != nil {
-			return "", "", err
+	var shortTitle, longMessage string
+	keyed := false
+	if keys := rowkey.Columns(ds.Structure); prevBody != nil && nextBody != nil && len(keys) > 0 {
+		// Bodies with a primary key are compared row by row, so inserting a row reports one
+		// added row instead of every following row as changed.
+		var rowChanges []*rowdiff.Change
+		rowStat, err := rowdiff.Diff(rowdiff.Values(prev.Structure, prevBody), rowdiff.Values(ds.Structure, nextBody), keys, func(c *rowdiff.Change) error {
+			rowChanges = append(rowChanges, c)
+			return nil
+		})
+		if err == nil {
+			shortTitle, longMessage = friendly.KeyedDiffDescriptions(headDiff, rowChanges, rowStat)
+			keyed = true
+		} else {
+			log.Debugf("keyed body diff: %s", err)
 		}
 	}
-
-	shortTitle, longMessage := friendly.DiffDescriptions(headDiff, bodyDiff, bodyStat, assumeBodyChanged)
+	if !keyed {
+		if prevBody != nil && nextBody != nil {
+			bodyDiff, bodyStat, err = deepdiff.New().StatDiff(ctx, prevBody, nextBody)
+			if err != nil {
+				return "", "", err
+			}
+		}
+		shortTitle, longMessage = friendly.DiffDescriptions(headDiff, bodyDiff, bodyStat, assumeBodyChanged)
+	}
 	if shortTitle == "" {
 		if forceIfNoChanges {
 			return "forced update", "forced update", nil
 		}
-		return "", "", fmt.Errorf("no changes")
+		return "", "", ErrNoChanges
 	}
 
 	return shortTitle, longMessage, nil
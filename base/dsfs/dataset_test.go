@@ -544,6 +544,29 @@ func TestGenerateCommitMessage(t *testing.T) {
 			"body updated row 1 and added row 3",
 			"body:\n\tupdated row 1\n\tadded row 3",
 		},
+		{
+			"keyed body with a row inserted at the top",
+			&dataset.Dataset{
+				Structure: &dataset.Structure{Format: "json", Schema: map[string]interface{}{"type": "array", "primaryKey": "fruit"}},
+				Body: toqtype.MustParseJSONAsArray(`[
+  { "fruit": "apple", "color": "red" },
+  { "fruit": "banana", "color": "yellow" },
+  { "fruit": "cherry", "color": "red" }
+]`),
+			},
+			&dataset.Dataset{
+				Structure: &dataset.Structure{Format: "json", Schema: map[string]interface{}{"type": "array", "primaryKey": "fruit"}},
+				Body: toqtype.MustParseJSONAsArray(`[
+  { "fruit": "apricot", "color": "orange" },
+  { "fruit": "apple", "color": "red" },
+  { "fruit": "banana", "color": "yellow" },
+  { "fruit": "cherry", "color": "black" }
+]`),
+			},
+			false,
+			`body added row "apricot" and updated row "cherry"`,
+			"body:\n\tadded row \"apricot\"\n\tupdated row \"cherry\"",
+		},
 		{
 			"body with lots of changes",
 			&dataset.Dataset{
@@ -6,6 +6,7 @@ import (
 
 	logger "github.com/ipfs/go-log"
 	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qri/base/rowdiff"
 )
 
 var log = logger.Logger("friendly")
@@ -32,6 +33,45 @@ func DiffDescriptions(headDeltas, bodyDeltas []*deepdiff.Delta, bodyStats *deepd
 
 	perComponentChanges := buildComponentChanges(headDeltas, bodyDeltas, bodyStats, assumeBodyChanged)
 
+	// Take the max of left and right to calculate the percentage of the body that changed.
+	bodySize := 0
+	if bodyStats != nil {
+		bodySize = bodyStats.Left
+		if bodyStats.Right > bodySize {
+			bodySize = bodyStats.Right
+		}
+	}
+	return describeChanges(perComponentChanges, bodySize)
+}
+
+// KeyedDiffDescriptions creates a friendly message from diff operations on everything but the
+// body, and the row changes of a keyed body diff, which describes rows by key instead of by
+// position. If there's no differences found, return empty strings.
+func KeyedDiffDescriptions(headDeltas []*deepdiff.Delta, rowChanges []*rowdiff.Change, rowStats *rowdiff.Stats) (string, string) {
+	if len(headDeltas) == 0 && len(rowChanges) == 0 {
+		return "", ""
+	}
+
+	headDeltas = preprocess(headDeltas, "")
+	perComponentChanges := buildComponentChanges(headDeltas, nil, nil, false)
+	if len(rowChanges) > 0 {
+		perComponentChanges["body"] = buildRowChanges(rowChanges)
+	}
+
+	// Rows changed are measured against the larger number of rows.
+	bodySize := 0
+	if rowStats != nil {
+		bodySize = rowStats.Left
+		if rowStats.Right > bodySize {
+			bodySize = rowStats.Right
+		}
+	}
+	return describeChanges(perComponentChanges, bodySize)
+}
+
+// describeChanges builds the short title & long message for a set of component changes.
+// bodySize is the size of the body that percentages of body changes are relative to.
+func describeChanges(perComponentChanges map[string]*ComponentChanges, bodySize int) (string, string) {
 	// Data accumulated while iterating over the components.
 	shortTitle := ""
 	longMessage := ""
@@ -54,12 +94,7 @@ func DiffDescriptions(headDeltas, bodyDeltas []*deepdiff.Delta, bodyStats *deepd
 				if changes.Rows == nil {
 					// Body works specially. If a significant number of changes have been made,
 					// just report the percentage of the body that has changed.
-					// Take the max of left and right to calculate the percentage change.
-					divisor := bodyStats.Left
-					if bodyStats.Right > divisor {
-						divisor = bodyStats.Right
-					}
-					percentChange := int(100.0 * changes.Size / divisor)
+					percentChange := int(100.0 * changes.Size / bodySize)
 					action := fmt.Sprintf("changed by %d%%", percentChange)
 					msg = fmt.Sprintf("%s:\n\t%s", compName, action)
 					shortTitle = fmt.Sprintf("%s %s", compName, action)
@@ -216,6 +251,38 @@ func buildBodyChanges(changes *ComponentChanges, parentPath string, deltas deepd
 	}
 }
 
+func buildRowChanges(rowChanges []*rowdiff.Change) *ComponentChanges {
+	changes := &ComponentChanges{}
+	for _, c := range rowChanges {
+		changes.Num++
+		changes.Size++
+		if changes.Num <= smallNumberOfChangesToBody {
+			changes.Rows = append(changes.Rows, fmt.Sprintf("%s row %s", rowChangeVerb(c.Type), rowKey(c.Key)))
+		} else {
+			changes.Rows = nil
+		}
+	}
+	return changes
+}
+
+func rowChangeVerb(t rowdiff.ChangeType) string {
+	switch t {
+	case rowdiff.Added:
+		return "added"
+	case rowdiff.Removed:
+		return "removed"
+	}
+	return "updated"
+}
+
+// rowKey formats a row key for display, dropping the brackets around the key values
+func rowKey(key string) string {
+	if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+		return key[1 : len(key)-1]
+	}
+	return key
+}
+
 func joinPath(parent, element string) string {
 	if parent == "" {
 		return element
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qri/base/rowdiff"
 )
 
 func TestFriendlyDiffDescriptions(t *testing.T) {
@@ -37,6 +38,32 @@ structure:
 	}
 }
 
+func TestKeyedDiffDescriptions(t *testing.T) {
+	rowChanges := []*rowdiff.Change{
+		{Type: rowdiff.Added, Key: `["brazil"]`},
+		{Type: rowdiff.Modified, Key: `["usa",2020]`},
+	}
+	shortTitle, longMessage := KeyedDiffDescriptions(nil, rowChanges, &rowdiff.Stats{Left: 3, Right: 4})
+	expect := `body added row "brazil" and updated row "usa",2020`
+	if shortTitle != expect {
+		t.Errorf("error comparing short title, expect: %s\ngot: %s", expect, shortTitle)
+	}
+	expect = `body:
+	added row "brazil"
+	updated row "usa",2020`
+	if longMessage != expect {
+		t.Errorf("error comparing long message, expect: %s\ngot: %s", expect, longMessage)
+	}
+
+	// Many changes are described as the percentage of rows that changed
+	rowChanges = append(rowChanges, &rowdiff.Change{Type: rowdiff.Removed, Key: `["chad"]`}, &rowdiff.Change{Type: rowdiff.Removed, Key: `["peru"]`})
+	shortTitle, _ = KeyedDiffDescriptions(nil, rowChanges, &rowdiff.Stats{Left: 8, Right: 7})
+	expect = "body changed by 50%"
+	if shortTitle != expect {
+		t.Errorf("error comparing short title, expect: %s\ngot: %s", expect, shortTitle)
+	}
+}
+
 func TestBuildComponentChanges(t *testing.T) {
 	// Change the meta.title
 	deltas := []*deepdiff.Delta{
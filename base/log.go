@@ -27,31 +27,7 @@ var ErrDatasetLogTimeout = fmt.Errorf("datasetLog: timeout")
 func DatasetLog(ctx context.Context, r repo.Repo, ref dsref.Ref, limit, offset int, loadDatasets bool) ([]DatasetLogItem, error) {
 	if book := r.Logbook(); book != nil {
 		if items, err := book.Items(ctx, ref, offset, limit); err == nil {
-			// logs are ok with history not existing. This keeps FSI interaction behaviour consistent
-			// TODO (b5) - we should consider having "empty history" be an ok state, instead of marking as an error
-			if len(items) == 0 {
-				return nil, repo.ErrNoHistory
-			}
-			// Logbook doesn't store the CommitMessage and CommitTitle
-			// (see infoFromOp in logbook/logbook.go), so we need to load
-			// each dataset, and assign the CommitMessage and CommitTitle field.
-			for i, item := range items {
-				if item.Path != "" {
-					local, err := r.Store().Has(ctx, item.Path)
-					if err != nil {
-						continue
-					}
-					if local {
-						if ds, err := dsfs.LoadDataset(ctx, r.Store(), item.Path); err == nil {
-							if ds.Commit != nil {
-								items[i].CommitMessage = ds.Commit.Message
-							}
-						}
-					}
-					items[i].Foreign = !local
-				}
-			}
-			return items, nil
+			return loadLogItemDetails(ctx, r, items)
 		}
 	}
 
@@ -140,6 +116,50 @@ func StoredHistoricalDatasets(ctx context.Context, r repo.Repo, headPath string,
 	}
 }
 
+// DatasetBranchLog fetches the version history of a named dataset branch from
+// logbook. Unlike the default branch, branch history has no refstore fallback
+func DatasetBranchLog(ctx context.Context, r repo.Repo, ref dsref.Ref, branch string, limit, offset int) ([]DatasetLogItem, error) {
+	book := r.Logbook()
+	if book == nil {
+		return nil, logbook.ErrNoLogbook
+	}
+	items, err := book.BranchItems(ctx, ref, branch, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return loadLogItemDetails(ctx, r, items)
+}
+
+// loadLogItemDetails adds details logbook doesn't keep to log items, loading
+// each locally stored dataset
+func loadLogItemDetails(ctx context.Context, r repo.Repo, items []DatasetLogItem) ([]DatasetLogItem, error) {
+	// logs are ok with history not existing. This keeps FSI interaction behaviour consistent
+	// TODO (b5) - we should consider having "empty history" be an ok state, instead of marking as an error
+	if len(items) == 0 {
+		return nil, repo.ErrNoHistory
+	}
+	// Logbook doesn't store the CommitMessage and CommitTitle
+	// (see infoFromOp in logbook/logbook.go), so we need to load
+	// each dataset, and assign the CommitMessage and CommitTitle field.
+	for i, item := range items {
+		if item.Path != "" {
+			local, err := r.Store().Has(ctx, item.Path)
+			if err != nil {
+				continue
+			}
+			if local {
+				if ds, err := dsfs.LoadDataset(ctx, r.Store(), item.Path); err == nil {
+					if ds.Commit != nil {
+						items[i].CommitMessage = ds.Commit.Message
+					}
+				}
+			}
+			items[i].Foreign = !local
+		}
+	}
+	return items, nil
+}
+
 // constructDatasetLogFromHistory constructs a log for a name if one doesn't
 // exist.
 func constructDatasetLogFromHistory(ctx context.Context, r repo.Repo, ref dsref.Ref) error {
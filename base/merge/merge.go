@@ -0,0 +1,410 @@
+// Package merge reconciles two versions of a dataset that share history with
+// a three-way merge against their common ancestor. Bodies are merged row by
+// row, matching rows by key, while meta & structure are merged field by field.
+// Changes made on only one side are kept, changes both sides made the same way
+// are kept once, and different changes to the same value are conflicts
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/rowkey"
+)
+
+var log = logger.Logger("merge")
+
+const (
+	// PreferNone leaves conflicts unresolved
+	PreferNone = ""
+	// PreferOurs resolves conflicts with the version being merged into
+	PreferOurs = "ours"
+	// PreferTheirs resolves conflicts with the version being merged
+	PreferTheirs = "theirs"
+)
+
+// Conflict is a value both sides of a merge changed in different ways.
+// Values a side removed are nil
+type Conflict struct {
+	// Component is the dataset component the conflict is in, like "body"
+	Component string `json:"component"`
+	// Key locates the value within the component: a field name for meta &
+	// structure, or a row key for the body
+	Key    string      `json:"key"`
+	Base   interface{} `json:"base,omitempty"`
+	Ours   interface{} `json:"ours,omitempty"`
+	Theirs interface{} `json:"theirs,omitempty"`
+}
+
+// String formats a conflict for display
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s: ours: %s, theirs: %s", c.Component, c.Key, valueString(c.Ours), valueString(c.Theirs))
+}
+
+// ConflictError is the error a merge returns when it stops on conflicts
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+// Error displays the number of conflicts
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("merge has %d conflicts", len(e.Conflicts))
+}
+
+func valueString(v interface{}) string {
+	if v == nil {
+		return "(removed)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// Ancestor finds the most recent version both ours & theirs descend from by
+// walking the history of each. The parents of a version are its previous
+// version and the versions merges lists for it, which maps versions saved by
+// a merge to the versions they merged. Versions that aren't stored locally end
+// the walk. Ancestor returns an empty string when versions share no history
+func Ancestor(ctx context.Context, store cafs.Filestore, ours, theirs string, merges map[string][]string) (string, error) {
+	seen := map[string]bool{}
+	for _, path := range history(ctx, store, ours, merges) {
+		seen[path] = true
+	}
+	for _, path := range history(ctx, store, theirs, merges) {
+		if seen[path] {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// history lists a version & its ancestors, nearest first
+func history(ctx context.Context, store cafs.Filestore, path string, merges map[string][]string) []string {
+	visited := map[string]bool{path: true}
+	queue := []string{path}
+	for i := 0; i < len(queue); i++ {
+		parents := append([]string{}, merges[queue[i]]...)
+		ds, err := dsfs.LoadDataset(ctx, store, queue[i])
+		if err != nil {
+			log.Debugf("ending history at %s: %s", queue[i], err)
+		} else if ds.PreviousPath != "" {
+			parents = append([]string{ds.PreviousPath}, parents...)
+		}
+		for _, p := range parents {
+			if !visited[p] {
+				visited[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return queue
+}
+
+// Datasets merges the meta, structure & body of ours & theirs, two versions
+// descending from base. Bodies must be loaded into the Body field of each
+// dataset as a native go array or map, and base may be an empty dataset when
+// ours & theirs share no history. Rows are matched by keyColumns, defaulting
+// to the merged structure's primary key. The merged dataset starts as a copy
+// of ours. Conflicts are resolved according to prefer, and returned when
+// prefer is PreferNone, in which case the merged dataset holds our side of
+// each conflict
+func Datasets(base, ours, theirs *dataset.Dataset, keyColumns []string, prefer string) (*dataset.Dataset, []Conflict, error) {
+	merged := &dataset.Dataset{}
+	merged.Assign(ours)
+	conflicts := []Conflict{}
+
+	baseMeta, oursMeta, theirsMeta, err := componentFields(base.Meta, ours.Meta, theirs.Meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, cs := Fields("meta", baseMeta, oursMeta, theirsMeta, prefer)
+	conflicts = append(conflicts, cs...)
+	merged.Meta = nil
+	if meta != nil {
+		merged.Meta = &dataset.Meta{}
+		if err := remarshal(meta, merged.Meta); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	baseSt, oursSt, theirsSt, err := componentFields(dropDerived(base.Structure), dropDerived(ours.Structure), dropDerived(theirs.Structure))
+	if err != nil {
+		return nil, nil, err
+	}
+	st, cs := Fields("structure", baseSt, oursSt, theirsSt, prefer)
+	conflicts = append(conflicts, cs...)
+	if st == nil {
+		return nil, nil, fmt.Errorf("merged dataset has no structure")
+	}
+	merged.Structure = &dataset.Structure{}
+	if err := remarshal(st, merged.Structure); err != nil {
+		return nil, nil, err
+	}
+
+	keyer, err := rowkey.New(merged.Structure, keyColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged.Body, cs, err = Body(base.Body, ours.Body, theirs.Body, keyer, prefer)
+	if err != nil {
+		return nil, nil, err
+	}
+	conflicts = append(conflicts, cs...)
+
+	if prefer != PreferNone {
+		conflicts = nil
+	}
+	return merged, conflicts, nil
+}
+
+func dropDerived(st *dataset.Structure) *dataset.Structure {
+	if st == nil {
+		return nil
+	}
+	cp := &dataset.Structure{}
+	cp.Assign(st)
+	cp.DropDerivedValues()
+	return cp
+}
+
+// componentFields converts three versions of a component to maps of fields.
+// Missing components are nil maps
+func componentFields(base, ours, theirs interface{}) (b, o, t map[string]interface{}, err error) {
+	if b, err = fields(base); err != nil {
+		return
+	}
+	if o, err = fields(ours); err != nil {
+		return
+	}
+	t, err = fields(theirs)
+	return
+}
+
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	m := map[string]interface{}{}
+	if err := remarshal(v, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func remarshal(v, dst interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// Fields merges the top level fields of three versions of a component. A nil
+// map is a missing component, and Fields returns nil when the merged component
+// has no fields
+func Fields(component string, base, ours, theirs map[string]interface{}, prefer string) (map[string]interface{}, []Conflict) {
+	keys := map[string]bool{}
+	for _, m := range []map[string]interface{}{base, ours, theirs} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	merged := map[string]interface{}{}
+	conflicts := []Conflict{}
+	for _, k := range sorted {
+		b, o, t := base[k], ours[k], theirs[k]
+		v, ok := value(b, o, t)
+		if !ok {
+			conflicts = append(conflicts, Conflict{Component: component, Key: k, Base: b, Ours: o, Theirs: t})
+			v = resolve(o, t, prefer)
+		}
+		if v != nil {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return nil, conflicts
+	}
+	return merged, conflicts
+}
+
+// value merges a single value, returning false if both sides changed it in
+// different ways
+func value(base, ours, theirs interface{}) (interface{}, bool) {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours, true
+	case reflect.DeepEqual(base, ours):
+		return theirs, true
+	case reflect.DeepEqual(base, theirs):
+		return ours, true
+	}
+	return nil, false
+}
+
+func resolve(ours, theirs interface{}, prefer string) interface{} {
+	if prefer == PreferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// Body merges three versions of a dataset body, each either an array of rows
+// or an object. Array rows are matched by the keys keyer builds, and object
+// entries by their keys. When both sides change the same row, the row's
+// cells are merged, and cells both sides changed differently make the row a
+// conflict. Merged rows are ordered as in ours, followed by rows only theirs
+// added in their order
+func Body(base, ours, theirs interface{}, keyer *rowkey.Keyer, prefer string) (interface{}, []Conflict, error) {
+	if obj, ok := ours.(map[string]interface{}); ok {
+		b, _ := base.(map[string]interface{})
+		t, ok := theirs.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("can't merge an object body with a %T body", theirs)
+		}
+		return objectBody(b, obj, t, prefer)
+	}
+
+	baseRows, err := keyRows(base, keyer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("base body: %w", err)
+	}
+	oursRows, err := keyRows(ours, keyer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("our body: %w", err)
+	}
+	theirsRows, err := keyRows(theirs, keyer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("their body: %w", err)
+	}
+
+	order := append([]string{}, oursRows.order...)
+	for _, k := range theirsRows.order {
+		if _, ok := oursRows.rows[k]; !ok {
+			if _, inBase := baseRows.rows[k]; !inBase {
+				order = append(order, k)
+			}
+		}
+	}
+	if keyer.ByPosition() {
+		sort.SliceStable(order, func(i, j int) bool {
+			a, _ := strconv.Atoi(order[i])
+			b, _ := strconv.Atoi(order[j])
+			return a < b
+		})
+	}
+
+	merged := make([]interface{}, 0, len(order))
+	conflicts := []Conflict{}
+	for _, k := range order {
+		row, conflict := mergeRow(baseRows.rows[k], oursRows.rows[k], theirsRows.rows[k], prefer)
+		if conflict {
+			conflicts = append(conflicts, Conflict{Component: "body", Key: k, Base: baseRows.rows[k], Ours: oursRows.rows[k], Theirs: theirsRows.rows[k]})
+		}
+		if row != nil {
+			merged = append(merged, row)
+		}
+	}
+	return merged, conflicts, nil
+}
+
+func objectBody(base, ours, theirs map[string]interface{}, prefer string) (interface{}, []Conflict, error) {
+	keys := map[string]bool{}
+	for _, m := range []map[string]interface{}{base, ours, theirs} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	merged := map[string]interface{}{}
+	conflicts := []Conflict{}
+	for k := range keys {
+		v, conflict := mergeRow(base[k], ours[k], theirs[k], prefer)
+		if conflict {
+			conflicts = append(conflicts, Conflict{Component: "body", Key: k, Base: base[k], Ours: ours[k], Theirs: theirs[k]})
+		}
+		if v != nil {
+			merged[k] = v
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return merged, conflicts, nil
+}
+
+// mergeRow merges a single row, falling back to merging cells when both
+// sides changed the row. It returns true if the row has a conflict
+func mergeRow(base, ours, theirs interface{}, prefer string) (interface{}, bool) {
+	if v, ok := value(base, ours, theirs); ok {
+		return v, false
+	}
+
+	switch o := ours.(type) {
+	case []interface{}:
+		b, bok := base.([]interface{})
+		t, tok := theirs.([]interface{})
+		if bok && tok && len(b) == len(o) && len(t) == len(o) {
+			row := make([]interface{}, len(o))
+			conflict := false
+			for i := range o {
+				v, ok := value(b[i], o[i], t[i])
+				if !ok {
+					conflict = true
+					v = resolve(o[i], t[i], prefer)
+				}
+				row[i] = v
+			}
+			return row, conflict
+		}
+	case map[string]interface{}:
+		b, bok := base.(map[string]interface{})
+		t, tok := theirs.(map[string]interface{})
+		if bok && tok {
+			row, cs := Fields("", b, o, t, prefer)
+			return row, len(cs) > 0
+		}
+	}
+	return resolve(ours, theirs, prefer), true
+}
+
+type keyedRows struct {
+	order []string
+	rows  map[string]interface{}
+}
+
+func keyRows(body interface{}, keyer *rowkey.Keyer) (keyedRows, error) {
+	kr := keyedRows{rows: map[string]interface{}{}}
+	if body == nil {
+		return kr, nil
+	}
+	rows, ok := body.([]interface{})
+	if !ok {
+		return kr, fmt.Errorf("can't merge rows of a %T body", body)
+	}
+	for i, row := range rows {
+		k, err := keyer.Key(i, row)
+		if err != nil {
+			return kr, err
+		}
+		if _, exists := kr.rows[k]; exists {
+			return kr, fmt.Errorf("duplicate key %s in row %d", k, i)
+		}
+		kr.order = append(kr.order, k)
+		kr.rows[k] = row
+	}
+	return kr, nil
+}
@@ -0,0 +1,196 @@
+package merge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/rowkey"
+)
+
+func peopleStructure() *dataset.Structure {
+	return &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type":       "array",
+			"primaryKey": []interface{}{"id"},
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "string"},
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "age", "type": "integer"},
+				},
+			},
+		},
+	}
+}
+
+func TestBody(t *testing.T) {
+	keyer, err := rowkey.New(peopleStructure(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := []interface{}{
+		[]interface{}{"a", "alice", 30.0},
+		[]interface{}{"b", "bob", 40.0},
+		[]interface{}{"c", "carol", 50.0},
+		[]interface{}{"d", "dan", 60.0},
+	}
+	ours := []interface{}{
+		[]interface{}{"a", "alice", 31.0},
+		[]interface{}{"b", "bobby", 40.0},
+		[]interface{}{"d", "dan", 60.0},
+		[]interface{}{"e", "erin", 20.0},
+	}
+	theirs := []interface{}{
+		[]interface{}{"a", "alice", 30.0},
+		[]interface{}{"b", "bob", 41.0},
+		[]interface{}{"c", "carol", 50.0},
+		[]interface{}{"d", "dan", 61.0},
+		[]interface{}{"f", "frank", 70.0},
+	}
+
+	got, conflicts, err := Body(base, ours, theirs, keyer, PreferNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"a", "alice", 31.0},
+		[]interface{}{"b", "bobby", 41.0},
+		[]interface{}{"d", "dan", 61.0},
+		[]interface{}{"e", "erin", 20.0},
+		[]interface{}{"f", "frank", 70.0},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("merged body mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got: %v", conflicts)
+	}
+
+	theirs[0] = []interface{}{"a", "alice", 32.0}
+	got, conflicts, err = Body(base, ours, theirs, keyer, PreferNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != `["a"]` {
+		t.Fatalf("expected a conflict for row a, got: %v", conflicts)
+	}
+	if row := got.([]interface{})[0].([]interface{}); row[2] != 31.0 {
+		t.Errorf("expected unresolved conflict to keep our value, got: %v", row)
+	}
+
+	got, _, err = Body(base, ours, theirs, keyer, PreferTheirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := got.([]interface{})[0].([]interface{}); row[2] != 32.0 {
+		t.Errorf("expected conflict to resolve to their value, got: %v", row)
+	}
+
+	dupes := append(ours, []interface{}{"a", "again", 1.0})
+	if _, _, err = Body(base, dupes, theirs, keyer, PreferNone); err == nil {
+		t.Errorf("expected error for duplicate keys")
+	}
+}
+
+func TestFields(t *testing.T) {
+	base := map[string]interface{}{"title": "a", "description": "b", "keywords": []interface{}{"x"}}
+	ours := map[string]interface{}{"title": "A", "description": "b", "keywords": []interface{}{"y"}}
+	theirs := map[string]interface{}{"title": "a", "description": "B", "keywords": []interface{}{"z"}, "license": "cc"}
+
+	got, conflicts := Fields("meta", base, ours, theirs, PreferNone)
+	expect := map[string]interface{}{"title": "A", "description": "B", "keywords": []interface{}{"y"}, "license": "cc"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("merged fields mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) != 1 || conflicts[0].Component != "meta" || conflicts[0].Key != "keywords" {
+		t.Errorf("expected a keywords conflict, got: %v", conflicts)
+	}
+}
+
+func TestDatasets(t *testing.T) {
+	base := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "people"},
+		Structure: peopleStructure(),
+		Body:      []interface{}{[]interface{}{"a", "alice", 30.0}},
+	}
+	ours := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "people", Description: "some people"},
+		Structure: peopleStructure(),
+		Body:      []interface{}{[]interface{}{"a", "alice", 31.0}},
+	}
+	theirs := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "all the people"},
+		Structure: peopleStructure(),
+		Body:      []interface{}{[]interface{}{"a", "alice", 30.0}, []interface{}{"b", "bob", 40.0}},
+	}
+
+	got, conflicts, err := Datasets(base, ours, theirs, nil, PreferNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got: %v", conflicts)
+	}
+	if got.Meta.Title != "all the people" || got.Meta.Description != "some people" {
+		t.Errorf("meta mismatch: %#v", got.Meta)
+	}
+	expect := []interface{}{[]interface{}{"a", "alice", 31.0}, []interface{}{"b", "bob", 40.0}}
+	if diff := cmp.Diff(expect, got.Body); diff != "" {
+		t.Errorf("merged body mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAncestor(t *testing.T) {
+	ctx := context.Background()
+	store := cafs.NewMapstore()
+
+	save := func(prev, title string) string {
+		ds := &dataset.Dataset{
+			PreviousPath: prev,
+			Commit:       &dataset.Commit{Title: title},
+			Structure:    &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`["`+title+`"]`)))
+		path, err := dsfs.WriteDataset(ctx, store, ds, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	root := save("", "root")
+	ours := save(save(root, "ours 1"), "ours 2")
+	theirs := save(root, "theirs 1")
+
+	got, err := Ancestor(ctx, store, ours, theirs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != root {
+		t.Errorf("ancestor mismatch. expected: %s, got: %s", root, got)
+	}
+
+	if got, _ = Ancestor(ctx, store, ours, root, nil); got != root {
+		t.Errorf("expected an ancestor of ours to be its own common ancestor, got: %s", got)
+	}
+
+	// a merge makes the merged version an ancestor
+	merged := save(ours, "merge theirs into ours")
+	merges := map[string][]string{merged: {theirs}}
+	if got, _ = Ancestor(ctx, store, merged, theirs, merges); got != theirs {
+		t.Errorf("expected merged version to be the ancestor. expected: %s, got: %s", theirs, got)
+	}
+
+	unrelated := save("", "unrelated")
+	if got, _ = Ancestor(ctx, store, ours, unrelated, nil); got != "" {
+		t.Errorf("expected unrelated versions to have no ancestor, got: %s", got)
+	}
+}
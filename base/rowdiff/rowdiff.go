@@ -0,0 +1,394 @@
+// Package rowdiff compares two dataset bodies row by row, matching rows by
+// key instead of by position, so inserting a row reports one added row
+// rather than every following row as changed. Bodies are streamed: Diff
+// holds a fingerprint per row of the left body and the keys of rows that
+// changed, but never the bodies themselves. Changed rows are read again in
+// batches to compare their cells. Fingerprints are SHA-256 hashes of a row's
+// JSON encoding, so a changed row is only missed if its hash collides with the
+// original's
+package rowdiff
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base/rowkey"
+)
+
+// ChangeType is the kind of change made to a row
+type ChangeType string
+
+const (
+	// Added is a row only the right body has
+	Added ChangeType = "add"
+	// Removed is a row only the left body has
+	Removed ChangeType = "remove"
+	// Modified is a row both bodies have with different values
+	Modified ChangeType = "modify"
+)
+
+// Change describes a row that differs between two bodies
+type Change struct {
+	Type ChangeType `json:"type"`
+	// Key identifies the row, see rowkey.Keyer for its format. Rows of
+	// object bodies are keyed by their entry key
+	Key   string      `json:"key"`
+	Left  interface{} `json:"left,omitempty"`
+	Right interface{} `json:"right,omitempty"`
+	// cells that differ, set for modified rows
+	Cells []CellChange `json:"cells,omitempty"`
+}
+
+// CellChange is a value within a row that differs between two bodies
+type CellChange struct {
+	// Column names the cell, taken from the schema for array rows & the
+	// field name for object rows
+	Column string      `json:"column"`
+	Left   interface{} `json:"left,omitempty"`
+	Right  interface{} `json:"right,omitempty"`
+}
+
+// Stats counts the rows of a diff
+type Stats struct {
+	// number of rows in the left & right bodies
+	Left  int `json:"left"`
+	Right int `json:"right"`
+
+	Added    int `json:"added"`
+	Removed  int `json:"removed"`
+	Modified int `json:"modified"`
+}
+
+// Source opens a reader over the entries of a body. Diff reads each body more
+// than once, so a source must be able to open its body more than once
+type Source func() (dsio.EntryReader, error)
+
+// modifiedBatchSize is the number of changed rows of each body Diff holds in
+// memory while comparing cells. The right body is read once per batch
+var modifiedBatchSize = 1024
+
+// Values creates a Source over a body held in memory, which must be an array
+// of rows or an object
+func Values(st *dataset.Structure, body interface{}) Source {
+	return func() (dsio.EntryReader, error) {
+		r := &valueReader{st: st}
+		switch b := body.(type) {
+		case []interface{}:
+			for i, v := range b {
+				r.entries = append(r.entries, dsio.Entry{Index: i, Value: v})
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(b))
+			for k := range b {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for i, k := range keys {
+				r.entries = append(r.entries, dsio.Entry{Index: i, Key: k, Value: b[k]})
+			}
+		default:
+			return nil, fmt.Errorf("cannot read rows of a %T body", body)
+		}
+		return r, nil
+	}
+}
+
+// valueReader is a dsio.EntryReader over entries in memory
+type valueReader struct {
+	st      *dataset.Structure
+	entries []dsio.Entry
+}
+
+func (r *valueReader) Structure() *dataset.Structure { return r.st }
+
+func (r *valueReader) ReadEntry() (dsio.Entry, error) {
+	if len(r.entries) == 0 {
+		return dsio.Entry{}, io.EOF
+	}
+	ent := r.entries[0]
+	r.entries = r.entries[1:]
+	return ent, nil
+}
+
+func (r *valueReader) Close() error { return nil }
+
+// Diff compares the rows of two bodies, matching them by the values of key
+// columns. Without columns, each body is keyed by the primary key of its
+// structure's schema, falling back to row position. Changes are passed to
+// emit as they're found: added rows in the order of the right body, followed
+// by removed & modified rows. Removed rows and each batch of modified rows are
+// in the order of the left body
+func Diff(left, right Source, columns []string, emit func(*Change) error) (*Stats, error) {
+	stats := &Stats{}
+
+	// index the left body by key, keeping only a fingerprint of each row
+	leftRows := map[string][sha256.Size]byte{}
+	leftHeader, err := eachRow(left, columns, func(key string, row interface{}) error {
+		if _, exists := leftRows[key]; exists {
+			return fmt.Errorf("left body: duplicate key %s", key)
+		}
+		sum, err := fingerprint(row)
+		if err != nil {
+			return err
+		}
+		leftRows[key] = sum
+		stats.Left++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// stream the right body, reporting added rows & recording the keys of
+	// changed ones
+	rightKeys := map[string]bool{}
+	modified := map[string]bool{}
+	rightHeader, err := eachRow(right, columns, func(key string, row interface{}) error {
+		if rightKeys[key] {
+			return fmt.Errorf("right body: duplicate key %s", key)
+		}
+		rightKeys[key] = true
+		stats.Right++
+
+		leftSum, ok := leftRows[key]
+		if !ok {
+			stats.Added++
+			return emit(&Change{Type: Added, Key: key, Right: row})
+		}
+		sum, err := fingerprint(row)
+		if err != nil {
+			return err
+		}
+		if sum != leftSum {
+			modified[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emitModified := func(key string, leftRow, rightRow interface{}) error {
+		var cells []CellChange
+		if leftHeader != nil && rightHeader != nil && !reflect.DeepEqual(leftHeader, rightHeader) {
+			// columns moved, compare cells by column name
+			cells = Cells(namedCells(leftRow, leftHeader), namedCells(rightRow, rightHeader), nil)
+		} else {
+			cells = Cells(leftRow, rightRow, rightHeader)
+		}
+		if len(cells) == 0 {
+			// rows with equal values can fingerprint differently, like an
+			// integer & an equal float
+			return nil
+		}
+		stats.Modified++
+		return emit(&Change{Type: Modified, Key: key, Left: leftRow, Right: rightRow, Cells: cells})
+	}
+
+	// read the right rows of a batch of modified left rows from the right body
+	// again, comparing their cells
+	var batchKeys []string
+	batchRows := map[string]interface{}{}
+	flush := func() error {
+		if len(batchKeys) == 0 {
+			return nil
+		}
+		rightRows := make(map[string]interface{}, len(batchKeys))
+		_, err := eachRow(right, columns, func(key string, row interface{}) error {
+			if _, ok := batchRows[key]; ok {
+				rightRows[key] = row
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range batchKeys {
+			if err := emitModified(key, batchRows[key], rightRows[key]); err != nil {
+				return err
+			}
+		}
+		batchKeys, batchRows = batchKeys[:0], map[string]interface{}{}
+		return nil
+	}
+
+	// stream the left body again, reporting removed rows & batching modified
+	// ones
+	_, err = eachRow(left, columns, func(key string, row interface{}) error {
+		if !rightKeys[key] {
+			stats.Removed++
+			return emit(&Change{Type: Removed, Key: key, Left: row})
+		}
+		if !modified[key] {
+			return nil
+		}
+		batchKeys = append(batchKeys, key)
+		batchRows[key] = row
+		if len(batchKeys) >= modifiedBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// namedCells converts an array row to an object keyed by column name
+func namedCells(row interface{}, header []string) interface{} {
+	arr, ok := row.([]interface{})
+	if !ok {
+		return row
+	}
+	obj := make(map[string]interface{}, len(arr))
+	for i, v := range arr {
+		col := strconv.Itoa(i)
+		if i < len(header) && header[i] != "" {
+			col = header[i]
+		}
+		obj[col] = v
+	}
+	return obj
+}
+
+// eachRow calls fn with the key & value of each row of a body, returning the
+// body's column names
+func eachRow(src Source, columns []string, fn func(key string, row interface{}) error) ([]string, error) {
+	rdr, err := src()
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+	keyer, err := rowkey.New(rdr.Structure(), columns)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		ent, err := rdr.ReadEntry()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return rowkey.Header(rdr.Structure()), nil
+			}
+			return nil, err
+		}
+		key := ent.Key
+		if key == "" {
+			if key, err = keyer.Key(i, ent.Value); err != nil {
+				return nil, err
+			}
+		}
+		if err = fn(key, ent.Value); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fingerprint hashes the JSON encoding of a row
+func fingerprint(row interface{}) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// Cells lists the values that differ between two versions of a row. Array
+// rows are compared by position, naming cells with header when it has a
+// title for the position. Object rows are compared by field
+func Cells(left, right interface{}, header []string) []CellChange {
+	var cells []CellChange
+	switch l := left.(type) {
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(l)
+		if len(r) > n {
+			n = len(r)
+		}
+		for i := 0; i < n; i++ {
+			var lv, rv interface{}
+			if i < len(l) {
+				lv = l[i]
+			}
+			if i < len(r) {
+				rv = r[i]
+			}
+			if !equal(lv, rv) {
+				col := strconv.Itoa(i)
+				if i < len(header) && header[i] != "" {
+					col = header[i]
+				}
+				cells = append(cells, CellChange{Column: col, Left: lv, Right: rv})
+			}
+		}
+		return cells
+	case map[string]interface{}:
+		r, ok := right.(map[string]interface{})
+		if !ok {
+			break
+		}
+		fields := map[string]bool{}
+		for k := range l {
+			fields[k] = true
+		}
+		for k := range r {
+			fields[k] = true
+		}
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			if !equal(l[k], r[k]) {
+				cells = append(cells, CellChange{Column: k, Left: l[k], Right: r[k]})
+			}
+		}
+		return cells
+	}
+
+	if !equal(left, right) {
+		cells = append(cells, CellChange{Left: left, Right: right})
+	}
+	return cells
+}
+
+// equal compares values, treating numbers of different types as equal when
+// their values are
+func equal(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	af, aok := number(a)
+	bf, bok := number(b)
+	return aok && bok && af == bf
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
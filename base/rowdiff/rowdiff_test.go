@@ -0,0 +1,179 @@
+package rowdiff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func csvStructure() *dataset.Structure {
+	return &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type":       "array",
+			"primaryKey": "country",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "country", "type": "string"},
+					map[string]interface{}{"title": "population", "type": "integer"},
+				},
+			},
+		},
+	}
+}
+
+func csvSource(st *dataset.Structure, data string) Source {
+	return func() (dsio.EntryReader, error) {
+		return dsio.NewEntryReader(st, bytes.NewBufferString(data))
+	}
+}
+
+func collect(t *testing.T, left, right Source, columns []string) ([]*Change, *Stats) {
+	t.Helper()
+	var changes []*Change
+	stats, err := Diff(left, right, columns, func(c *Change) error {
+		changes = append(changes, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changes, stats
+}
+
+func TestDiff(t *testing.T) {
+	st := csvStructure()
+
+	left := csvSource(st, "country,population\ncanada,38\nmexico,128\nusa,331\n")
+	// a row inserted at the top, a row removed & a cell changed
+	right := csvSource(st, "country,population\nbrazil,212\ncanada,38\nusa,332\n")
+
+	changes, stats := collect(t, left, right, nil)
+	expect := []*Change{
+		{Type: Added, Key: `["brazil"]`, Right: []interface{}{"brazil", int64(212)}},
+		{Type: Removed, Key: `["mexico"]`, Left: []interface{}{"mexico", int64(128)}},
+		{Type: Modified, Key: `["usa"]`,
+			Left:  []interface{}{"usa", int64(331)},
+			Right: []interface{}{"usa", int64(332)},
+			Cells: []CellChange{{Column: "population", Left: int64(331), Right: int64(332)}},
+		},
+	}
+	if diff := cmp.Diff(expect, changes); diff != "" {
+		t.Errorf("changes mismatch (-want +got):\n%s", diff)
+	}
+	expectStats := &Stats{Left: 3, Right: 3, Added: 1, Removed: 1, Modified: 1}
+	if diff := cmp.Diff(expectStats, stats); diff != "" {
+		t.Errorf("stats mismatch (-want +got):\n%s", diff)
+	}
+
+	// moving columns keys rows & names cells by column
+	moved := csvStructure()
+	moved.Schema["items"].(map[string]interface{})["items"] = []interface{}{
+		map[string]interface{}{"title": "population", "type": "integer"},
+		map[string]interface{}{"title": "country", "type": "string"},
+	}
+	changes, _ = collect(t, left, csvSource(moved, "population,country\n38,canada\n128,mexico\n332,usa\n"), nil)
+	if len(changes) != 1 || changes[0].Key != `["usa"]` {
+		t.Fatalf("expected one modified row after moving columns, got: %v", changes)
+	}
+	expectCells := []CellChange{{Column: "population", Left: int64(331), Right: int64(332)}}
+	if diff := cmp.Diff(expectCells, changes[0].Cells); diff != "" {
+		t.Errorf("cells mismatch (-want +got):\n%s", diff)
+	}
+
+	dupes := csvSource(st, "country,population\nusa,1\nusa,2\n")
+	if _, err := Diff(left, dupes, nil, func(*Change) error { return nil }); err == nil {
+		t.Errorf("expected error for duplicate keys")
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	left := Values(st, []interface{}{
+		map[string]interface{}{"id": "a", "n": 1.0},
+		map[string]interface{}{"id": "b", "n": 2.0},
+	})
+	right := Values(st, []interface{}{
+		map[string]interface{}{"id": "b", "n": 2.0},
+		map[string]interface{}{"id": "a", "n": 1.0, "note": "x"},
+	})
+
+	changes, stats := collect(t, left, right, []string{"id"})
+	if stats.Modified != 1 || stats.Added != 0 || stats.Removed != 0 {
+		t.Errorf("expected reordered rows to only report one modified row, got: %#v", stats)
+	}
+	expect := []CellChange{{Column: "note", Right: "x"}}
+	if diff := cmp.Diff(expect, changes[0].Cells); diff != "" {
+		t.Errorf("cells mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffModifiedBatches(t *testing.T) {
+	defer func(n int) { modifiedBatchSize = n }(modifiedBatchSize)
+	modifiedBatchSize = 2
+
+	st := csvStructure()
+	left := csvSource(st, "country,population\na,1\nb,2\nc,3\nd,4\ne,5\n")
+	rightData := "country,population\ne,50\nd,40\nc,3\nb,20\na,10\n"
+	opened := 0
+	right := func() (dsio.EntryReader, error) {
+		opened++
+		return dsio.NewEntryReader(st, bytes.NewBufferString(rightData))
+	}
+
+	changes, stats := collect(t, left, right, nil)
+	if stats.Modified != 4 {
+		t.Errorf("expected 4 modified rows, got: %d", stats.Modified)
+	}
+	keys := []string{}
+	for _, c := range changes {
+		keys = append(keys, c.Key)
+		if c.Type != Modified || c.Right.([]interface{})[1] != c.Left.([]interface{})[1].(int64)*10 {
+			t.Errorf("expected modified row %s to pair left & right values, got: %v -> %v", c.Key, c.Left, c.Right)
+		}
+	}
+	if diff := cmp.Diff([]string{`["a"]`, `["b"]`, `["d"]`, `["e"]`}, keys); diff != "" {
+		t.Errorf("key order mismatch (-want +got):\n%s", diff)
+	}
+	// one read to find changes & one per batch of modified rows
+	if opened != 3 {
+		t.Errorf("expected right body to be read 3 times, got: %d", opened)
+	}
+}
+
+// wrappedEOFReader ends its entries with an error wrapping io.EOF
+type wrappedEOFReader struct {
+	dsio.EntryReader
+}
+
+func (r wrappedEOFReader) ReadEntry() (dsio.Entry, error) {
+	ent, err := r.EntryReader.ReadEntry()
+	if err == io.EOF {
+		return ent, fmt.Errorf("reading body: %w", err)
+	}
+	return ent, err
+}
+
+func TestDiffWrappedEOF(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	wrapped := func(src Source) Source {
+		return func() (dsio.EntryReader, error) {
+			rdr, err := src()
+			return wrappedEOFReader{rdr}, err
+		}
+	}
+	left := wrapped(Values(st, []interface{}{"a", "b"}))
+	right := wrapped(Values(st, []interface{}{"a", "c"}))
+
+	_, stats := collect(t, left, right, nil)
+	if stats.Left != 2 || stats.Right != 2 || stats.Modified != 1 {
+		t.Errorf("expected both bodies to be read to the end, got: %#v", stats)
+	}
+}
@@ -0,0 +1,155 @@
+// Package rowkey identifies the rows of a dataset body, either by the values
+// of key columns or by position. A structure declares key columns by adding a
+// "primaryKey" to its schema, naming one column or a list of columns:
+//
+//	{
+//	  "type": "array",
+//	  "primaryKey": ["country", "year"],
+//	  "items": { ... }
+//	}
+package rowkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+)
+
+// Columns returns the key columns a structure's schema declares as its
+// primary key, or nil if it doesn't have one
+func Columns(st *dataset.Structure) []string {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	switch pk := st.Schema["primaryKey"].(type) {
+	case string:
+		return []string{pk}
+	case []interface{}:
+		cols := make([]string, 0, len(pk))
+		for _, c := range pk {
+			if s, ok := c.(string); ok {
+				cols = append(cols, s)
+			}
+		}
+		return cols
+	case []string:
+		return pk
+	}
+	return nil
+}
+
+// Header returns the column names of a tabular schema, in order, taken from
+// the titles of the schema's row items. It returns nil for schemas that don't
+// describe rows as arrays
+func Header(st *dataset.Structure) []string {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	items, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cols, ok := items["items"].([]interface{})
+	if !ok {
+		return nil
+	}
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		if col, ok := c.(map[string]interface{}); ok {
+			header[i], _ = col["title"].(string)
+		}
+	}
+	return header
+}
+
+// Keyer builds the keys that identify body rows
+type Keyer struct {
+	columns []string
+	// position of each key column within array rows
+	indexes []int
+}
+
+// New creates a Keyer for rows described by st. Rows are keyed by columns
+// when any are given, otherwise by the schema's primary key. Without key
+// columns rows are keyed by position
+func New(st *dataset.Structure, columns []string) (*Keyer, error) {
+	if len(columns) == 0 {
+		columns = Columns(st)
+	}
+	k := &Keyer{columns: columns}
+	if len(columns) == 0 {
+		return k, nil
+	}
+
+	header := Header(st)
+	if header == nil {
+		// rows are objects, keyed by field name
+		return k, nil
+	}
+	for _, col := range columns {
+		idx := -1
+		for i, name := range header {
+			if name == col {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("key column %q is not in the schema", col)
+		}
+		k.indexes = append(k.indexes, idx)
+	}
+	return k, nil
+}
+
+// Columns lists the key columns, which is empty when rows are keyed by
+// position
+func (k *Keyer) Columns() []string {
+	return k.columns
+}
+
+// ByPosition returns whether rows are keyed by position
+func (k *Keyer) ByPosition() bool {
+	return len(k.columns) == 0
+}
+
+// Key returns the key identifying row, the i'th row of a body. Keys for key
+// columns are a JSON array of the key values, like ["usa",2020]. Positional
+// keys are the row number
+func (k *Keyer) Key(i int, row interface{}) (string, error) {
+	if k.ByPosition() {
+		return strconv.Itoa(i), nil
+	}
+
+	vals := make([]interface{}, len(k.columns))
+	switch r := row.(type) {
+	case []interface{}:
+		if k.indexes == nil {
+			return "", fmt.Errorf("row %d: can't find key columns without a tabular schema", i)
+		}
+		for j, idx := range k.indexes {
+			if idx >= len(r) {
+				return "", fmt.Errorf("row %d: missing key column %q", i, k.columns[j])
+			}
+			vals[j] = r[idx]
+		}
+	case map[string]interface{}:
+		for j, col := range k.columns {
+			v, ok := r[col]
+			if !ok {
+				return "", fmt.Errorf("row %d: missing key column %q", i, col)
+			}
+			vals[j] = v
+		}
+	default:
+		return "", fmt.Errorf("row %d: can't find key columns in a %T", i, row)
+	}
+
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,72 @@
+package rowkey
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func tabularStructure(primaryKey interface{}) *dataset.Structure {
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "country", "type": "string"},
+				map[string]interface{}{"title": "year", "type": "integer"},
+				map[string]interface{}{"title": "population", "type": "integer"},
+			},
+		},
+	}
+	if primaryKey != nil {
+		schema["primaryKey"] = primaryKey
+	}
+	return &dataset.Structure{Format: "csv", Schema: schema}
+}
+
+func TestKeyer(t *testing.T) {
+	row := []interface{}{"usa", 2020, 331}
+
+	k, err := New(tabularStructure([]interface{}{"country", "year"}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Key(3, row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `["usa",2020]`; got != expect {
+		t.Errorf("primary key mismatch. expected: %s, got: %s", expect, got)
+	}
+
+	k, err = New(tabularStructure("country"), []string{"year"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = k.Key(3, row); got != `[2020]` {
+		t.Errorf("expected key columns to override the schema's primary key, got: %s", got)
+	}
+
+	k, err = New(tabularStructure(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = k.Key(3, row); !k.ByPosition() || got != "3" {
+		t.Errorf("expected positional key 3, got: %s", got)
+	}
+
+	k, err = New(&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, []string{"id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = k.Key(0, map[string]interface{}{"id": "a"}); got != `["a"]` {
+		t.Errorf("expected object row key, got: %s", got)
+	}
+	if _, err = k.Key(1, map[string]interface{}{"name": "b"}); err == nil {
+		t.Errorf("expected error for row missing a key column")
+	}
+
+	if _, err = New(tabularStructure(nil), []string{"missing"}); err == nil {
+		t.Errorf("expected error for key column not in the schema")
+	}
+}
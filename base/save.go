@@ -24,6 +24,17 @@ var ErrNameTaken = fmt.Errorf("name already in use")
 
 // SaveDataset saves a version of the dataset for the given initID at the current path
 func SaveDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, initID, prevPath string, changes *dataset.Dataset, sw SaveSwitches) (ref reporef.DatasetRef, err error) {
+	return saveDataset(ctx, r, writeDest, initID, logbook.DefaultBranchName, prevPath, changes, sw)
+}
+
+// SaveDatasetToBranch saves a version of the dataset to a named branch, where
+// prevPath is the latest version of that branch. Versions saved to a branch
+// other than the default don't change the dataset's head
+func SaveDatasetToBranch(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, initID, branch, prevPath string, changes *dataset.Dataset, sw SaveSwitches) (ref reporef.DatasetRef, err error) {
+	return saveDataset(ctx, r, writeDest, initID, branch, prevPath, changes, sw)
+}
+
+func saveDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, initID, branch, prevPath string, changes *dataset.Dataset, sw SaveSwitches) (ref reporef.DatasetRef, err error) {
 	var pro *profile.Profile
 	if pro, err = r.Profile(); err != nil {
 		return
@@ -105,13 +116,14 @@ func SaveDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, ini
 
 	// Write the dataset to storage and get back the new path.
 	// TODO(dustmop): Only return the cafs path, since this function shouldn't know about references
-	ref, err = CreateDataset(ctx, r, writeDest, changes, prev, sw)
+	onDefaultBranch := branch == "" || branch == logbook.DefaultBranchName
+	ref, err = createDataset(ctx, r, writeDest, changes, prev, sw, onDefaultBranch)
 	if err != nil {
 		return ref, err
 	}
 
 	// Write the save to logbook
-	err = r.Logbook().WriteVersionSave(ctx, initID, changes)
+	err = r.Logbook().WriteBranchVersionSave(ctx, initID, branch, changes)
 	if err != nil && err != logbook.ErrNoLogbook {
 		return ref, err
 	}
@@ -120,6 +132,12 @@ func SaveDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, ini
 
 // CreateDataset uses dsfs to add a dataset to a repo's store, updating the refstore
 func CreateDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, ds, dsPrev *dataset.Dataset, sw SaveSwitches) (ref reporef.DatasetRef, err error) {
+	return createDataset(ctx, r, writeDest, ds, dsPrev, sw, true)
+}
+
+// createDataset writes a dataset version, only moving the refstore's head to
+// the new version when updateRefs is true
+func createDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, ds, dsPrev *dataset.Dataset, sw SaveSwitches, updateRefs bool) (ref reporef.DatasetRef, err error) {
 	var (
 		pro     *profile.Profile
 		path    string
@@ -157,7 +175,7 @@ func CreateDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, d
 		log.Debugf("dsfs.CreateDataset: %s", err)
 		return
 	}
-	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+	if updateRefs && ds.PreviousPath != "" && ds.PreviousPath != "/" {
 		prev := reporef.DatasetRef{
 			ProfileID: pro.ID,
 			Peername:  pro.Peername,
@@ -179,9 +197,11 @@ func CreateDataset(ctx context.Context, r repo.Repo, writeDest qfs.Filesystem, d
 		Path:      path,
 	}
 
-	if err = r.PutRef(ref); err != nil {
-		log.Debugf("r.PutRef: %s", err)
-		return
+	if updateRefs {
+		if err = r.PutRef(ref); err != nil {
+			log.Debugf("r.PutRef: %s", err)
+			return
+		}
 	}
 
 	ds, err = dsfs.LoadDataset(ctx, r.Store(), ref.Path)
@@ -11,11 +11,14 @@ import (
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/startf"
+	skyqri "github.com/qri-io/qri/startf/qri"
 )
 
 // TODO(dustmop): Tests. Especially once the `apply` command exists.
 
-// TransformApply applies the transform script to order to modify the changing dataset
+// TransformApply applies the transform script to order to modify the changing dataset.
+// Additional execution options, like resource limits, are passed to the script
+// runtime
 func TransformApply(
 	ctx context.Context,
 	ds *dataset.Dataset,
@@ -24,6 +27,7 @@ func TransformApply(
 	str ioes.IOStreams,
 	scriptOut io.Writer,
 	secrets map[string]string,
+	execOpts ...func(*startf.ExecOpts),
 ) error {
 	pro, err := r.Profile()
 	if err != nil {
@@ -56,7 +60,9 @@ func TransformApply(
 		startf.SetErrWriter(scriptOut),
 		startf.SetSecrets(secrets),
 		startf.AddDatasetLoader(loader),
+		startf.AddLogFunc(transformLogFunc(r, loader)),
 	}
+	opts = append(opts, execOpts...)
 
 	if err = startf.ExecScript(ctx, target, head, opts...); err != nil {
 		return err
@@ -66,3 +72,19 @@ func TransformApply(
 
 	return nil
 }
+
+// transformLogFunc creates a function that lists dataset history for
+// transform scripts, resolving references with loader
+func transformLogFunc(r repo.Repo, loader dsref.ParseResolveLoad) skyqri.LogFunc {
+	return func(ctx context.Context, refstr string, limit, offset int) ([]DatasetLogItem, error) {
+		ds, err := loader(ctx, refstr)
+		if err != nil {
+			return nil, err
+		}
+		if ds.BodyFile() != nil {
+			ds.BodyFile().Close()
+		}
+		ref := dsref.Ref{Username: ds.Peername, Name: ds.Name, Path: ds.Path}
+		return DatasetLog(ctx, r, ref, limit, offset, false)
+	}
+}
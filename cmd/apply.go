@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewApplyCommand creates a new `qri apply` cobra command for running
+// transform scripts without saving
+func NewApplyCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &ApplyOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "apply [DATASET]",
+		Short: "run a transform script without saving",
+		Long: `Apply runs a transform script against the latest version of a dataset &
+shows the result, without committing a new version. Use apply to iterate on a
+transform script safely before saving it with 'qri save'.
+
+Apply prints the meta & structure the transform produces, a preview of the
+body, and a diff against the latest version of the dataset. Components the
+transform doesn't change carry over from the latest version, as they would
+when saving.
+
+Without a --file flag, apply runs the transform script saved with the
+dataset.
+
+The --http-fixture flag makes transforms reproducible offline. In "record"
+mode, http responses the download step receives are saved to a fixture file
+next to the transform script, named like "transform.fixture.json". In
+"replay" mode, responses are served from the fixture without using the
+network. Fixtures don't store request bodies, or response headers & query
+parameters that look like credentials, like cookies & API keys.`,
+		Example: `  # run a new transform script against me/annual_pop:
+  $ qri apply --file transform.star me/annual_pop
+
+  # re-run the transform saved with me/annual_pop:
+  $ qri apply me/annual_pop
+
+  # run a transform that creates a new dataset:
+  $ qri apply --file transform.star
+
+  # replay http responses recorded to transform.fixture.json, without using
+  # the network:
+  $ qri apply --file transform.star --http-fixture replay me/annual_pop`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.FilePath, "file", "", "path to a transform script file")
+	cmd.MarkFlagFilename("file", "star")
+	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
+	cmd.Flags().StringVar(&o.HTTPFixture, "http-fixture", "", "record or replay http responses with a fixture file next to the transform script. one of [record,replay]")
+	cmd.Flags().IntVar(&o.PreviewSize, "preview-size", 0, "number of body entries to show, default 100")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "pretty", "output format. one of [json,pretty]")
+
+	return cmd
+}
+
+// ApplyOptions encapsulates state for the apply command
+type ApplyOptions struct {
+	ioes.IOStreams
+
+	Refs        *RefSelect
+	FilePath    string
+	Secrets     []string
+	HTTPFixture string
+	PreviewSize int
+	Format      string
+
+	TransformMethods *lib.TransformMethods
+}
+
+// Complete adds any missing configuration that can only be added just before
+// calling Run
+func (o *ApplyOptions) Complete(f Factory, args []string) (err error) {
+	if o.TransformMethods, err = f.TransformMethods(); err != nil {
+		return err
+	}
+
+	if o.Refs, err = GetCurrentRefSelect(f, args, 1, nil); err != nil {
+		// an empty reference applies the transform to a new dataset
+		if err != repo.ErrEmptyRef {
+			return err
+		}
+		err = nil
+	}
+
+	return qfs.AbsPath(&o.FilePath)
+}
+
+// Run executes the apply command
+func (o *ApplyOptions) Run() (err error) {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	p := &lib.ApplyParams{
+		Refstr:       o.Refs.Ref(),
+		ScriptPath:   o.FilePath,
+		PreviewSize:  o.PreviewSize,
+		ScriptOutput: o.ErrOut,
+		HTTPFixture:  o.HTTPFixture,
+	}
+
+	if o.Secrets != nil {
+		if !confirm(o.ErrOut, o.In, `
+Warning: You are providing secrets to a dataset transformation.
+Never provide secrets to a transformation you do not trust.
+continue?`, true) {
+			return
+		}
+		if p.Secrets, err = parseSecrets(o.Secrets...); err != nil {
+			return err
+		}
+	}
+
+	res := &lib.ApplyResult{}
+	if err = o.TransformMethods.Apply(p, res); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		return json.NewEncoder(o.Out).Encode(res)
+	}
+
+	buf := &bytes.Buffer{}
+	ds := res.Data
+	if ds.Meta != nil {
+		if err = writeApplyComponent(buf, "meta", ds.Meta); err != nil {
+			return err
+		}
+	}
+	if ds.Structure != nil {
+		if err = writeApplyComponent(buf, "structure", ds.Structure); err != nil {
+			return err
+		}
+		title := fmt.Sprintf("body (%d entries)", ds.Structure.Entries)
+		if err = writeApplyComponent(buf, title, ds.Body); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("diff:\n")
+	if err = writeDiff(buf, res.Diff, false); err != nil {
+		return err
+	}
+
+	printToPager(o.Out, buf)
+	return nil
+}
+
+// writeApplyComponent formats a titled component of a transform result as
+// yaml
+func writeApplyComponent(buf *bytes.Buffer, title string, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "%s:\n", title)
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestApply(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_apply")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save me/movies --body testdata/movies/body_ten.csv")
+	head := run.LookupVersionInfo(t, "me/movies").Path
+
+	output := run.MustExec(t, "qri apply --file testdata/movies/tf_one_movie.star me/movies")
+	for _, expect := range []string{"structure:", "body (1 entries):", "Spectre", "diff:"} {
+		if !strings.Contains(output, expect) {
+			t.Errorf("expected output to contain %q, got:\n%s", expect, output)
+		}
+	}
+
+	output = run.MustExec(t, "qri apply --file testdata/movies/tf_set_meta.star --format json me/movies")
+	res := struct {
+		Data *dataset.Dataset `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(output), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Meta == nil || res.Data.Meta.Title != "Did Set Title" {
+		t.Errorf("expected transform to set meta title, got: %v", res.Data.Meta)
+	}
+	if res.Data.Structure == nil || res.Data.Structure.Entries != 8 {
+		t.Errorf("expected unchanged body to carry over from head, got structure: %v", res.Data.Structure)
+	}
+
+	if got := run.LookupVersionInfo(t, "me/movies").Path; got != head {
+		t.Errorf("expected apply not to commit. head was %s, now %s", head, got)
+	}
+
+	if err := run.ExecCommand("qri apply me/movies"); err == nil {
+		t.Error("expected apply without a transform script to fail")
+	}
+}
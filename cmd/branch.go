@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewBranchCommand creates a new `qri branch` cobra command for working with
+// named lines of dataset history
+func NewBranchCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &BranchOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "branch [DATASET] [BRANCH]",
+		Short: "create, list, delete & switch dataset branches",
+		Long: `Branch creates a named line of history for a dataset, starting from an
+existing version. Versions saved to a branch don't change the dataset's latest
+version, so branches are a place to stage changes without affecting anyone
+relying on the dataset. The history everyone sees is kept on the "main" branch.
+
+Save to a branch by adding its name to a dataset reference, like
+"me/dataset:cleanup". The same syntax selects the latest version of a branch
+anywhere a dataset reference is accepted.
+
+In a linked working directory, --switch replaces the dataset files with the
+latest version of a branch, and saves from the directory then go to that
+branch. Switch back with "qri branch --switch main". Without a branch name,
+branch lists the branches of a dataset.`,
+		Example: `  # start a branch from the latest version of me/annual_pop:
+  $ qri branch me/annual_pop cleanup
+
+  # start a branch from an earlier version:
+  $ qri branch me/annual_pop~2 backfill
+
+  # save to a branch:
+  $ qri save --body new_body.csv me/annual_pop:cleanup
+
+  # see the history of a branch:
+  $ qri log me/annual_pop:cleanup
+
+  # list branches:
+  $ qri branch me/annual_pop
+
+  # switch a linked working directory to a branch:
+  $ qri branch --switch cleanup
+
+  # delete a branch:
+  $ qri branch --delete me/annual_pop cleanup`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.Delete, "delete", "d", false, "delete the branch")
+	cmd.Flags().BoolVarP(&o.Switch, "switch", "s", false, "switch the linked working directory to the branch")
+
+	return cmd
+}
+
+// BranchOptions encapsulates state for the branch command
+type BranchOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Branch string
+	Delete bool
+	Switch bool
+
+	LogMethods *lib.LogMethods
+	FSIMethods *lib.FSIMethods
+}
+
+// Complete adds any missing configuration that can only be added just before
+// calling Run
+func (o *BranchOptions) Complete(f Factory, args []string) (err error) {
+	if o.LogMethods, err = f.LogMethods(); err != nil {
+		return err
+	}
+	if o.FSIMethods, err = f.FSIMethods(); err != nil {
+		return err
+	}
+
+	if o.Switch {
+		if len(args) != 1 {
+			return fmt.Errorf("switching requires exactly one branch name")
+		}
+		if o.Delete {
+			return fmt.Errorf("can't switch & delete at the same time")
+		}
+		o.Branch = args[0]
+		if o.Refs, err = GetLinkedRefSelect(); err != nil {
+			return fmt.Errorf("switching branches requires a linked working directory")
+		}
+		return nil
+	}
+
+	if len(args) == 2 {
+		o.Branch = args[1]
+		args = args[:1]
+	}
+	if o.Delete && o.Branch == "" {
+		return fmt.Errorf("a dataset & branch name are required to delete a branch")
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, 1, EnsureFSIAgrees(o.FSIMethods))
+	return err
+}
+
+// Run executes the branch command
+func (o *BranchOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	if o.Switch {
+		p := &lib.SwitchBranchParams{Dir: o.Refs.Dir(), Branch: o.Branch}
+		var res string
+		if err := o.FSIMethods.SwitchBranch(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "switched working directory to %s", res)
+		return nil
+	}
+
+	if o.Branch == "" {
+		branches := []lib.Branch{}
+		if err := o.LogMethods.Branches(&lib.BranchesParams{Ref: o.Refs.Ref()}, &branches); err != nil {
+			return err
+		}
+		buf := &bytes.Buffer{}
+		for _, b := range branches {
+			fmt.Fprintf(buf, "%s\t%s\t%d versions\n", b.Name, b.Path, b.Versions)
+		}
+		printToPager(o.Out, buf)
+		return nil
+	}
+
+	p := &lib.BranchParams{Ref: o.Refs.Ref(), Branch: o.Branch, Delete: o.Delete}
+	res := dsref.Ref{}
+	if err := o.LogMethods.Branch(p, &res); err != nil {
+		return err
+	}
+	if o.Delete {
+		printSuccess(o.Out, "deleted branch %s:%s", res.Human(), o.Branch)
+	} else {
+		printSuccess(o.Out, "created branch %s:%s from %s", res.Human(), o.Branch, res.Path)
+	}
+	return nil
+}
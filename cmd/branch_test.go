@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBranch(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_branch")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/test_movies")
+	head := run.LookupVersionInfo(t, "me/test_movies").Path
+
+	run.MustExec(t, "qri branch me/test_movies cleanup")
+	if err := run.ExecCommand("qri branch me/test_movies cleanup"); err == nil {
+		t.Errorf("expected creating an existing branch to error")
+	}
+	if err := run.ExecCommand("qri save --body=testdata/movies/body_twenty.csv me/test_movies:nope"); err == nil {
+		t.Errorf("expected saving to a missing branch to error")
+	}
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_twenty.csv me/test_movies:cleanup")
+
+	// saving to a branch leaves the latest version alone
+	if got := run.LookupVersionInfo(t, "me/test_movies").Path; got != head {
+		t.Errorf("expected branch save not to move the head. expected: %q, got: %q", head, got)
+	}
+	output := run.MustExec(t, "qri get structure.entries me/test_movies")
+	if expect := "8\n\n"; output != expect {
+		t.Errorf("get main mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExec(t, "qri get structure.entries me/test_movies:cleanup")
+	if expect := "18\n\n"; output != expect {
+		t.Errorf("get branch mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExec(t, "qri get structure.entries me/test_movies:cleanup~1")
+	if expect := "8\n\n"; output != expect {
+		t.Errorf("get relative to branch mismatch. expected: %q, got: %q", expect, output)
+	}
+
+	output = run.MustExec(t, "qri log me/test_movies:cleanup")
+	if count := strings.Count(output, "Commit:"); count != 2 {
+		t.Errorf("expected branch log to have 2 versions, got %d:\n%s", count, output)
+	}
+	output = run.MustExec(t, "qri log me/test_movies")
+	if count := strings.Count(output, "Commit:"); count != 1 {
+		t.Errorf("expected main log to have 1 version, got %d:\n%s", count, output)
+	}
+
+	// ranges list versions of the branch the end of the range is on, and
+	// error when the start of the range isn't in its history
+	output = run.MustExec(t, "qri log me/test_movies..me/test_movies:cleanup")
+	if count := strings.Count(output, "Commit:"); count != 1 {
+		t.Errorf("expected range onto branch to have 1 version, got %d:\n%s", count, output)
+	}
+	if err := run.ExecCommand("qri log me/test_movies:cleanup..me/test_movies"); err == nil {
+		t.Errorf("expected range starting outside the history of its end to error")
+	}
+
+	output = run.MustExec(t, "qri branch me/test_movies")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "main\t"+head+"\t1 versions") || !strings.HasPrefix(lines[1], "cleanup\t") {
+		t.Errorf("unexpected branch list:\n%s", output)
+	}
+
+	run.MustExec(t, "qri branch --delete me/test_movies cleanup")
+	if err := run.ExecCommand("qri get me/test_movies:cleanup"); err == nil {
+		t.Errorf("expected getting a deleted branch to error")
+	}
+}
+
+func TestBranchSwitchWorkingDirectory(t *testing.T) {
+	run := NewFSITestRunner(t, "qri_test_branch_switch")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/movies")
+	head := run.LookupVersionInfo(t, "me/movies").Path
+	run.MustExec(t, "qri branch me/movies cleanup")
+
+	run.ChdirToRoot()
+	run.MustExec(t, "qri checkout me/movies")
+	workDir := run.ChdirToWorkDir("movies")
+
+	run.MustExec(t, "qri branch --switch cleanup")
+	run.MustWriteFile(t, "meta.json", `{"title": "cleaned up"}`)
+	if err := run.ExecCommand("qri branch --switch main"); err == nil {
+		t.Errorf("expected switching with unsaved changes to error")
+	}
+
+	// saving in a directory switched to a branch saves to the branch
+	run.MustExec(t, "qri save")
+	if got := run.LookupVersionInfo(t, "me/movies").Path; got != head {
+		t.Errorf("expected branch save not to move the head. expected: %q, got: %q", head, got)
+	}
+	output := run.MustExec(t, "qri get meta.title me/movies:cleanup")
+	if expect := "cleaned up\n\n"; output != expect {
+		t.Errorf("get branch mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExecCombinedOutErr(t, "qri status")
+	if diff := cmpTextLines(cleanStatusMessage("test_peer/movies:cleanup"), output); diff != "" {
+		t.Errorf("qri status (-want +got):\n%s", diff)
+	}
+
+	// switching back replaces the files with the main version
+	run.MustExec(t, "qri branch --switch main")
+	if _, err := os.Stat(filepath.Join(workDir, "meta.json")); !os.IsNotExist(err) {
+		t.Errorf("expected meta.json to be removed after switching back to main")
+	}
+	output = run.MustExecCombinedOutErr(t, "qri status")
+	if diff := cmpTextLines(cleanStatusMessage("test_peer/movies"), output); diff != "" {
+		t.Errorf("qri status (-want +got):\n%s", diff)
+	}
+}
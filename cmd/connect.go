@@ -24,11 +24,19 @@ func NewConnectCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		Long: `While it’s not totally accurate, connect is like starting a server. Running 
 connect will start a process and stay there until you exit the process 
 (ctrl+c from the terminal, or killing the process using tools like activity 
-monitor on the mac, or the aptly-named “kill” command). Connect does three main 
+monitor on the mac, or the aptly-named “kill” command). Connect does four main 
 things:
 - Connect to the qri distributed network
 - Connect to IPFS
 - Start a local API server
+- Run dataset transforms on a schedule, if the scheduler is enabled
+
+The scheduler is off by default. Turn it on with:
+  $ qri config set scheduler.enabled true
+Transforms run on the cron-style schedule set in the "schedule" key of a 
+dataset's transform config, or in the scheduler section of the qri config. 
+Runs that change the dataset save a new version. Every run is recorded in the 
+dataset's logbook.
 
 When you run connect you are connecting to the distributed web, interacting with
 peers & swapping data.`,
@@ -111,6 +119,15 @@ func (o *ConnectOptions) Complete(f Factory, args []string) (err error) {
 // Run executes the connect command with currently configured state
 func (o *ConnectOptions) Run() error {
 	ctx := context.Background()
+
+	if cfg := o.inst.Config(); cfg.Scheduler != nil && cfg.Scheduler.Enabled {
+		go func() {
+			if err := lib.NewTransformScheduler(o.inst).Start(ctx); err != nil {
+				fmt.Fprintf(o.ErrOut, "error running transform scheduler: %s\n", err)
+			}
+		}()
+	}
+
 	err := api.New(o.inst).Serve(ctx)
 	if err != nil && err.Error() == "http: Server closed" {
 		return nil
@@ -7,6 +7,7 @@ import (
 
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/lib"
 	"github.com/spf13/cobra"
 )
@@ -30,7 +31,12 @@ qri diff works on structured data. qri diffs are measured in elements
 (think cells in a spreadsheet), each change is either an insert (added 
 elements), delete (removed elements), or update (changed values).
 
-Each change has a path that locates it within the document`,
+Each change has a path that locates it within the document.
+
+Body diffs of datasets whose structure schema declares a "primaryKey", or
+with --key, compare rows by key: each added, removed & modified row is listed
+once, with the cells that changed in modified rows. Inserting a row at the top
+of a body then shows as one added row, instead of every row changing.`,
 		Example: `  # Diff between a latest version & the next one back:
   $ qri diff me/annual_pop
 
@@ -40,6 +46,15 @@ Each change has a path that locates it within the document`,
   # Diff dataset body against its last version:
   $ qri diff body me/annual_pop
 
+  # Diff the version from three saves ago against the latest:
+  $ qri diff me/annual_pop~3..me/annual_pop
+
+  # Diff the latest version as of a date against the one before it:
+  $ qri diff me/annual_pop@{2020-01-01}~1 me/annual_pop@{2020-01-01}
+
+  # Diff a body row by row, matching rows by the "country" & "year" columns:
+  $ qri diff body --key country,year me/annual_pop
+
   # Diff two dataset meta components:
   $ qri diff meta me/population_2016 me/population_2017
 
@@ -61,6 +76,9 @@ Each change has a path that locates it within the document`,
 
 	cmd.Flags().StringVarP(&o.Format, "format", "f", "pretty", "output format. one of [json,pretty]")
 	cmd.Flags().BoolVar(&o.Summary, "summary", false, "just output the summary")
+	cmd.Flags().StringSliceVar(&o.Keys, "key", nil, "comma separated columns that identify body rows, diffing the body row by row")
+	cmd.Flags().IntVar(&o.Limit, "limit", 0, "for row by row diffs, max number of changed rows to show. 0 shows all")
+	cmd.Flags().IntVar(&o.Offset, "offset", 0, "for row by row diffs, number of changed rows to skip")
 
 	return cmd
 }
@@ -73,6 +91,9 @@ type DiffOptions struct {
 	Selector string
 	Format   string
 	Summary  bool
+	Keys     []string
+	Limit    int
+	Offset   int
 
 	DatasetMethods *lib.DatasetMethods
 }
@@ -112,6 +133,9 @@ func (o *DiffOptions) Run() (err error) {
 
 	p := &lib.DiffParams{
 		Selector: o.Selector,
+		Keys:     o.Keys,
+		Limit:    o.Limit,
+		Offset:   o.Offset,
 	}
 
 	if o.Refs.IsLinked() {
@@ -121,6 +145,11 @@ func (o *DiffOptions) Run() (err error) {
 		// left = me/example_ds@head   right = me/example_ds@working_dir
 		p.LeftSide = o.Refs.Ref()
 		p.WorkingDir = o.Refs.Dir()
+	} else if len(o.Refs.RefList()) == 1 && dsref.IsRevisionRange(o.Refs.Ref()) {
+		// > qri diff me/example_ds~2..me/example_ds
+		//
+		// left = me/example_ds~2   right = me/example_ds@head
+		p.LeftSide = o.Refs.Ref()
 	} else if len(o.Refs.RefList()) == 1 {
 		// > qri diff me/example_ds
 		//
@@ -35,6 +35,7 @@ type Factory interface {
 	ProfileMethods() (*lib.ProfileMethods, error)
 	SearchMethods() (*lib.SearchMethods, error)
 	SQLMethods() (*lib.SQLMethods, error)
+	TransformMethods() (*lib.TransformMethods, error)
 	FSIMethods() (*lib.FSIMethods, error)
 	RenderMethods() (*lib.RenderMethods, error)
 
@@ -185,6 +185,11 @@ func (t TestFactory) SQLMethods() (*lib.SQLMethods, error) {
 	return lib.NewSQLMethods(t.inst), nil
 }
 
+// TransformMethods generates a lib.TransformMethods from internal state
+func (t TestFactory) TransformMethods() (*lib.TransformMethods, error) {
+	return lib.NewTransformMethods(t.inst), nil
+}
+
 // RenderMethods generates a lib.RenderMethods from internal state
 func (t TestFactory) RenderMethods() (*lib.RenderMethods, error) {
 	return lib.NewRenderMethods(t.inst), nil
@@ -8,6 +8,7 @@ import (
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/base/columnar"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/lib"
 	"github.com/spf13/cobra"
@@ -26,7 +27,11 @@ further to specific fields in each section, use dot notation. The get
 command prints to the console in yaml format, by default.
 
 Check out https://qri.io/docs/reference/dataset/ to learn about each section of the 
-dataset and its fields.`,
+dataset and its fields.
+
+Earlier versions can be selected with git-style revisions like "me/ds~2" or
+"me/ds@{2020-01-01}". Getting a revision range like "me/ds~3..me/ds" prints
+a list of the selected component of each version in the range, newest first.`,
 		Example: `  # Print the entire dataset to the console:
   $ qri get me/annual_pop
 
@@ -34,7 +39,16 @@ dataset and its fields.`,
   $ qri get meta me/annual_pop
 
   # Print the dataset body size to the console:
-  $ qri get structure.length me/annual_pop`,
+  $ qri get structure.length me/annual_pop
+
+  # Print the commit of the version before the latest:
+  $ qri get commit me/annual_pop~1
+
+  # Print the body size of each of the last three versions:
+  $ qri get structure.length me/annual_pop~3..me/annual_pop
+
+  # Write the body of a tabular dataset to a parquet file (experimental):
+  $ qri get body --experimental-formats --format parquet -o annual_pop.parquet me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -51,12 +65,13 @@ dataset and its fields.`,
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json, yaml]")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json, yaml, csv, cbor, zip, parquet, arrow]")
 	cmd.Flags().BoolVar(&o.Pretty, "pretty", false, "whether to print output with indentation, only for json format")
 	cmd.Flags().IntVar(&o.PageSize, "page-size", -1, "for body, limit how many entries to get per page")
 	cmd.Flags().IntVar(&o.Page, "page", -1, "for body, page at which to get entries")
 	cmd.Flags().BoolVarP(&o.All, "all", "a", true, "for body, whether to get all entries")
 	cmd.Flags().StringVarP(&o.Outfile, "outfile", "o", "", "file to write output to")
+	cmd.Flags().BoolVar(&o.ExperimentalFormats, "experimental-formats", false, "experimental: enable the parquet & arrow formats")
 
 	return cmd
 }
@@ -77,6 +92,8 @@ type GetOptions struct {
 	HasPretty bool
 	Outfile   string
 
+	ExperimentalFormats bool
+
 	DatasetMethods *lib.DatasetMethods
 }
 
@@ -151,6 +168,8 @@ func (o *GetOptions) Run() (err error) {
 		Limit:        page.Limit(),
 		All:          o.All,
 		Outfile:      o.Outfile,
+
+		ExperimentalFormats: o.ExperimentalFormats,
 		// Generate a filename only if we're outputting to a terminal (not a pipe), and we're
 		// outputting a zip. lib.Get will also check that we're outputting a zip, this check is
 		// repeated here for clarity.
@@ -166,6 +185,11 @@ func (o *GetOptions) Run() (err error) {
 		return nil
 	}
 	if len(res.Bytes) > 0 {
+		if columnar.IsFormat(o.Format) {
+			// binary formats are written as-is, without paging
+			_, err = o.Out.Write(res.Bytes)
+			return err
+		}
 		buf := bytes.NewBuffer(res.Bytes)
 		buf.Write([]byte{'\n'})
 		printToPager(o.Out, buf)
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qri/base/columnar"
 )
 
 func TestGetComplete(t *testing.T) {
@@ -241,6 +244,29 @@ func TestGetDatasetFromRepo(t *testing.T) {
 	}
 }
 
+func TestGetBodyColumnar(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "get_body_columnar")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/my_ds")
+
+	// Columnar formats are experimental, and must be enabled
+	if err := run.ExecCommand("qri get body --format parquet me/my_ds"); !errors.Is(err, columnar.ErrExperimental) {
+		t.Errorf("expected parquet without experimental formats to fail, got: %v", err)
+	}
+
+	// Columnar formats are binary, and must be written without a trailing newline
+	output := run.MustExec(t, "qri get body --experimental-formats --format parquet me/my_ds")
+	if !strings.HasPrefix(output, "PAR1") || !strings.HasSuffix(output, "PAR1") {
+		t.Errorf("expected parquet output to start & end with magic bytes")
+	}
+
+	output = run.MustExec(t, "qri get body --experimental-formats --format arrow me/my_ds")
+	if !strings.HasSuffix(output, "\xff\xff\xff\xff\x00\x00\x00\x00") {
+		t.Errorf("expected arrow output to end with an end of stream marker")
+	}
+}
+
 func TestGetDatasetCheckedOut(t *testing.T) {
 	run := NewFSITestRunner(t, "get_dataset_checked_out")
 	defer run.Delete()
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewLineageCommand creates a new `qri lineage` cobra command for showing the
+// upstream inputs of a dataset version
+func NewLineageCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &LineageOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "lineage [DATASET]",
+		Short: "show the upstream datasets & URLs a dataset version was made from",
+		Long: `Lineage shows the graph of inputs a dataset version was produced from.
+When a transform runs, the exact versions of the datasets it loads & the URLs
+its download step fetches are recorded in the transform component. Lineage
+follows those records upstream, through the transforms of each upstream
+version.
+
+URLs are recorded without query strings, which often hold API keys. Upstream
+versions that aren't stored locally are listed with an error, and aren't
+followed further.`,
+		Example: `  # show the lineage of the latest version of me/annual_pop:
+  $ qri lineage me/annual_pop
+
+  # show only the direct inputs of a version:
+  $ qri lineage --depth 1 me/annual_pop@/ipfs/QmFoo`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().IntVar(&o.Depth, "depth", 0, "maximum number of transform steps to follow, 0 is unlimited")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "pretty", "output format. one of [json,pretty]")
+
+	return cmd
+}
+
+// LineageOptions encapsulates state for the lineage command
+type LineageOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Depth  int
+	Format string
+
+	DatasetMethods *lib.DatasetMethods
+}
+
+// Complete adds any missing configuration that can only be added just before
+// calling Run
+func (o *LineageOptions) Complete(f Factory, args []string) (err error) {
+	if o.DatasetMethods, err = f.DatasetMethods(); err != nil {
+		return err
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, 1, nil)
+	return err
+}
+
+// Run executes the lineage command
+func (o *LineageOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	res := &lib.LineageNode{}
+	if err := o.DatasetMethods.Lineage(&lib.LineageParams{Ref: o.Refs.Ref(), Depth: o.Depth}, res); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		return json.NewEncoder(o.Out).Encode(res)
+	}
+
+	buf := &bytes.Buffer{}
+	writeLineageNode(buf, res, "", "")
+	printToPager(o.Out, buf)
+	return nil
+}
+
+// writeLineageNode prints a lineage graph as a tree
+func writeLineageNode(buf *bytes.Buffer, node *lib.LineageNode, prefix, childPrefix string) {
+	buf.WriteString(prefix + node.Ref)
+	if node.Error != "" {
+		fmt.Fprintf(buf, " (%s)", node.Error)
+	}
+	buf.WriteByte('\n')
+	for i, in := range node.Inputs {
+		if i == len(node.Inputs)-1 {
+			writeLineageNode(buf, in, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			writeLineageNode(buf, in, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
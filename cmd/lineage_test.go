@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineage(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_lineage")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save me/movies --body testdata/movies/body_ten.csv")
+	upstream := run.LookupVersionInfo(t, "me/movies").Path
+	run.MustExec(t, "qri save me/first_movie --body testdata/movies/body_two.json")
+	run.MustExec(t, "qri save me/first_movie --file testdata/movies/tf_first_movie.star")
+
+	expect := "test_peer/movies@" + upstream
+	output := run.MustExec(t, "qri get transform.resources me/first_movie")
+	if !strings.Contains(output, expect) {
+		t.Errorf("expected transform resources to record %s, got:\n%s", expect, output)
+	}
+
+	output = run.MustExec(t, "qri lineage me/first_movie")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "test_peer/first_movie@") || lines[1] != "└── "+expect {
+		t.Errorf("unexpected lineage output:\n%s", output)
+	}
+}
@@ -33,10 +33,19 @@ working backwards in time.
 
 The log command can get the list of versions for a local dataset or a dataset
 on the network at a remote.
+
+Versions can be selected relative to the latest one with git-style revisions:
+"me/ds~3" is three versions back, "me/ds@{-1}" is one version back, and
+"me/ds@{2020-01-01}" is the latest version saved at or before a date. A range
+like "me/ds~5..me/ds" lists the versions after the left side, up to and
+including the right side.
 `,
 		Example: `  # Show log for the local dataset b5/precip:
   $ qri log b5/precip
 
+  # Show the last five versions of b5/precip:
+  $ qri log b5/precip~5..b5/precip
+
   # Show log for a dataset on the Qri Cloud registry called ramfox/league_stats
   $ qri log ramfox/league_stats
 	
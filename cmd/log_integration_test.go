@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // Test that deleting an entire dataset works properly with the logbook.
@@ -128,3 +131,44 @@ func TestLogAndDeletes(t *testing.T) {
 	// TODO(dlong): Get the logbook, verify that it contains 2 books. The first should end
 	// with a deletion, the second should have only two entries (1 init, 1 save).
 }
+
+// Test that git-style revisions & revision ranges select versions for log,
+// diff & get
+func TestLogRevisionRange(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_log_revision_range")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/test_movies")
+	run.MustExec(t, "qri save --body=testdata/movies/body_twenty.csv me/test_movies")
+	run.MustExec(t, "qri save --body=testdata/movies/body_thirty.csv me/test_movies")
+
+	output := run.MustExec(t, "qri log me/test_movies~2..me/test_movies")
+	if !strings.Contains(output, "2   Commit") || strings.Contains(output, "3   Commit") {
+		t.Errorf("expected log of range to list two versions, got:\n%s", output)
+	}
+
+	// the first version was saved at 01:01:01 UTC, the second a minute later
+	output = run.MustExec(t, "qri get structure.entries me/test_movies@{2001-01-01T01:01:30Z}")
+	if expect := "8\n\n"; output != expect {
+		t.Errorf("get as of date mismatch. expected: %q, got: %q", expect, output)
+	}
+
+	output = run.MustExec(t, "qri get structure.entries me/test_movies@{-2}..me/test_movies")
+	if expect := "- 28\n- 18\n\n"; output != expect {
+		t.Errorf("get of range mismatch. expected: %q, got: %q", expect, output)
+	}
+
+	prev := run.MustExec(t, "qri diff body me/test_movies")
+	output = run.MustExec(t, "qri diff body me/test_movies~1..me/test_movies")
+	if diff := cmp.Diff(prev, output); diff != "" {
+		t.Errorf("diff of range mismatch (-want +got):\n%s", diff)
+	}
+	output = run.MustExec(t, "qri diff body me/test_movies~2 me/test_movies^")
+	if !strings.Contains(output, "10 inserts. 0 deletes.") || !strings.Contains(output, "+17: ") {
+		t.Errorf("unexpected diff of revisions:\n%s", output)
+	}
+
+	if err := run.ExecCommand("qri log me/test_movies~3"); err == nil {
+		t.Errorf("expected stepping back past the first version to error")
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/base/merge"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewMergeCommand creates a new `qri merge` cobra command for reconciling
+// diverged dataset histories
+func NewMergeCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &MergeOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "merge [DATASET] OTHER",
+		Short: "merge another version of a dataset into its history",
+		Long: `Merge combines the changes another version of a dataset made with the
+changes made to a dataset since the two histories split, saving the result as a
+new version. Merge into a branch with a reference like "me/dataset:cleanup",
+and merge a branch back with "qri merge me/dataset me/dataset:cleanup". Merge
+also reconciles a local dataset with a version pulled from a peer.
+
+Merge finds the most recent version both histories share, and keeps changes
+either side made since then. Body rows are matched by the primary key of the
+structure's schema, set with --key, or by row number when there's no key.
+Changes to different cells of the same row are combined. Meta & structure are
+merged field by field.
+
+When both sides change the same value differently, the merge has a conflict.
+--prefer resolves conflicts by keeping one side. In a linked working directory,
+merge writes the merged files to the directory, keeping our side of each
+conflict, and status lists the components with conflicts. Edit the files to
+resolve them and run "qri merge --continue" to save, or "qri merge --abort" to
+restore the directory. Without a working directory, merges with conflicts
+aren't saved.`,
+		Example: `  # merge a branch into the latest version:
+  $ qri merge me/annual_pop me/annual_pop:cleanup
+
+  # merge the latest version into the branch of a linked working directory:
+  $ qri merge me/annual_pop
+
+  # match body rows by the "country" and "year" columns:
+  $ qri merge --key country,year me/annual_pop me/annual_pop:cleanup
+
+  # resolve conflicts with the other version:
+  $ qri merge --prefer theirs me/annual_pop me/annual_pop:cleanup
+
+  # save a merge once conflicts are resolved:
+  $ qri merge --continue`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.Keys, "key", nil, "comma separated columns that identify body rows")
+	cmd.Flags().StringVar(&o.Prefer, "prefer", "", "resolve conflicts with one side of the merge, one of \"ours\" or \"theirs\"")
+	cmd.Flags().BoolVar(&o.Continue, "continue", false, "save a merge once conflicts are resolved")
+	cmd.Flags().BoolVar(&o.Abort, "abort", false, "abandon a merge with conflicts, restoring the working directory")
+
+	return cmd
+}
+
+// MergeOptions encapsulates state for the merge command
+type MergeOptions struct {
+	ioes.IOStreams
+
+	Refs     *RefSelect
+	Theirs   string
+	Keys     []string
+	Prefer   string
+	Continue bool
+	Abort    bool
+
+	DatasetMethods *lib.DatasetMethods
+}
+
+// Complete adds any missing configuration that can only be added just before
+// calling Run
+func (o *MergeOptions) Complete(f Factory, args []string) (err error) {
+	if o.DatasetMethods, err = f.DatasetMethods(); err != nil {
+		return err
+	}
+	fsiMethods, err := f.FSIMethods()
+	if err != nil {
+		return err
+	}
+
+	if !o.Continue && !o.Abort {
+		if len(args) == 0 {
+			return fmt.Errorf("a version to merge is required")
+		}
+		o.Theirs = args[len(args)-1]
+		args = args[:len(args)-1]
+	} else if len(args) > 1 {
+		return fmt.Errorf("continuing or aborting a merge accepts at most one dataset")
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, 1, EnsureFSIAgrees(fsiMethods))
+	return err
+}
+
+// Run executes the merge command
+func (o *MergeOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	p := &lib.MergeParams{
+		Ours:     o.Refs.Ref(),
+		Theirs:   o.Theirs,
+		Keys:     o.Keys,
+		Prefer:   o.Prefer,
+		Continue: o.Continue,
+		Abort:    o.Abort,
+	}
+	res := &lib.MergeResult{}
+	if err := o.DatasetMethods.Merge(p, res); err != nil {
+		var conflictErr *merge.ConflictError
+		if !errors.As(err, &conflictErr) {
+			return err
+		}
+		for _, c := range conflictErr.Conflicts {
+			printErr(o.Out, fmt.Errorf("  conflict: %s", c))
+		}
+		return fmt.Errorf("%s and wasn't saved. resolve them with --prefer, or merge in a linked working directory", err)
+	}
+
+	switch {
+	case o.Abort:
+		printSuccess(o.Out, "aborted merge, restored %s", res.Dir)
+	case res.UpToDate:
+		printInfo(o.Out, "already up to date")
+	case len(res.Conflicts) > 0:
+		for _, c := range res.Conflicts {
+			printErr(o.Out, fmt.Errorf("  conflict: %s", c))
+		}
+		printErr(o.Out, fmt.Errorf("\nfix conflicts in the working directory, then run `qri merge --continue`"))
+	default:
+		printSuccess(o.Out, "merged into %s", res.Ref)
+	}
+	return nil
+}
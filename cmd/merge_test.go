@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+)
+
+func TestMerge(t *testing.T) {
+	run := NewFSITestRunner(t, "qri_test_merge")
+	defer run.Delete()
+
+	writeMeta := func(name, title string) string {
+		path := filepath.Join(run.RootPath, name)
+		if err := ioutil.WriteFile(path, []byte(`{"meta":{"title":"`+title+`"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/movies")
+	run.MustExec(t, "qri branch me/movies cleanup")
+	run.MustExec(t, "qri save --body=testdata/movies/body_twenty.csv me/movies:cleanup")
+	run.MustExec(t, "qri save --file="+writeMeta("ours.json", "movies")+" me/movies")
+
+	// changes on both sides are combined
+	output := run.MustExec(t, "qri merge me/movies me/movies:cleanup")
+	if !strings.Contains(output, "merged into") {
+		t.Errorf("expected merge to save, got: %q", output)
+	}
+	output = run.MustExec(t, "qri get structure.entries me/movies")
+	if expect := "18\n\n"; output != expect {
+		t.Errorf("merged body mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExec(t, "qri get meta.title me/movies")
+	if expect := "movies\n\n"; output != expect {
+		t.Errorf("merged meta mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExec(t, "qri merge me/movies me/movies:cleanup")
+	if !strings.Contains(output, "already up to date") {
+		t.Errorf("expected merging again to be up to date, got: %q", output)
+	}
+
+	// conflicting changes aren't saved without a working directory
+	run.MustExec(t, "qri save --file="+writeMeta("theirs.json", "their movies")+" me/movies:cleanup")
+	run.MustExec(t, "qri save --file="+writeMeta("ours2.json", "our movies")+" me/movies")
+	head := run.LookupVersionInfo(t, "me/movies").Path
+	if err := run.ExecCommand("qri merge me/movies me/movies:cleanup"); err == nil {
+		t.Errorf("expected merge with conflicts to error")
+	} else if !strings.Contains(err.Error(), "merge has 1 conflicts") {
+		t.Errorf("expected error to count conflicts, got: %q", err)
+	}
+	if got := run.LookupVersionInfo(t, "me/movies").Path; got != head {
+		t.Errorf("expected merge with conflicts not to save. expected: %q, got: %q", head, got)
+	}
+
+	// bodies over the size limit aren't read into memory
+	limit := lib.MergeBodySizeLimit
+	lib.MergeBodySizeLimit = 1
+	if err := run.ExecCommand("qri merge --prefer theirs me/movies me/movies:cleanup"); err == nil {
+		t.Errorf("expected merging bodies over the size limit to error")
+	} else if !strings.Contains(err.Error(), "merges are limited to bodies of 1 bytes or less") {
+		t.Errorf("expected size limit error, got: %q", err)
+	}
+	lib.MergeBodySizeLimit = limit
+
+	run.MustExec(t, "qri merge --prefer theirs me/movies me/movies:cleanup")
+	output = run.MustExec(t, "qri get meta.title me/movies")
+	if expect := "their movies\n\n"; output != expect {
+		t.Errorf("resolved meta mismatch. expected: %q, got: %q", expect, output)
+	}
+}
+
+func TestMergeWorkingDirectoryConflicts(t *testing.T) {
+	run := NewFSITestRunner(t, "qri_test_merge_fsi")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/movies")
+	run.MustExec(t, "qri branch me/movies cleanup")
+	theirs := filepath.Join(run.RootPath, "theirs.json")
+	if err := ioutil.WriteFile(theirs, []byte(`{"meta":{"title":"their movies"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run.MustExec(t, "qri save --file="+theirs+" me/movies:cleanup")
+
+	run.ChdirToRoot()
+	run.MustExec(t, "qri checkout me/movies")
+	run.ChdirToWorkDir("movies")
+	run.MustWriteFile(t, "meta.json", `{"title": "our movies"}`)
+	run.MustExec(t, "qri save")
+	head := run.LookupVersionInfo(t, "me/movies").Path
+
+	output := run.MustExec(t, "qri merge me/movies:cleanup")
+	if !strings.Contains(output, "conflict: meta title") {
+		t.Errorf("expected merge to list the conflict, got: %q", output)
+	}
+	output = run.MustExecCombinedOutErr(t, "qri status")
+	if !strings.Contains(output, "conflict error: meta (source: meta.json)") {
+		t.Errorf("expected status to show the conflict, got: %q", output)
+	}
+	if err := run.ExecCommand("qri save"); err == nil {
+		t.Errorf("expected saving during a merge to error")
+	}
+	if got := run.LookupVersionInfo(t, "me/movies").Path; got != head {
+		t.Errorf("expected merge with conflicts not to save. expected: %q, got: %q", head, got)
+	}
+
+	run.MustWriteFile(t, "meta.json", `{"title": "all movies"}`)
+	run.MustExec(t, "qri merge --continue")
+	output = run.MustExec(t, "qri get meta.title me/movies")
+	if expect := "all movies\n\n"; output != expect {
+		t.Errorf("merged meta mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExecCombinedOutErr(t, "qri status")
+	if diff := cmpTextLines(cleanStatusMessage("test_peer/movies"), output); diff != "" {
+		t.Errorf("qri status (-want +got):\n%s", diff)
+	}
+}
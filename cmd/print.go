@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qri/base/rowdiff"
 	qrierr "github.com/qri-io/qri/errors"
 	"github.com/qri-io/qri/lib"
 )
@@ -190,20 +192,69 @@ func doesCommandExist(cmdName string) bool {
 
 func printDiff(w io.Writer, res *lib.DiffResponse, summaryOnly bool) (err error) {
 	buf := &bytes.Buffer{}
-	// TODO (b5): this reading from a package variable is pretty hacky :/
-	// should use the IsATTY package from mattn
-	deepdiff.FormatPrettyStats(buf, res.Stat, !color.NoColor)
-	if !summaryOnly {
-		buf.WriteByte('\n')
-		if err = deepdiff.FormatPretty(buf, res.Diff, !color.NoColor); err != nil {
-			return err
-		}
+	if err = writeDiff(buf, res, summaryOnly); err != nil {
+		return err
 	}
 
 	printToPager(w, buf)
 	return nil
 }
 
+// writeDiff formats a diff for the terminal
+func writeDiff(buf *bytes.Buffer, res *lib.DiffResponse, summaryOnly bool) error {
+	if res.Stat != nil || res.RowStat == nil {
+		// TODO (b5): this reading from a package variable is pretty hacky :/
+		// should use the IsATTY package from mattn
+		deepdiff.FormatPrettyStats(buf, res.Stat, !color.NoColor)
+		if !summaryOnly {
+			buf.WriteByte('\n')
+			if err := deepdiff.FormatPretty(buf, res.Diff, !color.NoColor); err != nil {
+				return err
+			}
+		}
+	}
+	if res.RowStat != nil {
+		writeRowDiff(buf, res, summaryOnly)
+	}
+	return nil
+}
+
+// writeRowDiff formats a keyed body diff for the terminal, one line per changed row
+func writeRowDiff(buf *bytes.Buffer, res *lib.DiffResponse, summaryOnly bool) {
+	st := res.RowStat
+	fmt.Fprintf(buf, "%d rows -> %d rows | %s %s %s\n", st.Left, st.Right,
+		color.GreenString("+%d added", st.Added),
+		color.RedString("-%d removed", st.Removed),
+		color.YellowString("~%d modified", st.Modified))
+	if summaryOnly {
+		return
+	}
+	buf.WriteByte('\n')
+	for _, c := range res.Rows {
+		switch c.Type {
+		case rowdiff.Added:
+			buf.WriteString(color.GreenString("+ %s: %s", c.Key, diffValue(c.Right)))
+		case rowdiff.Removed:
+			buf.WriteString(color.RedString("- %s: %s", c.Key, diffValue(c.Left)))
+		default:
+			cells := make([]string, 0, len(c.Cells))
+			for _, cell := range c.Cells {
+				cells = append(cells, fmt.Sprintf("%s: %s -> %s", cell.Column, diffValue(cell.Left), diffValue(cell.Right)))
+			}
+			buf.WriteString(color.YellowString("~ %s: %s", c.Key, strings.Join(cells, ", ")))
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+func diffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
 func printRefSelect(w io.Writer, refset *RefSelect) {
 	if refset.IsExplicit() {
 		return
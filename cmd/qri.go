@@ -39,7 +39,9 @@ https://github.com/qri-io/qri/issues`,
 
 	cmd.AddCommand(
 		NewAddCommand(opt, ioStreams),
+		NewApplyCommand(opt, ioStreams),
 		NewAutocompleteCommand(opt, ioStreams),
+		NewBranchCommand(opt, ioStreams),
 		NewCheckoutCommand(opt, ioStreams),
 		NewConfigCommand(opt, ioStreams),
 		NewConnectCommand(opt, ioStreams),
@@ -49,9 +51,11 @@ https://github.com/qri-io/qri/issues`,
 		NewFSICommand(opt, ioStreams),
 		NewGetCommand(opt, ioStreams),
 		NewInitCommand(opt, ioStreams),
+		NewLineageCommand(opt, ioStreams),
 		NewListCommand(opt, ioStreams),
 		NewLogCommand(opt, ioStreams),
 		NewLogbookCommand(opt, ioStreams),
+		NewMergeCommand(opt, ioStreams),
 		NewPublishCommand(opt, ioStreams),
 		NewPeersCommand(opt, ioStreams),
 		NewRegistryCommand(opt, ioStreams),
@@ -65,6 +69,7 @@ https://github.com/qri-io/qri/issues`,
 		NewStatsCommand(opt, ioStreams),
 		NewStatusCommand(opt, ioStreams),
 		NewSQLCommand(opt, ioStreams),
+		NewTagCommand(opt, ioStreams),
 		NewUseCommand(opt, ioStreams),
 		NewValidateCommand(opt, ioStreams),
 		NewVersionCommand(opt, ioStreams),
@@ -262,6 +267,14 @@ func (o *QriOptions) SQLMethods() (*lib.SQLMethods, error) {
 	return lib.NewSQLMethods(o.inst), nil
 }
 
+// TransformMethods generates a lib.TransformMethods from internal state
+func (o *QriOptions) TransformMethods() (*lib.TransformMethods, error) {
+	if err := o.Init(); err != nil {
+		return nil, err
+	}
+	return lib.NewTransformMethods(o.inst), nil
+}
+
 // RenderMethods generates a lib.RenderMethods from internal state
 func (o *QriOptions) RenderMethods() (*lib.RenderMethods, error) {
 	if err := o.Init(); err != nil {
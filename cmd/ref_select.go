@@ -175,7 +175,12 @@ func GetLinkedRefSelect() (*RefSelect, error) {
 	if err == nil {
 		ref, ok := fsi.GetLinkedFilesysRef(dir)
 		if ok {
-			return NewLinkedDirectoryRefSelect(ref, dir), nil
+			refs := NewLinkedDirectoryRefSelect(ref, dir)
+			// directories switched to a branch refer to the branch
+			if branch := fsi.GetLinkedFilesysBranch(dir); branch != "" {
+				refs.refs[0] += ":" + branch
+			}
+			return refs, nil
 		}
 	}
 	// Empty refselect
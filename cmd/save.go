@@ -42,7 +42,11 @@ commit message and title to the save.`,
   $ qri save --file /path/to/dataset.yaml me/annual_pop
   
   # Re-execute a dataset that has a transform:
-  $ qri save me/tf_dataset`,
+  $ qri save me/tf_dataset
+
+  # Save a transform, recording http responses of its download step to
+  # /path/to/transform.fixture.json:
+  $ qri save --file /path/to/transform.star --http-fixture record me/tf_dataset`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -67,6 +71,7 @@ commit message and title to the save.`,
 	cmd.Flags().StringVarP(&o.Recall, "recall", "", "", "restore revisions from dataset history")
 	// cmd.Flags().BoolVarP(&o.ShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
+	cmd.Flags().StringVar(&o.HTTPFixture, "http-fixture", "", "record or replay transform http responses with a fixture file next to the transform script. one of [record,replay]")
 	cmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish this dataset to the registry")
 	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "simulate saving a dataset")
 	cmd.Flags().BoolVar(&o.Force, "force", false, "force a new commit, even if no changes are detected")
@@ -101,6 +106,7 @@ type SaveOptions struct {
 	Force          bool
 	NoRender       bool
 	Secrets        []string
+	HTTPFixture    string
 	NewName        bool
 	UseDscache     bool
 
@@ -156,6 +162,7 @@ func (o *SaveOptions) Run() (err error) {
 		Message:  o.Message,
 
 		ScriptOutput:        o.ErrOut,
+		HTTPFixture:         o.HTTPFixture,
 		FilePaths:           o.FilePaths,
 		Private:             false,
 		Publish:             o.Publish,
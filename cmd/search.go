@@ -21,9 +21,23 @@ func NewSearchCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		Short: "search the registry for datasets",
 		Long: `Search datasets & peers that match your query. Search pings the qri registry. 
 
-Any dataset that has been published to the registry is available for search.`,
+Any dataset that has been published to the registry is available for search.
+Registry results can be filtered by facet with --filter, using "key:value" or
+"key:relation:value". Facet keys are keywords, license, theme, publisher,
+format & updated. Relations are eq & neq, and updated also supports gt, gte,
+lt & lte.
+
+Use --local to search datasets in your own repo instead. Local search matches
+dataset titles, descriptions, keywords, readmes & field names, and doesn't
+need a network connection.`,
 		Example: `  # Search for datasets featuring "annual population":
-  $ qri search "annual population"`,
+  $ qri search "annual population"
+
+  # Search the registry for CSV datasets about health updated since 2020:
+  $ qri search health --filter format:csv --filter updated:gte:2020-01-01
+
+  # Search datasets in your repo for "annual population":
+  $ qri search --local "annual population"`,
 		Annotations: map[string]string{
 			"group": "network",
 		},
@@ -42,6 +56,8 @@ Any dataset that has been published to the registry is available for search.`,
 	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json|simple]")
 	cmd.Flags().IntVar(&o.PageSize, "page-size", 25, "page size of results, default 25")
 	cmd.Flags().IntVar(&o.Page, "page", 1, "page number of results, default 1")
+	cmd.Flags().StringSliceVar(&o.Filters, "filter", nil, "filter registry results by facet, eg: keywords:health")
+	cmd.Flags().BoolVar(&o.Local, "local", false, "search datasets in the local repo instead of the registry")
 
 	return cmd
 }
@@ -54,6 +70,8 @@ type SearchOptions struct {
 	Format   string
 	PageSize int
 	Page     int
+	Filters  []string
+	Local    bool
 	// Reindex bool
 
 	SearchMethods *lib.SearchMethods
@@ -70,7 +88,7 @@ func (o *SearchOptions) Complete(f Factory, args []string) (err error) {
 
 // Validate checks that any user inputs are valid
 func (o *SearchOptions) Validate() error {
-	if o.Query == "" {
+	if o.Query == "" && len(o.Filters) == 0 {
 		return errors.New(lib.ErrBadArgs, "please provide search parameters, for example:\n    $ qri search census\n    $ qri search 'census 2018'\nsee `qri search --help` for more information")
 	}
 	return nil
@@ -90,6 +108,8 @@ func (o *SearchOptions) Run() (err error) {
 		QueryString: o.Query,
 		Limit:       page.Limit(),
 		Offset:      page.Offset(),
+		Filters:     o.Filters,
+		Local:       o.Local,
 	}
 
 	results := []lib.SearchResult{}
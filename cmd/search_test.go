@@ -290,3 +290,16 @@ var jsonSearchResponse = `[
     }
   }
 ]`
+
+func TestSearchLocal(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_search_local")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body testdata/movies/body_ten.csv me/movies")
+	run.MustExec(t, "qri save --body testdata/movies/body_two.json me/two_movies")
+
+	output := run.MustExec(t, "qri search --local --format simple duration")
+	if expect := "test_peer/movies\n"; output != expect {
+		t.Errorf("result mismatch. expected: %q, got: %q", expect, output)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 
 	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/base/columnar"
 	"github.com/qri-io/qri/lib"
 	"github.com/spf13/cobra"
 )
@@ -28,7 +29,18 @@ PostgreSQL in a few ways:
   * For a dataset to be queryable it's schema must be properly configured to
     describe a tabular structure, with valid column names & types
   * Referencing columns that do not exist will return null values instead of
-    throwing an error`,
+    throwing an error
+  * Table names can refer to specific versions of a dataset with the same
+    revisions other commands accept: by path (me/dataset@/ipfs/QmFoo),
+    relative to the latest version (me/dataset~1 & me/dataset@HEAD^ both refer
+    to the version before the latest), by date (me/dataset@{2020-01-01}), by
+    tag (me/dataset@v1) or by branch (me/dataset:cleanup)
+  * Query results can be saved as a new dataset version with the --save flag,
+    or with a "CREATE DATASET [ref] AS SELECT ..." statement. The commit
+    message of the saved version records the query
+  * The parquet & arrow output formats write typed columns for use in tools
+    like pandas or spark. Columns with mixed types are written as strings.
+    Both formats are experimental, and need the --experimental-formats flag`,
 		Example: `  # first, fetch the dataset b5/world_bank_population:
   $ qri add b5/world_bank_population
   $ qri sql "SELECT 
@@ -42,7 +54,22 @@ PostgreSQL in a few ways:
     cc.official_name_en, wbp.year_2010, wbp.year_2011 
     FROM b5/world_bank_population as wbp
     LEFT JOIN b5/country_codes as cc 
-    ON cc.iso_3166_1_alpha_3 = wbp.country_code"`,
+    ON cc.iso_3166_1_alpha_3 = wbp.country_code"
+
+  # save query results as a new version of me/population_2018
+  $ qri sql --save me/population_2018 "SELECT 
+    wbp.country_name, wbp.year_2018
+    FROM b5/world_bank_population as wbp"
+
+  # CREATE DATASET does the same thing from within the query
+  $ qri sql "CREATE DATASET me/population_2018 AS SELECT 
+    wbp.country_name, wbp.year_2018
+    FROM b5/world_bank_population as wbp"
+
+  # write query results to an apache parquet file
+  $ qri sql --experimental-formats --format parquet "SELECT 
+    wbp.country_name, wbp.year_2018
+    FROM b5/world_bank_population as wbp" > population_2018.parquet`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -55,8 +82,10 @@ PostgreSQL in a few ways:
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.Format, "format", "f", "table", "set output format [table]")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "table", "set output format [table, table_row_separated, json, csv, tabbed, parquet, arrow]")
 	cmd.Flags().BoolVar(&o.Offline, "offline", false, "prevent network access")
+	cmd.Flags().StringVar(&o.Save, "save", "", "save query results as a new version of a dataset")
+	cmd.Flags().BoolVar(&o.ExperimentalFormats, "experimental-formats", false, "experimental: enable the parquet & arrow formats")
 
 	return cmd
 }
@@ -68,6 +97,9 @@ type SQLOptions struct {
 	Query   string
 	Format  string
 	Offline bool
+	Save    string
+
+	ExperimentalFormats bool
 
 	SQLMethods *lib.SQLMethods
 }
@@ -93,6 +125,9 @@ func (o *SQLOptions) Run() (err error) {
 		Query:        o.Query,
 		OutputFormat: o.Format,
 		ResolverMode: mode,
+		Save:         o.Save,
+
+		ExperimentalFormats: o.ExperimentalFormats,
 	}
 
 	res := []byte{}
@@ -102,6 +137,11 @@ func (o *SQLOptions) Run() (err error) {
 	}
 
 	o.StopSpinner()
+	if columnar.IsFormat(o.Format) && o.Save == "" {
+		// binary formats are written as-is, without paging
+		_, err = o.Out.Write(res)
+		return err
+	}
 	printToPager(o.Out, bytes.NewBuffer(res))
 	return nil
 }
@@ -20,3 +20,57 @@ func TestSQLRun(t *testing.T) {
 	// 	t.Errorf("result mismatch. (-want +got): %s\n", diff)
 	// }
 }
+
+func TestSQLSave(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_sql_save")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save me/one_ds --body testdata/movies/body_ten.csv")
+
+	query := "CREATE DATASET me/titles AS SELECT one.movie_title FROM me/one_ds as one LIMIT 2"
+	run.MustExecuteQuotedCommand(t, `qri sql "`+query+`"`)
+
+	ds := run.MustLoadDataset(t, run.LookupVersionInfo(t, "me/titles").Path)
+	if ds.Commit.Message != "SELECT one.movie_title FROM me/one_ds as one LIMIT 2" {
+		t.Errorf("expected commit message to record query, got: %q", ds.Commit.Message)
+	}
+	if ds.Structure.Entries != 2 {
+		t.Errorf("expected saved body to have 2 entries, got: %d", ds.Structure.Entries)
+	}
+
+	run.MustExecuteQuotedCommand(t, `qri sql "SELECT one.movie_title FROM me/one_ds as one LIMIT 3" "--save" "me/titles"`)
+	ds = run.MustLoadDataset(t, run.LookupVersionInfo(t, "me/titles").Path)
+	if ds.Structure.Entries != 3 {
+		t.Errorf("expected --save to create a new version with 3 entries, got: %d", ds.Structure.Entries)
+	}
+}
+
+func TestSQLPreviousVersion(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_sql_previous_version")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save me/movies --body testdata/movies/body_ten.csv")
+	run.MustExec(t, "qri save me/movies --body testdata/movies/body_twenty.csv")
+
+	// join the latest version against the previous one, finding rows in both
+	query := "SELECT a.movie_title FROM me/movies AS a JOIN me/movies~1 AS b ON a.movie_title = b.movie_title"
+	run.MustExecuteQuotedCommand(t, `qri sql "`+query+`" "--save" "me/unchanged"`)
+	if got := run.MustExec(t, "qri get structure.entries me/unchanged"); got != "8\n\n" {
+		t.Errorf("expected 8 rows in both versions, got: %q", got)
+	}
+
+	// HEAD^ refers to the same version as ~1
+	query = "SELECT b.movie_title FROM me/movies@HEAD^ AS b"
+	run.MustExecuteQuotedCommand(t, `qri sql "`+query+`" "--save" "me/previous"`)
+	if got := run.MustExec(t, "qri get structure.entries me/previous"); got != "8\n\n" {
+		t.Errorf("expected previous version to have 8 rows, got: %q", got)
+	}
+
+	// tags select versions from the logbook, like other commands
+	run.MustExec(t, "qri tag me/movies~1 v1")
+	query = "SELECT b.movie_title FROM me/movies@v1 AS b"
+	run.MustExecuteQuotedCommand(t, `qri sql "`+query+`" "--save" "me/tagged"`)
+	if got := run.MustExec(t, "qri get structure.entries me/tagged"); got != "8\n\n" {
+		t.Errorf("expected tagged version to have 8 rows, got: %q", got)
+	}
+}
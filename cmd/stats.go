@@ -14,15 +14,26 @@ import (
 func NewStatsCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	o := &StatsOptions{IOStreams: ioStreams}
 	cmd := &cobra.Command{
-		Use:   "stats DATASET",
+		Use:   "stats DATASET [DATASET]",
 		Short: "get aggregated stats for a dataset",
-		Long:  `Run the ` + "`stats`" + ` to generate and view stats for a dataset using a dataset reference.`,
+		Long: `Run the ` + "`stats`" + ` to generate and view stats for a dataset using a dataset reference.
+
+With the --diff flag, stats compares the stats of two dataset versions column
+by column, reporting changes in row count, null counts, mean values, and
+categorical values. Given a single reference, diff compares a dataset with its
+previous version.`,
 		Example: `  # Get stats for me/dataset_name:
-  $ qri stats me/dataset_name`,
+  $ qri stats me/dataset_name
+
+  # Compare stats of the latest version of me/dataset_name with the previous one:
+  $ qri stats --diff me/dataset_name
+
+  # Compare stats of two dataset versions:
+  $ qri stats --diff me/dataset_name@/ipfs/QmFoo me/dataset_name`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.MaximumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
 				return err
@@ -35,6 +46,7 @@ func NewStatsCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&o.Pretty, "pretty", "p", false, "whether to print output with indentation")
+	cmd.Flags().BoolVar(&o.Diff, "diff", false, "compare stats of two dataset versions")
 
 	return cmd
 }
@@ -45,6 +57,7 @@ type StatsOptions struct {
 
 	Refs   *RefSelect
 	Pretty bool
+	Diff   bool
 
 	DatasetMethods *lib.DatasetMethods
 }
@@ -55,7 +68,11 @@ func (o *StatsOptions) Complete(f Factory, args []string) (err error) {
 		return
 	}
 
-	o.Refs, err = GetCurrentRefSelect(f, args, 1, nil)
+	allowed := 1
+	if o.Diff {
+		allowed = 2
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, allowed, nil)
 	if err != nil {
 		return err
 	}
@@ -71,19 +88,35 @@ func (o *StatsOptions) Validate() error {
 func (o *StatsOptions) Run() (err error) {
 	printRefSelect(o.ErrOut, o.Refs)
 
-	p := &lib.StatsParams{Ref: o.Refs.Ref()}
-	r := &lib.StatsResponse{}
-	if err = o.DatasetMethods.Stats(p, r); err != nil {
-		return err
+	var data []byte
+	if o.Diff {
+		p := &lib.StatsDiffParams{LeftRef: o.Refs.Ref()}
+		if refs := o.Refs.RefList(); len(refs) == 2 {
+			p.LeftRef, p.RightRef = refs[0], refs[1]
+		}
+		r := &lib.StatsDiffResponse{}
+		if err = o.DatasetMethods.StatsDiff(p, r); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(r.Diff); err != nil {
+			return fmt.Errorf("err encoding stats diff: %s", err)
+		}
+	} else {
+		p := &lib.StatsParams{Ref: o.Refs.Ref()}
+		r := &lib.StatsResponse{}
+		if err = o.DatasetMethods.Stats(p, r); err != nil {
+			return err
+		}
+		data = r.StatsBytes
 	}
 
 	var buffer []byte
 	if !o.Pretty {
-		buffer = append(r.StatsBytes, byte('\n'))
+		buffer = append(data, byte('\n'))
 	} else {
 		// stats is already in JSON format, so just needs pretty printing
 		buf := new(bytes.Buffer)
-		err = json.Indent(buf, []byte(r.StatsBytes), "", "  ")
+		err = json.Indent(buf, data, "", "  ")
 		if err != nil {
 			return fmt.Errorf("err encoding stats: %s", err)
 		}
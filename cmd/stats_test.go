@@ -159,7 +159,7 @@ func TestStatsFSI(t *testing.T) {
 
 	expect := `for linked dataset [test_peer/move_dir]
 
-[{"count":2,"maxLength":4,"minLength":3,"type":"string","unique":2},{"count":2,"maxLength":4,"minLength":3,"type":"string","unique":2},{"count":2,"histogram":{"bins":[3,3.4,3.8,4.2,4.6,5,5.4,5.800000000000001,6.2,6.6,7],"frequencies":[1,0,0,0,0,0,0,1,0,0]},"max":6,"mean":4.5,"median":4.5,"min":3,"type":"numeric"}]
+[{"count":2,"distinct":2,"maxLength":4,"minLength":3,"type":"string","unique":2},{"count":2,"distinct":2,"maxLength":4,"minLength":3,"type":"string","unique":2},{"count":2,"distinct":2,"histogram":{"bins":[3,3.4,3.8,4.2,4.6,5,5.4,5.800000000000001,6.2,6.6,7],"frequencies":[1,0,0,0,0,0,0,1,0,0]},"max":6,"mean":4.5,"median":4.5,"min":3,"quantiles":{"p1":3,"p25":3,"p75":6,"p99":6},"type":"numeric"}]
 
 `
 
@@ -174,6 +174,7 @@ func TestStatsFSI(t *testing.T) {
 [
   {
     "count": 2,
+    "distinct": 2,
     "maxLength": 4,
     "minLength": 3,
     "type": "string",
@@ -181,6 +182,7 @@ func TestStatsFSI(t *testing.T) {
   },
   {
     "count": 2,
+    "distinct": 2,
     "maxLength": 4,
     "minLength": 3,
     "type": "string",
@@ -188,6 +190,7 @@ func TestStatsFSI(t *testing.T) {
   },
   {
     "count": 2,
+    "distinct": 2,
     "histogram": {
       "bins": [
         3,
@@ -219,6 +222,12 @@ func TestStatsFSI(t *testing.T) {
     "mean": 4.5,
     "median": 4.5,
     "min": 3,
+    "quantiles": {
+      "p1": 3,
+      "p25": 3,
+      "p75": 6,
+      "p99": 6
+    },
     "type": "numeric"
   }
 ]
@@ -160,7 +160,9 @@ func (r searchResultStringer) String() string {
 	ds := r.Value
 
 	fmt.Fprintf(w, "%s/%s", title(ds.Peername), title(ds.Name))
-	fmt.Fprintf(w, "\n%s", r.URL)
+	if r.URL != "" {
+		fmt.Fprintf(w, "\n%s", r.URL)
+	}
 	fmt.Fprintf(w, "\n%s", path(ds.Path))
 
 	if ds != nil && ds.Meta != nil && ds.Meta.Title != "" {
@@ -245,9 +247,11 @@ func (s dslogItemStringer) String() string {
 		storage = faint("remote")
 	}
 
-	msg := fmt.Sprintf("%s%s\n%s%s\n%s%s\n%s%s\n\n%s\n",
-		faint("Commit:  "),
-		yellow(s.Path),
+	msg := fmt.Sprintf("%s%s\n", faint("Commit:  "), yellow(s.Path))
+	if len(s.Tags) > 0 {
+		msg += fmt.Sprintf("%s%s\n", faint("Tags:    "), strings.Join(s.Tags, ", "))
+	}
+	msg += fmt.Sprintf("%s%s\n%s%s\n%s%s\n\n%s\n",
 		faint("Date:    "),
 		s.CommitTime.In(StringerLocation).Format(time.UnixDate),
 		faint("Storage: "),
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewTagCommand creates a new `qri tag` cobra command for labelling dataset
+// versions
+func NewTagCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &TagOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "tag [DATASET] [TAG]",
+		Short: "label dataset versions with human-readable names",
+		Long: `Tag gives a dataset version a human-readable name, like a release number.
+Tagged versions can be referred to by name anywhere a dataset reference is
+accepted, like "me/dataset@v2020-q3", instead of by their hash.
+
+Tags are recorded in the dataset's logbook, and are synced along with the
+rest of the dataset's history. Without a tag name, tag lists the tags of a
+dataset. Tags can't be moved, delete a tag & add it again instead.`,
+		Example: `  # tag the latest version of me/annual_pop:
+  $ qri tag me/annual_pop v2020-q3
+
+  # tag a specific version:
+  $ qri tag me/annual_pop@/ipfs/QmFoo v2020-q2
+
+  # list tags:
+  $ qri tag me/annual_pop
+
+  # get the body of a tagged version:
+  $ qri get body me/annual_pop@v2020-q3
+
+  # remove a tag:
+  $ qri tag --delete me/annual_pop v2020-q2`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.Delete, "delete", "d", false, "remove the tag")
+
+	return cmd
+}
+
+// TagOptions encapsulates state for the tag command
+type TagOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Tag    string
+	Delete bool
+
+	LogMethods *lib.LogMethods
+}
+
+// Complete adds any missing configuration that can only be added just before
+// calling Run
+func (o *TagOptions) Complete(f Factory, args []string) (err error) {
+	if o.LogMethods, err = f.LogMethods(); err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		o.Tag = args[1]
+		args = args[:1]
+	}
+	if o.Delete && o.Tag == "" {
+		return fmt.Errorf("a dataset & tag name are required to delete a tag")
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, 1, nil)
+	return err
+}
+
+// Run executes the tag command
+func (o *TagOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	if o.Tag == "" {
+		tags := []lib.Tag{}
+		if err := o.LogMethods.Tags(&lib.TagsParams{Ref: o.Refs.Ref()}, &tags); err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			printInfo(o.Out, "%s has no tags", o.Refs.Ref())
+			return nil
+		}
+		buf := &bytes.Buffer{}
+		for _, tag := range tags {
+			fmt.Fprintf(buf, "%s\t%s\t%s\n", tag.Name, tag.Path, tag.Timestamp.In(StringerLocation).Format(time.UnixDate))
+		}
+		printToPager(o.Out, buf)
+		return nil
+	}
+
+	p := &lib.TagParams{Ref: o.Refs.Ref(), Tag: o.Tag, Delete: o.Delete}
+	res := dsref.Ref{}
+	if err := o.LogMethods.Tag(p, &res); err != nil {
+		return err
+	}
+	if o.Delete {
+		printSuccess(o.Out, "removed tag %s from %s", o.Tag, res.Human())
+	} else {
+		printSuccess(o.Out, "tagged %s@%s as %s", res.Human(), res.Path, o.Tag)
+	}
+	return nil
+}
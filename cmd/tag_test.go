@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTag(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_tag")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/test_movies")
+	first := run.LookupVersionInfo(t, "me/test_movies").Path
+	run.MustExec(t, "qri save --body=testdata/movies/body_twenty.csv me/test_movies")
+
+	run.MustExec(t, "qri tag me/test_movies~1 v1")
+	run.MustExec(t, "qri tag me/test_movies v2")
+	if err := run.ExecCommand("qri tag me/test_movies v1"); err == nil {
+		t.Errorf("expected adding an existing tag to error")
+	}
+
+	output := run.MustExec(t, "qri tag me/test_movies")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "v1\t"+first) || !strings.HasPrefix(lines[1], "v2\t") {
+		t.Errorf("unexpected tag list:\n%s", output)
+	}
+
+	output = run.MustExec(t, "qri log me/test_movies")
+	if !strings.Contains(output, "Tags:    v1") || !strings.Contains(output, "Tags:    v2") {
+		t.Errorf("expected log to list tags, got:\n%s", output)
+	}
+
+	output = run.MustExec(t, "qri get structure.entries me/test_movies@v1")
+	if expect := "8\n\n"; output != expect {
+		t.Errorf("get tagged version mismatch. expected: %q, got: %q", expect, output)
+	}
+	output = run.MustExec(t, "qri get structure.entries me/test_movies@v2~1")
+	if expect := "8\n\n"; output != expect {
+		t.Errorf("get relative to tag mismatch. expected: %q, got: %q", expect, output)
+	}
+
+	run.MustExec(t, "qri tag --delete me/test_movies v1")
+	if err := run.ExecCommand("qri get me/test_movies@v1"); err == nil {
+		t.Errorf("expected getting a deleted tag to error")
+	}
+}
@@ -30,6 +30,9 @@ type Config struct {
 	Filesystems []qfs.Config
 	P2P         *P2P
 	Stats       *Stats
+	SQL         *SQL
+	Scheduler   *Scheduler
+	Transform   *Transform
 
 	Registry *Registry
 	Remotes  *Remotes
@@ -65,6 +68,9 @@ func DefaultConfig() *Config {
 		Filesystems: DefaultFilesystems(),
 		P2P:         DefaultP2P(),
 		Stats:       DefaultStats(),
+		SQL:         DefaultSQL(),
+		Scheduler:   DefaultScheduler(),
+		Transform:   DefaultTransform(),
 
 		Registry: DefaultRegistry(),
 		// default to no configured remotes
@@ -221,6 +227,9 @@ func (cfg Config) Validate() error {
 		cfg.API,
 		cfg.RPC,
 		cfg.Logging,
+		cfg.SQL,
+		cfg.Scheduler,
+		cfg.Transform,
 	}
 	for _, val := range validators {
 		// we need to check here because we're potentially calling methods on nil
@@ -279,6 +288,15 @@ func (cfg *Config) Copy() *Config {
 	if cfg.Stats != nil {
 		res.Stats = cfg.Stats.Copy()
 	}
+	if cfg.SQL != nil {
+		res.SQL = cfg.SQL.Copy()
+	}
+	if cfg.Scheduler != nil {
+		res.Scheduler = cfg.Scheduler.Copy()
+	}
+	if cfg.Transform != nil {
+		res.Transform = cfg.Transform.Copy()
+	}
 	if cfg.Filesystems != nil {
 		for _, fs := range cfg.Filesystems {
 			res.Filesystems = append(res.Filesystems, fs)
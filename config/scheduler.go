@@ -0,0 +1,69 @@
+package config
+
+import (
+	"github.com/qri-io/jsonschema"
+)
+
+// Scheduler configures running dataset transforms on a schedule while qri
+// connect is running
+type Scheduler struct {
+	// Enabled turns the scheduler on
+	Enabled bool `json:"enabled"`
+	// Schedules maps dataset references to cron-style schedules. A schedule
+	// set here takes precedence over the "schedule" value of the dataset's
+	// transform config
+	Schedules map[string]string `json:"schedules,omitempty"`
+}
+
+// SetArbitrary is an interface implementation of base/fill/struct in order to safely
+// consume config files that have definitions beyond those specified in the struct.
+// This simply ignores all additional fields at read time.
+func (cfg *Scheduler) SetArbitrary(key string, val interface{}) error {
+	return nil
+}
+
+// DefaultScheduler creates & returns a new default scheduler configuration
+func DefaultScheduler() *Scheduler {
+	return &Scheduler{
+		Enabled: false,
+	}
+}
+
+// Validate validates all the fields of Scheduler returning all errors found.
+func (cfg Scheduler) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "Scheduler",
+    "description": "Config for running dataset transforms on a schedule",
+    "type": "object",
+    "required": ["enabled"],
+    "properties": {
+      "enabled": {
+        "description": "When true, qri connect runs scheduled transforms",
+        "type": "boolean"
+      },
+      "schedules": {
+        "description": "Cron-style schedules keyed by dataset reference",
+        "type": "object",
+        "additionalProperties": {
+          "type": "string"
+        }
+      }
+    }
+  }`)
+	return validate(schema, &cfg)
+}
+
+// Copy returns a deep copy of the Scheduler struct
+func (cfg *Scheduler) Copy() *Scheduler {
+	res := &Scheduler{
+		Enabled: cfg.Enabled,
+	}
+	if cfg.Schedules != nil {
+		res.Schedules = map[string]string{}
+		for ref, spec := range cfg.Schedules {
+			res.Schedules[ref] = spec
+		}
+	}
+	return res
+}
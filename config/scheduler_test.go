@@ -0,0 +1,42 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchedulerValidate(t *testing.T) {
+	if err := DefaultScheduler().Validate(); err != nil {
+		t.Errorf("error validating default scheduler: %s", err)
+	}
+
+	scheduled := DefaultScheduler()
+	scheduled.Schedules = map[string]string{"me/hourly": "@hourly"}
+	if err := scheduled.Validate(); err != nil {
+		t.Errorf("error validating scheduler with schedules: %s", err)
+	}
+}
+
+func TestSchedulerCopy(t *testing.T) {
+	scheduled := DefaultScheduler()
+	scheduled.Schedules = map[string]string{"me/hourly": "0 * * * *"}
+	cases := []struct {
+		scheduler *Scheduler
+	}{
+		{DefaultScheduler()},
+		{scheduled},
+	}
+	for i, c := range cases {
+		cpy := c.scheduler.Copy()
+		if !reflect.DeepEqual(cpy, c.scheduler) {
+			t.Errorf("Scheduler Copy test case %v, scheduler structs are not equal: \ncopy: %v, \noriginal: %v", i, cpy, c.scheduler)
+			continue
+		}
+		if c.scheduler.Schedules != nil {
+			cpy.Schedules["me/other"] = "@daily"
+			if _, ok := c.scheduler.Schedules["me/other"]; ok {
+				t.Errorf("Scheduler Copy test case %v, expected copied schedules not to share a map", i)
+			}
+		}
+	}
+}
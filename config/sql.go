@@ -0,0 +1,99 @@
+package config
+
+import (
+	"github.com/qri-io/jsonschema"
+)
+
+// SQL configures the qri SQL query engine
+type SQL struct {
+	// ResultCache configures caching of query results. Results are cached by
+	// query & the versions of the datasets the query reads from, so a cached
+	// result is only used while every dataset in the query resolves to the
+	// same version
+	ResultCache SQLResultCache `json:"resultcache"`
+}
+
+// SetArbitrary is an interface implementation of base/fill/struct in order to safely
+// consume config files that have definitions beyond those specified in the struct.
+// This simply ignores all additional fields at read time.
+func (cfg *SQL) SetArbitrary(key string, val interface{}) error {
+	return nil
+}
+
+// SQLResultCache configures the cached storage of SQL query results
+type SQLResultCache struct {
+	// Type is the kind of cache to use. "mem" keeps results in memory, an
+	// empty type disables result caching
+	Type string `json:"type"`
+	// MaxSize is the maximum number of bytes all cached results can use
+	MaxSize uint64 `json:"maxsize"`
+	// MaxEntrySize is the largest result in bytes the cache will keep. Default
+	// is 0. If 0, any result that fits in MaxSize is cached
+	MaxEntrySize uint64 `json:"maxentrysize,omitempty"`
+}
+
+// DefaultSQL creates & returns a new default SQL configuration
+func DefaultSQL() *SQL {
+	return &SQL{
+		ResultCache: SQLResultCache{
+			Type: "mem",
+			// Default to 25MiB
+			MaxSize: 1024 * 1024 * 25,
+		},
+	}
+}
+
+// Validate validates all the fields of SQL returning all errors found.
+func (cfg SQL) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "SQL",
+    "description": "Config for the qri SQL query engine",
+    "type": "object",
+    "required": [
+      "resultcache"
+    ],
+    "properties": {
+      "resultcache": {
+        "description": "The configuration for the cache that stores recent query results.",
+        "type": "object",
+        "required": [
+          "type",
+          "maxsize"
+        ],
+        "properties": {
+          "type": {
+            "description": "Type of cache. An empty type disables result caching",
+            "type": "string",
+            "enum": [
+              "",
+              "mem"
+            ]
+          },
+          "maxsize": {
+            "description": "The maximum size, in bytes, that the cache should hold",
+            "type": "number",
+            "minimum": 0
+          },
+          "maxentrysize": {
+            "description": "The maximum size, in bytes, of a single cached result. If 0, any result that fits in the cache is kept",
+            "type": "number",
+            "minimum": 0
+          }
+        }
+      }
+    }
+  }`)
+	return validate(schema, &cfg)
+}
+
+// Copy returns a deep copy of the SQL struct
+func (cfg *SQL) Copy() *SQL {
+	return &SQL{
+		ResultCache: SQLResultCache{
+			Type:         cfg.ResultCache.Type,
+			MaxSize:      cfg.ResultCache.MaxSize,
+			MaxEntrySize: cfg.ResultCache.MaxEntrySize,
+		},
+	}
+}
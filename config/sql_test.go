@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQLValidate(t *testing.T) {
+	if err := DefaultSQL().Validate(); err != nil {
+		t.Errorf("error validating default sql: %s", err)
+	}
+
+	disabled := DefaultSQL()
+	disabled.ResultCache.Type = ""
+	if err := disabled.Validate(); err != nil {
+		t.Errorf("error validating disabled result cache: %s", err)
+	}
+
+	invalid := DefaultSQL()
+	invalid.ResultCache.Type = "fs"
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected invalid result cache type to error")
+	}
+}
+
+func TestSQLCopy(t *testing.T) {
+	s := DefaultSQL()
+	limited := DefaultSQL()
+	limited.ResultCache.MaxEntrySize = 1024
+	cases := []struct {
+		sql *SQL
+	}{
+		{s},
+		{limited},
+	}
+	for i, c := range cases {
+		cpy := c.sql.Copy()
+		if !reflect.DeepEqual(cpy, c.sql) {
+			t.Errorf("SQL Copy test case %v, sql structs are not equal: \ncopy: %v, \noriginal: %v", i, cpy, c.sql)
+			continue
+		}
+	}
+}
@@ -7,8 +7,16 @@ import (
 // Stats configures qri statistical metadata calculation
 type Stats struct {
 	Cache cache `json:"cache"`
-	// For later addition:
-	// StopFreqCountThreshold int
+	// BoundedMemory calculates stats in a fixed amount of memory, approximating
+	// medians, quantiles & distinct counts, and spilling frequency tables to
+	// disk. Use for datasets larger than available memory
+	BoundedMemory bool `json:"boundedmemory,omitempty"`
+	// MaxFrequencies is the number of distinct string values counted in memory
+	// before spilling to disk when BoundedMemory is set
+	MaxFrequencies int `json:"maxfrequencies,omitempty"`
+	// SpillPath is the directory frequency tables spill to. Default is empty.
+	// If empty, Qri will spill to the OS temp directory
+	SpillPath string `json:"spillpath,omitempty"`
 }
 
 // SetArbitrary is an interface implementation of base/fill/struct in order to safely
@@ -31,7 +39,7 @@ func DefaultStats() *Stats {
 		Cache: cache{
 			Type: "fs",
 			// Default to 25MiB
-			MaxSize: 1024 * 25,
+			MaxSize: 1024 * 1024 * 25,
 		},
 	}
 }
@@ -73,6 +81,19 @@ func (cfg Stats) Validate() error {
             ]
           }
         }
+      },
+      "boundedmemory": {
+        "description": "Calculate stats in bounded memory, approximating medians, quantiles and distinct counts",
+        "type": "boolean"
+      },
+      "maxfrequencies": {
+        "description": "The number of distinct values counted in memory before spilling to disk in bounded memory mode",
+        "type": "integer",
+        "minimum": 0
+      },
+      "spillpath": {
+        "description": "The directory to spill frequency tables to. Default is empty. If empty, Qri will use the OS temp directory",
+        "type": "string"
       }
     }
   }`)
@@ -87,5 +108,8 @@ func (cfg *Stats) Copy() *Stats {
 			MaxSize: cfg.Cache.MaxSize,
 			Path:    cfg.Cache.Path,
 		},
+		BoundedMemory:  cfg.BoundedMemory,
+		MaxFrequencies: cfg.MaxFrequencies,
+		SpillPath:      cfg.SpillPath,
 	}
 }
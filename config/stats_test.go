@@ -16,10 +16,15 @@ func TestStatsCopy(t *testing.T) {
 	// build off DefaultStats so we can test that the stats Copy
 	// actually copies over correctly
 	s := DefaultStats()
+	bounded := DefaultStats()
+	bounded.BoundedMemory = true
+	bounded.MaxFrequencies = 500
+	bounded.SpillPath = "/tmp/stats"
 	cases := []struct {
 		stats *Stats
 	}{
 		{s},
+		{bounded},
 	}
 	for i, c := range cases {
 		cpy := c.stats.Copy()
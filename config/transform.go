@@ -0,0 +1,91 @@
+package config
+
+import (
+	"github.com/qri-io/jsonschema"
+)
+
+// Transform configures the resources dataset transforms can use. Limits of
+// zero are unlimited
+type Transform struct {
+	// TimeoutMs is the longest a transform can run, in milliseconds
+	TimeoutMs int `json:"timeoutms"`
+	// MaxSteps is the most starlark execution steps a transform can take
+	MaxSteps uint64 `json:"maxsteps"`
+	// MaxBodyEntries is the most body entries a transform can produce
+	MaxBodyEntries int `json:"maxbodyentries"`
+	// MaxBodyBytes is the largest body a transform can produce, in bytes
+	MaxBodyBytes int64 `json:"maxbodybytes"`
+	// MaxHTTPRequests is the most HTTP requests a transform can make
+	MaxHTTPRequests int `json:"maxhttprequests"`
+	// MaxHTTPResponseBytes is the largest HTTP response body a transform can
+	// read, in bytes
+	MaxHTTPResponseBytes int64 `json:"maxhttpresponsebytes"`
+}
+
+// SetArbitrary is an interface implementation of base/fill/struct in order to safely
+// consume config files that have definitions beyond those specified in the struct.
+// This simply ignores all additional fields at read time.
+func (cfg *Transform) SetArbitrary(key string, val interface{}) error {
+	return nil
+}
+
+// DefaultTransform creates & returns a new default transform configuration.
+// Transforms are unlimited by default
+func DefaultTransform() *Transform {
+	return &Transform{}
+}
+
+// Validate validates all the fields of Transform returning all errors found.
+func (cfg Transform) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "Transform",
+    "description": "Config for the resources dataset transforms can use. Limits of zero are unlimited",
+    "type": "object",
+    "properties": {
+      "timeoutms": {
+        "description": "The longest a transform can run, in milliseconds",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxsteps": {
+        "description": "The most starlark execution steps a transform can take",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxbodyentries": {
+        "description": "The most body entries a transform can produce",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxbodybytes": {
+        "description": "The largest body a transform can produce, in bytes",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxhttprequests": {
+        "description": "The most HTTP requests a transform can make",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxhttpresponsebytes": {
+        "description": "The largest HTTP response body a transform can read, in bytes",
+        "type": "integer",
+        "minimum": 0
+      }
+    }
+  }`)
+	return validate(schema, &cfg)
+}
+
+// Copy returns a deep copy of the Transform struct
+func (cfg *Transform) Copy() *Transform {
+	return &Transform{
+		TimeoutMs:            cfg.TimeoutMs,
+		MaxSteps:             cfg.MaxSteps,
+		MaxBodyEntries:       cfg.MaxBodyEntries,
+		MaxBodyBytes:         cfg.MaxBodyBytes,
+		MaxHTTPRequests:      cfg.MaxHTTPRequests,
+		MaxHTTPResponseBytes: cfg.MaxHTTPResponseBytes,
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransformValidate(t *testing.T) {
+	if err := DefaultTransform().Validate(); err != nil {
+		t.Errorf("error validating default transform: %s", err)
+	}
+
+	limited := &Transform{TimeoutMs: 1000, MaxBodyEntries: 10, MaxHTTPRequests: 5}
+	if err := limited.Validate(); err != nil {
+		t.Errorf("error validating limited transform: %s", err)
+	}
+
+	negative := &Transform{MaxBodyBytes: -1}
+	if err := negative.Validate(); err == nil {
+		t.Error("expected negative limit to be invalid")
+	}
+}
+
+func TestTransformCopy(t *testing.T) {
+	cases := []struct {
+		transform *Transform
+	}{
+		{DefaultTransform()},
+		{&Transform{TimeoutMs: 1000, MaxSteps: 100000, MaxBodyEntries: 10, MaxBodyBytes: 1024, MaxHTTPRequests: 5, MaxHTTPResponseBytes: 2048}},
+	}
+	for i, c := range cases {
+		cpy := c.transform.Copy()
+		if !reflect.DeepEqual(cpy, c.transform) {
+			t.Errorf("Transform Copy test case %v, transform structs are not equal: \ncopy: %v, \noriginal: %v", i, cpy, c.transform)
+		}
+	}
+}
@@ -224,12 +224,19 @@ func convertDatasetHistoryToDsInfo(dsLog oplog.Log) *entryInfo {
 
 	// Get the init-id here, because this the log for the dataset model.
 	initID := dsLog.ID()
-	if len(dsLog.Logs) != 1 {
-		log.Errorf("expected only 1 branch, got %d\n", len(dsLog.Logs))
+	if len(dsLog.Logs) == 0 {
+		log.Errorf("expected a branch, got none\n")
 		return nil
 	}
 
+	// the cache tracks the default branch, which is the first unless named
 	historyLog := dsLog.Logs[0]
+	for _, branchLog := range dsLog.Logs {
+		if branchLog.Name() == logbook.DefaultBranchName {
+			historyLog = branchLog
+			break
+		}
+	}
 	topIndex, headRef := convertHistoryToIndexAndRef(*historyLog)
 	cursorIndex := topIndex
 	return &entryInfo{
@@ -247,6 +254,10 @@ func convertHistoryToIndexAndRef(historyLog oplog.Log) (int, string) {
 	refs := make([]string, 0, len(historyLog.Ops))
 	// Collect references added and removed to get those that remain.
 	for _, op := range historyLog.Ops {
+		if op.Model == logbook.TagModel || op.Model == logbook.MergeModel {
+			// tags & merges don't add versions
+			continue
+		}
 		if op.Type == oplog.OpTypeRemove {
 			refs = refs[0 : len(refs)-int(op.Size)]
 		} else {
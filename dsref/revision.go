@@ -0,0 +1,231 @@
+package dsref
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Revision selects a version of a dataset relative to a reference, using
+// syntax borrowed from git revisions:
+//
+//	<revision> = <dsref> [ ':' <branch> ] [ '@{' <selector> '}' | '@' <tag> ] { '~' [ <n> ] | '^' }
+//	<selector> = '-' <n> | <n> | <date>
+//
+// ":branch" selects the history of a named branch instead of the main
+// history. "~N" and "@{-N}" step back N versions, each "^" steps back one
+// version, and "@{date}" selects the latest version saved at or before date.
+// Dates are either RFC3339 timestamps or "YYYY-MM-DD", read as midnight UTC.
+// "@<tag>" selects the version a tag labels, except "@HEAD", which selects the
+// latest version. Some examples of revisions:
+//
+//	me/dataset~3
+//	me/dataset@HEAD^
+//	me/dataset:cleanup
+//	me/dataset:cleanup~1
+//	me/dataset^^
+//	me/dataset@{-1}
+//	me/dataset@{2020-01-01}
+//	me/dataset@v2020-q3
+//	me/dataset@/ipfs/QmSome1Commit2Hash3~1
+type Revision struct {
+	// Ref the revision is relative to. When Ref has no path, the revision is
+	// relative to the latest version
+	Ref Ref
+	// when set, the revision selects from the history of the named branch
+	Branch string
+	// when set, only versions saved at or before AsOf are considered
+	AsOf time.Time
+	// when set, the revision is relative to the version labelled with Tag
+	Tag string
+	// number of versions to step back
+	Back int
+}
+
+// RevisionRange is a pair of revisions of the form "<from>..<to>", selecting
+// the versions after From, up to and including To. An empty right side refers
+// to the latest version of the left side's dataset
+type RevisionRange struct {
+	From, To Revision
+}
+
+var (
+	revisionSuffix   = regexp.MustCompile(`^(.*?)(:` + branchName + `)?(@\{[^}]*\}|@` + tagName + `)?((?:~\d*|\^)*)$`)
+	tagNameCheck     = regexp.MustCompile(`^` + tagName + `$`)
+	branchNameCheck  = regexp.MustCompile(`^` + branchName + `$`)
+	branchSuffix     = regexp.MustCompile(`^([^:@]+):(` + branchName + `)$`)
+	revisionStepOp   = regexp.MustCompile(`~\d*|\^`)
+	revisionDateForm = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+	// ErrInvalidRevision is returned when a revision selector can't be parsed
+	ErrInvalidRevision = fmt.Errorf("invalid revision")
+	// ErrDescribeValidTag describes a valid tag name
+	ErrDescribeValidTag = fmt.Errorf("tag name must start with a letter or number, and only contain letters, numbers, dots, dashes, and underscores. Maximum length is 144 characters")
+	// ErrDescribeValidBranch describes a valid branch name
+	ErrDescribeValidBranch = fmt.Errorf("branch name must start with a letter or number, and only contain letters, numbers, dashes, and underscores. Maximum length is 144 characters")
+)
+
+// Head is the selector for the latest version of a dataset, which can't be
+// used as a tag name
+const Head = "HEAD"
+
+// tagName matches a tag. tags can't contain "..", which separates the sides
+// of a revision range
+const tagName = `[a-zA-Z0-9][\w.-]{0,143}`
+
+// branchName matches a branch
+const branchName = `[a-zA-Z0-9][\w-]{0,143}`
+
+// ParseRevision parses a reference with an optional revision suffix. Errors
+// from parsing the reference itself are the same as those returned by Parse,
+// including ErrBadCaseName, which is returned alongside a usable revision
+func ParseRevision(text string) (Revision, error) {
+	var rev Revision
+	matches := revisionSuffix.FindStringSubmatch(text)
+	refStr, branch, selector, steps := matches[1], matches[2], matches[3], matches[4]
+
+	ref, err := Parse(refStr)
+	if err != nil && err != ErrBadCaseName {
+		return rev, err
+	}
+	rev.Ref = ref
+	if branch != "" {
+		if ref.Path != "" {
+			return rev, fmt.Errorf("%w %q in %q. a branch can't be combined with a version path", ErrInvalidRevision, branch, text)
+		}
+		rev.Branch = branch[1:]
+	}
+
+	if selector == "@"+Head {
+		// the latest version, steps back are counted from it
+	} else if strings.HasPrefix(selector, "@") && !strings.HasPrefix(selector, "@{") {
+		rev.Tag = selector[1:]
+	} else if selector != "" {
+		sel := selector[2 : len(selector)-1]
+		if n, convErr := strconv.Atoi(strings.TrimPrefix(sel, "-")); convErr == nil {
+			rev.Back = n
+		} else if rev.AsOf, convErr = parseRevisionDate(sel); convErr != nil {
+			return rev, fmt.Errorf("%w %q in %q. expected a number of versions or a date", ErrInvalidRevision, selector, text)
+		} else if ref.Path != "" {
+			return rev, fmt.Errorf("%w %q in %q. a date can't be combined with a version path", ErrInvalidRevision, selector, text)
+		}
+	}
+
+	for _, op := range revisionStepOp.FindAllString(steps, -1) {
+		if op == "^" || op == "~" {
+			rev.Back++
+			continue
+		}
+		n, convErr := strconv.Atoi(op[1:])
+		if convErr != nil {
+			return rev, fmt.Errorf("%w %q in %q", ErrInvalidRevision, op, text)
+		}
+		rev.Back += n
+	}
+
+	return rev, err
+}
+
+func parseRevisionDate(s string) (t time.Time, err error) {
+	for _, layout := range revisionDateForm {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// IsRelative returns whether the revision selects a version other than the
+// one its reference resolves to
+func (r Revision) IsRelative() bool {
+	return r.Back > 0 || !r.AsOf.IsZero() || r.Tag != "" || r.Branch != ""
+}
+
+// String formats the revision using revision syntax
+func (r Revision) String() string {
+	s := r.Ref.String()
+	if r.Branch != "" {
+		s += ":" + r.Branch
+	}
+	if r.Tag != "" {
+		s += "@" + r.Tag
+	}
+	if !r.AsOf.IsZero() {
+		s += "@{" + r.AsOf.Format(time.RFC3339) + "}"
+	}
+	if r.Back > 0 {
+		s += "~" + strconv.Itoa(r.Back)
+	}
+	return s
+}
+
+// ParseRevisionRange parses a revision range of the form "<from>..<to>"
+func ParseRevisionRange(text string) (RevisionRange, error) {
+	var rng RevisionRange
+	parts := strings.Split(text, "..")
+	if len(parts) != 2 {
+		return rng, fmt.Errorf("%q is not a revision range. expected the form <from>..<to>", text)
+	}
+	if parts[0] == "" {
+		return rng, fmt.Errorf("revision range %q is missing a starting revision", text)
+	}
+
+	var err error
+	if rng.From, err = ParseRevision(parts[0]); err != nil && err != ErrBadCaseName {
+		return rng, err
+	}
+	if parts[1] == "" {
+		rng.To = Revision{Ref: Ref{Username: rng.From.Ref.Username, Name: rng.From.Ref.Name}, Branch: rng.From.Branch}
+		return rng, err
+	}
+	var toErr error
+	if rng.To, toErr = ParseRevision(parts[1]); toErr != nil {
+		return rng, toErr
+	}
+	return rng, err
+}
+
+// EnsureValidTag returns nil if the tag name is valid, and an error otherwise
+func EnsureValidTag(name string) error {
+	if !tagNameCheck.MatchString(name) || strings.Contains(name, "..") {
+		return ErrDescribeValidTag
+	}
+	if name == Head {
+		return fmt.Errorf("%q refers to the latest version and can't be used as a tag name", Head)
+	}
+	return nil
+}
+
+// EnsureValidBranch returns nil if the branch name is valid, and an error
+// otherwise
+func EnsureValidBranch(name string) error {
+	if !branchNameCheck.MatchString(name) {
+		return ErrDescribeValidBranch
+	}
+	return nil
+}
+
+// SplitBranch separates a branch suffix from a reference string like
+// "me/dataset:cleanup", returning the reference and branch name. Strings
+// without a branch suffix are returned unchanged with an empty branch
+func SplitBranch(text string) (refStr, branch string) {
+	if m := branchSuffix.FindStringSubmatch(text); m != nil {
+		return m[1], m[2]
+	}
+	return text, ""
+}
+
+// IsRevisionString returns whether text is a valid reference with an
+// optional revision suffix
+func IsRevisionString(text string) bool {
+	_, err := ParseRevision(text)
+	return err == nil || err == ErrBadCaseName
+}
+
+// IsRevisionRange returns whether text is a valid revision range
+func IsRevisionRange(text string) bool {
+	_, err := ParseRevisionRange(text)
+	return err == nil || err == ErrBadCaseName
+}
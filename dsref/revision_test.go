@@ -0,0 +1,125 @@
+package dsref
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRevision(t *testing.T) {
+	path := "/ipfs/QmdKvcBqKgLhQaG4dkn8jmmYFVRP75LVzKdvAw6DZMyRtv"
+	cases := []struct {
+		in     string
+		expect Revision
+	}{
+		{"me/ds", Revision{Ref: Ref{Username: "me", Name: "ds"}}},
+		{"me/ds~3", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 3}},
+		{"me/ds~", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 1}},
+		{"me/ds^^~2", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 4}},
+		{"me/ds@{-1}", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 1}},
+		{"me/ds@{2}~1", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 3}},
+		{"me/ds@{2020-01-01}", Revision{Ref: Ref{Username: "me", Name: "ds"}, AsOf: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"me/ds@{2020-01-01T12:30:00Z}^", Revision{Ref: Ref{Username: "me", Name: "ds"}, AsOf: time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC), Back: 1}},
+		{"me/ds@" + path + "~1", Revision{Ref: Ref{Username: "me", Name: "ds", Path: path}, Back: 1}},
+		{"me/ds@v2020-q3", Revision{Ref: Ref{Username: "me", Name: "ds"}, Tag: "v2020-q3"}},
+		{"me/ds@1.0.2^", Revision{Ref: Ref{Username: "me", Name: "ds"}, Tag: "1.0.2", Back: 1}},
+		{"me/ds@HEAD", Revision{Ref: Ref{Username: "me", Name: "ds"}}},
+		{"me/ds@HEAD^", Revision{Ref: Ref{Username: "me", Name: "ds"}, Back: 1}},
+		{"me/ds:cleanup@HEAD~2", Revision{Ref: Ref{Username: "me", Name: "ds"}, Branch: "cleanup", Back: 2}},
+		{"me/ds:cleanup", Revision{Ref: Ref{Username: "me", Name: "ds"}, Branch: "cleanup"}},
+		{"me/ds:cleanup~2", Revision{Ref: Ref{Username: "me", Name: "ds"}, Branch: "cleanup", Back: 2}},
+		{"me/ds:try_2@{2020-01-01}", Revision{Ref: Ref{Username: "me", Name: "ds"}, Branch: "try_2", AsOf: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	for _, c := range cases {
+		got, err := ParseRevision(c.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.in, err)
+			continue
+		}
+		if got.Ref != c.expect.Ref || got.Back != c.expect.Back || got.Tag != c.expect.Tag || got.Branch != c.expect.Branch || !got.AsOf.Equal(c.expect.AsOf) {
+			t.Errorf("%q: result mismatch. expected: %#v, got: %#v", c.in, c.expect, got)
+		}
+	}
+
+	bad := []string{
+		"",
+		"~3",
+		"me/ds@{yesterday}",
+		"me/ds@" + path + "@{2020-01-01}",
+		"me/ds~3x",
+		"me/ds@" + path + ":cleanup",
+		"me/ds:-cleanup",
+	}
+	for _, s := range bad {
+		if _, err := ParseRevision(s); err == nil {
+			t.Errorf("%q: expected error, got nil", s)
+		}
+	}
+}
+
+func TestParseRevisionRange(t *testing.T) {
+	rng, err := ParseRevisionRange("me/ds~5..me/ds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng.From.Back != 5 || rng.To.Back != 0 || rng.To.Ref.Human() != "me/ds" {
+		t.Errorf("unexpected range: %#v", rng)
+	}
+
+	rng, err = ParseRevisionRange("me/ds@{-2}..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng.From.Back != 2 || rng.To.IsRelative() || rng.To.Ref.Human() != "me/ds" {
+		t.Errorf("expected empty right side to refer to the latest version, got: %#v", rng.To)
+	}
+
+	for _, s := range []string{"me/ds", "..me/ds", "me/ds..me/ds..me/ds", "../body.json"} {
+		if IsRevisionRange(s) {
+			t.Errorf("%q: expected not to be a revision range", s)
+		}
+	}
+	if !IsRevisionString("me/ds~2") || IsRevisionString("body.json") {
+		t.Errorf("IsRevisionString mismatch")
+	}
+}
+
+func TestEnsureValidTag(t *testing.T) {
+	for _, name := range []string{"v1", "v2020-q3", "1.0.2", "release_candidate"} {
+		if err := EnsureValidTag(name); err != nil {
+			t.Errorf("%q: unexpected error: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "-v1", "v1..2", "v 1", "v1/2", "{v1}", "HEAD"} {
+		if err := EnsureValidTag(name); err == nil {
+			t.Errorf("%q: expected error, got nil", name)
+		}
+	}
+}
+
+func TestSplitBranch(t *testing.T) {
+	cases := []struct {
+		in, ref, branch string
+	}{
+		{"me/ds", "me/ds", ""},
+		{"me/ds:cleanup", "me/ds", "cleanup"},
+		{"ds:cleanup", "ds", "cleanup"},
+		{"me/ds:", "me/ds:", ""},
+		{"me/ds@/ipfs/QmFoo:cleanup", "me/ds@/ipfs/QmFoo:cleanup", ""},
+	}
+	for _, c := range cases {
+		ref, branch := SplitBranch(c.in)
+		if ref != c.ref || branch != c.branch {
+			t.Errorf("%q: expected (%q, %q), got (%q, %q)", c.in, c.ref, c.branch, ref, branch)
+		}
+	}
+
+	if err := EnsureValidBranch("cleanup-2"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	for _, name := range []string{"", "-x", "a.b", "a/b"} {
+		if err := EnsureValidBranch(name); err == nil {
+			t.Errorf("%q: expected error, got nil", name)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package hook
 
 import (
+	"sync"
+
 	"github.com/qri-io/qri/dsref"
 )
 
@@ -37,3 +39,40 @@ type DsChange struct {
 type ChangeNotifier interface {
 	SetChangeHook(func(change DsChange))
 }
+
+// ChangeBroadcaster relays changes from a set of ChangeNotifiers to any
+// number of hooks. Notifiers only hold a single hook, so a broadcaster allows
+// more than one subscriber to follow dataset changes
+type ChangeBroadcaster struct {
+	lk    sync.Mutex
+	hooks []func(change DsChange)
+}
+
+var _ ChangeNotifier = (*ChangeBroadcaster)(nil)
+
+// NewChangeBroadcaster creates a broadcaster, taking over the change hook of
+// each notifier
+func NewChangeBroadcaster(notifiers ...ChangeNotifier) *ChangeBroadcaster {
+	b := &ChangeBroadcaster{}
+	for _, n := range notifiers {
+		n.SetChangeHook(b.notify)
+	}
+	return b
+}
+
+// SetChangeHook adds a hook that will be called when a dataset changes.
+// Unlike other notifiers, a broadcaster keeps every hook it's given
+func (b *ChangeBroadcaster) SetChangeHook(changeHook func(change DsChange)) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	b.hooks = append(b.hooks, changeHook)
+}
+
+func (b *ChangeBroadcaster) notify(change DsChange) {
+	b.lk.Lock()
+	hooks := b.hooks
+	b.lk.Unlock()
+	for _, h := range hooks {
+		h(change)
+	}
+}
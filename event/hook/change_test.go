@@ -0,0 +1,35 @@
+package hook
+
+import (
+	"testing"
+)
+
+type testNotifier struct {
+	hook func(DsChange)
+}
+
+func (n *testNotifier) SetChangeHook(h func(DsChange)) {
+	n.hook = h
+}
+
+func TestChangeBroadcaster(t *testing.T) {
+	a, b := &testNotifier{}, &testNotifier{}
+	bc := NewChangeBroadcaster(a, b)
+
+	var got []string
+	bc.SetChangeHook(func(c DsChange) { got = append(got, "one:"+c.InitID) })
+	bc.SetChangeHook(func(c DsChange) { got = append(got, "two:"+c.InitID) })
+
+	a.hook(DsChange{InitID: "a"})
+	b.hook(DsChange{InitID: "b"})
+
+	expect := []string{"one:a", "two:a", "one:b", "two:b"}
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d calls, got %d: %v", len(expect), len(got), got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("call %d: expected %q, got %q", i, expect[i], got[i])
+		}
+	}
+}
@@ -43,6 +43,16 @@ func GetLinkedFilesysRef(dir string) (dsref.Ref, bool) {
 	return ref, err == nil
 }
 
+// GetLinkedFilesysBranch returns the name of the branch a linked directory is
+// on, which is empty for the default branch
+func GetLinkedFilesysBranch(dir string) string {
+	_, branch, err := linkfile.ReadBranch(filepath.Join(dir, linkfile.RefLinkHiddenFilename))
+	if err != nil {
+		return ""
+	}
+	return branch
+}
+
 // RepoPath returns the standard path to an FSI file for a given file-system
 // repo location
 func RepoPath(repoPath string) string {
@@ -219,12 +229,20 @@ func (fsi *FSI) ModifyLinkDirectory(dirPath string, ref dsref.Ref) (*dsref.Versi
 }
 
 // ModifyLinkReference changes the reference that is in .qri-ref linkfile in the working directory.
-// Does not affect the ref in the repo. Called when a rename command is invoked.
+// Does not affect the ref in the repo. Called when a rename command is invoked. The branch the
+// working directory is on is kept
 func (fsi *FSI) ModifyLinkReference(dirPath string, ref dsref.Ref) (string, error) {
-	log.Debugf("fsi.ModifyLinkReference: modify linkfile at %q, ref=%q", dirPath, ref)
+	return fsi.ModifyLinkBranch(dirPath, ref, GetLinkedFilesysBranch(dirPath))
+}
+
+// ModifyLinkBranch changes the reference & branch in the .qri-ref linkfile in the working
+// directory. Does not affect the ref in the repo, or the files in the working directory. An
+// empty branch refers to the default branch
+func (fsi *FSI) ModifyLinkBranch(dirPath string, ref dsref.Ref, branch string) (string, error) {
+	log.Debugf("fsi.ModifyLinkBranch: modify linkfile at %q, ref=%q, branch=%q", dirPath, ref, branch)
 	// Remove the path from the reference because linkfile's don't store full paths.
 	ref.Path = ""
-	return linkfile.WriteHiddenInDir(dirPath, ref)
+	return linkfile.WriteHiddenBranchInDir(dirPath, ref, branch)
 }
 
 // Unlink removes the link file (.qri-ref) in the directory, and removes the fsi path
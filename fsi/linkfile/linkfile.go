@@ -20,12 +20,22 @@ const RefLinkHiddenFilename = ".qri-ref"
 
 // Read reads a reference from a linkfile with the given filename
 func Read(filename string) (dsref.Ref, error) {
+	ref, _, err := ReadBranch(filename)
+	return ref, err
+}
+
+// ReadBranch reads a reference and the name of the branch the linked
+// directory is on from a linkfile. Linkfiles for the default branch have no
+// branch name
+func ReadBranch(filename string) (dsref.Ref, string, error) {
 	var ref dsref.Ref
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return ref, err
+		return ref, "", err
 	}
-	return dsref.Parse(strings.TrimSpace(string(data)))
+	refStr, branch := dsref.SplitBranch(strings.TrimSpace(string(data)))
+	ref, err = dsref.Parse(refStr)
+	return ref, branch, err
 }
 
 // ExistsInDir returns whether a linkfile exists in the directory
@@ -47,8 +57,18 @@ func WriteTextInDir(dir string, ref dsref.Ref) (string, error) {
 
 // WriteHiddenInDir writes a reference to a hidden linkfile in the given directory
 func WriteHiddenInDir(dir string, ref dsref.Ref) (string, error) {
+	return WriteHiddenBranchInDir(dir, ref, "")
+}
+
+// WriteHiddenBranchInDir writes a reference & branch name to a hidden linkfile
+// in the given directory. An empty branch refers to the default branch
+func WriteHiddenBranchInDir(dir string, ref dsref.Ref, branch string) (string, error) {
 	filename := filepath.Join(dir, RefLinkHiddenFilename)
-	return filename, hiddenfile.WriteHiddenFile(filename, refText(ref))
+	text := refText(ref)
+	if branch != "" {
+		text += ":" + branch
+	}
+	return filename, hiddenfile.WriteHiddenFile(filename, text)
 }
 
 // WriteRef writes a reference to the given io.Writer
@@ -0,0 +1,62 @@
+package fsi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/qri-io/qri/base/merge"
+	"github.com/qri-io/qri/fsi/hiddenfile"
+)
+
+// MergeStateFilename is the name of the hidden file that records a merge in
+// progress in a linked directory
+const MergeStateFilename = ".qri-merge.json"
+
+// MergeState records a merge that stopped on conflicts, which are written to
+// the working directory for the user to resolve before continuing the merge
+type MergeState struct {
+	// reference to the version being merged into the working directory
+	Theirs string `json:"theirs"`
+	// path of the version being merged
+	TheirsPath string `json:"theirsPath"`
+	// path of the common ancestor, empty if the versions share no history
+	Ancestor string `json:"ancestor,omitempty"`
+	// conflicts left to resolve
+	Conflicts []merge.Conflict `json:"conflicts"`
+}
+
+// WriteMergeState records a merge in progress in a linked directory
+func WriteMergeState(dir string, state *MergeState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return hiddenfile.WriteHiddenFile(filepath.Join(dir, MergeStateFilename), string(data))
+}
+
+// ReadMergeState reads the merge in progress in a linked directory, returning
+// nil if there isn't one
+func ReadMergeState(dir string) (*MergeState, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, MergeStateFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state := &MergeState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// RemoveMergeState clears the merge in progress in a linked directory
+func RemoveMergeState(dir string) error {
+	err := os.Remove(filepath.Join(dir, MergeStateFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -71,11 +71,24 @@ func (fsi *FSI) Status(ctx context.Context, dir string) (changes []StatusItem, e
 	if err != nil {
 		return nil, err
 	}
-	if vi.Path == "" {
+	path := vi.Path
+	if branch := GetLinkedFilesysBranch(dir); branch != "" {
+		// directories linked to a branch compare against the latest version of
+		// the branch
+		head, err := fsi.repo.Logbook().ResolveRevision(ctx, dsref.Revision{
+			Ref:    dsref.Ref{Username: vi.Username, Name: vi.Name},
+			Branch: branch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		path = head.Path
+	}
+	if path == "" {
 		// no dataset, compare to an empty ds
 		stored = &dataset.Dataset{}
 	} else {
-		if stored, err = dsfs.LoadDataset(ctx, fsi.repo.Store(), vi.Path); err != nil {
+		if stored, err = dsfs.LoadDataset(ctx, fsi.repo.Store(), path); err != nil {
 			return nil, err
 		}
 	}
@@ -99,7 +112,30 @@ func (fsi *FSI) Status(ctx context.Context, dir string) (changes []StatusItem, e
 
 	prevComps := component.ConvertDatasetToComponents(stored, fsi.repo.Filesystem())
 	nextComps := working
-	return fsi.CalculateStateTransition(ctx, prevComps, nextComps)
+	if changes, err = fsi.CalculateStateTransition(ctx, prevComps, nextComps); err != nil {
+		return nil, err
+	}
+	return markMergeConflicts(dir, changes)
+}
+
+// markMergeConflicts sets the status of components that have unresolved
+// conflicts from a merge in progress to STConflictError
+func markMergeConflicts(dir string, changes []StatusItem) ([]StatusItem, error) {
+	state, err := ReadMergeState(dir)
+	if err != nil || state == nil {
+		return changes, err
+	}
+	conflicted := map[string]bool{}
+	for _, c := range state.Conflicts {
+		conflicted[c.Component] = true
+	}
+	for i, ch := range changes {
+		if conflicted[ch.Component] {
+			changes[i].Type = STConflictError
+			changes[i].Message = fmt.Sprintf("unresolved conflicts merging %s", state.Theirs)
+		}
+	}
+	return changes, nil
 }
 
 // CalculateStateTransition calculates the differences between two versions of a dataset.
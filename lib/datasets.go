@@ -16,12 +16,15 @@ import (
 	"github.com/qri-io/dag"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qfs/localfs"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/archive"
+	"github.com/qri-io/qri/base/columnar"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/base/fill"
 	"github.com/qri-io/qri/dscache/build"
@@ -29,8 +32,10 @@ import (
 	qrierr "github.com/qri-io/qri/errors"
 	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/fsi/linkfile"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/stats"
 )
 
 // DatasetMethods encapsulates business logic for working with Datasets on Qri
@@ -194,6 +199,9 @@ type GetParams struct {
 
 	Limit, Offset int
 	All           bool
+	// ExperimentalFormats enables body formats that are still in development,
+	// like parquet & arrow
+	ExperimentalFormats bool
 
 	// outfile is a filename to save the dataset to
 	Outfile string
@@ -212,13 +220,24 @@ type GetResult struct {
 	Published bool             `json:"published"`
 }
 
+// checkExperimentalFormat errors if format is a columnar format & experimental
+// formats aren't enabled
+func checkExperimentalFormat(format string, enabled bool) error {
+	if columnar.IsFormat(format) && !enabled {
+		msg := fmt.Sprintf("the %s format is experimental, and hasn't been tested with other %s readers.\nenable it with the --experimental-formats flag", format, format)
+		return qrierr.New(columnar.ErrExperimental, msg)
+	}
+	return nil
+}
+
 // Get retrieves datasets and components for a given reference. p.Refstr is parsed to create
 // a reference, which is used to load the dataset. It will be loaded from the local repo
 // or from the filesystem if it has a linked working direoctry.
 // Using p.Selector will control what components are returned in res.Bytes. The default,
 // a blank selector, will also fill the entire dataset at res.Data. If the selector is "body"
 // then res.Bytes is loaded with the body. If the selector is "stats", then res.Bytes is loaded
-// with the generated stats.
+// with the generated stats. If p.Refstr is a revision range like "me/ds~3..me/ds", res.Bytes
+// is a list of the selected field of each version in the range, newest first.
 func (m *DatasetMethods) Get(p *GetParams, res *GetResult) error {
 	if err := qfs.AbsPath(&p.Outfile); err != nil {
 		return err
@@ -229,6 +248,10 @@ func (m *DatasetMethods) Get(p *GetParams, res *GetResult) error {
 	}
 	ctx := context.TODO()
 
+	if dsref.IsRevisionRange(p.Refstr) {
+		return m.getRange(ctx, p, res)
+	}
+
 	var ds *dataset.Dataset
 	ref, source, err := m.inst.ParseAndResolveRefWithWorkingDir(ctx, p.Refstr, p.Remote)
 	if err != nil {
@@ -298,6 +321,19 @@ func (m *DatasetMethods) Get(p *GetParams, res *GetResult) error {
 		if !p.All && (p.Limit < 0 || p.Offset < 0) {
 			return fmt.Errorf("invalid limit / offset settings")
 		}
+		if columnar.IsFormat(p.Format) {
+			if err = checkExperimentalFormat(p.Format, p.ExperimentalFormats); err != nil {
+				return err
+			}
+			// columnar formats aren't dataset body formats, and need column types
+			// from the dataset structure
+			res.Bytes, err = base.ReadColumnarBody(ds, p.Format, p.Limit, p.Offset, p.All)
+			if err != nil {
+				log.Debugf("Get dataset, base.ReadColumnarBody %q failed, error: %s", ds, err)
+				return err
+			}
+			return m.maybeWriteOutfile(p, res)
+		}
 		df, err := dataset.ParseDataFormatString(p.Format)
 		if err != nil {
 			log.Debugf("Get dataset, ParseDataFormatString %q failed, error: %s", p.Format, err)
@@ -352,6 +388,70 @@ func (m *DatasetMethods) Get(p *GetParams, res *GetResult) error {
 			return err
 		}
 	}
+	if res.Bytes, err = encodeGetValue(p, value); err != nil {
+		return err
+	}
+	return m.maybeWriteOutfile(p, res)
+}
+
+// getRange gets the selected field of each version in a revision range,
+// newest first. res.Ref & res.Dataset are set to the newest version
+func (m *DatasetMethods) getRange(ctx context.Context, p *GetParams, res *GetResult) error {
+	switch p.Selector {
+	case "body", "stats":
+		return fmt.Errorf("cannot get %s for a revision range", p.Selector)
+	}
+	if p.Format == "zip" {
+		return fmt.Errorf("cannot write a zip archive for a revision range")
+	}
+
+	items, err := m.inst.revisionRangeItems(ctx, p.Refstr, p.Remote)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		ref := dsref.Ref{Username: item.Username, Name: item.Name, ProfileID: item.ProfileID, Path: item.Path}
+		ds, err := m.inst.LoadDataset(ctx, ref, "")
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			res.Ref = &ref
+			res.Dataset = ds
+		}
+		value, err := rangeValue(ds, p.Selector)
+		// bodies & scripts aren't part of range values, close the files
+		// loading opened instead of holding one open per version
+		if closeErr := base.CloseDataset(ds); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+
+	if res.Bytes, err = encodeGetValue(p, values); err != nil {
+		return err
+	}
+	return m.maybeWriteOutfile(p, res)
+}
+
+// rangeValue selects the value of one version in a revision range
+func rangeValue(ds *dataset.Dataset, selector string) (interface{}, error) {
+	if _, ok := scriptFileSelection(ds, selector); ok {
+		return nil, fmt.Errorf("cannot get %s for a revision range", selector)
+	}
+	if selector == "" {
+		return ds, nil
+	}
+	return base.ApplyPath(ds, selector)
+}
+
+// encodeGetValue serializes a value selected by Get in the requested format
+func encodeGetValue(p *GetParams, value interface{}) ([]byte, error) {
 	switch p.Format {
 	case "json":
 		// Pretty defaults to true for the dataset head, unless explicitly set in the config.
@@ -363,16 +463,13 @@ func (m *DatasetMethods) Get(p *GetParams, res *GetResult) error {
 			}
 		}
 		if pretty {
-			res.Bytes, err = json.MarshalIndent(value, "", " ")
-		} else {
-			res.Bytes, err = json.Marshal(value)
+			return json.MarshalIndent(value, "", " ")
 		}
+		return json.Marshal(value)
 	case "yaml", "":
-		res.Bytes, err = yaml.Marshal(value)
-	default:
-		return fmt.Errorf("unknown format: \"%s\"", p.Format)
+		return yaml.Marshal(value)
 	}
-	return m.maybeWriteOutfile(p, res)
+	return nil, fmt.Errorf("unknown format: \"%s\"", p.Format)
 }
 
 func (m *DatasetMethods) maybeWriteOutfile(p *GetParams, res *GetResult) error {
@@ -412,7 +509,8 @@ type SaveParams struct {
 	// supplied by dataset
 	Dataset *dataset.Dataset
 
-	// dataset reference string, the name to save to
+	// dataset reference string, the name to save to. A branch suffix like
+	// "me/dataset:cleanup" saves to an existing branch
 	Ref string
 	// commit title, defaults to a generated string based on diff
 	Title string
@@ -427,6 +525,9 @@ type SaveParams struct {
 	// optional writer to have transform script record standard output to
 	// note: this won't work over RPC, only on local calls
 	ScriptOutput io.Writer
+	// record or replay HTTP responses of the transform download step with a
+	// fixture file next to the transform script. one of "record", "replay"
+	HTTPFixture string
 
 	// Replace writes the entire given dataset as a new snapshot instead of
 	// applying save params as augmentations to the existing history
@@ -491,7 +592,11 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 		return fmt.Errorf("option to make dataset private not yet implemented, refer to https://github.com/qri-io/qri/issues/291 for updates")
 	}
 
-	ref, err := dsref.ParseHumanFriendly(p.Ref)
+	refStr, branch := dsref.SplitBranch(p.Ref)
+	if branch == logbook.DefaultBranchName {
+		branch = ""
+	}
+	ref, err := dsref.ParseHumanFriendly(refStr)
 	if errors.Is(err, dsref.ErrBadCaseName) {
 		// If dataset name is using bad-case characters, and is not yet in use, fail with error.
 		if !m.nameIsInUse(ctx, ref) {
@@ -519,6 +624,18 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 		return fmt.Errorf("cannot save using a different username than \"%s\"", pro.Peername)
 	}
 
+	// Saving to a branch requires both the dataset & branch to exist. The latest
+	// version of the branch becomes the previous version
+	var branchHead dsref.Ref
+	if branch != "" {
+		if p.NewName {
+			return fmt.Errorf("cannot create a new dataset while saving to a branch")
+		}
+		if branchHead, _, err = m.inst.ParseAndResolveRef(ctx, p.Ref, "local"); err != nil {
+			return fmt.Errorf("cannot save to branch %q: %w", branch, err)
+		}
+	}
+
 	// Parsed human-friendly dsref can only have username and name.
 	datasetRef := reporef.DatasetRef{
 		Peername: ref.Username,
@@ -538,9 +655,18 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 	if err == repo.ErrNotFound || err == repo.ErrEmptyRef {
 		// do nothing
 	} else if err == nil || err == repo.ErrNoHistory {
+		// A working directory only applies to saves to the branch it's on
+		if datasetRef.FSIPath != "" && fsi.GetLinkedFilesysBranch(datasetRef.FSIPath) != branch {
+			datasetRef.FSIPath = ""
+		}
 		// When saving in an FSI directory, the ref should exist (due to `qri init`), and we
 		// need to load the previous version from the working directory.
 		if datasetRef.FSIPath != "" {
+			if state, err := fsi.ReadMergeState(datasetRef.FSIPath); err != nil {
+				return err
+			} else if state != nil {
+				return qrierr.New(fmt.Errorf("cannot save while a merge is in progress"), "resolve conflicts in the working directory, then run `qri merge --continue` to save the merge, or `qri merge --abort` to abandon it")
+			}
 			ds, err = fsi.ReadDir(datasetRef.FSIPath)
 			if err != nil {
 				return err
@@ -626,7 +752,7 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 	// TODO(dustmop): This will become a call to `apply` in the future, and will require the
 	// `--apply` flag to be true.
 	if ds.Transform != nil {
-		str := m.inst.node.LocalStreams
+		str := m.inst.localStreams()
 		scriptOut := p.ScriptOutput
 		secrets := p.Secrets
 		r := m.inst.repo
@@ -641,10 +767,16 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 		// on the passed in mode string instead of just using the default resolver
 		// cmd can then define "remote" and "offline" flags, that set the ResolverMode
 		// string and control how transform functions
-		loader := NewParseResolveLoadFunc("", m.inst.defaultResolver(), m.inst)
+		loader := m.inst.newRevisionLoadFunc("", m.inst.defaultResolver())
+
+		execOpts, err := httpFixtureOpts(p.HTTPFixture, ds.Transform.ScriptPath)
+		if err != nil {
+			return err
+		}
+		execOpts = append(m.inst.transformExecOpts(loader), execOpts...)
 
 		// apply the transform
-		err := base.TransformApply(ctx, ds, r, loader, str, scriptOut, secrets)
+		err = base.TransformApply(ctx, ds, r, loader, str, scriptOut, secrets, execOpts...)
 		if err != nil {
 			return err
 		}
@@ -705,14 +837,19 @@ func (m *DatasetMethods) Save(p *SaveParams, res *reporef.DatasetRef) error {
 		NewName:             p.NewName,
 		Drop:                p.Drop,
 	}
-	datasetRef, err = base.SaveDataset(ctx, m.inst.repo, writeDest, trueRef.InitID, trueRef.Path, ds, switches)
+	if branch != "" {
+		datasetRef, err = base.SaveDatasetToBranch(ctx, m.inst.repo, writeDest, trueRef.InitID, branch, branchHead.Path, ds, switches)
+	} else {
+		datasetRef, err = base.SaveDataset(ctx, m.inst.repo, writeDest, trueRef.InitID, trueRef.Path, ds, switches)
+	}
 	if err != nil {
 		log.Debugf("create ds error: %s\n", err.Error())
 		return err
 	}
 
 	// TODO (b5) - this should be integrated into base.SaveDataset
-	if fsiPath != "" && !p.DryRun {
+	// the refstore tracks the head of the default branch only
+	if fsiPath != "" && !p.DryRun && branch == "" {
 		datasetRef.FSIPath = fsiPath
 		if err = m.inst.repo.PutRef(datasetRef); err != nil {
 			return err
@@ -1317,35 +1454,143 @@ func (m *DatasetMethods) Stats(p *StatsParams, res *StatsResponse) error {
 	ctx := context.TODO()
 
 	if p.Dataset == nil {
-		// TODO (b5) - stats is currently local-only, supply a source parameter
-		ref, source, err := m.inst.ParseAndResolveRefWithWorkingDir(ctx, p.Ref, "local")
-		if err != nil {
+		if p.Dataset, err = m.loadStatsDataset(ctx, p.Ref); err != nil {
 			return err
 		}
-		p.Dataset, err = m.inst.LoadDataset(ctx, ref, source)
-		if err != nil {
-			return fmt.Errorf("loading dataset: %w", err)
-		}
 	}
 
-	if p.Dataset.Structure == nil || p.Dataset.Structure.IsEmpty() {
-		p.Dataset.Structure = &dataset.Structure{}
-		p.Dataset.Structure.Format = filepath.Ext(p.Dataset.BodyFile().FileName())
-		p.Dataset.Structure.Schema, _, err = detect.Schema(p.Dataset.Structure, p.Dataset.BodyFile())
+	res.StatsBytes, err = m.statsJSON(ctx, p.Dataset)
+	return err
+}
+
+// statsJSON calculates stats for a dataset, detecting a schema for the
+// dataset body if the dataset doesn't define one
+func (m *DatasetMethods) statsJSON(ctx context.Context, ds *dataset.Dataset) ([]byte, error) {
+	var err error
+	if ds.Structure == nil || ds.Structure.IsEmpty() {
+		ds.Structure = &dataset.Structure{}
+		ds.Structure.Format = filepath.Ext(ds.BodyFile().FileName())
+		ds.Structure.Schema, _, err = detect.Schema(ds.Structure, ds.BodyFile())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// TODO (ramfox): this feels gross, but since we consume the reader when
 		// detecting the schema, we need to open up the file again, since we don't
 		// have the option to seek back to the front
-		if err = p.Dataset.OpenBodyFile(ctx, m.inst.repo.Filesystem()); err != nil {
-			return err
+		if err = ds.OpenBodyFile(ctx, m.inst.repo.Filesystem()); err != nil {
+			return nil, err
 		}
 	}
-	reader, err := m.inst.stats.JSON(ctx, p.Dataset)
+	reader, err := m.inst.stats.JSON(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// StatsDiffParams defines parameters for comparing the stats of two dataset
+// versions. If RightRef is empty, LeftRef is compared against its previous
+// version
+type StatsDiffParams struct {
+	// string representations of dataset references
+	LeftRef, RightRef string
+}
+
+// StatsDiffResponse defines the response for a StatsDiff request
+type StatsDiffResponse struct {
+	Diff *stats.Diff
+}
+
+// StatsDiff compares the statistical profile of two dataset versions
+func (m *DatasetMethods) StatsDiff(p *StatsDiffParams, res *StatsDiffResponse) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("DatasetMethods.StatsDiff", p, res))
+	}
+	ctx := context.TODO()
+
+	if p.LeftRef == "" {
+		return fmt.Errorf("stats diff requires a dataset reference")
+	}
+
+	left, err := m.loadStatsDataset(ctx, p.LeftRef)
 	if err != nil {
 		return err
 	}
-	res.StatsBytes, err = ioutil.ReadAll(reader)
-	return err
+
+	var right *dataset.Dataset
+	if p.RightRef == "" {
+		if left.PreviousPath == "" {
+			return fmt.Errorf("dataset has only one version, nothing to diff against")
+		}
+		right = left
+		prev := dsref.Ref{Username: right.Peername, Name: right.Name, Path: right.PreviousPath}
+		if left, err = m.inst.LoadDataset(ctx, prev, ""); err != nil {
+			return fmt.Errorf("loading previous version: %w", err)
+		}
+	} else if right, err = m.loadStatsDataset(ctx, p.RightRef); err != nil {
+		return err
+	}
+
+	leftIn, err := m.statsDiffInput(ctx, left)
+	if err != nil {
+		return err
+	}
+	rightIn, err := m.statsDiffInput(ctx, right)
+	if err != nil {
+		return err
+	}
+
+	res.Diff = stats.CalcDiff(leftIn, rightIn)
+	return nil
+}
+
+func (m *DatasetMethods) loadStatsDataset(ctx context.Context, refstr string) (*dataset.Dataset, error) {
+	// TODO (b5) - stats is currently local-only, supply a source parameter
+	ref, source, err := m.inst.ParseAndResolveRefWithWorkingDir(ctx, refstr, "local")
+	if err != nil {
+		return nil, err
+	}
+	ds, err := m.inst.LoadDataset(ctx, ref, source)
+	if err != nil {
+		return nil, fmt.Errorf("loading dataset: %w", err)
+	}
+	return ds, nil
+}
+
+func (m *DatasetMethods) statsDiffInput(ctx context.Context, ds *dataset.Dataset) (stats.DiffInput, error) {
+	in := stats.DiffInput{}
+	data, err := m.statsJSON(ctx, ds)
+	if err != nil {
+		return in, err
+	}
+	if err := json.Unmarshal(data, &in.Stats); err != nil {
+		return in, err
+	}
+
+	in.RowCount = ds.Structure.Entries
+	if in.RowCount == 0 {
+		// structures read from a working directory may not have an entry count,
+		// fall back to the largest column count
+		for _, st := range in.Stats {
+			if count, ok := st["count"].(float64); ok && int(count) > in.RowCount {
+				in.RowCount = int(count)
+			}
+		}
+	}
+	if cols, _, err := tabular.ColumnsFromJSONSchema(ds.Structure.Schema); err == nil {
+		in.Columns = cols.Titles()
+	}
+
+	// frequency tables omit values that appear once, read the body again to
+	// collect the distinct values categorical changes are calculated from
+	if err = ds.OpenBodyFile(ctx, m.inst.repo.Filesystem()); err != nil {
+		return in, err
+	}
+	defer ds.BodyFile().Close()
+	rdr, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+	if err != nil {
+		return in, err
+	}
+	in.Values, err = stats.ColumnValues(rdr, stats.StopFreqCountThreshold)
+	return in, err
 }
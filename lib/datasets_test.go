@@ -30,6 +30,7 @@ import (
 	p2ptest "github.com/qri-io/qri/p2p/test"
 	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/stats"
 )
 
 func TestDatasetRequestsSave(t *testing.T) {
@@ -464,7 +465,7 @@ func TestDatasetRequestsGet(t *testing.T) {
 		expect      string
 	}{
 		{"invalid peer name",
-			&GetParams{Refstr: "peer/ABC@abc"}, `"peer/ABC@abc" is not a valid dataset reference: unexpected character at position 8: '@'`},
+			&GetParams{Refstr: "peer/ABC@/abc"}, `"peer/ABC@/abc" is not a valid dataset reference: unexpected character at position 8: '@'`},
 
 		{"peername without path",
 			&GetParams{Refstr: "peer/movies"},
@@ -1164,8 +1165,8 @@ func TestDatasetRequestsStats(t *testing.T) {
 		ref         string
 		expected    []byte
 	}{
-		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"maxLength":8,"minLength":7,"type":"string","unique":5},{"count":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"type":"numeric"},{"count":5,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"type":"numeric"},{"count":5,"falseCount":1,"trueCount":4,"type":"boolean"}]`)},
-		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"type":"numeric"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","maxLength":24,"minLength":24,"type":"string"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","maxLength":24,"minLength":24,"type":"string"},{"count":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"type":"numeric"},{"count":10,"key":"hash","maxLength":68,"minLength":68,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"maxLength":58,"minLength":14,"unique":10},{"count":10,"maxLength":115,"minLength":19,"unique":10},{"count":10,"maxLength":68,"minLength":22,"unique":10},{"count":10,"maxLength":115,"minLength":14,"unique":10},{"count":9,"maxLength":70,"minLength":15,"unique":9},{"count":9,"maxLength":115,"minLength":37,"unique":9},{"count":9,"maxLength":52,"minLength":15,"unique":9},{"count":9,"maxLength":75,"minLength":19,"unique":9},{"count":9,"maxLength":66,"minLength":15,"unique":9},{"count":7,"maxLength":75,"minLength":19,"unique":7},{"count":7,"maxLength":66,"minLength":22,"unique":7},{"count":6,"maxLength":43,"minLength":19,"unique":6},{"count":6,"maxLength":77,"minLength":14,"unique":6},{"count":6,"maxLength":77,"minLength":21,"unique":6},{"count":4,"maxLength":43,"minLength":14,"unique":4},{"count":3,"maxLength":32,"minLength":21,"unique":3},{"count":3,"maxLength":42,"minLength":19,"unique":3},{"count":3,"maxLength":66,"minLength":32,"unique":3},{"count":3,"maxLength":46,"minLength":19,"unique":3},{"count":2,"maxLength":66,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":23,"unique":2},{"count":2,"maxLength":33,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":27,"unique":2},{"count":1,"maxLength":33,"minLength":33,"unique":1},{"count":1,"maxLength":27,"minLength":27,"unique":1}]},{"count":1,"key":"redirectTo","maxLength":18,"minLength":18,"type":"string","unique":1},{"count":11,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"type":"numeric"},{"count":11,"key":"timestamp","maxLength":35,"minLength":35,"type":"string","unique":11},{"count":10,"key":"title","maxLength":88,"minLength":53,"type":"string","unique":10},{"count":11,"key":"url","maxLength":78,"minLength":18,"type":"string","unique":11}]`)},
+		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"distinct":5,"maxLength":8,"minLength":7,"type":"string","unique":5},{"count":5,"distinct":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"quantiles":{"p1":35000,"p25":250000,"p75":8500000,"p99":40000000},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"quantiles":{"p1":44.4,"p25":44.4,"p75":55.5,"p99":65.25},"type":"numeric"},{"count":5,"falseCount":1,"trueCount":4,"type":"boolean"}]`)},
+		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"distinct":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"quantiles":{"p1":24515,"p25":25028,"p75":30258,"p99":40079},"type":"numeric"},{"count":10,"distinct":1,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","maxLength":24,"minLength":24,"type":"string"},{"count":10,"distinct":1,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","maxLength":24,"minLength":24,"type":"string"},{"count":10,"distinct":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"quantiles":{"p1":74291866,"p25":4055332831,"p75":4080164896,"p99":4081577841},"type":"numeric"},{"count":10,"distinct":10,"key":"hash","maxLength":68,"minLength":68,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"distinct":10,"maxLength":58,"minLength":14,"unique":10},{"count":10,"distinct":10,"maxLength":115,"minLength":19,"unique":10},{"count":10,"distinct":10,"maxLength":68,"minLength":22,"unique":10},{"count":10,"distinct":10,"maxLength":115,"minLength":14,"unique":10},{"count":9,"distinct":9,"maxLength":70,"minLength":15,"unique":9},{"count":9,"distinct":9,"maxLength":115,"minLength":37,"unique":9},{"count":9,"distinct":9,"maxLength":52,"minLength":15,"unique":9},{"count":9,"distinct":9,"maxLength":75,"minLength":19,"unique":9},{"count":9,"distinct":9,"maxLength":66,"minLength":15,"unique":9},{"count":7,"distinct":7,"maxLength":75,"minLength":19,"unique":7},{"count":7,"distinct":7,"maxLength":66,"minLength":22,"unique":7},{"count":6,"distinct":6,"maxLength":43,"minLength":19,"unique":6},{"count":6,"distinct":6,"maxLength":77,"minLength":14,"unique":6},{"count":6,"distinct":6,"maxLength":77,"minLength":21,"unique":6},{"count":4,"distinct":4,"maxLength":43,"minLength":14,"unique":4},{"count":3,"distinct":3,"maxLength":32,"minLength":21,"unique":3},{"count":3,"distinct":3,"maxLength":42,"minLength":19,"unique":3},{"count":3,"distinct":3,"maxLength":66,"minLength":32,"unique":3},{"count":3,"distinct":3,"maxLength":46,"minLength":19,"unique":3},{"count":2,"distinct":2,"maxLength":66,"minLength":22,"unique":2},{"count":2,"distinct":2,"maxLength":32,"minLength":23,"unique":2},{"count":2,"distinct":2,"maxLength":33,"minLength":22,"unique":2},{"count":2,"distinct":2,"maxLength":32,"minLength":27,"unique":2},{"count":1,"distinct":1,"maxLength":33,"minLength":33,"unique":1},{"count":1,"distinct":1,"maxLength":27,"minLength":27,"unique":1}]},{"count":1,"distinct":1,"key":"redirectTo","maxLength":18,"minLength":18,"type":"string","unique":1},{"count":11,"distinct":2,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"quantiles":{"p1":200,"p25":200,"p75":200,"p99":301},"type":"numeric"},{"count":11,"distinct":11,"key":"timestamp","maxLength":35,"minLength":35,"type":"string","unique":11},{"count":10,"distinct":10,"key":"title","maxLength":88,"minLength":53,"type":"string","unique":10},{"count":11,"distinct":11,"key":"url","maxLength":78,"minLength":18,"type":"string","unique":11}]`)},
 	}
 	for i, c := range goodCases {
 		res := &StatsResponse{}
@@ -1180,6 +1181,47 @@ func TestDatasetRequestsStats(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsStatsDiff(t *testing.T) {
+	tr := newTestRunner(t)
+	defer tr.Delete()
+
+	tr.MustSaveFromBody(t, "cities", "testdata/cities_2/body.csv")
+	tr.MustSaveFromBody(t, "cities", "testdata/cities_2/body_more.csv")
+
+	m := NewDatasetMethods(tr.Instance)
+
+	if err := m.StatsDiff(&StatsDiffParams{}, &StatsDiffResponse{}); err == nil {
+		t.Error("expected stats diff without a reference to fail")
+	}
+
+	res := &StatsDiffResponse{}
+	if err := m.StatsDiff(&StatsDiffParams{LeftRef: "peer/cities"}, res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Diff.LeftRowCount != 5 || res.Diff.RightRowCount != 7 || res.Diff.RowCountDelta != 2 {
+		t.Errorf("row count mismatch. expected 5 -> 7 (+2), got: %d -> %d (%+d)", res.Diff.LeftRowCount, res.Diff.RightRowCount, res.Diff.RowCountDelta)
+	}
+
+	cols := []string{}
+	for _, col := range res.Diff.Columns {
+		cols = append(cols, col.Column)
+	}
+	if diff := cmp.Diff([]string{"city", "pop", "avg_age", "in_usa"}, cols); diff != "" {
+		t.Errorf("column mismatch (-want +got):\n%s", diff)
+	}
+
+	pop := res.Diff.Columns[1]
+	if pop.Status != stats.ColumnChanged || pop.MeanShift == nil || *pop.MeanShift <= 0 {
+		t.Errorf("expected pop column mean to increase. got status %q, shift: %v", pop.Status, pop.MeanShift)
+	}
+
+	// every city appears once, and is missing from frequency tables
+	if diff := cmp.Diff([]string{"los angeles", "mexico city"}, res.Diff.Columns[0].AddedValues); diff != "" {
+		t.Errorf("added cities mismatch (-want +got):\n%s", diff)
+	}
+}
+
 // Convert the interface value into an array, or panic if not possible
 func mustBeArray(i interface{}, err error) []interface{} {
 	if err != nil {
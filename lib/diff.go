@@ -3,12 +3,22 @@ package lib
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/deepdiff"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/rowdiff"
+	"github.com/qri-io/qri/base/rowkey"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -20,6 +30,13 @@ type Delta = deepdiff.Delta
 // away from packages that depend on lib
 type DiffStat = deepdiff.Stats
 
+// RowChange is an alias for rowdiff.Change, a row that differs between the bodies of a
+// keyed diff
+type RowChange = rowdiff.Change
+
+// RowDiffStat is an alias for rowdiff.Stats, counting the rows of a keyed diff
+type RowDiffStat = rowdiff.Stats
+
 // DiffParams defines parameters for diffing two sources. There are three valid ways to use these
 // parameters: 1) both LeftSide and RightSide set, 2) only LeftSide set with a WorkingDir, 3) only
 // LeftSide set with the UseLeftPrevVersion flag.
@@ -33,6 +50,13 @@ type DiffParams struct {
 
 	// Which component or part of a dataset to compare
 	Selector string
+	// Key columns that identify body rows. Body diffs with key columns, given here or as the
+	// primary key of the structure's schema, compare rows by key instead of diffing the
+	// body's tree of values. Setting Keys without a Selector diffs the body
+	Keys []string
+	// Limit & Offset page the changed rows of a keyed body diff. A Limit of 0 or less returns
+	// every changed row. RowStat counts all rows regardless
+	Limit, Offset int
 }
 
 // DiffResponse is the result of a call to diff
@@ -41,6 +65,10 @@ type DiffResponse struct {
 	SchemaStat *DiffStat `json:"schemaStat,omitempty"`
 	Schema     []*Delta  `json:"schema,omitempty"`
 	Diff       []*Delta  `json:"diff,omitempty"`
+	// Rows & RowStat are set by keyed body diffs. Rows holds the page of changed rows set by
+	// the Limit & Offset params
+	RowStat *RowDiffStat `json:"rowStat,omitempty"`
+	Rows    []*RowChange `json:"rows,omitempty"`
 }
 
 // DiffMode is one of the methods that diff can perform
@@ -62,12 +90,12 @@ const (
 // Diff computes the diff of two sources
 func (m *DatasetMethods) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	// absolutize any local paths before a possible trip over RPC to another local process
-	if !dsref.IsRefString(p.LeftSide) {
+	if !isRefString(p.LeftSide) {
 		if err = qfs.AbsPath(&p.LeftSide); err != nil {
 			return err
 		}
 	}
-	if !dsref.IsRefString(p.RightSide) {
+	if !isRefString(p.RightSide) {
 		if err = qfs.AbsPath(&p.RightSide); err != nil {
 			return err
 		}
@@ -78,6 +106,26 @@ func (m *DatasetMethods) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	}
 	ctx := context.TODO()
 
+	// a revision range on the left with no right side compares both ends of
+	// the range
+	if p.RightSide == "" && p.WorkingDir == "" && !p.UseLeftPrevVersion && dsref.IsRevisionRange(p.LeftSide) {
+		from, to, _, err := m.inst.ParseAndResolveRevisionRange(ctx, p.LeftSide, "local")
+		if err != nil {
+			return err
+		}
+		p.LeftSide, p.RightSide = from.String(), to.String()
+	}
+	if p.LeftSide, err = m.resolveDiffRevision(ctx, p.LeftSide); err != nil {
+		return err
+	}
+	if p.RightSide, err = m.resolveDiffRevision(ctx, p.RightSide); err != nil {
+		return err
+	}
+
+	if len(p.Keys) > 0 && p.Selector == "" {
+		p.Selector = "body"
+	}
+
 	diffMode := InvalidDiffMode
 
 	// Check parameters to make sure they fit one of the three cases that diff allows.
@@ -118,6 +166,10 @@ func (m *DatasetMethods) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	}
 
 	if diffMode == FilepathDiffMode {
+		if len(p.Keys) > 0 {
+			return keyedBodyDiff(fileBodySource(p.LeftSide, nil), fileBodySource(p.RightSide, nil), p.Keys, p.Offset, p.Limit, res)
+		}
+
 		// Compare body files.
 		leftComp := component.NewBodyComponent(p.LeftSide)
 		leftData, err := leftComp.StructuredData()
@@ -157,6 +209,15 @@ func (m *DatasetMethods) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	if err != nil {
 		return err
 	}
+	if p.Selector == "body" {
+		left, right, keys, err := m.datasetBodySources(ctx, diffMode, p, ds)
+		if err != nil {
+			return err
+		}
+		if keys = keyColumns(p.Keys, keys); len(keys) > 0 && left != nil {
+			return keyedBodyDiff(left, right, keys, p.Offset, p.Limit, res)
+		}
+	}
 	leftComp := component.ConvertDatasetToComponents(ds, m.inst.repo.Filesystem())
 
 	// Right side of diff laoded into a component
@@ -289,6 +350,135 @@ func (m *DatasetMethods) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	return err
 }
 
+// keyedBodyDiff compares two bodies row by row, matching rows by the values of key columns.
+// Only changes from offset up to limit are kept, a limit of 0 or less keeps all of them
+func keyedBodyDiff(left, right rowdiff.Source, keys []string, offset, limit int, res *DiffResponse) (err error) {
+	res.Rows = []*RowChange{}
+	res.RowStat, err = rowdiff.Diff(left, right, keys, func(c *RowChange) error {
+		if offset > 0 {
+			offset--
+			return nil
+		}
+		if limit <= 0 || len(res.Rows) < limit {
+			res.Rows = append(res.Rows, c)
+		}
+		return nil
+	})
+	return err
+}
+
+// keyColumns picks the columns a keyed body diff uses, preferring columns set explicitly,
+// then the primary key of either side
+func keyColumns(explicit, primaryKey []string) []string {
+	if len(explicit) > 0 {
+		return explicit
+	}
+	return primaryKey
+}
+
+// datasetBodySources creates sources that stream the bodies on each side of a dataset diff,
+// returning the primary key of the structures as well. left is the version the left side of
+// the diff resolved to. Sources are nil when the right side has no body
+func (m *DatasetMethods) datasetBodySources(ctx context.Context, diffMode DiffMode, p *DiffParams, left *dataset.Dataset) (leftSrc, rightSrc rowdiff.Source, keys []string, err error) {
+	store := m.inst.repo.Store()
+	var right *dataset.Dataset
+
+	switch diffMode {
+	case PrevVersionDiffMode:
+		if left.PreviousPath == "" {
+			return nil, nil, nil, fmt.Errorf("dataset has only one version, nothing to diff against")
+		}
+		right = left
+		if left, err = dsfs.LoadDataset(ctx, store, right.PreviousPath); err != nil {
+			return nil, nil, nil, err
+		}
+	case DatasetRefDiffMode:
+		ref, err := repo.ParseDatasetRef(p.RightSide)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err = repo.CanonicalizeDatasetRef(m.inst.repo, &ref); err != nil && err != repo.ErrNoHistory {
+			return nil, nil, nil, err
+		}
+		if right, err = dsfs.LoadDataset(ctx, store, ref.Path); err != nil {
+			return nil, nil, nil, err
+		}
+	case WorkingDirectoryDiffMode:
+		wd, err := fsi.ReadDir(p.WorkingDir)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if wd.BodyPath == "" {
+			// nothing to compare rows against
+			return nil, nil, nil, nil
+		}
+		var st *dataset.Structure
+		if wd.Structure != nil && wd.Structure.Schema != nil {
+			st = wd.Structure
+		}
+		keys = rowkey.Columns(left.Structure)
+		if st != nil {
+			keys = keyColumns(rowkey.Columns(st), keys)
+		}
+		return storedBodySource(ctx, store, left), fileBodySource(wd.BodyPath, st), keys, nil
+	}
+
+	keys = keyColumns(rowkey.Columns(right.Structure), rowkey.Columns(left.Structure))
+	return storedBodySource(ctx, store, left), storedBodySource(ctx, store, right), keys, nil
+}
+
+// storedBodySource streams the body of a dataset version from a store
+func storedBodySource(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) rowdiff.Source {
+	return func() (dsio.EntryReader, error) {
+		if ds.Structure == nil {
+			return nil, fmt.Errorf("dataset has no structure, can't read body rows")
+		}
+		f, err := dsfs.LoadBody(ctx, store, ds)
+		if err != nil {
+			return nil, err
+		}
+		rdr, err := dsio.NewEntryReader(ds.Structure, f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return closingReader{rdr, f}, nil
+	}
+}
+
+// fileBodySource streams a body file from the local filesystem. Without a structure, the
+// structure is detected from the file's contents
+func fileBodySource(path string, st *dataset.Structure) rowdiff.Source {
+	return func() (dsio.EntryReader, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		var rdr dsio.EntryReader
+		if st != nil {
+			rdr, err = dsio.NewEntryReader(st, f)
+		} else {
+			rdr, err = component.OpenEntryReader(f, strings.TrimPrefix(filepath.Ext(path), "."))
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return closingReader{rdr, f}, nil
+	}
+}
+
+// closingReader closes the file an entry reader reads from when the reader closes
+type closingReader struct {
+	dsio.EntryReader
+	f io.Closer
+}
+
+func (r closingReader) Close() error {
+	r.EntryReader.Close()
+	return r.f.Close()
+}
+
 func schemaDiff(ctx context.Context, left, right *component.BodyComponent) ([]*Delta, *DiffStat, error) {
 	dd := deepdiff.New()
 	if left.Format == ".csv" && right.Format == ".csv" {
@@ -335,3 +525,24 @@ func isFilePath(text string) bool {
 	}
 	return !dsref.IsRefString(text)
 }
+
+// isRefString returns whether text is a dataset reference, which may have a
+// revision suffix or be a revision range
+func isRefString(text string) bool {
+	return dsref.IsRevisionString(text) || dsref.IsRevisionRange(text)
+}
+
+// resolveDiffRevision replaces a reference with a relative revision suffix,
+// like "me/ds~2", with a reference to the version it selects. Other strings
+// are returned unchanged
+func (m *DatasetMethods) resolveDiffRevision(ctx context.Context, refStr string) (string, error) {
+	rev, err := dsref.ParseRevision(refStr)
+	if err != nil || !rev.IsRelative() {
+		return refStr, nil
+	}
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, refStr, "local")
+	if err != nil {
+		return "", err
+	}
+	return ref.String(), nil
+}
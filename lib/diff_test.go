@@ -282,6 +282,66 @@ func TestDiffLocalJsonFiles(t *testing.T) {
 	}
 }
 
+// Test that keyed diffs compare bodies row by row
+func TestDiffKeyedBody(t *testing.T) {
+	run := newTestRunner(t)
+	defer run.Delete()
+
+	run.MustSaveFromBody(t, "test_cities", "testdata/cities_2/body_more.csv")
+	run.MustSaveFromBody(t, "test_cities", "testdata/cities_2/body_even_more.csv")
+
+	output, err := run.DiffWithParams(&DiffParams{
+		LeftSide:           "me/test_cities",
+		UseLeftPrevVersion: true,
+		Keys:               []string{"city"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"rowStat":{"left":7,"right":9,"added":3,"removed":1,"modified":1},"rows":[{"type":"add","key":"[\"dallas\"]","right":["dallas",1340000,30,true]},{"type":"add","key":"[\"paris\"]","right":["paris",2100000,41.1,false]},{"type":"add","key":"[\"london\"]","right":["london",8900000,36.5,false]},{"type":"remove","key":"[\"chicago\"]","left":["chicago",300000,44.4,true]},{"type":"modify","key":"[\"mexico city\"]","left":["mexico city",70000000,28.6,false],"right":["mexico city",80000000,28.6,false],"cells":[{"column":"pop","left":70000000,"right":80000000}]}]}`
+	if diff := cmp.Diff(expect, output); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	// pages of changed rows still count every row
+	output, err = run.DiffWithParams(&DiffParams{
+		LeftSide:           "me/test_cities",
+		UseLeftPrevVersion: true,
+		Keys:               []string{"city"},
+		Offset:             2,
+		Limit:              2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `{"rowStat":{"left":7,"right":9,"added":3,"removed":1,"modified":1},"rows":[{"type":"add","key":"[\"london\"]","right":["london",8900000,36.5,false]},{"type":"remove","key":"[\"chicago\"]","left":["chicago",300000,44.4,true]}]}`
+	if diff := cmp.Diff(expect, output); diff != "" {
+		t.Errorf("paged output mismatch (-want +got):\n%s", diff)
+	}
+
+	// local files are keyed the same way
+	output, err = run.DiffWithParams(&DiffParams{
+		LeftSide:  "testdata/cities_2/body.csv",
+		RightSide: "testdata/cities_2/body_more.csv",
+		Keys:      []string{"city"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `{"rowStat":{"left":5,"right":7,"added":2,"removed":0,"modified":0},"rows":[{"type":"add","key":"[\"los angeles\"]","right":["los angeles",3990000,42.7,true]},{"type":"add","key":"[\"mexico city\"]","right":["mexico city",70000000,28.6,false]}]}`
+	if diff := cmp.Diff(expect, output); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err = run.DiffWithParams(&DiffParams{
+		LeftSide:           "me/test_cities",
+		UseLeftPrevVersion: true,
+		Keys:               []string{"country"},
+	}); err == nil {
+		t.Error("expected diffing by a missing key column to fail")
+	}
+}
+
 func TestDiffErrors(t *testing.T) {
 	run := newTestRunner(t)
 	defer run.Delete()
@@ -13,6 +13,7 @@ import (
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -285,7 +286,8 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 	if p.Ref == "" {
 		return repo.ErrEmptyRef
 	}
-	ref, err := repo.ParseDatasetRef(p.Ref)
+	refStr, branch := dsref.SplitBranch(p.Ref)
+	ref, err := repo.ParseDatasetRef(refStr)
 	if err != nil {
 		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
 	}
@@ -293,6 +295,14 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 	if err != nil && err != repo.ErrNoHistory {
 		return
 	}
+	if branch != "" {
+		// restore from the latest version of the branch
+		head, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+		if err != nil {
+			return err
+		}
+		ref.Path = head.Path
+	}
 
 	// Directory to write components to can be determined from FSIPath of ref.
 	if p.Dir == "" && ref.FSIPath != "" {
@@ -342,6 +352,68 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 	return nil
 }
 
+// SwitchBranchParams provides parameters to the SwitchBranch method
+type SwitchBranchParams struct {
+	// Dir is the linked working directory to switch
+	Dir string
+	// Branch to switch to. An empty branch refers to the default branch
+	Branch string
+}
+
+// SwitchBranch replaces the component files in a linked working directory with
+// the latest version of a branch, and records the branch in the link. Saves
+// from the working directory then go to that branch. The working directory
+// must not have unsaved changes
+func (m *FSIMethods) SwitchBranch(p *SwitchBranchParams, res *string) (err error) {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("FSIMethods.SwitchBranch", p, res))
+	}
+	ctx := context.TODO()
+
+	ref, ok := fsi.GetLinkedFilesysRef(p.Dir)
+	if !ok {
+		return fsi.ErrNoLink
+	}
+	if err = m.inst.fsi.IsWorkingDirectoryClean(ctx, p.Dir); err != nil {
+		if err == fsi.ErrWorkingDirectoryDirty {
+			return fmt.Errorf("working directory has unsaved changes. save or restore them before switching branches")
+		}
+		return err
+	}
+
+	branch := p.Branch
+	if branch == logbook.DefaultBranchName {
+		branch = ""
+	}
+	refStr := ref.Human()
+	if branch != "" {
+		refStr += ":" + branch
+	}
+	head, _, err := m.inst.ParseAndResolveRef(ctx, refStr, "local")
+	if err != nil {
+		return err
+	}
+	if head.Path == "" {
+		return fmt.Errorf("cannot switch to %s: %w", refStr, repo.ErrNoHistory)
+	}
+	ds, err := m.inst.LoadDataset(ctx, head, "")
+	if err != nil {
+		return err
+	}
+
+	if err = fsi.DeleteComponentFiles(p.Dir); err != nil {
+		return err
+	}
+	if err = fsi.WriteComponents(ds, p.Dir, m.inst.repo.Filesystem()); err != nil {
+		return err
+	}
+	if _, err = m.inst.fsi.ModifyLinkBranch(p.Dir, ref, branch); err != nil {
+		return err
+	}
+	*res = refStr
+	return nil
+}
+
 // InitFSIDatasetParams proxies parameters to initialization
 type InitFSIDatasetParams = fsi.InitParams
 
@@ -384,7 +456,8 @@ func (m *FSIMethods) EnsureRef(p *EnsureParams, out *dsref.VersionInfo) error {
 		return checkRPCError(m.inst.rpc.Call("FSIMethods.EnsureRef", p, out))
 	}
 
-	ref, err := dsref.Parse(p.Ref)
+	refStr, _ := dsref.SplitBranch(p.Ref)
+	ref, err := dsref.Parse(refStr)
 	if err != nil {
 		return err
 	}
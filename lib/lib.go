@@ -38,6 +38,8 @@ import (
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/buildrepo"
 	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/search"
+	"github.com/qri-io/qri/sql"
 	"github.com/qri-io/qri/stats"
 	"github.com/qri-io/qri/watchfs"
 )
@@ -71,6 +73,7 @@ func Receivers(inst *Instance) []Methods {
 		NewConfigMethods(inst),
 		NewSearchMethods(inst),
 		NewSQLMethods(inst),
+		NewTransformMethods(inst),
 		NewRenderMethods(inst),
 		NewFSIMethods(inst),
 
@@ -419,19 +422,40 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		inst.stats = newStats(inst.repoPath, cfg)
 	}
 
+	if inst.sqlCache == nil {
+		inst.sqlCache = newSQLCache(cfg)
+	}
+
 	if inst.repo != nil {
 		// Try to make the repo a hidden directory, but it's okay if we can't. Ignore the error.
 		_ = hiddenfile.SetFileHidden(inst.repoPath)
 		inst.fsi = fsi.NewFSI(inst.repo, inst.bus)
 	}
 
+	// logbook & fsi notify a single hook, broadcast changes to everything that
+	// follows them
+	inst.changes = hook.NewChangeBroadcaster(inst.logbook, inst.fsi)
 	if inst.dscache == nil {
-		inst.dscache, err = newDscache(ctx, inst.qfs, []hook.ChangeNotifier{inst.logbook, inst.fsi}, pro.Peername, inst.repoPath)
+		inst.dscache, err = newDscache(ctx, inst.qfs, []hook.ChangeNotifier{inst.changes}, pro.Peername, inst.repoPath)
 		if err != nil {
 			return nil, fmt.Errorf("newDsache: %w", err)
 		}
 	}
 
+	if inst.searchIndex == nil && inst.repo != nil {
+		inst.searchIndex = newSearchIndex(inst.repo.Store(), []hook.ChangeNotifier{inst.changes}, inst.repoPath)
+
+		// write index changes that haven't been saved yet on shutdown
+		inst.releasers.Add(1)
+		go func() {
+			<-ctx.Done()
+			if err := inst.searchIndex.Flush(); err != nil {
+				log.Errorf("saving search index: %s", err)
+			}
+			inst.releasers.Done()
+		}()
+	}
+
 	if inst.node == nil {
 		if inst.node, err = p2p.NewQriNode(inst.repo, cfg.P2P); err != nil {
 			log.Error("intializing p2p:", err.Error())
@@ -513,6 +537,13 @@ func newEventBus(ctx context.Context) event.Bus {
 	return event.NewBus(ctx)
 }
 
+func newSearchIndex(store cafs.Filestore, hooks []hook.ChangeNotifier, repoPath string) *search.Index {
+	if repoPath == "" {
+		return search.NewIndex(store, hooks, "")
+	}
+	return search.NewIndex(store, hooks, filepath.Join(repoPath, "searchindex.json"))
+}
+
 func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	// The stats cache default location is repoPath/stats
 	// can be overridden in the config: cfg.Stats.Path
@@ -523,11 +554,28 @@ func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	if cfg.Stats.Cache.Path != "" {
 		path = cfg.Stats.Cache.Path
 	}
+	opts := func(o *stats.Options) {
+		o.BoundedMemory = cfg.Stats.BoundedMemory
+		o.MaxFrequencies = cfg.Stats.MaxFrequencies
+		o.SpillDir = cfg.Stats.SpillPath
+	}
 	switch cfg.Stats.Cache.Type {
 	case "fs":
-		return stats.New(stats.NewOSCache(path, cfg.Stats.Cache.MaxSize))
+		return stats.New(stats.NewOSCache(path, cfg.Stats.Cache.MaxSize), opts)
 	default:
-		return stats.New(nil)
+		return stats.New(nil, opts)
+	}
+}
+
+func newSQLCache(cfg *config.Config) *sql.ResultCache {
+	if cfg.SQL == nil {
+		return nil
+	}
+	switch cfg.SQL.ResultCache.Type {
+	case "mem":
+		return sql.NewResultCache(cfg.SQL.ResultCache.MaxSize, cfg.SQL.ResultCache.MaxEntrySize)
+	default:
+		return nil
 	}
 }
 
@@ -547,7 +595,8 @@ func NewInstanceFromConfigAndNode(ctx context.Context, cfg *config.Config, node
 	}
 	bus := event.NewBus(ctx)
 	fsint := fsi.NewFSI(r, bus)
-	dc := dscache.NewDscache(ctx, r.Filesystem(), []hook.ChangeNotifier{r.Logbook(), fsint}, pro.Peername, "")
+	changes := hook.NewChangeBroadcaster(r.Logbook(), fsint)
+	dc := dscache.NewDscache(ctx, r.Filesystem(), []hook.ChangeNotifier{changes}, pro.Peername, "")
 
 	// TODO (b5) - lots of tests pass "DefaultConfigForTesting", which uses a different peername /
 	// identity from what the repo already has. This disagreement is a potential source of bugs
@@ -560,10 +609,14 @@ func NewInstanceFromConfigAndNode(ctx context.Context, cfg *config.Config, node
 		cancel: cancel,
 		doneCh: make(chan struct{}),
 
-		cfg:     cfg,
-		node:    node,
-		dscache: dc,
-		stats:   stats.New(nil),
+		cfg:      cfg,
+		node:     node,
+		dscache:  dc,
+		stats:    stats.New(nil),
+		sqlCache: newSQLCache(cfg),
+
+		searchIndex: search.NewIndex(r.Store(), []hook.ChangeNotifier{changes}, ""),
+		changes:     changes,
 	}
 
 	inst.remoteClient, err = remote.NewClient(node)
@@ -601,8 +654,11 @@ type Instance struct {
 	remoteClient remote.Client
 	registry     *regclient.Client
 	stats        *stats.Stats
+	sqlCache     *sql.ResultCache
+	searchIndex  *search.Index
 	logbook      *logbook.Book
 	dscache      *dscache.Dscache
+	changes      *hook.ChangeBroadcaster
 	bus          event.Bus
 	Watcher      *watchfs.FilesysWatcher
 
@@ -706,6 +762,18 @@ func (inst *Instance) Repo() repo.Repo {
 	return nil
 }
 
+// localStreams returns the streams of the instance node, falling back to the
+// instance streams when there's no node
+func (inst *Instance) localStreams() ioes.IOStreams {
+	if inst.node != nil {
+		return inst.node.LocalStreams
+	}
+	if inst.streams.Out == nil {
+		return ioes.NewDiscardIOStreams()
+	}
+	return inst.streams
+}
+
 // RepoPath returns the path to the directory qri is operating from
 func (inst *Instance) RepoPath() string {
 	if inst == nil {
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"context"
+	"sort"
+
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+)
+
+// LineageParams are parameters for walking the inputs of a dataset version
+type LineageParams struct {
+	// dataset reference to trace
+	Ref string
+	// maximum number of transform steps to walk, 0 is unlimited
+	Depth int
+}
+
+// LineageNode is a dataset version or URL in the graph of transform inputs
+type LineageNode struct {
+	// dataset reference with a version path, or the URL of an HTTP resource
+	Ref string `json:"ref"`
+	// resources the transform that produced this version read
+	Inputs []*LineageNode `json:"inputs,omitempty"`
+	// set when this version can't be loaded, for example because it isn't
+	// stored locally
+	Error string `json:"error,omitempty"`
+}
+
+// Lineage lists the upstream dataset versions & URLs a dataset version was
+// produced from, following the transform resources of each upstream version
+func (m *DatasetMethods) Lineage(p *LineageParams, res *LineageNode) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("DatasetMethods.Lineage", p, res))
+	}
+	ctx := context.TODO()
+
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+	if err != nil {
+		return err
+	}
+
+	*res = *m.lineage(ctx, ref, p.Depth, map[string]bool{})
+	return nil
+}
+
+// lineage builds the lineage graph of a resolved dataset reference. visiting
+// holds the versions currently being walked, guarding against cycles
+func (m *DatasetMethods) lineage(ctx context.Context, ref dsref.Ref, depth int, visiting map[string]bool) *LineageNode {
+	node := &LineageNode{Ref: versionRef(ref)}
+	if visiting[ref.Path] {
+		return node
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, m.inst.repo.Store(), ref.Path)
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	if ds.Transform == nil || len(ds.Transform.Resources) == 0 {
+		return node
+	}
+
+	visiting[ref.Path] = true
+	defer delete(visiting, ref.Path)
+	for _, r := range ds.Transform.Resources {
+		if r == nil {
+			continue
+		}
+		if qfs.PathKind(r.Path) == "http" {
+			node.Inputs = append(node.Inputs, &LineageNode{Ref: r.Path})
+			continue
+		}
+		upstream, err := dsref.Parse(r.Path)
+		if err != nil || upstream.Path == "" {
+			node.Inputs = append(node.Inputs, &LineageNode{Ref: r.Path, Error: "not a versioned dataset reference"})
+			continue
+		}
+		if depth == 1 {
+			node.Inputs = append(node.Inputs, &LineageNode{Ref: versionRef(upstream)})
+			continue
+		}
+		node.Inputs = append(node.Inputs, m.lineage(ctx, upstream, depth-1, visiting))
+	}
+	sort.Slice(node.Inputs, func(i, j int) bool {
+		return node.Inputs[i].Ref < node.Inputs[j].Ref
+	})
+	return node
+}
+
+// versionRef formats a reference as a human-readable alias & version path
+func versionRef(ref dsref.Ref) string {
+	return dsref.Ref{Username: ref.Username, Name: ref.Name, Path: ref.Path}.String()
+}
@@ -86,26 +86,69 @@ func NewParseResolveLoadFunc(username string, resolver dsref.Resolver, loader ds
 		if err != nil {
 			return nil, err
 		}
-
-		if username == "" && ref.Username == "me" {
-			msg := fmt.Sprintf(`Can't use the "me" keyword to refer to a dataset in this context.
-Replace "me" with your username for the reference:
-%s`, refStr)
-			return nil, qerr.New(fmt.Errorf("invalid contextual reference"), msg)
-		} else if username != "" && ref.Username == "me" {
-			ref.Username = username
+		if err = replaceMeKeyword(username, refStr, &ref); err != nil {
+			return nil, err
 		}
 
 		source, err := resolver.ResolveRef(ctx, &ref)
 		if err != nil {
 			return nil, err
 		}
+		if err = requireHistory(ref); err != nil {
+			return nil, err
+		}
+		return loader.LoadDataset(ctx, ref, source)
+	}
+}
 
-		if ref.Path == "" {
-			err = qerr.New(dsref.ErrNoHistory, fmt.Sprintf("can't load dataset %q, it has no saved versions", ref.Human()))
+// newRevisionLoadFunc is NewParseResolveLoadFunc for reference strings that
+// may select an earlier version with a revision like "me/ds~1", "me/ds:branch"
+// or "me/ds@{2020-01-01}". Revisions are selected from the logbook
+func (inst *Instance) newRevisionLoadFunc(username string, resolver dsref.Resolver) dsref.ParseResolveLoad {
+	return func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+		rev, err := dsref.ParseRevision(refStr)
+		if err != nil {
+			return nil, err
+		}
+		if err = replaceMeKeyword(username, refStr, &rev.Ref); err != nil {
 			return nil, err
 		}
 
-		return loader.LoadDataset(ctx, ref, source)
+		ref := rev.Ref
+		source, err := resolver.ResolveRef(ctx, &ref)
+		if err != nil {
+			return nil, err
+		}
+		if ref, err = inst.resolveRevision(ctx, rev, ref); err != nil {
+			return nil, err
+		}
+		if err = requireHistory(ref); err != nil {
+			return nil, err
+		}
+		return inst.LoadDataset(ctx, ref, source)
+	}
+}
+
+// replaceMeKeyword swaps the "me" keyword in ref for username, erroring if
+// username is empty
+func replaceMeKeyword(username, refStr string, ref *dsref.Ref) error {
+	if ref.Username != "me" {
+		return nil
+	}
+	if username == "" {
+		msg := fmt.Sprintf(`Can't use the "me" keyword to refer to a dataset in this context.
+Replace "me" with your username for the reference:
+%s`, refStr)
+		return qerr.New(fmt.Errorf("invalid contextual reference"), msg)
+	}
+	ref.Username = username
+	return nil
+}
+
+// requireHistory errors if a resolved reference has no saved versions
+func requireHistory(ref dsref.Ref) error {
+	if ref.Path == "" {
+		return qerr.New(dsref.ErrNoHistory, fmt.Sprintf("can't load dataset %q, it has no saved versions", ref.Human()))
 	}
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
@@ -65,6 +66,25 @@ func (m *LogMethods) Log(params *LogParams, res *[]DatasetLogItem) error {
 		}
 	}
 
+	if dsref.IsRevisionRange(params.Ref) {
+		if params.Pull {
+			return fmt.Errorf("cannot pull history for a revision range")
+		}
+		items, err := m.inst.revisionRangeItems(ctx, params.Ref, params.Source)
+		if err != nil {
+			return err
+		}
+		if params.Offset > len(items) {
+			params.Offset = len(items)
+		}
+		items = items[params.Offset:]
+		if params.Limit < len(items) {
+			items = items[:params.Limit]
+		}
+		*res = items
+		return nil
+	}
+
 	ref, source, err := m.inst.ParseAndResolveRef(ctx, params.Ref, params.Source)
 	if err != nil {
 		return err
@@ -72,6 +92,10 @@ func (m *LogMethods) Log(params *LogParams, res *[]DatasetLogItem) error {
 
 	if source == "" {
 		// local resolution
+		if rev, _ := dsref.ParseRevision(params.Ref); rev.Branch != "" {
+			*res, err = base.DatasetBranchLog(ctx, m.inst.repo, ref, rev.Branch, params.Limit, params.Offset)
+			return err
+		}
 		*res, err = base.DatasetLog(ctx, m.inst.repo, ref, params.Limit, params.Offset, true)
 		return err
 	}
@@ -172,3 +196,151 @@ func (m *LogMethods) PlainLogs(p *PlainLogsParams, res *PlainLogs) error {
 	*res, err = m.inst.repo.Logbook().PlainLogs(ctx)
 	return err
 }
+
+// Tag is an alias for a human-readable label of a dataset version
+type Tag = logbook.Tag
+
+// TagParams defines parameters for the Tag method
+type TagParams struct {
+	// Reference to the version to tag, defaults to the latest version. When
+	// deleting, only the dataset name is used
+	Ref string
+	// Name of the tag
+	Tag string
+	// Delete removes the tag instead of adding it
+	Delete bool
+}
+
+// Tag adds or removes a human-readable label for a dataset version. Tagged
+// versions can be referred to by tag name, like "me/dataset@v2020-q3"
+func (m *LogMethods) Tag(p *TagParams, res *dsref.Ref) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("LogMethods.Tag", p, res))
+	}
+	ctx := context.TODO()
+
+	if p.Tag == "" {
+		return fmt.Errorf("tag name is required")
+	}
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+	if err != nil {
+		return err
+	}
+
+	book := m.inst.repo.Logbook()
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return err
+	}
+
+	if p.Delete {
+		err = book.WriteTagDelete(ctx, initID, p.Tag)
+	} else if ref.Path == "" {
+		err = fmt.Errorf("cannot tag %s: %w", ref.Human(), repo.ErrNoHistory)
+	} else {
+		err = book.WriteTag(ctx, initID, p.Tag, ref.Path)
+	}
+	if err != nil {
+		return err
+	}
+	*res = ref
+	return nil
+}
+
+// TagsParams defines parameters for the Tags method
+type TagsParams struct {
+	// Reference to the dataset to list tags for
+	Ref string
+}
+
+// Tags lists the tags of a dataset in the order they were added
+func (m *LogMethods) Tags(p *TagsParams, res *[]Tag) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("LogMethods.Tags", p, res))
+	}
+	ctx := context.TODO()
+
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+	if err != nil {
+		return err
+	}
+	*res, err = m.inst.repo.Logbook().Tags(ctx, ref)
+	return err
+}
+
+// Branch is an alias for a named line of dataset history
+type Branch = logbook.Branch
+
+// BranchParams defines parameters for the Branch method
+type BranchParams struct {
+	// Reference to the version the branch starts from, like "me/dataset~1" or
+	// "me/dataset:other". Defaults to the latest version. When deleting, only
+	// the dataset name is used
+	Ref string
+	// Name of the branch
+	Branch string
+	// Delete removes the branch instead of creating it
+	Delete bool
+}
+
+// Branch creates or removes a named branch of dataset history. New branches
+// start with the history up to and including the referenced version, and
+// versions saved to a branch don't change the dataset's latest version
+func (m *LogMethods) Branch(p *BranchParams, res *dsref.Ref) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("LogMethods.Branch", p, res))
+	}
+	ctx := context.TODO()
+
+	if p.Branch == "" {
+		return fmt.Errorf("branch name is required")
+	}
+	rev, err := dsref.ParseRevision(p.Ref)
+	if err != nil && err != dsref.ErrBadCaseName {
+		return fmt.Errorf("%q is not a valid dataset reference: %w", p.Ref, err)
+	}
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+	if err != nil {
+		return err
+	}
+
+	book := m.inst.repo.Logbook()
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return err
+	}
+
+	if p.Delete {
+		err = book.WriteBranchDelete(ctx, initID, p.Branch)
+	} else if ref.Path == "" {
+		err = fmt.Errorf("cannot branch %s: %w", ref.Human(), repo.ErrNoHistory)
+	} else {
+		err = book.WriteBranchInit(ctx, initID, p.Branch, rev.Branch, ref.Path)
+	}
+	if err != nil {
+		return err
+	}
+	*res = ref
+	return nil
+}
+
+// BranchesParams defines parameters for the Branches method
+type BranchesParams struct {
+	// Reference to the dataset to list branches for
+	Ref string
+}
+
+// Branches lists the branches of a dataset, starting with the default branch
+func (m *LogMethods) Branches(p *BranchesParams, res *[]Branch) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("LogMethods.Branches", p, res))
+	}
+	ctx := context.TODO()
+
+	ref, _, err := m.inst.ParseAndResolveRef(ctx, p.Ref, "local")
+	if err != nil {
+		return err
+	}
+	*res, err = m.inst.repo.Logbook().Branches(ctx, ref)
+	return err
+}
@@ -0,0 +1,317 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/merge"
+	"github.com/qri-io/qri/dsref"
+	qrierr "github.com/qri-io/qri/errors"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// MergeBodySizeLimit is the largest body, in bytes, a merge will read. Merges
+// hold the bodies of both sides and their common ancestor in memory, so
+// larger datasets are refused rather than risk running out of memory
+var MergeBodySizeLimit = 20000000
+
+// MergeParams are parameters for merging one version of a dataset into another
+type MergeParams struct {
+	// reference to merge into, which is the latest version of a dataset or
+	// of one of its branches, like "me/dataset:cleanup"
+	Ours string
+	// reference to the version to merge, which may be another branch, a
+	// revision, or a version pulled from a peer
+	Theirs string
+	// key columns that identify body rows, defaulting to the primary key of
+	// the structure's schema. Without key columns rows are matched by position
+	Keys []string
+	// resolve conflicts with one side of the merge, either "ours" or "theirs"
+	Prefer string
+	// save the resolved working directory of a merge that stopped on conflicts
+	Continue bool
+	// abandon a merge that stopped on conflicts, restoring the working
+	// directory
+	Abort bool
+}
+
+// MergeResult describes the outcome of a merge
+type MergeResult struct {
+	// reference to the saved merge, empty when the merge wasn't saved
+	Ref string `json:"ref,omitempty"`
+	// path of the most recent version both sides share
+	Ancestor string `json:"ancestor,omitempty"`
+	// conflicts that stopped the merge
+	Conflicts []merge.Conflict `json:"conflicts,omitempty"`
+	// working directory the merge was written to
+	Dir string `json:"dir,omitempty"`
+	// true if ours already contains all of theirs
+	UpToDate bool `json:"upToDate,omitempty"`
+}
+
+// Merge reconciles two versions of a dataset with a three-way merge against
+// their common ancestor, saving the result as a new version of ours.
+// Merges into a dataset linked to a working directory write the merged
+// components to the directory. When a merge has conflicts, the directory
+// holds our side of each conflict and status lists the conflicting
+// components, which are saved with Continue once resolved. Merges without a
+// working directory save nothing when they have conflicts, which are listed
+// in the result and returned as a *merge.ConflictError
+func (m *DatasetMethods) Merge(p *MergeParams, res *MergeResult) error {
+	if m.inst.rpc != nil {
+		return checkRPCError(m.inst.rpc.Call("DatasetMethods.Merge", p, res))
+	}
+	ctx := context.TODO()
+
+	if p.Prefer != merge.PreferNone && p.Prefer != merge.PreferOurs && p.Prefer != merge.PreferTheirs {
+		return fmt.Errorf("invalid prefer %q. must be one of %q or %q", p.Prefer, merge.PreferOurs, merge.PreferTheirs)
+	}
+	if p.Continue && p.Abort {
+		return fmt.Errorf("cannot continue and abort a merge at the same time")
+	}
+
+	wd, _, err := m.inst.ParseAndResolveRefWithWorkingDir(ctx, p.Ours, "local")
+	if err != nil {
+		return err
+	}
+	dir := ""
+	if strings.HasPrefix(wd.Path, "/fsi") {
+		dir = strings.TrimPrefix(wd.Path, "/fsi")
+	}
+	if p.Continue || p.Abort {
+		return m.finishMerge(ctx, p, dir, res)
+	}
+
+	ours, _, err := m.inst.ParseAndResolveRef(ctx, p.Ours, "local")
+	if err != nil {
+		return err
+	}
+	if ours.Path == "" {
+		return fmt.Errorf("cannot merge into %s: %w", p.Ours, repo.ErrNoHistory)
+	}
+	theirs, _, err := m.inst.ParseAndResolveRef(ctx, p.Theirs, "local")
+	if err != nil {
+		return err
+	}
+	if theirs.Path == "" {
+		return fmt.Errorf("cannot merge %s: %w", p.Theirs, repo.ErrNoHistory)
+	}
+
+	if dir != "" {
+		if state, err := fsi.ReadMergeState(dir); err != nil {
+			return err
+		} else if state != nil {
+			return qrierr.New(fmt.Errorf("a merge is already in progress"), "run `qri merge --continue` to save it, or `qri merge --abort` to abandon it")
+		}
+		if err = m.inst.fsi.IsWorkingDirectoryClean(ctx, dir); err != nil {
+			if err == fsi.ErrWorkingDirectoryDirty {
+				return fmt.Errorf("working directory has unsaved changes. save or restore them before merging")
+			}
+			return err
+		}
+	}
+
+	store := m.inst.repo.Store()
+	merges, err := m.mergeParents(ctx, ours, theirs)
+	if err != nil {
+		return err
+	}
+	if res.Ancestor, err = merge.Ancestor(ctx, store, ours.Path, theirs.Path, merges); err != nil {
+		return err
+	}
+	if ours.Path == theirs.Path || res.Ancestor == theirs.Path {
+		res.UpToDate = true
+		res.Ref = ours.String()
+		return nil
+	}
+
+	ancestor := &dataset.Dataset{}
+	if res.Ancestor != "" {
+		if ancestor, err = loadMergeVersion(ctx, store, res.Ancestor); err != nil {
+			return err
+		}
+	}
+	oursDs, err := loadMergeVersion(ctx, store, ours.Path)
+	if err != nil {
+		return err
+	}
+	theirsDs, err := loadMergeVersion(ctx, store, theirs.Path)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts, err := merge.Datasets(ancestor, oursDs, theirsDs, p.Keys, p.Prefer)
+	if err != nil {
+		return err
+	}
+	// merges start a new version of ours. transforms aren't carried over,
+	// saving one would run it again
+	merged.Path = ""
+	merged.PreviousPath = ""
+	merged.Commit = nil
+	merged.Transform = nil
+	merged.BodyPath = ""
+
+	if dir != "" {
+		if err = fsi.DeleteComponentFiles(dir); err != nil {
+			return err
+		}
+		if err = fsi.WriteComponents(merged, dir, m.inst.repo.Filesystem()); err != nil {
+			return err
+		}
+		res.Dir = dir
+		if len(conflicts) > 0 {
+			res.Conflicts = conflicts
+			return fsi.WriteMergeState(dir, &fsi.MergeState{
+				Theirs:     p.Theirs,
+				TheirsPath: theirs.Path,
+				Ancestor:   res.Ancestor,
+				Conflicts:  conflicts,
+			})
+		}
+		return m.saveMerge(ctx, p.Ours, p.Theirs, theirs.Path, nil, res)
+	}
+
+	if len(conflicts) > 0 {
+		res.Conflicts = conflicts
+		return &merge.ConflictError{Conflicts: conflicts}
+	}
+	data, err := component.SerializeBody(merged.Body, merged.Structure)
+	if err != nil {
+		return err
+	}
+	merged.Body = nil
+	merged.SetBodyFile(qfs.NewMemfileBytes("body."+merged.Structure.Format, data))
+	return m.saveMerge(ctx, p.Ours, p.Theirs, theirs.Path, merged, res)
+}
+
+// finishMerge continues or aborts a merge that stopped on conflicts
+func (m *DatasetMethods) finishMerge(ctx context.Context, p *MergeParams, dir string, res *MergeResult) error {
+	if dir == "" {
+		return fmt.Errorf("no merge in progress: %s is not linked to a working directory", p.Ours)
+	}
+	state, err := fsi.ReadMergeState(dir)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no merge in progress in %s", dir)
+	}
+	res.Dir = dir
+	res.Ancestor = state.Ancestor
+
+	if p.Abort {
+		ours, _, err := m.inst.ParseAndResolveRef(ctx, p.Ours, "local")
+		if err != nil {
+			return err
+		}
+		ds, err := m.inst.LoadDataset(ctx, ours, "")
+		if err != nil {
+			return err
+		}
+		if err = fsi.DeleteComponentFiles(dir); err != nil {
+			return err
+		}
+		if err = fsi.WriteComponents(ds, dir, m.inst.repo.Filesystem()); err != nil {
+			return err
+		}
+		res.Ref = ours.String()
+		return fsi.RemoveMergeState(dir)
+	}
+
+	// saving from a working directory is blocked while a merge is in progress
+	if err = fsi.RemoveMergeState(dir); err != nil {
+		return err
+	}
+	if err = m.saveMerge(ctx, p.Ours, state.Theirs, state.TheirsPath, nil, res); err != nil {
+		if writeErr := fsi.WriteMergeState(dir, state); writeErr != nil {
+			log.Error(writeErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// saveMerge saves a merge of theirs as the next version of ours, recording
+// the merged version in the logbook. A nil dataset saves the components of
+// ours' working directory
+func (m *DatasetMethods) saveMerge(ctx context.Context, ours, theirs, theirsPath string, ds *dataset.Dataset, res *MergeResult) error {
+	params := &SaveParams{
+		Ref:     ours,
+		Dataset: ds,
+		Title:   fmt.Sprintf("merge %s into %s", theirs, ours),
+		Replace: ds != nil,
+	}
+	saved := &reporef.DatasetRef{}
+	if err := m.Save(params, saved); err != nil {
+		return err
+	}
+	ref := reporef.ConvertToDsref(*saved)
+	res.Ref = ref.String()
+
+	book := m.inst.repo.Logbook()
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return err
+	}
+	_, branch := dsref.SplitBranch(ours)
+	return book.WriteMerge(ctx, initID, branch, ref.Path, theirsPath)
+}
+
+// mergeParents collects the versions merges saved in the histories of ours
+// & theirs, which may be different datasets
+func (m *DatasetMethods) mergeParents(ctx context.Context, ours, theirs dsref.Ref) (map[string][]string, error) {
+	book := m.inst.repo.Logbook()
+	merges, err := book.MergeParents(ctx, ours)
+	if err != nil {
+		return nil, err
+	}
+	if theirs.Human() != ours.Human() {
+		theirMerges, err := book.MergeParents(ctx, theirs)
+		if err != nil {
+			log.Debugf("reading merges of %s: %s", theirs, err)
+		}
+		for path, parents := range theirMerges {
+			merges[path] = append(merges[path], parents...)
+		}
+	}
+	return merges, nil
+}
+
+// loadMergeVersion loads a dataset version with its body read into the Body
+// field. Bodies larger than MergeBodySizeLimit are refused
+func loadMergeVersion(ctx context.Context, store cafs.Filestore, path string) (*dataset.Dataset, error) {
+	ds, err := dsfs.LoadDataset(ctx, store, path)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("version %s has no structure", path)
+	}
+	if ds.Structure.Length > MergeBodySizeLimit {
+		return nil, fmt.Errorf("cannot merge version %s: body is %d bytes, merges are limited to bodies of %d bytes or less", path, ds.Structure.Length, MergeBodySizeLimit)
+	}
+	f, err := dsfs.LoadBody(ctx, store, ds)
+	if err != nil {
+		return nil, fmt.Errorf("loading body of %s: %w", path, err)
+	}
+	defer f.Close()
+	rdr, err := dsio.NewEntryReader(ds.Structure, f)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Body, err = base.ReadEntries(rdr); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
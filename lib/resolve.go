@@ -3,48 +3,153 @@ package lib
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/remote"
 )
 
-// ParseAndResolveRef combines reference parsing and resolution
+// ParseAndResolveRef combines reference parsing and resolution. refStr may
+// have a revision suffix like "~2" or "@{2020-01-01}", which selects an
+// earlier version from the dataset's logbook
 func (inst *Instance) ParseAndResolveRef(ctx context.Context, refStr, source string) (dsref.Ref, string, error) {
-	ref, err := dsref.Parse(refStr)
+	rev, err := dsref.ParseRevision(refStr)
 	if err != nil {
-		return ref, "", fmt.Errorf("%q is not a valid dataset reference: %w", refStr, err)
+		return rev.Ref, "", fmt.Errorf("%q is not a valid dataset reference: %w", refStr, err)
 	}
 
+	ref := rev.Ref
 	resolvedSource, err := inst.ResolveReference(ctx, &ref, source)
 	if err != nil {
 		return ref, resolvedSource, err
 	}
+	if rev.IsRelative() {
+		ref, err = inst.resolveRevision(ctx, rev, ref)
+	}
 	return ref, resolvedSource, err
 }
 
 // ParseAndResolveRefWithWorkingDir combines reference parsing and resolution,
 // including setting default Path to a linked working directory if one exists
 func (inst *Instance) ParseAndResolveRefWithWorkingDir(ctx context.Context, refStr, source string) (dsref.Ref, string, error) {
-	ref, err := dsref.Parse(refStr)
+	rev, err := dsref.ParseRevision(refStr)
 	if err != nil && err != dsref.ErrBadCaseName {
-		return ref, "", fmt.Errorf("%q is not a valid dataset reference: %w", refStr, err)
+		return rev.Ref, "", fmt.Errorf("%q is not a valid dataset reference: %w", refStr, err)
 	}
 
+	ref := rev.Ref
 	explicitPath := ref.Path != ""
 	resolvedSource, err := inst.ResolveReference(ctx, &ref, source)
 	if err != nil {
 		return ref, resolvedSource, err
 	}
-	if !explicitPath {
-		err = inst.fsi.ResolvedPath(&ref)
-		if err == fsi.ErrNoLink {
-			err = nil
-		}
+	if rev.Branch == logbook.DefaultBranchName {
+		rev.Branch = ""
+	}
+	if explicitPath || rev.Back > 0 || !rev.AsOf.IsZero() || rev.Tag != "" {
+		ref, err = inst.resolveRevision(ctx, rev, ref)
+		return ref, resolvedSource, err
+	}
+
+	// use the working directory when it's linked to the selected branch
+	wd := ref
+	err = inst.fsi.ResolvedPath(&wd)
+	if err == nil && fsi.GetLinkedFilesysBranch(strings.TrimPrefix(wd.Path, "/fsi")) == rev.Branch {
+		return wd, resolvedSource, nil
+	} else if err != nil && err != fsi.ErrNoLink {
+		return ref, resolvedSource, err
 	}
+	ref, err = inst.resolveRevision(ctx, rev, ref)
 	return ref, resolvedSource, err
 }
 
+// ParseAndResolveRevisionRange parses & resolves both sides of a revision
+// range like "me/ds~5..me/ds"
+func (inst *Instance) ParseAndResolveRevisionRange(ctx context.Context, rangeStr, source string) (from, to dsref.Ref, resolvedSource string, err error) {
+	rng, err := dsref.ParseRevisionRange(rangeStr)
+	if err != nil && err != dsref.ErrBadCaseName {
+		return from, to, "", fmt.Errorf("%q is not a valid revision range: %w", rangeStr, err)
+	}
+
+	from = rng.From.Ref
+	if resolvedSource, err = inst.ResolveReference(ctx, &from, source); err != nil {
+		return from, to, resolvedSource, err
+	}
+	if from, err = inst.resolveRevision(ctx, rng.From, from); err != nil {
+		return from, to, resolvedSource, err
+	}
+
+	to = rng.To.Ref
+	if resolvedSource, err = inst.ResolveReference(ctx, &to, source); err != nil {
+		return from, to, resolvedSource, err
+	}
+	to, err = inst.resolveRevision(ctx, rng.To, to)
+	return from, to, resolvedSource, err
+}
+
+// resolveRevision selects the version a revision refers to from the logbook,
+// which covers both local & pulled history. ref is the resolved form of
+// rev.Ref. Revisions without a relative suffix keep the resolved path
+func (inst *Instance) resolveRevision(ctx context.Context, rev dsref.Revision, ref dsref.Ref) (dsref.Ref, error) {
+	if !rev.IsRelative() {
+		return ref, nil
+	}
+	rev.Ref = dsref.Ref{Username: ref.Username, Name: ref.Name, ProfileID: ref.ProfileID, Path: rev.Ref.Path}
+	resolved, err := inst.repo.Logbook().ResolveRevision(ctx, rev)
+	if err != nil {
+		return ref, err
+	}
+	ref.Path = resolved.Path
+	return ref, nil
+}
+
+// revisionRangeItems lists the versions in a revision range, newest first.
+// Both sides of the range must refer to the same dataset, and the versions
+// listed are those after "from", up to and including "to", taken from the
+// history of the branch "to" is on
+func (inst *Instance) revisionRangeItems(ctx context.Context, rangeStr, source string) ([]DatasetLogItem, error) {
+	from, to, _, err := inst.ParseAndResolveRevisionRange(ctx, rangeStr, source)
+	if err != nil {
+		return nil, err
+	}
+	if from.Human() != to.Human() {
+		return nil, fmt.Errorf("revision range %q must refer to a single dataset", rangeStr)
+	}
+
+	var items []DatasetLogItem
+	dsRef := dsref.Ref{Username: to.Username, Name: to.Name, ProfileID: to.ProfileID}
+	if rng, _ := dsref.ParseRevisionRange(rangeStr); rng.To.Branch != "" {
+		items, err = base.DatasetBranchLog(ctx, inst.repo, dsRef, rng.To.Branch, -1, 0)
+	} else {
+		items, err = base.DatasetLog(ctx, inst.repo, dsRef, -1, 0, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	start, end := -1, -1
+	for i, item := range items {
+		if item.Path == to.Path && start < 0 {
+			start = i
+		}
+		if item.Path == from.Path && end < 0 {
+			end = i
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("revision range %q: version %s isn't in the history of %s", rangeStr, to.Path, dsRef.Human())
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("revision range %q: version %s isn't in the history of %s", rangeStr, from.Path, dsRef.Human())
+	}
+	if start > end {
+		return []DatasetLogItem{}, nil
+	}
+	return items[start:end], nil
+}
+
 // ResolveReference finds the identifier & HEAD path for a dataset reference.
 // the mode parameter determines which subsystems of Qri to use when resolving
 func (inst *Instance) ResolveReference(ctx context.Context, ref *dsref.Ref, mode string) (string, error) {
@@ -0,0 +1,259 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/event/hook"
+	"github.com/qri-io/qri/logbook"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/scheduler"
+)
+
+// ScheduleConfigKey is the transform config key that holds a dataset's
+// cron-style run schedule
+const ScheduleConfigKey = "schedule"
+
+// TransformScheduler runs dataset transforms on a schedule, saving a new
+// version whenever a run changes the dataset. Schedules are read from the
+// "schedule" key of each dataset's transform config. Schedules set in the
+// scheduler configuration take precedence. Every run is recorded in the
+// logbook
+type TransformScheduler struct {
+	inst  *Instance
+	sched *scheduler.Scheduler
+}
+
+// NewTransformScheduler creates a scheduler for the datasets in an instance
+func NewTransformScheduler(inst *Instance) *TransformScheduler {
+	ts := &TransformScheduler{inst: inst}
+	ts.sched = scheduler.New(ts.run)
+	return ts
+}
+
+// Jobs lists scheduled transform runs, ordered by the time they're next due
+func (ts *TransformScheduler) Jobs() []scheduler.Job {
+	return ts.sched.Jobs()
+}
+
+// Start loads schedules & runs transforms as they come due, following
+// dataset changes to keep schedules current. Start blocks until ctx is
+// cancelled
+func (ts *TransformScheduler) Start(ctx context.Context) error {
+	if err := ts.Reload(ctx); err != nil {
+		return err
+	}
+
+	if ts.inst.changes != nil {
+		ts.inst.changes.SetChangeHook(func(change hook.DsChange) {
+			if ctx.Err() != nil {
+				return
+			}
+			if change.Type == hook.DatasetDeleteAll {
+				ts.sched.Unschedule(change.InitID)
+				return
+			}
+			// hooks fire while datasets are being written, update outside the
+			// writer
+			go func() {
+				if err := ts.update(ctx, change.InitID); err != nil {
+					log.Debugf("updating schedule for %q: %s", change.InitID, err)
+				}
+			}()
+		})
+	}
+
+	return ts.sched.Start(ctx)
+}
+
+// Reload reads the schedule of every local dataset, replacing any existing
+// schedules
+func (ts *TransformScheduler) Reload(ctx context.Context) error {
+	r := ts.inst.repo
+	num, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return err
+	}
+
+	scheduled := map[string]bool{}
+	for _, ref := range refs {
+		initID, err := ts.schedule(ctx, ref)
+		if err != nil {
+			log.Errorf("scheduling %s/%s: %s", ref.Peername, ref.Name, err)
+			continue
+		}
+		if initID != "" {
+			scheduled[initID] = true
+		}
+	}
+
+	for _, job := range ts.sched.Jobs() {
+		if !scheduled[job.ID] {
+			ts.sched.Unschedule(job.ID)
+		}
+	}
+	return nil
+}
+
+// update reloads the schedule of a single dataset
+func (ts *TransformScheduler) update(ctx context.Context, initID string) error {
+	r := ts.inst.repo
+	num, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ts.initID(ref) != initID {
+			continue
+		}
+		id, err := ts.schedule(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			ts.sched.Unschedule(initID)
+		}
+		return nil
+	}
+	ts.sched.Unschedule(initID)
+	return nil
+}
+
+// schedule adds a job for a dataset if it has a schedule & a transform,
+// returning the InitID of scheduled datasets
+func (ts *TransformScheduler) schedule(ctx context.Context, ref reporef.DatasetRef) (string, error) {
+	if ref.Path == "" {
+		return "", nil
+	}
+	ds, err := dsfs.LoadDataset(ctx, ts.inst.repo.Store(), ref.Path)
+	if err != nil {
+		return "", err
+	}
+
+	spec := ts.configSchedule(ref)
+	if spec == "" && ds.Transform != nil {
+		spec, _ = ds.Transform.Config[ScheduleConfigKey].(string)
+	}
+	if spec == "" {
+		return "", nil
+	}
+	if ds.Transform == nil || ds.Transform.ScriptPath == "" {
+		return "", fmt.Errorf("dataset has a schedule but no transform script")
+	}
+
+	initID := ts.initID(ref)
+	if initID == "" {
+		return "", fmt.Errorf("dataset has no logbook history")
+	}
+	return initID, ts.sched.Schedule(initID, ref.AliasString(), spec)
+}
+
+// configSchedule returns the schedule the scheduler configuration sets for a
+// dataset, if any
+func (ts *TransformScheduler) configSchedule(ref reporef.DatasetRef) string {
+	cfg := ts.inst.cfg
+	if cfg == nil || cfg.Scheduler == nil {
+		return ""
+	}
+	for refstr, spec := range cfg.Scheduler.Schedules {
+		r, err := dsref.Parse(refstr)
+		if err != nil {
+			continue
+		}
+		if r.Username == "me" {
+			r.Username = cfg.Profile.Peername
+		}
+		if r.Username == ref.Peername && r.Name == ref.Name {
+			return spec
+		}
+	}
+	return ""
+}
+
+func (ts *TransformScheduler) initID(ref reporef.DatasetRef) string {
+	id, err := ts.inst.repo.Logbook().RefToInitID(dsref.Ref{Username: ref.Peername, Name: ref.Name})
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// run executes the transform of a scheduled dataset, saving the result if it
+// changes the dataset
+func (ts *TransformScheduler) run(ctx context.Context, job scheduler.Job) error {
+	run := logbook.TransformRun{Timestamp: time.Now()}
+	saved, err := ts.save(ctx, job.Name)
+	switch {
+	case err == nil:
+		run.Status = logbook.RunSucceeded
+		run.Path = saved
+	case errors.Is(err, dsfs.ErrNoChanges):
+		run.Status = logbook.RunUnchanged
+		err = nil
+	default:
+		run.Status = logbook.RunFailed
+		run.Message = err.Error()
+	}
+
+	if logErr := ts.inst.repo.Logbook().WriteTransformRun(ctx, job.ID, run); logErr != nil {
+		log.Errorf("recording transform run for %s: %s", job.Name, logErr)
+	}
+	return err
+}
+
+// save re-runs the transform of the latest version of a dataset, returning
+// the path of the saved version
+func (ts *TransformScheduler) save(ctx context.Context, refstr string) (string, error) {
+	ref, _, err := ts.inst.ParseAndResolveRef(ctx, refstr, "local")
+	if err != nil {
+		return "", err
+	}
+	head, err := dsfs.LoadDataset(ctx, ts.inst.repo.Store(), ref.Path)
+	if err != nil {
+		return "", err
+	}
+	if head.Transform == nil || head.Transform.ScriptPath == "" {
+		return "", fmt.Errorf("no transform script to run")
+	}
+	if err := head.Transform.OpenScriptFile(ctx, ts.inst.repo.Filesystem()); err != nil {
+		return "", err
+	}
+	script, err := ioutil.ReadAll(head.Transform.ScriptFile())
+	if err != nil {
+		return "", err
+	}
+
+	// script bytes let save compare the script to the previous version
+	tf := &dataset.Transform{
+		Syntax:      head.Transform.Syntax,
+		Config:      head.Transform.Config,
+		ScriptBytes: script,
+	}
+	tf.SetScriptFile(qfs.NewMemfileBytes("transform.star", script))
+	p := &SaveParams{
+		Ref:          refstr,
+		Title:        "scheduled transform run",
+		Dataset:      &dataset.Dataset{Transform: tf},
+		ScriptOutput: ioutil.Discard,
+	}
+	res := &reporef.DatasetRef{}
+	if err := NewDatasetMethods(ts.inst).Save(p, res); err != nil {
+		return "", err
+	}
+	return res.Path, nil
+}
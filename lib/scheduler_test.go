@@ -0,0 +1,138 @@
+package lib
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/p2p"
+	reporef "github.com/qri-io/qri/repo/ref"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestTransformScheduler(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := config.DefaultConfigForTesting()
+	cfg.Scheduler.Schedules = map[string]string{"me/ticker": "@daily"}
+	inst := NewInstanceFromConfigAndNode(ctx, cfg, node)
+
+	dir, err := ioutil.TempDir("", "lib_test_transform_scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// ticks add a body entry every run, constant never changes
+	tickPath := filepath.Join(dir, "tick.star")
+	tick := `
+def transform(ds, ctx):
+	body = ds.get_body([])
+	ds.set_body(body + [[len(body)]])
+`
+	if err := ioutil.WriteFile(tickPath, []byte(tick), 0644); err != nil {
+		t.Fatal(err)
+	}
+	constantPath := filepath.Join(dir, "constant.star")
+	constant := `
+def transform(ds, ctx):
+	ds.set_body([[1]])
+`
+	if err := ioutil.WriteFile(constantPath, []byte(constant), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	save := func(name, scriptPath, schedule string) *reporef.DatasetRef {
+		res := &reporef.DatasetRef{}
+		p := &SaveParams{
+			Ref: "me/" + name,
+			Dataset: &dataset.Dataset{
+				Transform: &dataset.Transform{
+					ScriptPath: scriptPath,
+					Config:     map[string]interface{}{ScheduleConfigKey: schedule},
+				},
+			},
+			ScriptOutput: ioutil.Discard,
+		}
+		if err := NewDatasetMethods(inst).Save(p, res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	save("constant", constantPath, "@hourly")
+	ticker := save("ticker", tickPath, "@hourly")
+
+	ts := NewTransformScheduler(inst)
+	if err := ts.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := ts.Jobs()
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 scheduled datasets, got %d", len(jobs))
+	}
+	if jobs[0].Name != "peer/constant" || jobs[0].Spec != "@hourly" {
+		t.Errorf("expected transform config schedule. got: %q %q", jobs[0].Name, jobs[0].Spec)
+	}
+	if jobs[1].Name != "peer/ticker" || jobs[1].Spec != "@daily" {
+		t.Errorf("expected configured schedule to take precedence. got: %q %q", jobs[1].Name, jobs[1].Spec)
+	}
+
+	if err := ts.run(ctx, jobs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := inst.repo.Logbook().TransformRuns(ctx, dsref.Ref{Username: "peer", Name: "ticker"}, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 recorded run, got %d", len(runs))
+	}
+	if runs[0].Status != logbook.RunSucceeded {
+		t.Errorf("expected run to succeed, got status %q: %s", runs[0].Status, runs[0].Message)
+	}
+
+	head := &GetResult{}
+	if err := NewDatasetMethods(inst).Get(&GetParams{Refstr: "peer/ticker"}, head); err != nil {
+		t.Fatal(err)
+	}
+	if head.Dataset.Path == ticker.Path || head.Dataset.Path != runs[0].Path {
+		t.Errorf("expected run to save a new version at %q, head is %q", runs[0].Path, head.Dataset.Path)
+	}
+	if head.Dataset.Structure.Entries != 2 {
+		t.Errorf("expected scheduled run to add a body entry, got %d entries", head.Dataset.Structure.Entries)
+	}
+
+	if err := ts.run(ctx, jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+	runs, err = inst.repo.Logbook().TransformRuns(ctx, dsref.Ref{Username: "peer", Name: "constant"}, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Status != logbook.RunUnchanged {
+		t.Errorf("expected an unchanged run to be recorded, got: %v", runs)
+	}
+
+	if err := ts.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.Jobs()) != 2 {
+		t.Errorf("expected reloading to keep schedules, got %d jobs", len(ts.Jobs()))
+	}
+}
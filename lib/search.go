@@ -1,9 +1,11 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/registry"
 	"github.com/qri-io/qri/registry/regclient"
 	"github.com/qri-io/qri/repo"
 )
@@ -27,6 +29,12 @@ type SearchParams struct {
 	QueryString string `json:"q"`
 	Limit       int    `json:"limit,omitempty"`
 	Offset      int    `json:"offset,omitempty"`
+	// Filters narrow registry search results by facet. Filters are strings of
+	// the form "key:value" or "key:relation:value", eg: "keywords:health" or
+	// "updated:gte:2020-01-01"
+	Filters []string `json:"filters,omitempty"`
+	// Local searches datasets in the local repo instead of the registry
+	Local bool `json:"local,omitempty"`
 }
 
 // SearchResult struct
@@ -45,6 +53,9 @@ func (m *SearchMethods) Search(p *SearchParams, results *[]SearchResult) error {
 		return fmt.Errorf("error: search params cannot be nil")
 	}
 
+	if p.Local {
+		return m.localSearch(p, results)
+	}
 	reg := m.inst.registry
 	if reg == nil {
 		return repo.ErrNoRegistry
@@ -54,6 +65,13 @@ func (m *SearchMethods) Search(p *SearchParams, results *[]SearchResult) error {
 		Limit:       p.Limit,
 		Offset:      p.Offset,
 	}
+	for _, str := range p.Filters {
+		f, err := registry.ParseSearchFilter(str)
+		if err != nil {
+			return err
+		}
+		params.Filters = append(params.Filters, f)
+	}
 
 	regResults, err := reg.Search(params)
 	if err != nil {
@@ -74,3 +92,39 @@ func (m *SearchMethods) Search(p *SearchParams, results *[]SearchResult) error {
 	*results = searchResults
 	return nil
 }
+
+// localSearch queries the search index of the local repo
+func (m *SearchMethods) localSearch(p *SearchParams, results *[]SearchResult) error {
+	ctx := context.TODO()
+	if len(p.Filters) > 0 {
+		return fmt.Errorf("search filters are only supported when searching a registry")
+	}
+	idx := m.inst.searchIndex
+	if idx == nil {
+		return repo.ErrNoRegistry
+	}
+	// build the index from the repo the first time it's used
+	if !idx.Built() {
+		if err := idx.Build(ctx, m.inst.repo); err != nil {
+			return err
+		}
+	}
+
+	refs, err := idx.Search(repo.SearchParams{
+		Q:      p.QueryString,
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	searchResults := make([]SearchResult, len(refs))
+	for i, ref := range refs {
+		searchResults[i].Type = "dataset"
+		searchResults[i].ID = ref.Path
+		searchResults[i].Value = ref.Dataset
+	}
+	*results = searchResults
+	return nil
+}
@@ -2,13 +2,18 @@ package lib
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/registry/regclient"
+	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
 
@@ -34,7 +39,7 @@ func TestSearch(t *testing.T) {
 
 	m := NewSearchMethods(inst)
 
-	p := &SearchParams{"nuun", 0, 100}
+	p := &SearchParams{QueryString: "nuun", Limit: 0, Offset: 100}
 	got := &[]SearchResult{}
 	if err = m.Search(p, got); err != nil {
 		t.Error(err)
@@ -45,6 +50,54 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestLocalSearch(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(ctx, config.DefaultConfigForTesting(), node)
+	m := NewSearchMethods(inst)
+
+	search := func(q string) []string {
+		t.Helper()
+		got := []SearchResult{}
+		if err := m.Search(&SearchParams{QueryString: q, Local: true}, &got); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(got))
+		for i, res := range got {
+			names[i] = fmt.Sprintf("%s/%s", res.Value.Peername, res.Value.Name)
+		}
+		return names
+	}
+
+	// existing datasets are indexed on first search
+	if diff := cmp.Diff([]string{"peer/movies"}, search("movie")); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	// saved datasets are indexed as they change
+	metaPath := tempDatasetFile(t, "*-search_meta.json", &dataset.Dataset{Meta: &dataset.Meta{
+		Title:    "Warehouse inventory",
+		Keywords: []string{"logistics"},
+	}})
+	defer os.RemoveAll(metaPath)
+	saveParams := &SaveParams{Ref: "me/cities", FilePaths: []string{metaPath}}
+	if err := NewDatasetMethods(inst).Save(saveParams, &reporef.DatasetRef{}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"peer/cities"}, search("logistics")); diff != "" {
+		t.Errorf("result mismatch after save (-want +got):\n%s", diff)
+	}
+}
+
 var mockResponse = []byte(`{"data":[
   {
     "Type": "dataset",
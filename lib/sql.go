@@ -3,8 +3,14 @@ package lib
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
 	"github.com/qri-io/qri/sql"
 )
 
@@ -28,6 +34,13 @@ type SQLQueryParams struct {
 	Query        string
 	OutputFormat string
 	ResolverMode string
+	// Save is an optional reference to save query results to as a new dataset
+	// version. Queries in the form "CREATE DATASET [ref] AS SELECT ..." set the
+	// save reference within the query
+	Save string
+	// ExperimentalFormats enables output formats that are still in
+	// development, like parquet & arrow
+	ExperimentalFormats bool
 }
 
 // Exec runs an SQL query
@@ -38,6 +51,9 @@ func (m *SQLMethods) Exec(p *SQLQueryParams, results *[]byte) error {
 	if p == nil {
 		return fmt.Errorf("error: search params cannot be nil")
 	}
+	if err := checkExperimentalFormat(p.OutputFormat, p.ExperimentalFormats); err != nil {
+		return err
+	}
 	ctx := context.TODO()
 
 	resolver, err := m.inst.resolverForMode(p.ResolverMode)
@@ -46,14 +62,81 @@ func (m *SQLMethods) Exec(p *SQLQueryParams, results *[]byte) error {
 	}
 	// create a loader sql will use to load & fetch datasets
 	// pass in the configured peername, allowing the "me" alias in reference strings
-	loadDataset := NewParseResolveLoadFunc(m.inst.cfg.Profile.Peername, resolver, m.inst)
-	svc := sql.New(m.inst.repo, loadDataset)
+	loadDataset := m.inst.newRevisionLoadFunc(m.inst.cfg.Profile.Peername, resolver)
+	svc := sql.New(m.inst.repo, loadDataset, func(o *sql.Options) {
+		o.ResultCache = m.inst.sqlCache
+	})
+
+	query, saveRef := p.Query, p.Save
+	if ref, selectQuery, ok := sql.ParseCreateDataset(p.Query); ok {
+		if saveRef != "" && saveRef != ref {
+			return fmt.Errorf("query creates dataset %q, which conflicts with save reference %q", ref, saveRef)
+		}
+		query, saveRef = selectQuery, ref
+	}
+	if saveRef != "" {
+		return m.save(ctx, svc, query, saveRef, p.OutputFormat, results)
+	}
 
 	buf := &bytes.Buffer{}
-	if err := svc.Exec(ctx, buf, p.OutputFormat, p.Query); err != nil {
+	if err := svc.Exec(ctx, buf, p.OutputFormat, query); err != nil {
 		return err
 	}
 
 	*results = buf.Bytes()
 	return nil
 }
+
+// save runs a SELECT query, committing the result as a new version of the
+// dataset at refStr with base.SaveDataset, recording the query in the commit
+// message
+func (m *SQLMethods) save(ctx context.Context, svc *sql.Service, query, refStr, outputFormat string, results *[]byte) error {
+	ref, err := dsref.Parse(refStr)
+	if err != nil {
+		return err
+	}
+	peername := m.inst.cfg.Profile.Peername
+	if err = replaceMeKeyword(peername, refStr, &ref); err != nil {
+		return err
+	}
+	if ref.Username != peername {
+		return fmt.Errorf("cannot save using a different username than %q", peername)
+	}
+	if ref.Path != "" {
+		return fmt.Errorf("cannot save to a specific version of %s", ref.Human())
+	}
+
+	// saving to a linked dataset would leave its working directory out of date
+	linked := &reporef.DatasetRef{Peername: ref.Username, Name: ref.Name}
+	if err = repo.CanonicalizeDatasetRef(m.inst.repo, linked); err == nil && linked.FSIPath != "" {
+		return fmt.Errorf("cannot save query results to %s, which is linked to a working directory", ref.Human())
+	}
+
+	ds, err := svc.Dataset(ctx, query)
+	if err != nil {
+		return err
+	}
+	ds.Peername = ref.Username
+	ds.Name = ref.Name
+	ds.Commit = &dataset.Commit{
+		Title:   "created from SQL query",
+		Message: query,
+	}
+
+	trueRef, err := base.FinalizeNameAndStableIdentifers(ctx, m.inst.repo, peername, ds.Name, ds, false)
+	if err != nil {
+		return err
+	}
+	res, err := base.SaveDataset(ctx, m.inst.repo, m.inst.qfs.DefaultWriteFS(), trueRef.InitID, trueRef.Path, ds, base.SaveSwitches{Pin: true})
+	if err != nil {
+		return err
+	}
+
+	saved := reporef.ConvertToDsref(res)
+	if outputFormat == "json" {
+		*results, err = json.Marshal(saved)
+		return err
+	}
+	*results = []byte(fmt.Sprintf("dataset saved: %s\n", saved.String()))
+	return nil
+}
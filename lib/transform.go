@@ -0,0 +1,386 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/rowdiff"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/sql"
+	"github.com/qri-io/qri/startf"
+)
+
+// TransformMethods encapsulates business logic for working with transform
+// scripts
+type TransformMethods struct {
+	inst *Instance
+}
+
+// NewTransformMethods creates TransformMethods from a qri Instance
+func NewTransformMethods(inst *Instance) *TransformMethods {
+	return &TransformMethods{inst: inst}
+}
+
+// CoreRequestsName implements the requests interface
+func (m TransformMethods) CoreRequestsName() string { return "transform" }
+
+// ApplyParams are parameters for the apply command
+type ApplyParams struct {
+	// dataset reference to apply the transform to. the transform runs against
+	// the head of this dataset. An empty reference applies the transform to a
+	// new, empty dataset
+	Refstr string
+	// path to a transform script file. defaults to the transform script of the
+	// dataset head
+	ScriptPath string
+	// transform script source, takes precedence over ScriptPath
+	Script string
+	// secrets for transform execution
+	Secrets map[string]string
+	// number of body entries to include in the result, defaults to
+	// base.MaxNumDatasetRowsInPreview
+	PreviewSize int
+	// optional writer to have transform script record standard output to
+	// note: this won't work over RPC, only on local calls
+	ScriptOutput io.Writer
+	// record or replay HTTP responses of the download step with a fixture
+	// file next to the transform script. one of "record", "replay"
+	HTTPFixture string
+}
+
+// ApplyResult is the outcome of running a transform without saving
+type ApplyResult struct {
+	// Data is the dataset the transform produces, with a preview of the body
+	Data *dataset.Dataset `json:"data"`
+	// Diff describes changes from the dataset head to the transform result.
+	// Body changes are listed by row in Diff.Rows
+	Diff *DiffResponse `json:"diff"`
+}
+
+// Apply runs a transform script against the head of a dataset, returning the
+// result & a diff against head without committing anything
+func (m *TransformMethods) Apply(p *ApplyParams, res *ApplyResult) error {
+	if err := qfs.AbsPath(&p.ScriptPath); err != nil {
+		return err
+	}
+
+	if m.inst.rpc != nil {
+		p.ScriptOutput = nil
+		return checkRPCError(m.inst.rpc.Call("TransformMethods.Apply", p, res))
+	}
+	ctx := context.TODO()
+
+	loader := m.inst.newRevisionLoadFunc(m.inst.cfg.Profile.Peername, m.inst.defaultResolver())
+
+	head := &dataset.Dataset{}
+	if p.Refstr != "" {
+		ds, err := loader(ctx, p.Refstr)
+		if err == nil {
+			head = ds
+		} else if !errors.Is(err, dsref.ErrNoHistory) {
+			return err
+		}
+		// the head body is streamed from the store when it's diffed
+		if head.BodyFile() != nil {
+			head.BodyFile().Close()
+		}
+	}
+
+	ds := &dataset.Dataset{Name: head.Name}
+	if p.Refstr != "" && ds.Name == "" {
+		ref, err := dsref.Parse(p.Refstr)
+		if err != nil {
+			return err
+		}
+		ds.Name = ref.Name
+	}
+
+	if p.Script != "" {
+		ds.Transform = &dataset.Transform{}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", []byte(p.Script)))
+	} else if p.ScriptPath != "" {
+		ds.Transform = &dataset.Transform{ScriptPath: p.ScriptPath}
+		if err := ds.Transform.OpenScriptFile(ctx, m.inst.repo.Filesystem()); err != nil {
+			return err
+		}
+	} else if head.Transform != nil && head.Transform.ScriptFile() != nil {
+		// copy the head script, leaving the transform component of head intact
+		data, err := ioutil.ReadAll(head.Transform.ScriptFile())
+		if err != nil {
+			return err
+		}
+		ds.Transform = &dataset.Transform{Config: head.Transform.Config}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", data))
+	} else {
+		return fmt.Errorf("no transform script to apply")
+	}
+
+	execOpts, err := httpFixtureOpts(p.HTTPFixture, p.ScriptPath)
+	if err != nil {
+		return err
+	}
+	execOpts = append(m.inst.transformExecOpts(loader), execOpts...)
+	if err := base.TransformApply(ctx, ds, m.inst.repo, loader, m.inst.localStreams(), p.ScriptOutput, p.Secrets, execOpts...); err != nil {
+		return err
+	}
+
+	// components the transform doesn't set carry over from head, as they
+	// would when saving
+	applied := &dataset.Dataset{
+		Peername: head.Peername,
+		Name:     head.Name,
+		Readme:   head.Readme,
+		Viz:      head.Viz,
+	}
+	applied.Assign(&dataset.Dataset{
+		Transform: ds.Transform,
+		Readme:    ds.Readme,
+		Viz:       ds.Viz,
+	})
+	if head.Meta != nil || ds.Meta != nil {
+		applied.Meta = &dataset.Meta{}
+		applied.Meta.Assign(head.Meta, ds.Meta)
+	}
+	if head.Structure != nil || ds.Structure != nil {
+		applied.Structure = &dataset.Structure{}
+		applied.Structure.Assign(head.Structure, ds.Structure)
+	}
+
+	size := p.PreviewSize
+	if size <= 0 {
+		size = base.MaxNumDatasetRowsInPreview
+	}
+
+	// bodies are streamed through a row diff, keeping the changed rows &
+	// a preview of the transform body instead of either body in memory
+	diff := &DiffResponse{}
+	var preview *previewReader
+	headBody := emptyBodySource
+	if head.BodyPath != "" && head.Structure != nil {
+		headBody = storedBodySource(ctx, m.inst.repo.Store(), head)
+	}
+	body := headBody
+	if f := ds.BodyFile(); f != nil {
+		var cleanup func()
+		body, cleanup = readerBodySource(applied.Structure, f)
+		defer cleanup()
+	}
+	if head.BodyPath != "" || ds.BodyFile() != nil {
+		body = previewSource(body, size, &preview)
+		if err = keyedBodyDiff(headBody, body, nil, 0, 0, diff); err != nil {
+			return fmt.Errorf("diffing transform body: %w", err)
+		}
+		applied.Body = preview.body()
+		if applied.Structure != nil {
+			applied.Structure.Entries = preview.entries
+		}
+	}
+
+	left, err := applyDiffData(head)
+	if err != nil {
+		return err
+	}
+	right, err := applyDiffData(applied)
+	if err != nil {
+		return err
+	}
+	if diff.Diff, diff.Stat, err = deepdiff.New().StatDiff(ctx, left, right); err != nil {
+		return err
+	}
+
+	*res = ApplyResult{
+		Data: applied,
+		Diff: diff,
+	}
+	return nil
+}
+
+// transformExecOpts configures transform execution with the resources of an
+// instance, enabling the qri.sql & qri.stats starlark builtins
+func (inst *Instance) transformExecOpts(loader dsref.ParseResolveLoad) []func(*startf.ExecOpts) {
+	return []func(*startf.ExecOpts){
+		startf.SetLimits(inst.transformLimits()),
+		startf.AddQueryFunc(func(ctx context.Context, load dsref.ParseResolveLoad, query string) ([]map[string]interface{}, error) {
+			// transforms pass a loader that records the datasets queries read
+			if load == nil {
+				load = loader
+			}
+			svc := sql.New(inst.repo, load, func(o *sql.Options) {
+				o.ResultCache = inst.sqlCache
+			})
+			buf := &bytes.Buffer{}
+			if err := svc.Exec(ctx, buf, "json", query); err != nil {
+				return nil, err
+			}
+			rows := []map[string]interface{}{}
+			// queries without results only write a closing bracket
+			if data := bytes.TrimSpace(buf.Bytes()); len(data) > 1 {
+				if err := json.Unmarshal(data, &rows); err != nil {
+					return nil, err
+				}
+			}
+			return rows, nil
+		}),
+		startf.AddStats(inst.stats),
+	}
+}
+
+// httpFixtureOpts configures transforms to record or replay HTTP responses of
+// the download step with the fixture stored next to the script at scriptPath
+func httpFixtureOpts(mode, scriptPath string) ([]func(*startf.ExecOpts), error) {
+	fm, err := startf.ParseFixtureMode(mode)
+	if err != nil || fm == startf.FixtureOff {
+		return nil, err
+	}
+	if qfs.PathKind(scriptPath) != "local" {
+		return nil, fmt.Errorf("http fixtures require a local transform script file")
+	}
+	return []func(*startf.ExecOpts){
+		startf.SetHTTPFixture(startf.FixturePath(scriptPath), fm),
+	}, nil
+}
+
+// transformLimits reads the resource limits for transforms from the instance
+// configuration
+func (inst *Instance) transformLimits() startf.Limits {
+	if inst.cfg == nil || inst.cfg.Transform == nil {
+		return startf.Limits{}
+	}
+	cfg := inst.cfg.Transform
+	return startf.Limits{
+		Timeout:              time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		MaxSteps:             cfg.MaxSteps,
+		MaxBodyEntries:       cfg.MaxBodyEntries,
+		MaxBodyBytes:         cfg.MaxBodyBytes,
+		MaxHTTPRequests:      cfg.MaxHTTPRequests,
+		MaxHTTPResponseBytes: cfg.MaxHTTPResponseBytes,
+	}
+}
+
+// applyDiffData combines the components of a dataset a transform can change,
+// other than the body, into a single document for diffing. Values derived
+// when a dataset is written are dropped, because transform results aren't
+// written
+func applyDiffData(ds *dataset.Dataset) (interface{}, error) {
+	d := &dataset.Dataset{Meta: ds.Meta}
+	if ds.Structure != nil {
+		d.Structure = &dataset.Structure{
+			Format:       ds.Structure.Format,
+			FormatConfig: ds.Structure.FormatConfig,
+			Schema:       ds.Structure.Schema,
+		}
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// emptyBodySource is the body of a dataset without one
+func emptyBodySource() (dsio.EntryReader, error) {
+	return rowdiff.Values(nil, []interface{}{})()
+}
+
+// readerBodySource streams a body file that can only be read once, like the
+// body a transform produces. The first read copies the file to a temporary
+// file, which later reads stream from. cleanup removes the temporary file
+func readerBodySource(st *dataset.Structure, f qfs.File) (src rowdiff.Source, cleanup func()) {
+	var spool *os.File
+	cleanup = func() {
+		if spool != nil {
+			spool.Close()
+			os.Remove(spool.Name())
+		}
+	}
+	src = func() (dsio.EntryReader, error) {
+		if st == nil {
+			f.Close()
+			return nil, fmt.Errorf("transform body has no structure")
+		}
+		if spool != nil {
+			if _, err := spool.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			// hide the temporary file's Close method, it's closed by cleanup
+			return dsio.NewEntryReader(st, struct{ io.Reader }{spool})
+		}
+
+		tmp, err := ioutil.TempFile("", "qri_transform_body")
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		spool = tmp
+		rdr, err := dsio.NewEntryReader(st, io.TeeReader(f, spool))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return closingReader{rdr, f}, nil
+	}
+	return src, cleanup
+}
+
+// previewSource wraps the first reader a source opens, setting *preview to it
+func previewSource(src rowdiff.Source, size int, preview **previewReader) rowdiff.Source {
+	return func() (dsio.EntryReader, error) {
+		rdr, err := src()
+		if err != nil || *preview != nil {
+			return rdr, err
+		}
+		*preview = &previewReader{EntryReader: rdr, size: size}
+		return *preview, nil
+	}
+}
+
+// previewReader counts the entries read from an entry reader, keeping the
+// first size of them
+type previewReader struct {
+	dsio.EntryReader
+	size    int
+	entries int
+	kept    []dsio.Entry
+}
+
+// ReadEntry implements the dsio.EntryReader interface
+func (r *previewReader) ReadEntry() (dsio.Entry, error) {
+	ent, err := r.EntryReader.ReadEntry()
+	if err == nil {
+		r.entries++
+		if len(r.kept) < r.size {
+			r.kept = append(r.kept, ent)
+		}
+	}
+	return ent, err
+}
+
+// body returns the kept entries as an array or object body
+func (r *previewReader) body() interface{} {
+	if tlt, _ := dsio.GetTopLevelType(r.Structure()); tlt == "object" {
+		obj := make(map[string]interface{}, len(r.kept))
+		for _, ent := range r.kept {
+			obj[ent.Key] = ent.Value
+		}
+		return obj
+	}
+	arr := make([]interface{}, 0, len(r.kept))
+	for _, ent := range r.kept {
+		arr = append(arr, ent.Value)
+	}
+	return arr
+}
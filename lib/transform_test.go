@@ -0,0 +1,265 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/startf"
+)
+
+func TestApply(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(ctx, config.DefaultConfigForTesting(), node)
+	m := NewTransformMethods(inst)
+
+	dir, err := ioutil.TempDir("", "lib_test_apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	scriptPath := filepath.Join(dir, "transform.star")
+	script := `
+def transform(ds, ctx):
+	ds.set_meta("title", "first movies")
+	ds.set_body(ds.get_body()[:3])
+`
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	headRef := "peer/movies"
+	head := &GetResult{}
+	if err := NewDatasetMethods(inst).Get(&GetParams{Refstr: headRef}, head); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &ApplyResult{}
+	p := &ApplyParams{Refstr: "me/movies", ScriptPath: scriptPath, PreviewSize: 2}
+	if err := m.Apply(p, res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Data.Meta == nil || res.Data.Meta.Title != "first movies" {
+		t.Errorf("expected transform to set meta title, got: %v", res.Data.Meta)
+	}
+	if res.Data.Structure == nil || res.Data.Structure.Entries != 3 {
+		t.Errorf("expected structure to count 3 entries, got: %v", res.Data.Structure)
+	}
+	if body, ok := res.Data.Body.([]interface{}); !ok || len(body) != 2 {
+		t.Errorf("expected body preview of 2 entries, got: %v", res.Data.Body)
+	}
+	if res.Diff == nil || res.Diff.RowStat == nil || res.Diff.RowStat.Removed != head.Dataset.Structure.Entries-3 {
+		t.Errorf("expected diff to remove body rows, got: %#v", res.Diff.RowStat)
+	}
+	if res.Diff.Stat == nil || res.Diff.Stat.Inserts+res.Diff.Stat.Updates == 0 {
+		t.Errorf("expected diff to change meta title, got: %#v", res.Diff.Stat)
+	}
+
+	// applying doesn't commit
+	after := &GetResult{}
+	if err := NewDatasetMethods(inst).Get(&GetParams{Refstr: headRef}, after); err != nil {
+		t.Fatal(err)
+	}
+	if head.Dataset.Path != after.Dataset.Path {
+		t.Errorf("expected apply not to change dataset head. was: %s, now: %s", head.Dataset.Path, after.Dataset.Path)
+	}
+
+	if err := m.Apply(&ApplyParams{Refstr: "peer/movies"}, res); err == nil {
+		t.Error("expected applying without a transform script to fail")
+	}
+
+	// instances without a p2p node can still apply transforms
+	inst.node = nil
+	if err := m.Apply(p, &ApplyResult{}); err != nil {
+		t.Errorf("unexpected error applying without a node: %s", err)
+	}
+}
+
+func TestApplyLimits(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := config.DefaultConfigForTesting()
+	cfg.Transform.MaxBodyEntries = 2
+	inst := NewInstanceFromConfigAndNode(ctx, cfg, node)
+	m := NewTransformMethods(inst)
+
+	script := `
+def transform(ds, ctx):
+	ds.set_body([["a", 1], ["b", 2], ["c", 3]])
+`
+	err = m.Apply(&ApplyParams{Refstr: "me/movies", Script: script}, &ApplyResult{})
+	if !errors.Is(err, startf.ErrLimitExceeded) {
+		t.Errorf("expected transform to exceed body entry limit, got: %v", err)
+	}
+
+	cfg.Transform.MaxBodyEntries = 3
+	if err := m.Apply(&ApplyParams{Refstr: "me/movies", Script: script}, &ApplyResult{}); err != nil {
+		t.Errorf("unexpected error within limits: %s", err)
+	}
+}
+
+func TestApplyQriModule(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(ctx, config.DefaultConfigForTesting(), node)
+	m := NewTransformMethods(inst)
+
+	script := `
+load("qri.star", "qri")
+
+def transform(ds, ctx):
+	versions = qri.log("peer/movies")
+	columns = qri.stats("peer/movies")
+	rows = qri.sql("SELECT * FROM peer/movies m LIMIT 2")
+	stat = qri.diff("peer/movies", "peer/movies")["stat"]
+	changes = stat.get("inserts", 0) + stat.get("updates", 0) + stat.get("deletes", 0)
+	ds.set_meta("title", "%d versions, %d columns, %d rows, %d changes" % (len(versions), len(columns), len(rows), changes))
+`
+	res := &ApplyResult{}
+	if err := m.Apply(&ApplyParams{Refstr: "me/movies", Script: script}, res); err != nil {
+		t.Fatal(err)
+	}
+	expect := "1 versions, 2 columns, 2 rows, 0 changes"
+	if res.Data.Meta == nil || res.Data.Meta.Title != expect {
+		t.Errorf("meta title mismatch. expected: %q, got: %v", expect, res.Data.Meta)
+	}
+}
+
+func TestApplyHTTPFixture(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[["a",1],["b",2]]`))
+	}))
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(ctx, config.DefaultConfigForTesting(), node)
+	m := NewTransformMethods(inst)
+
+	dir, err := ioutil.TempDir("", "lib_test_apply_http_fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	scriptPath := filepath.Join(dir, "transform.star")
+	script := fmt.Sprintf(`
+load("http.star", "http")
+
+def download(ctx):
+	return http.get("%s").json()
+
+def transform(ds, ctx):
+	ds.set_body(ctx.download)
+`, s.URL)
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &ApplyParams{Refstr: "me/movies", ScriptPath: scriptPath, HTTPFixture: "record"}
+	if err := m.Apply(p, &ApplyResult{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "transform.fixture.json")); err != nil {
+		t.Errorf("expected recording to write a fixture next to the script: %s", err)
+	}
+
+	s.Close()
+	res := &ApplyResult{}
+	p.HTTPFixture = "replay"
+	if err := m.Apply(p, res); err != nil {
+		t.Fatal(err)
+	}
+	if body, ok := res.Data.Body.([]interface{}); !ok || len(body) != 2 {
+		t.Errorf("expected replayed body of 2 entries, got: %v", res.Data.Body)
+	}
+
+	err = m.Apply(&ApplyParams{Refstr: "me/movies", Script: script, HTTPFixture: "replay"}, &ApplyResult{})
+	if err == nil {
+		t.Errorf("expected http fixture without a script file to error")
+	}
+}
+
+func TestReaderBodySourceReopens(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	f := qfs.NewMemfileBytes("body.json", []byte(`[["a",1],["b",2]]`))
+	src, cleanup := readerBodySource(st, f)
+	defer cleanup()
+
+	readAll := func() []interface{} {
+		rdr, err := src()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rdr.Close()
+		var vals []interface{}
+		err = dsio.EachEntry(rdr, func(_ int, ent dsio.Entry, err error) error {
+			vals = append(vals, ent.Value)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vals
+	}
+
+	first := readAll()
+	if len(first) != 2 {
+		t.Fatalf("expected 2 entries, got: %v", first)
+	}
+	for i := 0; i < 2; i++ {
+		if again := readAll(); !reflect.DeepEqual(first, again) {
+			t.Errorf("read %d: expected entries %v, got: %v", i+2, first, again)
+		}
+	}
+}
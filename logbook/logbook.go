@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,6 +37,15 @@ var (
 	// ErrAccessDenied indicates insufficent privileges to perform a logbook
 	// operation
 	ErrAccessDenied = fmt.Errorf("access denied")
+	// ErrTagExists is returned when adding a tag a dataset already has
+	ErrTagExists = fmt.Errorf("logbook: tag already exists")
+	// ErrTagNotFound is returned when a dataset has no tag with a given name
+	ErrTagNotFound = fmt.Errorf("logbook: tag not found")
+	// ErrBranchExists is returned when creating a branch a dataset already has
+	ErrBranchExists = fmt.Errorf("logbook: branch already exists")
+	// ErrBranchNotFound is returned when a dataset has no branch with a given
+	// name
+	ErrBranchNotFound = fmt.Errorf("logbook: branch not found")
 
 	// NewTimestamp generates the current unix nanosecond time.
 	// This is mainly here for tests to override
@@ -58,6 +68,12 @@ const (
 	PublicationModel
 	// ACLModel is the enum for a acl model
 	ACLModel
+	// RunModel is the enum for a transform run model
+	RunModel
+	// TagModel is the enum for a version tag model
+	TagModel
+	// MergeModel is the enum for a merge model
+	MergeModel
 )
 
 // DefaultBranchName is the default name all branch-level logbook data is read
@@ -80,6 +96,12 @@ func ModelString(m uint32) string {
 		return "publication"
 	case ACLModel:
 		return "acl"
+	case RunModel:
+		return "run"
+	case TagModel:
+		return "tag"
+	case MergeModel:
+		return "merge"
 	default:
 		return ""
 	}
@@ -423,16 +445,34 @@ func (book *Book) datasetLog(ctx context.Context, initID string) (*DatasetLog, e
 	return newDatasetLog(lg), nil
 }
 
-// Return a strongly typed BranchLog
+// Return a strongly typed BranchLog for the default branch
 func (book *Book) branchLog(ctx context.Context, initID string) (*BranchLog, error) {
+	return book.namedBranchLog(ctx, initID, DefaultBranchName)
+}
+
+// Return a strongly typed BranchLog for a named branch. An empty name refers
+// to the default branch
+func (book *Book) namedBranchLog(ctx context.Context, initID, name string) (*BranchLog, error) {
 	lg, err := book.store.Get(ctx, initID)
 	if err != nil {
 		return nil, err
 	}
-	if len(lg.Logs) != 1 {
-		return nil, fmt.Errorf("expected dataset to have 1 branch, has %d", len(lg.Logs))
+	if len(lg.Logs) == 0 {
+		return nil, fmt.Errorf("expected dataset to have a branch, has none")
+	}
+	if name == "" {
+		name = DefaultBranchName
+	}
+	for _, l := range lg.Logs {
+		if l.Name() == name && !l.Removed() {
+			return newBranchLog(l), nil
+		}
+	}
+	if name == DefaultBranchName {
+		// the first branch is the default, regardless of name
+		return newBranchLog(lg.Logs[0]), nil
 	}
-	return newBranchLog(lg.Logs[0]), nil
+	return nil, fmt.Errorf("%w: %q", ErrBranchNotFound, name)
 }
 
 // hasWriteAccess is a simple author-matching check
@@ -529,7 +569,14 @@ func (book *Book) appendVersionSave(blog *BranchLog, ds *dataset.Dataset) int {
 
 	blog.Append(op)
 
-	return blog.Size() - 1
+	// tags & merges don't count toward the index of the top version
+	top := -1
+	for _, o := range blog.Ops() {
+		if o.Model != TagModel && o.Model != MergeModel {
+			top++
+		}
+	}
+	return top
 }
 
 // WriteVersionAmend adds an operation to a log when a dataset amends a commit
@@ -659,6 +706,497 @@ func (book *Book) WriteUnpublish(ctx context.Context, initID string, revisions i
 	return book.save(ctx)
 }
 
+// Transform run statuses
+const (
+	// RunSucceeded is the status of a transform run that saved a new version
+	RunSucceeded = "succeeded"
+	// RunUnchanged is the status of a transform run that produced no changes
+	RunUnchanged = "unchanged"
+	// RunFailed is the status of a transform run that errored
+	RunFailed = "failed"
+)
+
+// TransformRun is a record of a single execution of a dataset transform
+type TransformRun struct {
+	// Timestamp is the time the run started
+	Timestamp time.Time `json:"timestamp"`
+	// Status is one of RunSucceeded, RunUnchanged or RunFailed
+	Status string `json:"status"`
+	// Path of the version the run saved, if any
+	Path string `json:"path,omitempty"`
+	// Message describes the run, failed runs record their error here
+	Message string `json:"message,omitempty"`
+}
+
+// WriteTransformRun adds an operation to a log recording a transform run.
+// Runs are local history kept in a top-level log of their own, named by the
+// dataset's initID. They never become part of the dataset log that's synced to
+// peers, where logbook readers count every op in a branch as a version
+func (book *Book) WriteTransformRun(ctx context.Context, initID string, run TransformRun) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteTransformRun: %s, status: %s", initID, run.Status)
+
+	dsLog, err := book.datasetLog(ctx, initID)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(dsLog.l); err != nil {
+		return err
+	}
+
+	runLog, err := book.runLog(ctx, initID)
+	if err != nil {
+		return err
+	}
+	if runLog == nil {
+		runLog = oplog.InitLog(oplog.Op{
+			Type:      oplog.OpTypeInit,
+			Model:     RunModel,
+			AuthorID:  book.AuthorID(),
+			Name:      initID,
+			Timestamp: NewTimestamp(),
+		})
+		if err := book.store.MergeLog(ctx, runLog); err != nil {
+			return err
+		}
+	}
+	// naming an op renames the log it's appended to, so the run's status is
+	// stored as a relation instead
+	runLog.Append(oplog.Op{
+		Type:      oplog.OpTypeAmend,
+		Model:     RunModel,
+		Ref:       run.Path,
+		Relations: []string{run.Status},
+		Timestamp: run.Timestamp.UnixNano(),
+		Note:      run.Message,
+	})
+
+	return book.save(ctx)
+}
+
+// runLog returns the log of transform runs for a dataset, nil if the dataset
+// has never recorded a run
+func (book *Book) runLog(ctx context.Context, initID string) (*oplog.Log, error) {
+	logs, err := book.store.Logs(ctx, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		if l.Model() == RunModel && l.Name() == initID {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// TransformRuns lists the transform runs recorded for a dataset, newest first
+func (book *Book) TransformRuns(ctx context.Context, ref dsref.Ref, offset, limit int) ([]TransformRun, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return nil, err
+	}
+	runLog, err := book.runLog(ctx, initID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []TransformRun{}
+	if runLog == nil {
+		return runs, nil
+	}
+	// the first op initializes the log and isn't a run
+	ops := runLog.Ops[1:]
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if offset > 0 {
+			offset--
+			continue
+		}
+		status := ""
+		if len(op.Relations) > 0 {
+			status = op.Relations[0]
+		}
+		runs = append(runs, TransformRun{
+			Timestamp: time.Unix(0, op.Timestamp),
+			Status:    status,
+			Path:      op.Ref,
+			Message:   op.Note,
+		})
+		if len(runs) == limit {
+			break
+		}
+	}
+	return runs, nil
+}
+
+// Tag is a human-readable label for a dataset version
+type Tag struct {
+	// Name of the tag, like "v2020-q3"
+	Name string `json:"name"`
+	// Path of the version the tag labels
+	Path string `json:"path"`
+	// Timestamp is the time the tag was added
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WriteTag adds an operation to a log labelling a version with a tag. The
+// version must be part of the dataset's history, and tag names are unique
+// within a dataset
+func (book *Book) WriteTag(ctx context.Context, initID, name, path string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteTag: %s, tag: %s, path: %s", initID, name, path)
+	if err := dsref.EnsureValidTag(name); err != nil {
+		return err
+	}
+
+	branchLog, err := book.branchLog(ctx, initID)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(branchLog.l); err != nil {
+		return err
+	}
+	if _, ok := branchTags(branchLog)[name]; ok {
+		return fmt.Errorf("%w: %q", ErrTagExists, name)
+	}
+	found := false
+	for _, item := range branchToLogItems(branchLog, dsref.Ref{}, 0, -1, true) {
+		if item.Path == path {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("cannot tag %s: version is not in the dataset's history", path)
+	}
+
+	branchLog.Append(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     TagModel,
+		Name:      name,
+		Ref:       path,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// WriteTagDelete adds an operation to a log removing a tag
+func (book *Book) WriteTagDelete(ctx context.Context, initID, name string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteTagDelete: %s, tag: %s", initID, name)
+
+	branchLog, err := book.branchLog(ctx, initID)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(branchLog.l); err != nil {
+		return err
+	}
+	if _, ok := branchTags(branchLog)[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrTagNotFound, name)
+	}
+
+	branchLog.Append(oplog.Op{
+		Type:      oplog.OpTypeRemove,
+		Model:     TagModel,
+		Name:      name,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// Tags lists the tags of a dataset in the order they were added
+func (book *Book) Tags(ctx context.Context, ref dsref.Ref) ([]Tag, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return nil, err
+	}
+	branchLog, err := book.branchLog(ctx, initID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := branchTags(branchLog)
+	tags := make([]Tag, 0, len(byName))
+	for _, op := range branchLog.Ops() {
+		if op.Model != TagModel || op.Type != oplog.OpTypeInit {
+			continue
+		}
+		// skip tags that were later removed, or removed & re-added
+		if t, ok := byName[op.Name]; ok && t.Timestamp.Equal(time.Unix(0, op.Timestamp)) && t.Path == op.Ref {
+			tags = append(tags, t)
+			delete(byName, op.Name)
+		}
+	}
+	return tags, nil
+}
+
+// branchTags plays the tag operations of a branch forward, returning the
+// current tags by name
+func branchTags(blog *BranchLog) map[string]Tag {
+	tags := map[string]Tag{}
+	for _, op := range blog.Ops() {
+		if op.Model != TagModel {
+			continue
+		}
+		switch op.Type {
+		case oplog.OpTypeInit:
+			tags[op.Name] = Tag{Name: op.Name, Path: op.Ref, Timestamp: time.Unix(0, op.Timestamp)}
+		case oplog.OpTypeRemove:
+			delete(tags, op.Name)
+		}
+	}
+	return tags
+}
+
+// Branch is a named line of dataset history
+type Branch struct {
+	// Name of the branch, like "cleanup"
+	Name string `json:"name"`
+	// Path of the latest version on the branch
+	Path string `json:"path"`
+	// Versions is the number of versions in the branch's history
+	Versions int `json:"versions"`
+	// Timestamp is the time the branch was created
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WriteBranchInit adds a named branch to a dataset. The new branch starts
+// with a copy of the history of fromBranch, up to and including fromPath.
+// An empty fromBranch refers to the default branch, and an empty fromPath to
+// the latest version of fromBranch
+func (book *Book) WriteBranchInit(ctx context.Context, initID, name, fromBranch, fromPath string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchInit: %s, branch: %s, from: %s@%s", initID, name, fromBranch, fromPath)
+	if err := dsref.EnsureValidBranch(name); err != nil {
+		return err
+	}
+	if name == DefaultBranchName {
+		return fmt.Errorf("%w: %q", ErrBranchExists, name)
+	}
+
+	dsLog, err := book.datasetLog(ctx, initID)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(dsLog.l); err != nil {
+		return err
+	}
+	if _, err := book.namedBranchLog(ctx, initID, name); err == nil {
+		return fmt.Errorf("%w: %q", ErrBranchExists, name)
+	}
+
+	src, err := book.namedBranchLog(ctx, initID, fromBranch)
+	if err != nil {
+		return err
+	}
+	items := branchToLogItems(src, dsref.Ref{}, 0, -1, true)
+	if len(items) == 0 {
+		return dsref.ErrNoHistory
+	}
+	start := 0
+	if fromPath != "" {
+		start = -1
+		for i, item := range items {
+			if item.Path == fromPath {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return fmt.Errorf("cannot branch from %s: version is not in the dataset's history", fromPath)
+		}
+	}
+
+	branch := oplog.InitLog(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     BranchModel,
+		AuthorID:  book.AuthorID(),
+		Name:      name,
+		Timestamp: NewTimestamp(),
+	})
+	// copy history oldest-first, so the branch replays like any other
+	prev := ""
+	for i := len(items) - 1; i >= start; i-- {
+		branch.Append(oplog.Op{
+			Type:      oplog.OpTypeInit,
+			Model:     CommitModel,
+			Ref:       items[i].Path,
+			Prev:      prev,
+			Timestamp: items[i].CommitTime.UnixNano(),
+			Size:      int64(items[i].BodySize),
+			Note:      items[i].CommitTitle,
+		})
+		prev = items[i].Path
+	}
+	dsLog.AddChild(branch)
+
+	return book.save(ctx)
+}
+
+// WriteBranchVersionSave adds an operation to a named branch marking the
+// creation of a dataset version. Saves to the default branch are the same as
+// WriteVersionSave. Other branches don't affect the dataset's head, so saving
+// to them doesn't call the change hook
+func (book *Book) WriteBranchVersionSave(ctx context.Context, initID, branch string, ds *dataset.Dataset) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	if branch == "" || branch == DefaultBranchName {
+		return book.WriteVersionSave(ctx, initID, ds)
+	}
+	log.Debugf("WriteBranchVersionSave: %s, branch: %s", initID, branch)
+
+	branchLog, err := book.namedBranchLog(ctx, initID, branch)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(branchLog.l); err != nil {
+		return err
+	}
+
+	book.appendVersionSave(branchLog, ds)
+	return book.save(ctx)
+}
+
+// WriteBranchDelete adds an operation to a branch log marking it as removed.
+// The default branch can't be removed
+func (book *Book) WriteBranchDelete(ctx context.Context, initID, name string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchDelete: %s, branch: %s", initID, name)
+	if name == "" || name == DefaultBranchName {
+		return fmt.Errorf("cannot delete the %q branch", DefaultBranchName)
+	}
+
+	branchLog, err := book.namedBranchLog(ctx, initID, name)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(branchLog.l); err != nil {
+		return err
+	}
+
+	branchLog.Append(oplog.Op{
+		Type:      oplog.OpTypeRemove,
+		Model:     BranchModel,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// Branches lists the branches of a dataset, starting with the default branch
+// followed by the others in the order they were created
+func (book *Book) Branches(ctx context.Context, ref dsref.Ref) ([]Branch, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return nil, err
+	}
+	dsLog, err := book.datasetLog(ctx, initID)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]Branch, 0, len(dsLog.l.Logs))
+	for _, l := range dsLog.l.Logs {
+		if l.Removed() {
+			continue
+		}
+		items := branchToLogItems(newBranchLog(l), dsref.Ref{}, 0, -1, true)
+		b := Branch{
+			Name:      l.Name(),
+			Versions:  len(items),
+			Timestamp: time.Unix(0, l.Ops[0].Timestamp),
+		}
+		if len(items) > 0 {
+			b.Path = items[0].Path
+		}
+		branches = append(branches, b)
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		if branches[i].Name == DefaultBranchName || branches[j].Name == DefaultBranchName {
+			return branches[i].Name == DefaultBranchName
+		}
+		return branches[i].Timestamp.Before(branches[j].Timestamp)
+	})
+	return branches, nil
+}
+
+// WriteMerge adds an operation to a branch log recording that the version at
+// path merged the version at mergedPath, which becomes a second parent of the
+// version
+func (book *Book) WriteMerge(ctx context.Context, initID, branch, path, mergedPath string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteMerge: %s, branch: %s, path: %s, merged: %s", initID, branch, path, mergedPath)
+
+	branchLog, err := book.namedBranchLog(ctx, initID, branch)
+	if err != nil {
+		return err
+	}
+	if err := book.hasWriteAccess(branchLog.l); err != nil {
+		return err
+	}
+
+	branchLog.Append(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     MergeModel,
+		Ref:       path,
+		Relations: []string{mergedPath},
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// MergeParents maps versions of a dataset that were saved by a merge to the
+// versions they merged, across all branches
+func (book *Book) MergeParents(ctx context.Context, ref dsref.Ref) (map[string][]string, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return nil, err
+	}
+	dsLog, err := book.datasetLog(ctx, initID)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := map[string][]string{}
+	for _, l := range dsLog.l.Logs {
+		for _, op := range l.Ops {
+			if op.Model == MergeModel && op.Type == oplog.OpTypeInit {
+				parents[op.Ref] = append(parents[op.Ref], op.Relations...)
+			}
+		}
+	}
+	return parents, nil
+}
+
 // SetChangeHook assigns a hook that will be called when a dataset changes
 func (book *Book) SetChangeHook(changeHook func(hook.DsChange)) {
 	book.onChangeHook = changeHook
@@ -960,13 +1498,20 @@ func itemFromOp(ref dsref.Ref, op oplog.Op) DatasetLogItem {
 	}
 }
 
-// Items collapses the history of a dataset branch into linear log items
+// Items collapses the history of a dataset's default branch into linear log
+// items
 func (book Book) Items(ctx context.Context, ref dsref.Ref, offset, limit int) ([]DatasetLogItem, error) {
+	return book.BranchItems(ctx, ref, DefaultBranchName, offset, limit)
+}
+
+// BranchItems collapses the history of a named dataset branch into linear log
+// items
+func (book Book) BranchItems(ctx context.Context, ref dsref.Ref, branch string, offset, limit int) ([]DatasetLogItem, error) {
 	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
 	if err != nil {
 		return nil, err
 	}
-	branchLog, err := book.branchLog(ctx, initID)
+	branchLog, err := book.namedBranchLog(ctx, initID, branch)
 	if err != nil {
 		return nil, err
 	}
@@ -974,6 +1519,70 @@ func (book Book) Items(ctx context.Context, ref dsref.Ref, offset, limit int) ([
 	return branchToLogItems(branchLog, ref, offset, limit, true), nil
 }
 
+// ResolveRevision selects the version a revision refers to from the history
+// of a dataset. rev.Ref must have a resolved username & dataset name. When
+// rev.Ref has a path or rev has a tag, steps back are counted from that
+// version, otherwise from the latest version of rev.Branch. The returned
+// reference has the selected version's path
+func (book Book) ResolveRevision(ctx context.Context, rev dsref.Revision) (dsref.Ref, error) {
+	ref := rev.Ref
+	initID, err := book.RefToInitID(dsref.Ref{Username: ref.Username, Name: ref.Name})
+	if err != nil {
+		return ref, err
+	}
+	blog, err := book.namedBranchLog(ctx, initID, rev.Branch)
+	if err != nil {
+		return ref, err
+	}
+	items := branchToLogItems(blog, ref, 0, -1, true)
+	if len(items) == 0 {
+		return ref, dsref.ErrNoHistory
+	}
+
+	start := 0
+	if rev.Tag != "" {
+		// tags are kept on the default branch
+		main, err := book.branchLog(ctx, initID)
+		if err != nil {
+			return ref, err
+		}
+		tag, ok := branchTags(main)[rev.Tag]
+		if !ok {
+			return ref, fmt.Errorf("%w: %s has no tag %q", ErrTagNotFound, ref.Human(), rev.Tag)
+		}
+		ref.Path = tag.Path
+	}
+	if ref.Path != "" {
+		start = -1
+		for i, item := range items {
+			if item.Path == ref.Path {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return ref, fmt.Errorf("version %s is not in the history of %s", ref.Path, ref.Human())
+		}
+	} else if !rev.AsOf.IsZero() {
+		start = -1
+		for i, item := range items {
+			if !item.CommitTime.After(rev.AsOf) {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return ref, fmt.Errorf("%s has no versions saved at or before %s", ref.Human(), rev.AsOf.Format(time.RFC3339))
+		}
+	}
+
+	if i := start + rev.Back; i < len(items) {
+		ref.Path = items[i].Path
+		return ref, nil
+	}
+	return ref, fmt.Errorf("cannot resolve %s: only %d earlier version(s) exist", rev, len(items)-1-start)
+}
+
 // ConvertLogsToItems collapses the history of a dataset branch into linear log items
 func ConvertLogsToItems(l *oplog.Log, ref dsref.Ref) []DatasetLogItem {
 	return branchToLogItems(newBranchLog(l), ref, 0, -1, true)
@@ -1023,6 +1632,17 @@ func branchToLogItems(blog *BranchLog, ref dsref.Ref, offset, limit int, collaps
 		}
 	}
 
+	for _, tag := range branchTags(blog) {
+		for i := range refs {
+			if refs[i].Path == tag.Path {
+				refs[i].Tags = append(refs[i].Tags, tag.Name)
+			}
+		}
+	}
+	for i := range refs {
+		sort.Strings(refs[i].Tags)
+	}
+
 	// reverse the slice, placing newest first
 	// https://github.com/golang/go/wiki/SliceTricks#reversing
 	for i := len(refs)/2 - 1; i >= 0; i-- {
@@ -1085,6 +1705,9 @@ var actionStrings = map[uint32][3]string{
 	CommitModel:      [3]string{"save commit", "amend commit", "remove commit"},
 	PublicationModel: [3]string{"publish", "", "unpublish"},
 	ACLModel:         [3]string{"update access", "update access", "remove all access"},
+	RunModel:         [3]string{"init runs", "run transform", ""},
+	TagModel:         [3]string{"tag version", "", "remove tag"},
+	MergeModel:       [3]string{"merge version", "", ""},
 }
 
 func logEntryFromOp(author string, op oplog.Op) LogEntry {
@@ -1153,6 +1776,8 @@ type DatasetLogItem struct {
 	CommitTitle string `json:"commitTitle,omitempty"`
 	// Message field from the commit
 	CommitMessage string `json:"commitMessage,omitempty"`
+	// Tags labelling this version
+	Tags []string `json:"tags,omitempty"`
 }
 
 // PlainOp is a human-oriented representation of oplog.Op intended for serialization
@@ -805,6 +805,215 @@ func TestItems(t *testing.T) {
 	}
 }
 
+func TestResolveRevision(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	initID := tr.WriteWorldBankExample(t)
+	tr.WriteMoreWorldBankCommits(t, initID)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	cases := []struct {
+		rev  dsref.Revision
+		path string
+	}{
+		{dsref.Revision{Ref: ref}, "QmHashOfVersion5"},
+		{dsref.Revision{Ref: ref, Back: 2}, "QmHashOfVersion3"},
+		{dsref.Revision{Ref: dsref.Ref{Username: ref.Username, Name: ref.Name, Path: "QmHashOfVersion4"}, Back: 1}, "QmHashOfVersion3"},
+		{dsref.Revision{Ref: ref, AsOf: mustTime("2000-01-04T12:00:00Z")}, "QmHashOfVersion4"},
+		{dsref.Revision{Ref: ref, AsOf: mustTime("2000-01-04T12:00:00Z"), Back: 1}, "QmHashOfVersion3"},
+	}
+	for i, c := range cases {
+		got, err := book.ResolveRevision(tr.Ctx, c.rev)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+			continue
+		}
+		if got.Path != c.path {
+			t.Errorf("case %d: path mismatch. expected: %q, got: %q", i, c.path, got.Path)
+		}
+	}
+
+	bad := []dsref.Revision{
+		{Ref: ref, Back: 3},
+		{Ref: ref, AsOf: mustTime("1999-01-01T00:00:00Z")},
+		{Ref: dsref.Ref{Username: ref.Username, Name: ref.Name, Path: "QmHashOfVersion1"}},
+	}
+	for i, rev := range bad {
+		if _, err := book.ResolveRevision(tr.Ctx, rev); err == nil {
+			t.Errorf("bad case %d: expected error, got nil", i)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	initID := tr.WriteWorldBankExample(t)
+	tr.WriteMoreWorldBankCommits(t, initID)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	if err := book.WriteTag(tr.Ctx, initID, "v1", "QmHashOfVersion3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteTag(tr.Ctx, initID, "v2", "QmHashOfVersion4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteTag(tr.Ctx, initID, "v1", "QmHashOfVersion5"); !errors.Is(err, logbook.ErrTagExists) {
+		t.Errorf("expected adding an existing tag to fail with ErrTagExists, got: %v", err)
+	}
+	if err := book.WriteTag(tr.Ctx, initID, "gone", "QmHashOfVersion2"); err == nil {
+		t.Errorf("expected tagging a removed version to fail")
+	}
+	if err := book.WriteTag(tr.Ctx, initID, "bad..name", "QmHashOfVersion5"); err == nil {
+		t.Errorf("expected an invalid tag name to fail")
+	}
+
+	// moving a tag is a delete followed by an add
+	if err := book.WriteTagDelete(tr.Ctx, initID, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteTagDelete(tr.Ctx, initID, "v1"); !errors.Is(err, logbook.ErrTagNotFound) {
+		t.Errorf("expected removing a missing tag to fail with ErrTagNotFound, got: %v", err)
+	}
+	if err := book.WriteTag(tr.Ctx, initID, "v1", "QmHashOfVersion4"); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := book.Tags(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, tag := range tags {
+		names = append(names, tag.Name+":"+tag.Path)
+	}
+	if diff := cmp.Diff([]string{"v2:QmHashOfVersion4", "v1:QmHashOfVersion4"}, names); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+
+	items, err := book.Items(tr.Ctx, ref, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected tags not to add log items, got %d items", len(items))
+	}
+	if diff := cmp.Diff([]string{"v1", "v2"}, items[1].Tags); diff != "" {
+		t.Errorf("item tags mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := book.ResolveRevision(tr.Ctx, dsref.Revision{Ref: ref, Tag: "v2", Back: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "QmHashOfVersion3" {
+		t.Errorf("expected v2~1 to resolve to QmHashOfVersion3, got: %q", got.Path)
+	}
+	if _, err := book.ResolveRevision(tr.Ctx, dsref.Revision{Ref: ref, Tag: "nope"}); !errors.Is(err, logbook.ErrTagNotFound) {
+		t.Errorf("expected resolving a missing tag to fail with ErrTagNotFound, got: %v", err)
+	}
+}
+
+func TestBranches(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	initID := tr.WriteWorldBankExample(t)
+	tr.WriteMoreWorldBankCommits(t, initID)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	if err := book.WriteBranchInit(tr.Ctx, initID, "cleanup", "", "QmHashOfVersion4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteBranchInit(tr.Ctx, initID, "cleanup", "", ""); !errors.Is(err, logbook.ErrBranchExists) {
+		t.Errorf("expected creating an existing branch to fail with ErrBranchExists, got: %v", err)
+	}
+	if err := book.WriteBranchInit(tr.Ctx, initID, "main", "", ""); !errors.Is(err, logbook.ErrBranchExists) {
+		t.Errorf("expected creating the main branch to fail with ErrBranchExists, got: %v", err)
+	}
+	if err := book.WriteBranchInit(tr.Ctx, initID, "bad.name", "", ""); err == nil {
+		t.Errorf("expected an invalid branch name to fail")
+	}
+
+	ds := &dataset.Dataset{
+		Peername: tr.Username,
+		Name:     "world_bank_population",
+		Commit: &dataset.Commit{
+			Timestamp: time.Date(2000, time.January, 6, 0, 0, 0, 0, time.UTC),
+			Title:     "drop empty rows",
+		},
+		Path:         "QmHashOfCleanup1",
+		PreviousPath: "QmHashOfVersion4",
+	}
+	if err := book.WriteBranchVersionSave(tr.Ctx, initID, "cleanup", ds); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := book.BranchItems(tr.Ctx, ref, "cleanup", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	if diff := cmp.Diff([]string{"QmHashOfCleanup1", "QmHashOfVersion4", "QmHashOfVersion3"}, paths); diff != "" {
+		t.Errorf("branch items mismatch (-want +got):\n%s", diff)
+	}
+
+	// saving to a branch leaves the default branch alone
+	items, err = book.Items(tr.Ctx, ref, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Path != "QmHashOfVersion5" {
+		t.Errorf("expected main head to be QmHashOfVersion5, got: %q", items[0].Path)
+	}
+
+	got, err := book.ResolveRevision(tr.Ctx, dsref.Revision{Ref: ref, Branch: "cleanup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "QmHashOfCleanup1" {
+		t.Errorf("expected branch to resolve to QmHashOfCleanup1, got: %q", got.Path)
+	}
+
+	branches, err := book.Branches(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := []string{}
+	for _, b := range branches {
+		summary = append(summary, fmt.Sprintf("%s:%s:%d", b.Name, b.Path, b.Versions))
+	}
+	if diff := cmp.Diff([]string{"main:QmHashOfVersion5:3", "cleanup:QmHashOfCleanup1:3"}, summary); diff != "" {
+		t.Errorf("branches mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := book.WriteBranchDelete(tr.Ctx, initID, "main"); err == nil {
+		t.Errorf("expected deleting the main branch to fail")
+	}
+	if err := book.WriteBranchDelete(tr.Ctx, initID, "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := book.BranchItems(tr.Ctx, ref, "cleanup", 0, 10); !errors.Is(err, logbook.ErrBranchNotFound) {
+		t.Errorf("expected a deleted branch to fail with ErrBranchNotFound, got: %v", err)
+	}
+	// versions saved only to a deleted branch are still referenced
+	referenced, err := book.AllReferencedDatasetPaths(tr.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := referenced["QmHashOfCleanup1"]; !ok {
+		t.Errorf("expected branch versions to be referenced")
+	}
+}
+
 func TestConstructDatasetLog(t *testing.T) {
 	tr, cleanup := newTestRunner(t)
 	defer cleanup()
@@ -1119,3 +1328,82 @@ func GenerateExampleOplog(ctx context.Context, t *testing.T, journal *logbook.Bo
 
 	return initID, lg
 }
+
+func TestTransformRuns(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	initID := tr.WriteWorldBankExample(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	before, err := book.Items(tr.Ctx, ref, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := []logbook.TransformRun{
+		{Timestamp: mustTime("2000-01-05T19:00:00-05:00"), Status: logbook.RunSucceeded, Path: "QmHashOfVersion4"},
+		{Timestamp: mustTime("2000-01-06T19:00:00-05:00"), Status: logbook.RunUnchanged},
+		{Timestamp: mustTime("2000-01-07T19:00:00-05:00"), Status: logbook.RunFailed, Message: "transform error: oh noes"},
+	}
+	for _, run := range runs {
+		if err := book.WriteTransformRun(tr.Ctx, initID, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := book.TransformRuns(tr.Ctx, ref, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []logbook.TransformRun{runs[2], runs[1], runs[0]}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = book.TransformRuns(tr.Ctx, ref, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect[1:2], got); diff != "" {
+		t.Errorf("offset & limit result mismatch (-want +got):\n%s", diff)
+	}
+
+	// runs don't change dataset history
+	items, err := book.Items(tr.Ctx, ref, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(before, items); diff != "" {
+		t.Errorf("expected runs not to change versions (-want +got):\n%s", diff)
+	}
+	resolved := dsref.Ref{Username: ref.Username, Name: ref.Name}
+	if _, err := book.ResolveRef(tr.Ctx, &resolved); err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Path != items[0].Path {
+		t.Errorf("expected head to resolve to %q, got %q", items[0].Path, resolved.Path)
+	}
+
+	// runs are kept out of the dataset log that's synced with peers
+	dsLog, err := book.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runOps func(l *oplog.Log) int
+	runOps = func(l *oplog.Log) (n int) {
+		for _, op := range l.Ops {
+			if op.Model == logbook.RunModel {
+				n++
+			}
+		}
+		for _, child := range l.Logs {
+			n += runOps(child)
+		}
+		return n
+	}
+	if n := runOps(dsLog); n != 0 {
+		t.Errorf("expected synced dataset log to have no run ops, found %d", n)
+	}
+}
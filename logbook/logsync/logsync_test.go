@@ -296,6 +296,65 @@ func TestHookErrors(t *testing.T) {
 	}
 }
 
+func TestSyncTags(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	a, b := tr.DefaultLogsyncs()
+	server := httptest.NewServer(HTTPHandler(a))
+	defer server.Close()
+
+	ref, err := writeNasdaqLogs(tr.Ctx, tr.A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initID, err := tr.A.RefToInitID(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.A.WriteTag(tr.Ctx, initID, "first", "v0"); err != nil {
+		t.Fatal(err)
+	}
+
+	pull := func() {
+		p, err := b.NewPull(ref, server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Merge = true
+		if _, err := p.Do(tr.Ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pull()
+	rev := dsref.Revision{Ref: ref, Tag: "first"}
+	got, err := tr.B.ResolveRevision(tr.Ctx, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "v0" {
+		t.Errorf("expected pulled tag to resolve to v0, got: %q", got.Path)
+	}
+
+	if err := tr.A.WriteTag(tr.Ctx, initID, "latest", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	pull()
+
+	expect, err := tr.A.Tags(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := tr.B.Tags(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, tags); diff != "" {
+		t.Errorf("tags mismatch. (-want +got):\n%s", diff)
+	}
+}
+
 func TestNilCallable(t *testing.T) {
 	var logsync *Logsync
 
@@ -60,6 +60,11 @@ func (dlog *DatasetLog) InitID() string {
 	return dlog.l.ID()
 }
 
+// AddChild adds a branch log to the DatasetLog
+func (dlog *DatasetLog) AddChild(l *oplog.Log) {
+	dlog.l.AddChild(l)
+}
+
 // BranchLog is the bottom-level log representing a branch of a dataset history
 type BranchLog struct {
 	l *oplog.Log
@@ -76,7 +81,7 @@ func newBranchLog(l *oplog.Log) *BranchLog {
 
 // Append adds an op to the BranchLog
 func (blog *BranchLog) Append(op oplog.Op) {
-	if op.Model != BranchModel && op.Model != CommitModel && op.Model != PublicationModel {
+	if op.Model != BranchModel && op.Model != CommitModel && op.Model != PublicationModel && op.Model != TagModel && op.Model != MergeModel {
 		log.Errorf("cannot Append, incorrect model %d for BranchLog", op.Model)
 		return
 	}
@@ -13,19 +13,7 @@ import (
 
 // SearchFilter stores various types of filters that may be applied
 // to a search
-type SearchFilter struct {
-	// Type denotes the ype of search filter
-	Type string
-	// Relation indicates the relation between the key and value
-	// supported options include ["eq"|"neq"|"gt"|"gte"|"lt"|"lte"]
-	Relation string
-	// Key corresponds to the name of the index mapping that we wish to
-	// apply the filter to
-	Key string
-	// Value is the predicate of the subject-relation-predicate triple
-	// eg. [key=timestamp] [gte] [value=[today]]
-	Value interface{}
-}
+type SearchFilter = registry.SearchFilter
 
 // SearchParams contains the parameters that are passed to a
 // Client.Search method
@@ -39,10 +27,10 @@ type SearchParams struct {
 // Search makes a registry search request
 func (c Client) Search(p *SearchParams) ([]*dataset.Dataset, error) {
 	params := &registry.SearchParams{
-		Q: p.QueryString,
-		//Filters: p.Filters,
-		Limit:  p.Limit,
-		Offset: p.Offset,
+		Q:       p.QueryString,
+		Filters: p.Filters,
+		Limit:   p.Limit,
+		Offset:  p.Offset,
 	}
 	results, err := c.doJSONSearchReq("GET", params)
 	if err != nil {
@@ -77,6 +65,9 @@ func (c Client) prepGetReq(s *registry.SearchParams) (*http.Request, error) {
 	if s.Offset > -1 {
 		q.Add("offset", fmt.Sprintf("%d", s.Offset))
 	}
+	for _, f := range s.Filters {
+		q.Add("filter", f.String())
+	}
 	req.URL.RawQuery = q.Encode()
 	return req, nil
 }
@@ -4,6 +4,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/registry"
 	"github.com/qri-io/qri/registry/regserver/handlers"
 )
@@ -27,3 +28,39 @@ func TestSearchMethods(t *testing.T) {
 		t.Errorf("error executing search: %s", err)
 	}
 }
+
+func TestSearchFilters(t *testing.T) {
+	idx := registry.NewSearchIndex()
+	err := idx.IndexDatasets([]*dataset.Dataset{
+		{Peername: "a", Name: "census_csv", Meta: &dataset.Meta{Title: "census"}, Structure: &dataset.Structure{Format: "csv"}},
+		{Peername: "a", Name: "census_json", Meta: &dataset.Meta{Title: "census"}, Structure: &dataset.Structure{Format: "json"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{
+		Profiles: registry.NewMemProfiles(),
+		Search:   idx,
+	}))
+	c := NewClient(&Config{Location: srv.URL})
+
+	res, err := c.Search(&SearchParams{
+		QueryString: "census",
+		Filters:     []SearchFilter{{Key: registry.FilterFormat, Relation: "eq", Value: "json"}},
+		Limit:       10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Name != "census_json" {
+		t.Errorf("expected filtered search to return census_json, got %d results", len(res))
+	}
+
+	_, err = c.Search(&SearchParams{
+		QueryString: "census",
+		Filters:     []SearchFilter{{Key: "color", Value: "blue"}},
+	})
+	if err == nil {
+		t.Error("expected an unknown filter key to error")
+	}
+}
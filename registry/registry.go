@@ -25,9 +25,12 @@ package registry
 import (
 	"fmt"
 
+	golog "github.com/ipfs/go-log"
 	"github.com/qri-io/qri/remote"
 )
 
+var log = golog.Logger("registry")
+
 // Registry a collection of interfaces that together form a registry service
 type Registry struct {
 	Remote   *remote.Remote
@@ -30,6 +30,14 @@ func NewSearchHandler(s registry.Searchable) http.HandlerFunc {
 			p.Limit = apiutil.ReqParamInt(r, "limit", defaultLimit)
 			p.Offset = apiutil.ReqParamInt(r, "offset", defaultOffset)
 			p.Q = r.FormValue("q")
+			for _, str := range r.URL.Query()["filter"] {
+				f, err := registry.ParseSearchFilter(str)
+				if err != nil {
+					apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+					return
+				}
+				p.Filters = append(p.Filters, f)
+			}
 		}
 		switch r.Method {
 		case "GET":
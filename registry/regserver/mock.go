@@ -82,7 +82,8 @@ func NewTempRegistry(ctx context.Context, peername, tmpDirPrefix string, g gen.C
 		AllowRemoves:     true,
 	}
 
-	rem, err := remote.NewRemote(node, remoteCfg)
+	idx := registry.NewSearchIndex()
+	rem, err := registry.NewIndexedRemote(ctx, node, remoteCfg, idx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -90,7 +91,8 @@ func NewTempRegistry(ctx context.Context, peername, tmpDirPrefix string, g gen.C
 	reg := &registry.Registry{
 		Remote:   rem,
 		Profiles: registry.NewMemProfiles(),
-		Search:   MockRepoSearch{Repo: r},
+		Search:   idx,
+		Indexer:  idx,
 	}
 
 	return reg, teardown, nil
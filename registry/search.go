@@ -3,6 +3,7 @@ package registry
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/qri-io/dataset"
 )
@@ -24,9 +25,81 @@ type Indexer interface {
 // SearchParams encapsulates parameters provided to Searchable.Search
 type SearchParams struct {
 	Q             string
+	Filters       []SearchFilter
 	Limit, Offset int
 }
 
+// Search filter keys name the dataset facets a search can be filtered by
+const (
+	// FilterKeywords matches datasets with a meta keyword
+	FilterKeywords = "keywords"
+	// FilterLicense matches datasets by meta license type
+	FilterLicense = "license"
+	// FilterTheme matches datasets with a meta theme
+	FilterTheme = "theme"
+	// FilterPublisher matches datasets by the username of the peer that
+	// published them
+	FilterPublisher = "publisher"
+	// FilterFormat matches datasets by body format
+	FilterFormat = "format"
+	// FilterUpdated compares the commit timestamp of a dataset's latest
+	// version to a time
+	FilterUpdated = "updated"
+)
+
+// SearchFilter stores various types of filters that may be applied
+// to a search
+type SearchFilter struct {
+	// Type denotes the type of search filter
+	Type string
+	// Relation indicates the relation between the key and value
+	// supported options include ["eq"|"neq"|"gt"|"gte"|"lt"|"lte"]
+	Relation string
+	// Key corresponds to the name of the index mapping that we wish to
+	// apply the filter to
+	Key string
+	// Value is the predicate of the subject-relation-predicate triple
+	// eg. [key=timestamp] [gte] [value=[today]]
+	Value interface{}
+}
+
+// ErrInvalidSearchFilter indicates a search filter can't be applied
+var ErrInvalidSearchFilter = fmt.Errorf("invalid search filter")
+
+// searchRelations are the supported search filter relations
+var searchRelations = map[string]bool{
+	"eq": true, "neq": true, "gt": true, "gte": true, "lt": true, "lte": true,
+}
+
+// ParseSearchFilter parses a search filter from a string of the form
+// "key:value" or "key:relation:value", eg: "keywords:health" or
+// "updated:gte:2020-01-01". A filter without a relation uses "eq"
+func ParseSearchFilter(str string) (SearchFilter, error) {
+	parts := strings.SplitN(str, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return SearchFilter{}, fmt.Errorf("%w %q, expected key:value or key:relation:value", ErrInvalidSearchFilter, str)
+	}
+	f := SearchFilter{Key: parts[0], Relation: "eq", Value: strings.Join(parts[1:], ":")}
+	if len(parts) == 3 && searchRelations[parts[1]] {
+		f.Relation = parts[1]
+		f.Value = parts[2]
+	}
+	return f, nil
+}
+
+// String formats a filter in the form ParseSearchFilter reads
+func (f SearchFilter) String() string {
+	relation := f.Relation
+	if relation == "" {
+		relation = "eq"
+	}
+	value := f.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s:%s:%v", f.Key, relation, value)
+}
+
 // ErrSearchNotSupported is the canonical error to indicate search
 // isn't implemented
 var ErrSearchNotSupported = fmt.Errorf("search not supported")
@@ -0,0 +1,385 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/search"
+)
+
+// SearchIndex is an in-memory search index of registry datasets. Results are
+// ranked by relevance to the query & can be filtered by facet. A search with
+// an empty query lists all datasets that pass its filters, most recently
+// updated first. SearchIndex is safe for concurrent use
+type SearchIndex struct {
+	lk       sync.RWMutex
+	docs     map[string]*dataset.Dataset
+	postings *search.Postings
+}
+
+var (
+	_ Searchable = (*SearchIndex)(nil)
+	_ Indexer    = (*SearchIndex)(nil)
+)
+
+// NewSearchIndex creates an empty search index
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     map[string]*dataset.Dataset{},
+		postings: search.NewPostings(),
+	}
+}
+
+// IndexDatasets adds datasets to the index, replacing any previously indexed
+// version of the same peername & name
+func (idx *SearchIndex) IndexDatasets(datasets []*dataset.Dataset) error {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	for _, ds := range datasets {
+		id, err := searchID(ds)
+		if err != nil {
+			return err
+		}
+		idx.docs[id] = ds
+		idx.postings.Add(id, datasetTerms(ds))
+	}
+	return nil
+}
+
+// UnindexDatasets removes datasets from the index
+func (idx *SearchIndex) UnindexDatasets(datasets []*dataset.Dataset) error {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	for _, ds := range datasets {
+		id, err := searchID(ds)
+		if err != nil {
+			return err
+		}
+		delete(idx.docs, id)
+		idx.postings.Remove(id)
+	}
+	return nil
+}
+
+// Search returns datasets that match every term of a query & pass all filters
+func (idx *SearchIndex) Search(p SearchParams) ([]*dataset.Dataset, error) {
+	filters := make([]facetFilter, len(p.Filters))
+	for i, f := range p.Filters {
+		ff, err := newFacetFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = ff
+	}
+
+	idx.lk.RLock()
+	defer idx.lk.RUnlock()
+
+	var scores map[string]float64
+	if terms := search.Tokenize(p.Q); len(terms) > 0 {
+		scores = idx.postings.Match(terms)
+	} else {
+		scores = make(map[string]float64, len(idx.docs))
+		for id := range idx.docs {
+			scores[id] = 0
+		}
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		if matchesFilters(idx.docs[id], filters) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if ta, tb := updated(idx.docs[a]), updated(idx.docs[b]); !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+		return a < b
+	})
+
+	if p.Offset > len(ids) {
+		p.Offset = len(ids)
+	}
+	ids = ids[p.Offset:]
+	if p.Limit > 0 && p.Limit < len(ids) {
+		ids = ids[:p.Limit]
+	}
+
+	results := make([]*dataset.Dataset, len(ids))
+	for i, id := range ids {
+		results[i] = idx.docs[id]
+	}
+	return results, nil
+}
+
+// searchID is the key for a dataset in the index
+func searchID(ds *dataset.Dataset) (string, error) {
+	if ds == nil || ds.Peername == "" || ds.Name == "" {
+		return "", fmt.Errorf("indexing datasets requires a peername & name")
+	}
+	return fmt.Sprintf("%s/%s", ds.Peername, ds.Name), nil
+}
+
+// datasetTerms collects the weighted search terms of a dataset
+func datasetTerms(ds *dataset.Dataset) search.Terms {
+	terms := search.Terms{}
+	terms.Add(ds.Name, search.NameWeight)
+	if ds.Meta != nil {
+		terms.Add(ds.Meta.Title, search.TitleWeight)
+		terms.Add(ds.Meta.Description, search.DescriptionWeight)
+		for _, kw := range ds.Meta.Keywords {
+			terms.Add(kw, search.KeywordWeight)
+		}
+		for _, theme := range ds.Meta.Theme {
+			terms.Add(theme, search.ThemeWeight)
+		}
+	}
+	if ds.Structure != nil {
+		for _, name := range search.FieldNames(ds.Structure) {
+			terms.Add(name, search.FieldWeight)
+		}
+	}
+	return terms
+}
+
+// updated gives the time a dataset was last changed
+func updated(ds *dataset.Dataset) time.Time {
+	if ds.Commit != nil {
+		return ds.Commit.Timestamp
+	}
+	return time.Time{}
+}
+
+// facetFilter is a validated search filter
+type facetFilter struct {
+	key, relation string
+	value         string
+	t             time.Time
+}
+
+// newFacetFilter checks a search filter can be applied
+func newFacetFilter(f SearchFilter) (facetFilter, error) {
+	ff := facetFilter{key: f.Key, relation: f.Relation}
+	if ff.relation == "" {
+		ff.relation = "eq"
+	}
+	if !searchRelations[ff.relation] {
+		return ff, fmt.Errorf("%w: unknown relation %q", ErrInvalidSearchFilter, f.Relation)
+	}
+
+	switch f.Key {
+	case FilterKeywords, FilterLicense, FilterTheme, FilterPublisher, FilterFormat:
+		if ff.relation != "eq" && ff.relation != "neq" {
+			return ff, fmt.Errorf("%w: %q filters only support eq & neq relations", ErrInvalidSearchFilter, f.Key)
+		}
+		ff.value = strings.ToLower(strings.TrimSpace(fmt.Sprintf("%v", f.Value)))
+	case FilterUpdated:
+		switch v := f.Value.(type) {
+		case time.Time:
+			ff.t = v
+		case string:
+			t, err := parseFilterTime(v)
+			if err != nil {
+				return ff, fmt.Errorf("%w: %s", ErrInvalidSearchFilter, err)
+			}
+			ff.t = t
+		default:
+			return ff, fmt.Errorf("%w: %q filter value must be a time", ErrInvalidSearchFilter, f.Key)
+		}
+	default:
+		return ff, fmt.Errorf("%w: unknown key %q", ErrInvalidSearchFilter, f.Key)
+	}
+	return ff, nil
+}
+
+// parseFilterTime reads a time as an RFC3339 timestamp or a date
+func parseFilterTime(str string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q, expected RFC3339 timestamp or YYYY-MM-DD date", str)
+	}
+	return t, nil
+}
+
+// matchesFilters returns true if a dataset passes every filter
+func matchesFilters(ds *dataset.Dataset, filters []facetFilter) bool {
+	for _, f := range filters {
+		if !f.match(ds) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f facetFilter) match(ds *dataset.Dataset) bool {
+	if f.key == FilterUpdated {
+		t := updated(ds)
+		switch f.relation {
+		case "eq":
+			return t.Equal(f.t)
+		case "neq":
+			return !t.Equal(f.t)
+		case "gt":
+			return t.After(f.t)
+		case "gte":
+			return !t.Before(f.t)
+		case "lt":
+			return t.Before(f.t)
+		default:
+			return !t.After(f.t)
+		}
+	}
+
+	has := false
+	for _, v := range facetValues(ds, f.key) {
+		if strings.ToLower(v) == f.value {
+			has = true
+			break
+		}
+	}
+	if f.relation == "neq" {
+		return !has
+	}
+	return has
+}
+
+// facetValues gives the values a dataset has for a facet
+func facetValues(ds *dataset.Dataset, key string) []string {
+	switch key {
+	case FilterPublisher:
+		return []string{ds.Peername}
+	case FilterFormat:
+		if ds.Structure != nil {
+			return []string{ds.Structure.Format}
+		}
+	case FilterKeywords:
+		if ds.Meta != nil {
+			return ds.Meta.Keywords
+		}
+	case FilterTheme:
+		if ds.Meta != nil {
+			return ds.Meta.Theme
+		}
+	case FilterLicense:
+		if ds.Meta != nil && ds.Meta.License != nil {
+			return []string{ds.Meta.License.Type}
+		}
+	}
+	return nil
+}
+
+// IndexRepo adds the latest version of every dataset in a repo to an index.
+// Datasets that fail to load are logged & skipped
+func IndexRepo(ctx context.Context, r repo.Repo, idx Indexer) error {
+	num, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return err
+	}
+
+	datasets := make([]*dataset.Dataset, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		ds, err := loadIndexDataset(ctx, r, ref)
+		if err != nil {
+			// one unloadable dataset shouldn't keep the rest out of the index
+			log.Errorf("indexing dataset %s: %s", ref, err)
+			continue
+		}
+		datasets = append(datasets, ds)
+	}
+	return idx.IndexDatasets(datasets)
+}
+
+// NewIndexedRemote creates the remote a registry node accepts datasets with,
+// keeping idx up to date. The index starts with the latest version of every
+// dataset already in the node's repo, and follows datasets pushed to &
+// removed from the remote afterward
+func NewIndexedRemote(ctx context.Context, node *p2p.QriNode, cfg *config.Remote, idx Indexer, opts ...func(o *remote.Options)) (*remote.Remote, error) {
+	opts = append(opts, OptIndexRemote(node.Repo, idx))
+	rem, err := remote.NewRemote(node, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// index after the remote is listening for pushes, so datasets pushed
+	// while the repo is indexed aren't missed
+	if err := IndexRepo(ctx, node.Repo, idx); err != nil {
+		return nil, fmt.Errorf("indexing repo: %w", err)
+	}
+	return rem, nil
+}
+
+// OptIndexRemote configures a remote to keep a search index up to date as
+// datasets are pushed to & removed from it. Indexing errors are logged
+// instead of failing the push or remove
+func OptIndexRemote(r repo.Repo, idx Indexer) func(o *remote.Options) {
+	return func(o *remote.Options) {
+		pushed, removed := o.DatasetPushed, o.DatasetRemoved
+
+		o.DatasetPushed = func(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+			if pushed != nil {
+				if err := pushed(ctx, pid, ref); err != nil {
+					return err
+				}
+			}
+			ds, err := loadIndexDataset(ctx, r, ref)
+			if err == nil {
+				err = idx.IndexDatasets([]*dataset.Dataset{ds})
+			}
+			if err != nil {
+				log.Errorf("indexing pushed dataset %s: %s", ref, err)
+			}
+			return nil
+		}
+
+		o.DatasetRemoved = func(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+			if removed != nil {
+				if err := removed(ctx, pid, ref); err != nil {
+					return err
+				}
+			}
+			ds := &dataset.Dataset{Peername: ref.Peername, Name: ref.Name}
+			if err := idx.UnindexDatasets([]*dataset.Dataset{ds}); err != nil {
+				log.Errorf("unindexing removed dataset %s: %s", ref, err)
+			}
+			return nil
+		}
+	}
+}
+
+// loadIndexDataset loads the version of a dataset a reference points to
+func loadIndexDataset(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) (*dataset.Dataset, error) {
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		return nil, err
+	}
+	ds.Peername = ref.Peername
+	ds.Name = ref.Name
+	ds.Path = ref.Path
+	return ds, nil
+}
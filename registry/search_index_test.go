@@ -0,0 +1,255 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	p2ptest "github.com/qri-io/qri/p2p/test"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func newSearchTestIndex(t *testing.T) *SearchIndex {
+	t.Helper()
+	idx := NewSearchIndex()
+	err := idx.IndexDatasets([]*dataset.Dataset{
+		{
+			Peername: "health_dept",
+			Name:     "hospital_beds",
+			Commit:   &dataset.Commit{Timestamp: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+			Meta: &dataset.Meta{
+				Title:    "Hospital beds by county",
+				Keywords: []string{"health", "hospitals"},
+				License:  &dataset.License{Type: "CC-BY-4.0"},
+				Theme:    []string{"Health"},
+			},
+			Structure: &dataset.Structure{Format: "csv"},
+		},
+		{
+			Peername: "health_dept",
+			Name:     "vaccinations",
+			Commit:   &dataset.Commit{Timestamp: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+			Meta: &dataset.Meta{
+				Title:       "Vaccination rates",
+				Description: "annual vaccination rates for county health districts",
+				Keywords:    []string{"health"},
+				License:     &dataset.License{Type: "CC0"},
+			},
+			Structure: &dataset.Structure{Format: "json"},
+		},
+		{
+			Peername: "transit",
+			Name:     "bus_stops",
+			Commit:   &dataset.Commit{Timestamp: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)},
+			Meta: &dataset.Meta{
+				Title: "Bus stop locations",
+				Theme: []string{"transportation"},
+			},
+			Structure: &dataset.Structure{
+				Format: "csv",
+				Schema: map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "array",
+						"items": []interface{}{
+							map[string]interface{}{"title": "stop_id"},
+							map[string]interface{}{"title": "county"},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestSearchIndex(t *testing.T) {
+	idx := newSearchTestIndex(t)
+
+	cases := []struct {
+		description string
+		params      SearchParams
+		expect      []string
+	}{
+		{"empty query lists newest first", SearchParams{}, []string{"bus_stops", "hospital_beds", "vaccinations"}},
+		{"title match", SearchParams{Q: "hospital"}, []string{"hospital_beds"}},
+		{"title ranks above description", SearchParams{Q: "county"}, []string{"hospital_beds", "bus_stops", "vaccinations"}},
+		{"every term must match", SearchParams{Q: "county bus"}, []string{"bus_stops"}},
+		{"prefix match", SearchParams{Q: "vacc"}, []string{"vaccinations"}},
+		{"no match", SearchParams{Q: "weather"}, []string{}},
+		{"limit", SearchParams{Limit: 1}, []string{"bus_stops"}},
+		{"offset", SearchParams{Offset: 2}, []string{"vaccinations"}},
+		{"offset past end", SearchParams{Offset: 10}, []string{}},
+
+		{"keywords", SearchParams{Filters: []SearchFilter{{Key: FilterKeywords, Value: "Health"}}}, []string{"hospital_beds", "vaccinations"}},
+		{"license", SearchParams{Filters: []SearchFilter{{Key: FilterLicense, Value: "cc0"}}}, []string{"vaccinations"}},
+		{"theme", SearchParams{Filters: []SearchFilter{{Key: FilterTheme, Value: "transportation"}}}, []string{"bus_stops"}},
+		{"publisher", SearchParams{Filters: []SearchFilter{{Key: FilterPublisher, Value: "health_dept"}}}, []string{"hospital_beds", "vaccinations"}},
+		{"format", SearchParams{Filters: []SearchFilter{{Key: FilterFormat, Value: "csv"}}}, []string{"bus_stops", "hospital_beds"}},
+		{"neq", SearchParams{Filters: []SearchFilter{{Key: FilterFormat, Relation: "neq", Value: "csv"}}}, []string{"vaccinations"}},
+		{"updated after", SearchParams{Filters: []SearchFilter{{Key: FilterUpdated, Relation: "gt", Value: "2020-01-01"}}}, []string{"bus_stops", "hospital_beds"}},
+		{"updated before", SearchParams{Filters: []SearchFilter{{Key: FilterUpdated, Relation: "lte", Value: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)}}}, []string{"hospital_beds", "vaccinations"}},
+		{"query & filters", SearchParams{Q: "county", Filters: []SearchFilter{
+			{Key: FilterFormat, Value: "csv"},
+			{Key: FilterUpdated, Relation: "gte", Value: "2020-06-01T00:00:00Z"},
+		}}, []string{"bus_stops"}},
+	}
+
+	for _, c := range cases {
+		res, err := idx.Search(c.params)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.description, err)
+			continue
+		}
+		got := make([]string, len(res))
+		for i, ds := range res {
+			got[i] = ds.Name
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s: result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+
+	bad := []SearchFilter{
+		{Key: "color", Value: "blue"},
+		{Key: FilterKeywords, Relation: "gt", Value: "health"},
+		{Key: FilterUpdated, Relation: "about", Value: "2020-01-01"},
+		{Key: FilterUpdated, Value: "last tuesday"},
+		{Key: FilterUpdated, Value: 2020},
+	}
+	for _, f := range bad {
+		if _, err := idx.Search(SearchParams{Filters: []SearchFilter{f}}); !errors.Is(err, ErrInvalidSearchFilter) {
+			t.Errorf("filter %s: expected invalid filter error, got: %v", f, err)
+		}
+	}
+}
+
+func TestSearchIndexUpdates(t *testing.T) {
+	idx := newSearchTestIndex(t)
+
+	// indexing a dataset with the same name replaces it
+	err := idx.IndexDatasets([]*dataset.Dataset{{
+		Peername: "transit",
+		Name:     "bus_stops",
+		Meta:     &dataset.Meta{Title: "Bus routes"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := idx.Search(SearchParams{Q: "locations"}); len(res) != 0 {
+		t.Errorf("expected replaced dataset not to match old terms, got %d results", len(res))
+	}
+	if res, _ := idx.Search(SearchParams{Q: "routes"}); len(res) != 1 {
+		t.Errorf("expected 1 result, got %d", len(res))
+	}
+
+	if err := idx.UnindexDatasets([]*dataset.Dataset{{Peername: "transit", Name: "bus_stops"}}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := idx.Search(SearchParams{}); len(res) != 2 {
+		t.Errorf("expected 2 results after removing a dataset, got %d", len(res))
+	}
+
+	if err := idx.IndexDatasets([]*dataset.Dataset{{Name: "no_peername"}}); err == nil {
+		t.Error("expected indexing a dataset without a peername to fail")
+	}
+}
+
+func TestNewIndexedRemote(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	nodes, _, err := p2ptest.MakeIPFSSwarm(ctx, true, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p2ptest.MakeRepoFromIPFSNode(ctx, nodes[0], "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(r, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// save a dataset before the remote starts
+	ds := &dataset.Dataset{
+		Name:      "hospital_beds",
+		Commit:    &dataset.Commit{Title: "initial commit"},
+		Meta:      &dataset.Meta{Title: "Hospital beds by county"},
+		Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[100]")))
+	initID, err := r.Logbook().WriteDatasetInit(ctx, ds.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := base.SaveDataset(ctx, r, r.Filesystem().DefaultWriteFS(), initID, "", ds, base.SaveSwitches{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a dataset that can't be loaded is skipped
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := reporef.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: "unloadable", Path: "/ipfs/QmNotAHash"}
+	if err := r.PutRef(bad); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewSearchIndex()
+	cfg := &config.Remote{Enabled: true, AcceptSizeMax: -1, AcceptTimeoutMs: -1}
+	if _, err := NewIndexedRemote(ctx, node, cfg, idx); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := idx.Search(SearchParams{Q: "hospital", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Name != "hospital_beds" {
+		t.Errorf("expected datasets saved before startup to be indexed, got: %v", res)
+	}
+}
+
+func TestParseSearchFilter(t *testing.T) {
+	cases := []struct {
+		str    string
+		expect SearchFilter
+	}{
+		{"keywords:health", SearchFilter{Key: "keywords", Relation: "eq", Value: "health"}},
+		{"format:neq:csv", SearchFilter{Key: "format", Relation: "neq", Value: "csv"}},
+		{"updated:gte:2020-01-01T00:00:00Z", SearchFilter{Key: "updated", Relation: "gte", Value: "2020-01-01T00:00:00Z"}},
+		{"updated:2020-01-01T00:00:00Z", SearchFilter{Key: "updated", Relation: "eq", Value: "2020-01-01T00:00:00Z"}},
+	}
+	for _, c := range cases {
+		got, err := ParseSearchFilter(c.str)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.str, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%q: result mismatch (-want +got):\n%s", c.str, diff)
+		}
+		if parsed, _ := ParseSearchFilter(got.String()); !cmp.Equal(got, parsed) {
+			t.Errorf("%q: expected filter to round trip through String, got: %v", c.str, parsed)
+		}
+	}
+
+	for _, str := range []string{"", "keywords", ":health"} {
+		if _, err := ParseSearchFilter(str); !errors.Is(err, ErrInvalidSearchFilter) {
+			t.Errorf("%q: expected invalid filter error, got: %v", str, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when a job runs
+type Schedule interface {
+	// Next returns the first time after t the schedule is due, or the zero
+	// time if the schedule is never due again
+	Next(t time.Time) time.Time
+}
+
+// descriptors are shorthands for common cron schedules
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule reads a cron-style schedule. Specs are either five
+// space-separated fields: minute, hour, day of month, month & day of week, a
+// descriptor like "@hourly" or "@daily", or "@every" followed by a duration,
+// like "@every 90m". Fields accept "*", values, ranges ("1-5"), steps ("*/15",
+// "0-30/10") and comma-separated lists of each. Days of the week run from 0
+// (Sunday) to 6, with 7 also accepted for Sunday
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be at least one second", spec)
+		}
+		return every(d), nil
+	}
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("invalid schedule %q: unknown descriptor", spec)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &cron{}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+	// 7 is an alias for sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = fields[2] == "*"
+	s.anyDow = fields[4] == "*"
+	return s, nil
+}
+
+// every is a schedule that's due at a fixed interval
+type every time.Duration
+
+// Next implements the Schedule interface
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cron is a schedule parsed from cron fields. Each field is a bitset of the
+// values it matches
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// when both day fields are restricted, a day matching either field is due
+	anyDom, anyDow bool
+}
+
+// maxSearch bounds how far into the future Next looks for a due time. Specs
+// like "0 0 30 2 *" are never due
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next implements the Schedule interface
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := has(c.dom, t.Day())
+	dow := has(c.dow, int(t.Weekday()))
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// parseField reads a comma-separated list of cron field expressions into a
+// bitset of values between min & max
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, expr := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(expr, "/"); i >= 0 {
+			n, err := strconv.Atoi(expr[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", expr[i+1:])
+			}
+			step = n
+			expr = expr[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case expr == "*":
+		case strings.Contains(expr, "-"):
+			parts := strings.SplitN(expr, "-", 2)
+			var err error
+			if lo, err = parseValue(parts[0], min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(parts[1], min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", expr)
+			}
+		default:
+			v, err := parseValue(expr, min, max)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(str string, min, max int) (int, error) {
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", str)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, min, max)
+	}
+	return v, nil
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	bad := []struct {
+		spec, err string
+	}{
+		{"", `invalid schedule "": expected 5 fields, got 0`},
+		{"* * * *", `invalid schedule "* * * *": expected 5 fields, got 4`},
+		{"60 * * * *", `invalid schedule "60 * * * *": minute: value 60 out of range 0-59`},
+		{"* 24 * * *", `invalid schedule "* 24 * * *": hour: value 24 out of range 0-23`},
+		{"* * 0 * *", `invalid schedule "* * 0 * *": day of month: value 0 out of range 1-31`},
+		{"* * * 13 *", `invalid schedule "* * * 13 *": month: value 13 out of range 1-12`},
+		{"* * * * 8", `invalid schedule "* * * * 8": day of week: value 8 out of range 0-7`},
+		{"*/0 * * * *", `invalid schedule "*/0 * * * *": minute: invalid step "0"`},
+		{"5-1 * * * *", `invalid schedule "5-1 * * * *": minute: invalid range "5-1"`},
+		{"a * * * *", `invalid schedule "a * * * *": minute: invalid value "a"`},
+		{"@fortnightly", `invalid schedule "@fortnightly": unknown descriptor`},
+		{"@every soon", `invalid schedule "@every soon": time: invalid duration "soon"`},
+		{"@every 10ms", `invalid schedule "@every 10ms": interval must be at least one second`},
+	}
+
+	for _, c := range bad {
+		_, err := ParseSchedule(c.spec)
+		if err == nil {
+			t.Errorf("spec %q: expected error, got nil", c.spec)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("spec %q: error mismatch. expected: %q, got: %q", c.spec, c.err, err.Error())
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// a wednesday
+	start := time.Date(2020, 7, 15, 10, 30, 20, 0, time.UTC)
+
+	cases := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{"* * * * *", time.Date(2020, 7, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 7, 15, 10, 45, 0, 0, time.UTC)},
+		{"0,20 * * * *", time.Date(2020, 7, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2020, 7, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2020, 7, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2020, 7, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 7, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * 2 *", time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{"0 0 20 * 5", time.Date(2020, 7, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 7, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 7, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, 7, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2020, 7, 15, 12, 0, 20, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, c := range cases {
+		s, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Errorf("spec %q: unexpected error: %s", c.spec, err)
+			continue
+		}
+		if got := s.Next(start); !got.Equal(c.expect) {
+			t.Errorf("spec %q: next mismatch. expected: %s, got: %s", c.spec, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package scheduler runs jobs on cron-style schedules
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	golog "github.com/ipfs/go-log"
+)
+
+var log = golog.Logger("scheduler")
+
+// RunFunc performs a scheduled job
+type RunFunc func(ctx context.Context, job Job) error
+
+// Job is a named task that runs on a schedule
+type Job struct {
+	// ID uniquely identifies the job
+	ID string
+	// Name is a human-readable description of the job
+	Name string
+	// Spec is the schedule string the job was created with
+	Spec string
+	// NextRun is the next time the job is due
+	NextRun time.Time
+	// LastRun is the time the job last started, zero if it hasn't run
+	LastRun time.Time
+	// LastError is the error the last run returned, if any
+	LastError string
+
+	schedule Schedule
+}
+
+// Scheduler keeps a set of jobs & runs each one when it's due. Jobs run one at
+// a time
+type Scheduler struct {
+	run RunFunc
+	// now returns the current time. overridden in tests
+	now func() time.Time
+
+	lk   sync.Mutex
+	jobs map[string]*Job
+	wake chan struct{}
+}
+
+// New creates a scheduler that performs jobs with run
+func New(run RunFunc) *Scheduler {
+	return &Scheduler{
+		run:  run,
+		now:  time.Now,
+		jobs: map[string]*Job{},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Schedule adds a job, replacing any existing job with the same ID. The job
+// is next due at the first time spec matches after now
+func (s *Scheduler) Schedule(id, name, spec string) error {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.lk.Lock()
+	if job, ok := s.jobs[id]; ok && job.Spec == spec {
+		// keep the existing job so an unchanged schedule doesn't reset
+		job.Name = name
+		s.lk.Unlock()
+		return nil
+	}
+	next := sched.Next(s.now())
+	if next.IsZero() {
+		s.lk.Unlock()
+		return fmt.Errorf("schedule %q is never due", spec)
+	}
+	s.jobs[id] = &Job{
+		ID:       id,
+		Name:     name,
+		Spec:     spec,
+		NextRun:  next,
+		schedule: sched,
+	}
+	s.lk.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// Unschedule removes a job. Removing a job that doesn't exist is a no-op
+func (s *Scheduler) Unschedule(id string) {
+	s.lk.Lock()
+	delete(s.jobs, id)
+	s.lk.Unlock()
+	s.notify()
+}
+
+// Jobs lists scheduled jobs ordered by the time they're next due
+func (s *Scheduler) Jobs() []Job {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].NextRun.Equal(jobs[j].NextRun) {
+			return jobs[i].ID < jobs[j].ID
+		}
+		return jobs[i].NextRun.Before(jobs[j].NextRun)
+	})
+	return jobs
+}
+
+// RunDue performs every job that's due, returning the number of jobs run.
+// Each job is rescheduled for the next time its spec matches
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	now := s.now()
+	ran := 0
+	for _, job := range s.Jobs() {
+		if job.NextRun.After(now) {
+			break
+		}
+		if ctx.Err() != nil {
+			return ran
+		}
+
+		log.Debugf("running job %q", job.Name)
+		errStr := ""
+		if err := s.run(ctx, job); err != nil {
+			log.Errorf("job %q: %s", job.Name, err)
+			errStr = err.Error()
+		}
+		ran++
+
+		s.lk.Lock()
+		// the job may have been removed or replaced while it ran
+		if j, ok := s.jobs[job.ID]; ok && j.Spec == job.Spec {
+			j.LastRun = now
+			j.LastError = errStr
+			j.NextRun = j.schedule.Next(s.now())
+			if j.NextRun.IsZero() {
+				delete(s.jobs, j.ID)
+			}
+		}
+		s.lk.Unlock()
+	}
+	return ran
+}
+
+// Start runs jobs as they come due, blocking until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) error {
+	for {
+		s.RunDue(ctx)
+
+		wait := time.Hour
+		if jobs := s.Jobs(); len(jobs) > 0 {
+			wait = jobs[0].NextRun.Sub(s.now())
+		}
+		t := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-s.wake:
+			t.Stop()
+		case <-t.C:
+		}
+	}
+}
+
+// notify wakes the run loop so it can account for changed jobs
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 7, 15, 10, 30, 0, 0, time.UTC)
+
+	ran := []string{}
+	s := New(func(ctx context.Context, job Job) error {
+		ran = append(ran, job.ID)
+		if job.ID == "b" {
+			return fmt.Errorf("oh noes")
+		}
+		return nil
+	})
+	s.now = func() time.Time { return now }
+
+	if err := s.Schedule("a", "every minute", "* * * * *"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule("b", "hourly", "@hourly"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Schedule("c", "bad", "nope"); err == nil {
+		t.Error("expected invalid schedule to error")
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	if jobs[0].ID != "a" || jobs[1].ID != "b" {
+		t.Errorf("expected jobs ordered by next run, got %q, %q", jobs[0].ID, jobs[1].ID)
+	}
+
+	if n := s.RunDue(ctx); n != 0 {
+		t.Errorf("expected no jobs to be due, ran %d", n)
+	}
+
+	now = now.Add(time.Hour)
+	if n := s.RunDue(ctx); n != 2 {
+		t.Errorf("expected 2 jobs to run, ran %d", n)
+	}
+	if fmt.Sprintf("%v", ran) != "[a b]" {
+		t.Errorf("run order mismatch. got: %v", ran)
+	}
+
+	jobs = s.Jobs()
+	if !jobs[0].NextRun.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected job a to be rescheduled for %s, got %s", now.Add(time.Minute), jobs[0].NextRun)
+	}
+	if !jobs[0].LastRun.Equal(now) {
+		t.Errorf("expected job a last run to be %s, got %s", now, jobs[0].LastRun)
+	}
+	if jobs[1].LastError != "oh noes" {
+		t.Errorf("expected job b to record error, got %q", jobs[1].LastError)
+	}
+
+	// rescheduling with the same spec keeps the next run time
+	next := jobs[1].NextRun
+	if err := s.Schedule("b", "renamed", "@hourly"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Jobs()[1]; !got.NextRun.Equal(next) || got.Name != "renamed" {
+		t.Errorf("expected rescheduled job to keep next run & update name. got: %#v", got)
+	}
+
+	s.Unschedule("a")
+	s.Unschedule("missing")
+	if jobs = s.Jobs(); len(jobs) != 1 {
+		t.Errorf("expected 1 job after unscheduling, got %d", len(jobs))
+	}
+}
+
+func TestSchedulerStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan string, 1)
+	s := New(func(ctx context.Context, job Job) error {
+		ran <- job.ID
+		cancel()
+		return nil
+	})
+
+	errs := make(chan error)
+	go func() { errs <- s.Start(ctx) }()
+
+	if err := s.Schedule("a", "soon", "@every 1s"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case id := <-ran:
+		if id != "a" {
+			t.Errorf("expected job a to run, got %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for job to run")
+	}
+
+	if err := <-errs; err != nil {
+		t.Errorf("unexpected error from start: %s", err)
+	}
+}
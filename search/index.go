@@ -0,0 +1,445 @@
+// Package search is a full-text index of the datasets in a repo. The index
+// covers each dataset's meta title, description & keywords, readme text, and
+// structure field names, and is kept up to date by following dataset change
+// hooks, without needing a registry
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/event/hook"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+var (
+	log = golog.Logger("search")
+	// ErrNoIndex is returned when methods are called on a non-existant Index
+	ErrNoIndex = fmt.Errorf("search: no index")
+)
+
+// Index is an inverted index of dataset text. Index is safe for concurrent
+// use, and implements repo.Searchable
+type Index struct {
+	store    cafs.Filestore
+	filename string
+
+	lk       sync.RWMutex
+	built    bool
+	docs     map[string]*document
+	postings *Postings
+	// savePending is true while a write of the index file is scheduled
+	savePending bool
+
+	// saveLk serializes writes of the index file
+	saveLk sync.Mutex
+}
+
+// saveDelay is how long changes wait before the index file is written, so a
+// burst of changes writes the file once
+var saveDelay = time.Second
+
+var _ repo.Searchable = (*Index)(nil)
+
+// document is an indexed dataset, keyed by InitID
+type document struct {
+	InitID    string   `json:"initID"`
+	Username  string   `json:"username"`
+	ProfileID string   `json:"profileID,omitempty"`
+	Name      string   `json:"name"`
+	Path      string   `json:"path,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Keywords  []string `json:"keywords,omitempty"`
+	// Description is stored so results can show it without loading datasets
+	Description string `json:"description,omitempty"`
+	// Terms maps each term in the dataset to its weighted frequency
+	Terms Terms `json:"terms,omitempty"`
+}
+
+// NewIndex creates a search index that persists to the given filename,
+// loading any index already written there. An empty filename keeps the index
+// in memory only. Dataset versions are read from store, and the index follows
+// changes from each of hooks
+func NewIndex(store cafs.Filestore, hooks []hook.ChangeNotifier, filename string) *Index {
+	idx := &Index{
+		store:    store,
+		filename: filename,
+		docs:     map[string]*document{},
+		postings: NewPostings(),
+	}
+	if filename != "" {
+		if err := idx.load(); err != nil && !os.IsNotExist(err) {
+			log.Errorf("loading search index: %s", err)
+		}
+	}
+	for _, h := range hooks {
+		h.SetChangeHook(idx.update)
+	}
+	return idx
+}
+
+// Built returns true if the index has been built from the contents of a repo.
+// An unbuilt index only knows about datasets that have changed since it was
+// created
+func (idx *Index) Built() bool {
+	if idx == nil {
+		return false
+	}
+	idx.lk.RLock()
+	defer idx.lk.RUnlock()
+	return idx.built
+}
+
+// Len returns the number of indexed datasets
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	idx.lk.RLock()
+	defer idx.lk.RUnlock()
+	return len(idx.docs)
+}
+
+// Build replaces the contents of the index with every dataset in a repo
+func (idx *Index) Build(ctx context.Context, r repo.Repo) error {
+	if idx == nil {
+		return ErrNoIndex
+	}
+	num, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return err
+	}
+
+	docs := map[string]*document{}
+	for _, rref := range refs {
+		if rref.Path == "" || rref.Foreign {
+			continue
+		}
+		ref := reporef.ConvertToDsref(rref)
+		if _, err := r.ResolveRef(ctx, &ref); err != nil || ref.InitID == "" {
+			log.Debugf("skipping %s/%s, couldn't resolve init ID: %v", rref.Peername, rref.Name, err)
+			continue
+		}
+		doc := &document{
+			InitID:    ref.InitID,
+			Username:  rref.Peername,
+			ProfileID: rref.ProfileID.String(),
+			Name:      rref.Name,
+		}
+		if err := idx.indexVersion(ctx, doc, rref.Path); err != nil {
+			log.Debugf("indexing %s/%s: %s", rref.Peername, rref.Name, err)
+		}
+		docs[doc.InitID] = doc
+	}
+
+	idx.lk.Lock()
+	idx.docs = docs
+	idx.postings = NewPostings()
+	for _, doc := range docs {
+		idx.postings.Add(doc.InitID, doc.Terms)
+	}
+	idx.built = true
+	idx.lk.Unlock()
+	return idx.Flush()
+}
+
+// Search returns datasets that match every term in a query, ordered by
+// relevance
+func (idx *Index) Search(p repo.SearchParams) ([]reporef.DatasetRef, error) {
+	if idx == nil {
+		return nil, ErrNoIndex
+	}
+	terms := Tokenize(p.Q)
+	if len(terms) == 0 {
+		return []reporef.DatasetRef{}, nil
+	}
+
+	idx.lk.RLock()
+	defer idx.lk.RUnlock()
+
+	scores := idx.postings.Match(terms)
+	matches := make([]*document, 0, len(scores))
+	for id := range scores {
+		matches = append(matches, idx.docs[id])
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if scores[a.InitID] != scores[b.InitID] {
+			return scores[a.InitID] > scores[b.InitID]
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.Name < b.Name
+	})
+
+	if p.Offset > len(matches) {
+		p.Offset = len(matches)
+	}
+	matches = matches[p.Offset:]
+	if p.Limit > 0 && p.Limit < len(matches) {
+		matches = matches[:p.Limit]
+	}
+
+	res := make([]reporef.DatasetRef, len(matches))
+	for i, doc := range matches {
+		res[i] = doc.datasetRef()
+	}
+	return res, nil
+}
+
+// update adjusts the index in response to a dataset change. Dataset versions
+// are loaded before the index is locked, and the index file is written later,
+// so searches aren't held up by store or file IO
+func (idx *Index) update(act hook.DsChange) {
+	var version *document
+	if act.Type == hook.DatasetCommitChange {
+		version = &document{}
+		if err := idx.indexVersion(context.TODO(), version, act.HeadRef); err != nil {
+			log.Errorf("indexing version %s: %s", act.HeadRef, err)
+		}
+	}
+
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+
+	switch act.Type {
+	case hook.DatasetNameInit:
+		idx.docs[act.InitID] = &document{
+			InitID:    act.InitID,
+			Username:  act.Username,
+			ProfileID: act.ProfileID,
+			Name:      act.PrettyName,
+		}
+	case hook.DatasetCommitChange:
+		doc, ok := idx.docs[act.InitID]
+		if !ok {
+			doc = &document{InitID: act.InitID}
+			idx.docs[act.InitID] = doc
+		}
+		if act.Info != nil {
+			if act.Info.Username != "" {
+				doc.Username = act.Info.Username
+			}
+			if act.Info.Name != "" {
+				doc.Name = act.Info.Name
+			}
+		}
+		doc.Path, doc.Title, doc.Description, doc.Keywords, doc.Terms = version.Path, version.Title, version.Description, version.Keywords, version.Terms
+		idx.postings.Add(doc.InitID, doc.Terms)
+	case hook.DatasetDeleteAll:
+		idx.postings.Remove(act.InitID)
+		delete(idx.docs, act.InitID)
+	case hook.DatasetRename:
+		if doc, ok := idx.docs[act.InitID]; ok {
+			doc.Name = act.PrettyName
+		}
+	default:
+		return
+	}
+
+	idx.scheduleSave()
+}
+
+// indexVersion sets the text of a document from a dataset version
+func (idx *Index) indexVersion(ctx context.Context, doc *document, path string) error {
+	doc.Path = path
+	doc.Title, doc.Description, doc.Keywords, doc.Terms = "", "", nil, nil
+	if path == "" || idx.store == nil {
+		return nil
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, idx.store, path)
+	if err != nil {
+		return err
+	}
+
+	terms := Terms{}
+
+	if ds.Meta != nil {
+		doc.Title = ds.Meta.Title
+		doc.Description = ds.Meta.Description
+		doc.Keywords = ds.Meta.Keywords
+		terms.Add(ds.Meta.Title, TitleWeight)
+		terms.Add(ds.Meta.Description, DescriptionWeight)
+		for _, kw := range ds.Meta.Keywords {
+			terms.Add(kw, KeywordWeight)
+		}
+	}
+	if ds.Structure != nil {
+		for _, name := range FieldNames(ds.Structure) {
+			terms.Add(name, FieldWeight)
+		}
+	}
+	if ds.Readme != nil && ds.Readme.ScriptPath != "" {
+		if err := ds.Readme.OpenScriptFile(ctx, idx.store); err == nil {
+			if f := ds.Readme.ScriptFile(); f != nil {
+				data, err := ioutil.ReadAll(f)
+				f.Close()
+				if err == nil {
+					terms.Add(string(data), ReadmeWeight)
+				}
+			}
+		}
+	}
+
+	doc.Terms = terms
+	return nil
+}
+
+// FieldNames lists the titles of schema fields in a structure
+func FieldNames(st *dataset.Structure) []string {
+	var names []string
+	var walk func(sch map[string]interface{})
+	walk = func(sch map[string]interface{}) {
+		if title, ok := sch["title"].(string); ok {
+			names = append(names, title)
+		}
+		if props, ok := sch["properties"].(map[string]interface{}); ok {
+			keys := make([]string, 0, len(props))
+			for key := range props {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				names = append(names, key)
+				if sub, ok := props[key].(map[string]interface{}); ok {
+					walk(sub)
+				}
+			}
+		}
+		switch items := sch["items"].(type) {
+		case map[string]interface{}:
+			walk(items)
+		case []interface{}:
+			for _, item := range items {
+				if sub, ok := item.(map[string]interface{}); ok {
+					walk(sub)
+				}
+			}
+		}
+	}
+
+	if st.Schema != nil {
+		if items, ok := st.Schema["items"]; ok {
+			walk(map[string]interface{}{"items": items})
+		}
+		if props, ok := st.Schema["properties"]; ok {
+			walk(map[string]interface{}{"properties": props})
+		}
+	}
+	return names
+}
+
+// indexFile is the persisted form of an index. Postings are derived from
+// documents when the index is loaded
+type indexFile struct {
+	Built bool        `json:"built"`
+	Docs  []*document `json:"docs"`
+}
+
+// load reads the index from its file
+func (idx *Index) load() error {
+	data, err := ioutil.ReadFile(idx.filename)
+	if err != nil {
+		return err
+	}
+	f := indexFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	for _, doc := range f.Docs {
+		idx.docs[doc.InitID] = doc
+		idx.postings.Add(doc.InitID, doc.Terms)
+	}
+	idx.built = f.Built
+	return nil
+}
+
+// scheduleSave writes the index file after saveDelay, unless a write is
+// already scheduled. callers must hold the lock
+func (idx *Index) scheduleSave() {
+	if idx.filename == "" || idx.savePending {
+		return
+	}
+	idx.savePending = true
+	time.AfterFunc(saveDelay, func() {
+		if err := idx.Flush(); err != nil {
+			log.Errorf("saving search index: %s", err)
+		}
+	})
+}
+
+// Flush writes the index to its file. Changes the index follows are written
+// shortly after they happen, Flush writes them immediately
+func (idx *Index) Flush() error {
+	if idx == nil {
+		return ErrNoIndex
+	}
+	if idx.filename == "" {
+		return nil
+	}
+	idx.saveLk.Lock()
+	defer idx.saveLk.Unlock()
+
+	idx.lk.Lock()
+	idx.savePending = false
+	f := indexFile{Built: idx.built, Docs: make([]*document, 0, len(idx.docs))}
+	for _, doc := range idx.docs {
+		f.Docs = append(f.Docs, doc)
+	}
+	sort.Slice(f.Docs, func(i, j int) bool { return f.Docs[i].InitID < f.Docs[j].InitID })
+	data, err := json.Marshal(f)
+	idx.lk.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file & rename it, so readers never see a partial index
+	tmp := idx.filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.filename)
+}
+
+// datasetRef converts a document to a dataset reference, including the meta
+// fields the index keeps
+func (doc *document) datasetRef() reporef.DatasetRef {
+	ref := reporef.DatasetRef{
+		Peername: doc.Username,
+		Name:     doc.Name,
+		Path:     doc.Path,
+		Dataset: &dataset.Dataset{
+			Peername: doc.Username,
+			Name:     doc.Name,
+			Path:     doc.Path,
+		},
+	}
+	if doc.Title != "" || doc.Description != "" || len(doc.Keywords) > 0 {
+		ref.Dataset.Meta = &dataset.Meta{
+			Title:       doc.Title,
+			Description: doc.Description,
+			Keywords:    doc.Keywords,
+		}
+	}
+	return ref
+}
@@ -0,0 +1,186 @@
+package search
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/event/hook"
+	"github.com/qri-io/qri/repo"
+	repotest "github.com/qri-io/qri/repo/test"
+)
+
+func TestBuildAndSearch(t *testing.T) {
+	ctx := context.Background()
+	r, err := repotest.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndex(r.Store(), nil, "")
+	if idx.Built() {
+		t.Error("expected new in-memory index not to be built")
+	}
+	if err := idx.Build(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if !idx.Built() || idx.Len() == 0 {
+		t.Fatalf("expected built index with datasets, got %d datasets", idx.Len())
+	}
+
+	cases := []struct {
+		query  string
+		expect []string
+	}{
+		// meta title
+		{"example city", []string{"peer/cities"}},
+		// meta description
+		{"crawl", []string{"peer/sitemap"}},
+		// structure field names
+		{"avg_age", []string{"peer/cities"}},
+		{"duration", []string{"peer/movies"}},
+		// prefix matches
+		{"sitem", []string{"peer/sitemap"}},
+		// every term must match
+		{"movie city", []string{}},
+		{"the", []string{}},
+		{"", []string{}},
+	}
+
+	for _, c := range cases {
+		res, err := idx.Search(repo.SearchParams{Q: c.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(res))
+		for i, ref := range res {
+			got[i] = ref.AliasString()
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%q result mismatch (-want +got):\n%s", c.query, diff)
+		}
+	}
+
+	res, err := idx.Search(repo.SearchParams{Q: "example", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Errorf("expected limit to give 1 result, got %d", len(res))
+	}
+	if res[0].Dataset == nil || res[0].Dataset.Meta == nil || res[0].Dataset.Meta.Title == "" {
+		t.Errorf("expected result to include a meta title")
+	}
+	res, err = idx.Search(repo.SearchParams{Q: "example", Offset: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 0 {
+		t.Errorf("expected no results past the last match, got %d", len(res))
+	}
+}
+
+type testNotifier struct {
+	hook func(hook.DsChange)
+}
+
+func (n *testNotifier) SetChangeHook(h func(hook.DsChange)) {
+	n.hook = h
+}
+
+func TestIndexChanges(t *testing.T) {
+	ctx := context.Background()
+	r, err := repotest.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	movies := dsref.MustParse("peer/movies")
+	if _, err := r.ResolveRef(ctx, &movies); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "search_index_changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "search.json")
+
+	n := &testNotifier{}
+	idx := NewIndex(r.Store(), []hook.ChangeNotifier{n}, filename)
+
+	search := func(idx *Index, q string) []string {
+		t.Helper()
+		res, err := idx.Search(repo.SearchParams{Q: q})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, ref := range res {
+			got = append(got, ref.AliasString())
+		}
+		return got
+	}
+
+	n.hook(hook.DsChange{
+		Type:       hook.DatasetNameInit,
+		InitID:     "init_id",
+		Username:   "analyst",
+		PrettyName: "films",
+	})
+	n.hook(hook.DsChange{
+		Type:    hook.DatasetCommitChange,
+		InitID:  "init_id",
+		HeadRef: movies.Path,
+	})
+	if diff := cmp.Diff([]string{"analyst/films"}, search(idx, "movie")); diff != "" {
+		t.Errorf("result mismatch after commit (-want +got):\n%s", diff)
+	}
+
+	n.hook(hook.DsChange{
+		Type:       hook.DatasetRename,
+		InitID:     "init_id",
+		PrettyName: "movies",
+	})
+	if diff := cmp.Diff([]string{"analyst/movies"}, search(idx, "movie")); diff != "" {
+		t.Errorf("result mismatch after rename (-want +got):\n%s", diff)
+	}
+
+	// the index persists to & loads from its file, writing changes in batches
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("expected changes not to be written to the index file right away, got: %v", err)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewIndex(r.Store(), nil, filename)
+	if loaded.Built() {
+		t.Error("expected index that only followed changes not to be built")
+	}
+	if diff := cmp.Diff([]string{"analyst/movies"}, search(loaded, "movie")); diff != "" {
+		t.Errorf("result mismatch after loading (-want +got):\n%s", diff)
+	}
+
+	n.hook(hook.DsChange{
+		Type:   hook.DatasetDeleteAll,
+		InitID: "init_id",
+	})
+	if diff := cmp.Diff([]string{}, search(idx, "movie")); diff != "" {
+		t.Errorf("result mismatch after delete (-want +got):\n%s", diff)
+	}
+	if idx.Len() != 0 {
+		t.Errorf("expected empty index after delete, got %d datasets", idx.Len())
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("The Population_2010 of São Paulo, by-district")
+	expect := []string{"population", "2010", "são", "paulo", "district"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	// prefixWeight scales matches where a query term is a prefix of an indexed
+	// term, ranking them below whole-word matches
+	prefixWeight = 0.5
+	// minPrefixLen is the shortest query term that matches by prefix
+	minPrefixLen = 3
+)
+
+// Field weights scale how much a matching term in each part of a dataset
+// contributes to a result's score. Both the repo index & the registry search
+// index rank with these weights, so results are ordered the same way
+const (
+	TitleWeight       = 4
+	NameWeight        = 3
+	KeywordWeight     = 3
+	ThemeWeight       = 2
+	FieldWeight       = 2
+	DescriptionWeight = 1.5
+	ReadmeWeight      = 1
+)
+
+// Terms maps each term in a document to its weighted frequency
+type Terms map[string]float64
+
+// Add tokenizes text, adding each term with the given weight
+func (t Terms) Add(text string, weight float64) {
+	for _, term := range Tokenize(text) {
+		t[term] += weight
+	}
+}
+
+// Postings is an inverted index from terms to the documents that contain
+// them. Postings isn't safe for concurrent use
+type Postings struct {
+	docs  map[string]Terms
+	terms map[string]map[string]float64
+}
+
+// NewPostings creates an empty inverted index
+func NewPostings() *Postings {
+	return &Postings{
+		docs:  map[string]Terms{},
+		terms: map[string]map[string]float64{},
+	}
+}
+
+// Len gives the number of documents in the index
+func (p *Postings) Len() int {
+	return len(p.docs)
+}
+
+// Add indexes the terms of a document, replacing any terms previously added
+// with the same id
+func (p *Postings) Add(id string, terms Terms) {
+	p.Remove(id)
+	p.docs[id] = terms
+	for term, tf := range terms {
+		docs, ok := p.terms[term]
+		if !ok {
+			docs = map[string]float64{}
+			p.terms[term] = docs
+		}
+		docs[id] = tf
+	}
+}
+
+// Remove drops a document from the index
+func (p *Postings) Remove(id string) {
+	for term := range p.docs[id] {
+		if docs, ok := p.terms[term]; ok {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(p.terms, term)
+			}
+		}
+	}
+	delete(p.docs, id)
+}
+
+// Match scores the documents that match every term in a query. Query terms
+// match indexed terms exactly, or as a prefix of a longer term
+func (p *Postings) Match(query []string) map[string]float64 {
+	var scores map[string]float64
+	for _, term := range uniqueTerms(query) {
+		termScores := p.termScores(term)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, score := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] = score + s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	if scores == nil {
+		scores = map[string]float64{}
+	}
+	return scores
+}
+
+// termScores gives the score of each document containing a term. Terms are
+// weighted by inverse document frequency, so rare terms count for more
+func (p *Postings) termScores(term string) map[string]float64 {
+	scores := map[string]float64{}
+	add := func(t string, weight float64) {
+		docs := p.terms[t]
+		idf := math.Log(1 + float64(len(p.docs))/float64(len(docs)))
+		for id, tf := range docs {
+			if s := weight * tf * idf; s > scores[id] {
+				scores[id] = s
+			}
+		}
+	}
+
+	if _, ok := p.terms[term]; ok {
+		add(term, 1)
+	}
+	if len([]rune(term)) >= minPrefixLen {
+		for t := range p.terms {
+			if t != term && strings.HasPrefix(t, term) {
+				add(t, prefixWeight)
+			}
+		}
+	}
+	return scores
+}
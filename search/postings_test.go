@@ -0,0 +1,49 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestPostings(t *testing.T) {
+	p := NewPostings()
+	a := Terms{}
+	a.Add("annual population estimates", 2)
+	a.Add("population by county", 1)
+	b := Terms{}
+	b.Add("annual rainfall", 1)
+	p.Add("a", a)
+	p.Add("b", b)
+
+	if p.Len() != 2 {
+		t.Errorf("expected 2 documents, got %d", p.Len())
+	}
+	if a["population"] != 3 {
+		t.Errorf("expected weighted frequency of 3, got %v", a["population"])
+	}
+
+	scores := p.Match([]string{"annual"})
+	if len(scores) != 2 || scores["a"] <= scores["b"] {
+		t.Errorf("expected both documents to match, ranking a first. got: %v", scores)
+	}
+	scores = p.Match([]string{"annual", "popul"})
+	if len(scores) != 1 || scores["a"] == 0 {
+		t.Errorf("expected only a to match every term by prefix, got: %v", scores)
+	}
+	if scores := p.Match([]string{"po"}); len(scores) != 0 {
+		t.Errorf("expected short terms not to match by prefix, got: %v", scores)
+	}
+
+	// adding a document again replaces its terms
+	c := Terms{}
+	c.Add("monthly rainfall", 1)
+	p.Add("b", c)
+	if scores := p.Match([]string{"annual"}); len(scores) != 1 {
+		t.Errorf("expected replaced terms to be removed, got: %v", scores)
+	}
+
+	p.Remove("a")
+	p.Remove("b")
+	if p.Len() != 0 || len(p.terms) != 0 {
+		t.Errorf("expected empty index, got %d docs & %d terms", p.Len(), len(p.terms))
+	}
+}
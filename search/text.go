@@ -0,0 +1,43 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common english words that aren't indexed
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+// Tokenize splits text into lower case terms. Terms are runs of letters &
+// numbers, so field names like "population_2010" give the terms "population"
+// and "2010"
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if !stopWords[w] {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// uniqueTerms removes repeated terms, preserving order
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(terms))
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,205 @@
+package sql
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cube2222/octosql/parser/sqlparser"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/sql/preprocess"
+)
+
+// ResultCache is an in-memory store of query results. Entries are evicted
+// least-recently-used first when the total size of the cache exceeds its
+// maximum size. ResultCache is safe for concurrent use, and nil-callable
+type ResultCache struct {
+	maxSize      uint64
+	maxEntrySize uint64
+
+	lk      sync.Mutex
+	size    uint64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// resultEntry is a cached query result
+type resultEntry struct {
+	key  string
+	data []byte
+}
+
+// NewResultCache creates a result cache that holds up to maxSize bytes of
+// results. Results larger than maxEntrySize aren't cached, a maxEntrySize of
+// 0 caches any result that fits in maxSize
+func NewResultCache(maxSize, maxEntrySize uint64) *ResultCache {
+	if maxEntrySize == 0 || maxEntrySize > maxSize {
+		maxEntrySize = maxSize
+	}
+	return &ResultCache{
+		maxSize:      maxSize,
+		maxEntrySize: maxEntrySize,
+		order:        list.New(),
+		entries:      map[string]*list.Element{},
+	}
+}
+
+// Get returns a cached result by key
+func (c *ResultCache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*resultEntry).data, true
+}
+
+// Put adds a result to the cache, evicting least recently used results to
+// stay within the size limit. Results larger than the maximum entry size are
+// ignored
+func (c *ResultCache) Put(key string, data []byte) {
+	if c == nil || uint64(len(data)) > c.maxEntrySize {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&resultEntry{key: key, data: data})
+	c.size += uint64(len(data))
+
+	for c.size > c.maxSize {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len gives the number of cached results
+func (c *ResultCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return len(c.entries)
+}
+
+// remove drops an entry from the cache. callers must hold the lock
+func (c *ResultCache) remove(el *list.Element) {
+	ent := c.order.Remove(el).(*resultEntry)
+	delete(c.entries, ent.key)
+	c.size -= uint64(len(ent.data))
+}
+
+// cachedExec runs a query, using the result cache. Every dataset the query
+// reads is resolved up front, and the query runs against those versions, so
+// the cached result always matches the versions in its key. When a dataset
+// name resolves to a new version the key changes, and stale results age out
+// of the cache
+func (svc *Service) cachedExec(ctx context.Context, w io.Writer, outFormat, query string) error {
+	buf := &bytes.Buffer{}
+	out, err := newOutput(buf, outFormat)
+	if err != nil {
+		return err
+	}
+
+	processedQuery, sources, err := preprocess.Query(query)
+	var stmt sqlparser.Statement
+	if err == nil {
+		stmt, err = sqlparser.Parse(processedQuery)
+	}
+	if err != nil || !deterministic(stmt) {
+		// run without caching, leaving exec to report any errors
+		return svc.uncachedExec(ctx, w, outFormat, query)
+	}
+
+	resolved := map[string]*dataset.Dataset{}
+	paths := map[string]string{}
+	for name, refStr := range sources {
+		ds, err := svc.loadDataset(ctx, refStr)
+		if err != nil {
+			return err
+		}
+		// datasets without an immutable version path, like those in a working
+		// directory, can change without changing the cache key
+		if ds.Path == "" || strings.HasPrefix(ds.Path, "/fsi") {
+			return svc.uncachedExec(ctx, w, outFormat, query)
+		}
+		resolved[refStr] = ds
+		paths[name] = ds.Path
+	}
+
+	key := resultCacheKey(outFormat, sqlparser.String(stmt), paths)
+	if data, ok := svc.cache.Get(key); ok {
+		log.Debugf("sql result cache hit: %s", key)
+		_, err := w.Write(data)
+		return err
+	}
+
+	load := func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+		if ds, ok := resolved[refStr]; ok {
+			return ds, nil
+		}
+		return svc.loadDataset(ctx, refStr)
+	}
+	if err := svc.exec(ctx, out, query, load); err != nil {
+		return err
+	}
+
+	svc.cache.Put(key, buf.Bytes())
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// uncachedExec runs a query without reading or writing the result cache
+func (svc *Service) uncachedExec(ctx context.Context, w io.Writer, outFormat, query string) error {
+	out, err := newOutput(w, outFormat)
+	if err != nil {
+		return err
+	}
+	return svc.exec(ctx, out, query, svc.loadDataset)
+}
+
+// resultCacheKey combines an output format, a normalized query & the resolved
+// version path of each data source in the query into a cache key
+func resultCacheKey(outFormat, query string, paths map[string]string) string {
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", outFormat, query)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, paths[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// deterministic returns false if a statement calls functions that give a
+// different result each time they're called, which makes results uncacheable
+func deterministic(stmt sqlparser.SQLNode) bool {
+	det := true
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if fn, ok := node.(*sqlparser.FuncExpr); ok && fn.Name.Lowered() == "now" {
+			det = false
+		}
+		return det, nil
+	}, stmt)
+	return det
+}
@@ -0,0 +1,159 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cube2222/octosql/parser/sqlparser"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/dsref"
+	repotest "github.com/qri-io/qri/repo/test"
+)
+
+func TestResultCache(t *testing.T) {
+	c := NewResultCache(10, 6)
+	c.Put("a", []byte("aaaa"))
+	c.Put("b", []byte("bbbb"))
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be cached")
+	}
+
+	// adding c exceeds the max size, evicting b, which was used least recently
+	c.Put("c", []byte("cc"))
+	c.Put("d", []byte("dd"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+
+	c.Put("e", []byte("eeeeeee"))
+	if _, ok := c.Get("e"); ok {
+		t.Error("expected result larger than max entry size not to be cached")
+	}
+
+	// replacing an entry updates the size
+	c.Put("a", []byte("a"))
+	if data, _ := c.Get("a"); string(data) != "a" {
+		t.Errorf("expected replaced entry to be 'a', got: %q", data)
+	}
+	if c.Len() != 3 || c.size != 5 {
+		t.Errorf("expected 3 entries using 5 bytes, got %d entries using %d bytes", c.Len(), c.size)
+	}
+
+	var nilCache *ResultCache
+	nilCache.Put("a", []byte("a"))
+	if _, ok := nilCache.Get("a"); ok || nilCache.Len() != 0 {
+		t.Error("expected nil cache to hold nothing")
+	}
+}
+
+func TestResultCacheKey(t *testing.T) {
+	a := resultCacheKey("csv", "select 1", map[string]string{"me_a": "/ipfs/QmA", "me_b": "/ipfs/QmB"})
+	b := resultCacheKey("csv", "select 1", map[string]string{"me_b": "/ipfs/QmB", "me_a": "/ipfs/QmA"})
+	if a != b {
+		t.Error("expected key to be independent of source order")
+	}
+
+	differ := []string{
+		resultCacheKey("json", "select 1", map[string]string{"me_a": "/ipfs/QmA", "me_b": "/ipfs/QmB"}),
+		resultCacheKey("csv", "select 2", map[string]string{"me_a": "/ipfs/QmA", "me_b": "/ipfs/QmB"}),
+		resultCacheKey("csv", "select 1", map[string]string{"me_a": "/ipfs/QmC", "me_b": "/ipfs/QmB"}),
+	}
+	for i, key := range differ {
+		if key == a {
+			t.Errorf("case %d: expected key to change", i)
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	cases := []struct {
+		query  string
+		expect bool
+	}{
+		{"select m.title from me_movies as m", true},
+		{"select m.title, now() as t from me_movies as m", false},
+		{"select m.title from me_movies as m where m.released < NOW()", false},
+	}
+	for _, c := range cases {
+		stmt, err := sqlparser.Parse(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := deterministic(stmt); got != c.expect {
+			t.Errorf("%q: expected %t, got %t", c.query, c.expect, got)
+		}
+	}
+}
+
+func TestCachedExec(t *testing.T) {
+	ctx := context.Background()
+	r, err := repotest.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// redirect makes "me/movies" resolve to another dataset, standing in for
+	// a new version of me/movies
+	redirect := ""
+	loader := base.NewLocalDatasetLoader(r)
+	load := func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+		if redirect != "" {
+			refStr = redirect
+		}
+		ref, err := dsref.Parse(strings.Replace(refStr, "me/", "peer/", 1))
+		if err != nil {
+			return nil, err
+		}
+		source, err := r.ResolveRef(ctx, &ref)
+		if err != nil {
+			return nil, err
+		}
+		return loader.LoadDataset(ctx, ref, source)
+	}
+
+	cache := NewResultCache(1024*1024, 0)
+	svc := New(r, load, func(o *Options) {
+		o.ResultCache = cache
+	})
+
+	query := "SELECT m.title FROM me/movies AS m LIMIT 1"
+	exec := func() string {
+		buf := &bytes.Buffer{}
+		if err := svc.Exec(ctx, buf, "csv", query); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if got := exec(); got != "m.title\n'Avatar '\n" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("expected 1 cached result, got %d", cache.Len())
+	}
+
+	// overwrite the cached result to confirm it's used
+	for _, el := range cache.entries {
+		el.Value.(*resultEntry).data = []byte("cached")
+	}
+	if got := exec(); got != "cached" {
+		t.Errorf("expected cached result, got: %q", got)
+	}
+
+	// when the dataset resolves to a different version, the query runs again
+	redirect = "me/cities"
+	if got := exec(); got == "cached" {
+		t.Error("expected query to run again when the dataset version changes")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached results, got %d", cache.Len())
+	}
+}
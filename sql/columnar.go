@@ -0,0 +1,51 @@
+package sql
+
+import (
+	"io"
+
+	"github.com/cube2222/octosql"
+	"github.com/qri-io/qri/base/columnar"
+)
+
+// columnarOutput is an octosql output that writes records in a columnar
+// format like parquet or arrow. Column types are only known once every record
+// has been seen, so records are collected & written when the output closes
+type columnarOutput struct {
+	datasetOutput
+	format string
+	w      io.Writer
+}
+
+// Close writes all records to the underlying writer
+func (o *columnarOutput) Close() error {
+	titles, types, body := o.table()
+	cols := make([]columnar.Column, len(titles))
+	for i := range titles {
+		cols[i] = columnar.Column{Title: titles[i], Type: columnarType(types[i])}
+	}
+
+	w, err := columnar.NewWriter(o.format, cols, o.w)
+	if err != nil {
+		return err
+	}
+	for _, row := range body {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// columnarType gives the columnar type for a column type. Columns with no
+// single scalar type are written as strings
+func columnarType(t octosql.Type) columnar.Type {
+	switch t {
+	case octosql.TypeInt, octosql.TypeDuration:
+		return columnar.Int
+	case octosql.TypeFloat:
+		return columnar.Float
+	case octosql.TypeBool:
+		return columnar.Bool
+	}
+	return columnar.String
+}
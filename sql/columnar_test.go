@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cube2222/octosql"
+	"github.com/cube2222/octosql/execution"
+	"github.com/qri-io/qri/base/columnar"
+)
+
+func TestColumnarOutput(t *testing.T) {
+	fields := []octosql.VariableName{"m.title", "m.duration"}
+	rows := [][]octosql.Value{
+		{octosql.MakeString("Avatar"), octosql.MakeInt(178)},
+		{octosql.MakeString("Spectre"), octosql.MakeNull()},
+	}
+
+	buf := &bytes.Buffer{}
+	out := &columnarOutput{format: columnar.ParquetFormat, w: buf}
+	for _, row := range rows {
+		if err := out.WriteRecord(execution.NewRecordFromSlice(fields, row)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected records to be buffered until close")
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Errorf("expected parquet output to start & end with magic bytes")
+	}
+	for _, s := range []string{"title", "duration", "Avatar", "Spectre"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("expected parquet output to contain %q", s)
+		}
+	}
+}
+
+func TestColumnarType(t *testing.T) {
+	cases := []struct {
+		in     octosql.Type
+		expect columnar.Type
+	}{
+		{octosql.TypeInt, columnar.Int},
+		{octosql.TypeDuration, columnar.Int},
+		{octosql.TypeFloat, columnar.Float},
+		{octosql.TypeBool, columnar.Bool},
+		{octosql.TypeString, columnar.String},
+		{octosql.TypeTime, columnar.String},
+		{octosql.TypePhantom, columnar.String},
+	}
+	for _, c := range cases {
+		if got := columnarType(c.in); got != c.expect {
+			t.Errorf("type %v: expected %s, got %s", c.in, c.expect, got)
+		}
+	}
+}
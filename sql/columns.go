@@ -0,0 +1,92 @@
+package sql
+
+import (
+	"sort"
+
+	"github.com/cube2222/octosql/parser/sqlparser"
+)
+
+// referencedColumns determines which columns of each data source a statement
+// uses, so data sources only need to read those columns. The returned map is
+// keyed by data source name. Sources that are missing from the map, either
+// because they're selected with a "*" or their use can't be determined, need
+// all columns
+func referencedColumns(stmt sqlparser.SQLNode, sources []string) map[string][]string {
+	isSource := map[string]bool{}
+	for _, name := range sources {
+		isSource[name] = true
+	}
+
+	var (
+		// map of alias to the data source it refers to
+		aliases = map[string]string{}
+		// unqualified column names could belong to any source
+		unqualified = map[string]bool{}
+		qualified   = map[string]map[string]bool{}
+		// sources that need all columns, keyed by alias. the empty string key
+		// marks an unqualified "*", which applies to all sources
+		star = map[string]bool{}
+	)
+
+	err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.AliasedTableExpr:
+			if tn, ok := n.Expr.(sqlparser.TableName); ok && isSource[tn.Name.String()] {
+				alias := tn.Name.String()
+				if !n.As.IsEmpty() {
+					alias = n.As.String()
+				}
+				aliases[alias] = tn.Name.String()
+			}
+		case *sqlparser.ColName:
+			col := n.Name.String()
+			if n.Qualifier.IsEmpty() {
+				unqualified[col] = true
+				return true, nil
+			}
+			alias := n.Qualifier.Name.String()
+			if qualified[alias] == nil {
+				qualified[alias] = map[string]bool{}
+			}
+			qualified[alias][col] = true
+		case *sqlparser.StarExpr:
+			star[n.TableName.Name.String()] = true
+		}
+		return true, nil
+	}, stmt)
+	if err != nil || star[""] {
+		return nil
+	}
+
+	cols := map[string]map[string]bool{}
+	all := map[string]bool{}
+	for alias, source := range aliases {
+		if star[alias] {
+			all[source] = true
+			continue
+		}
+		if cols[source] == nil {
+			cols[source] = map[string]bool{}
+		}
+		for col := range qualified[alias] {
+			cols[source][col] = true
+		}
+		for col := range unqualified {
+			cols[source][col] = true
+		}
+	}
+
+	res := map[string][]string{}
+	for source, set := range cols {
+		if all[source] {
+			continue
+		}
+		names := make([]string, 0, len(set))
+		for col := range set {
+			names = append(names, col)
+		}
+		sort.Strings(names)
+		res[source] = names
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/cube2222/octosql/parser/sqlparser"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReferencedColumns(t *testing.T) {
+	cases := []struct {
+		query   string
+		sources []string
+		expect  map[string][]string
+	}{
+		{"SELECT * FROM me_movies as m", []string{"me_movies"}, nil},
+		{"SELECT m.title FROM me_movies as m WHERE m.duration > 100 ORDER BY m.year",
+			[]string{"me_movies"},
+			map[string][]string{"me_movies": {"duration", "title", "year"}},
+		},
+		{"SELECT m.* , c.name FROM me_movies as m LEFT JOIN me_cast as c ON m.id = c.movie_id",
+			[]string{"me_movies", "me_cast"},
+			map[string][]string{"me_cast": {"movie_id", "name"}},
+		},
+		{"SELECT title FROM me_movies as m WHERE m.id = 1",
+			[]string{"me_movies"},
+			map[string][]string{"me_movies": {"id", "title"}},
+		},
+	}
+
+	for i, c := range cases {
+		stmt, err := sqlparser.Parse(c.query)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		got := referencedColumns(stmt, c.sources)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
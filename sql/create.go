@@ -0,0 +1,201 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cube2222/octosql"
+	"github.com/cube2222/octosql/execution"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+)
+
+// createDatasetRegex matches statements in the form
+// "CREATE DATASET [ref] AS SELECT ...", accepting "TABLE" in place of "DATASET"
+var createDatasetRegex = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:DATASET|TABLE)\s+(\S+)\s+AS\s+(SELECT\b.*?)\s*;?\s*$`)
+
+// ParseCreateDataset checks if query is a CREATE DATASET ... AS SELECT
+// statement, returning the dataset reference to save to and the SELECT query
+// that produces the dataset body. ok is false for any other kind of statement
+func ParseCreateDataset(query string) (ref, selectQuery string, ok bool) {
+	matches := createDatasetRegex.FindStringSubmatch(query)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// Dataset runs a SELECT query, returning the result as a dataset with a CSV
+// body & a structure generated from the columns in the query result, which
+// keeps the result queryable with SQL. The returned dataset has no name or
+// commit
+func (svc *Service) Dataset(ctx context.Context, query string) (*dataset.Dataset, error) {
+	out := &datasetOutput{}
+	if err := svc.exec(ctx, out, query, svc.loadDataset); err != nil {
+		return nil, err
+	}
+	return out.Dataset()
+}
+
+// datasetOutput is an octosql output that collects records into the body &
+// structure of a dataset
+type datasetOutput struct {
+	records []*execution.Record
+}
+
+// WriteRecord adds a record to the output
+func (o *datasetOutput) WriteRecord(record *execution.Record) error {
+	o.records = append(o.records, record)
+	return nil
+}
+
+// Close finalizes the output
+func (o *datasetOutput) Close() error { return nil }
+
+// table arranges all records written to the output into rows of values,
+// returning column titles & types alongside the rows
+func (o *datasetOutput) table() (titles []string, types []octosql.Type, body [][]interface{}) {
+	var fields []string
+	seen := map[string]bool{}
+	for _, rec := range o.records {
+		for _, name := range rec.FieldNames {
+			if !seen[name] {
+				seen[name] = true
+				fields = append(fields, name)
+			}
+		}
+	}
+
+	types = make([]octosql.Type, len(fields))
+	body = make([][]interface{}, len(o.records))
+	for i, rec := range o.records {
+		row := make([]interface{}, len(fields))
+		for j, name := range fields {
+			val := rec.Value(octosql.NewVariableName(name))
+			types[j] = mergeType(types[j], val.GetType())
+			row[j] = rawValue(val)
+		}
+		body[i] = row
+	}
+
+	return columnTitles(fields), types, body
+}
+
+// Dataset constructs a dataset from all records written to the output
+func (o *datasetOutput) Dataset() (*dataset.Dataset, error) {
+	titles, types, body := o.table()
+	items := make([]interface{}, len(titles))
+	for i := range titles {
+		items[i] = map[string]interface{}{
+			"title": titles[i],
+			"type":  jsonSchemaType(types[i]),
+		}
+	}
+
+	st := &dataset.Structure{
+		Format: "csv",
+		FormatConfig: map[string]interface{}{
+			"headerRow": true,
+		},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(st, buf)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range body {
+		if err := w.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	ds := &dataset.Dataset{Structure: st}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", buf.Bytes()))
+	return ds, nil
+}
+
+// rawValue converts an octosql value to a go value that can be written to
+// a dataset body
+func rawValue(val octosql.Value) interface{} {
+	switch val.GetType() {
+	case octosql.TypeTime:
+		return val.AsTime().Format(time.RFC3339Nano)
+	case octosql.TypeDuration:
+		// durations are encoded as integer nanoseconds
+		return int64(val.AsDuration())
+	case octosql.TypePhantom:
+		return nil
+	}
+	return val.ToRawValue()
+}
+
+// mergeType combines the type of a column seen so far with the type of a new
+// value, ignoring nulls & promoting mixed integers & floats to floats.
+// Columns with otherwise conflicting types are marked with TypePhantom, which
+// has no schema type
+func mergeType(prev, next octosql.Type) octosql.Type {
+	switch {
+	case next == octosql.TypeNull || next == octosql.TypeZero:
+		return prev
+	case prev == octosql.TypeZero || prev == next:
+		return next
+	case prev == octosql.TypeInt && next == octosql.TypeFloat, prev == octosql.TypeFloat && next == octosql.TypeInt:
+		return octosql.TypeFloat
+	}
+	return octosql.TypePhantom
+}
+
+// jsonSchemaType gives the JSON schema type name for a column type. Columns
+// with no single type are typed as strings
+func jsonSchemaType(t octosql.Type) string {
+	switch t {
+	case octosql.TypeInt, octosql.TypeDuration:
+		return "integer"
+	case octosql.TypeFloat:
+		return "number"
+	case octosql.TypeBool:
+		return "boolean"
+	case octosql.TypeTuple:
+		return "array"
+	case octosql.TypeObject:
+		return "object"
+	}
+	return "string"
+}
+
+// columnTitles drops table alias prefixes from field names (eg: "a.title"
+// becomes "title"), falling back to replacing the separating period with an
+// underscore when dropping the prefix would create duplicate titles
+func columnTitles(fields []string) []string {
+	titles := make([]string, len(fields))
+	counts := map[string]int{}
+	for i, f := range fields {
+		if idx := strings.Index(f, "."); idx >= 0 {
+			titles[i] = f[idx+1:]
+		} else {
+			titles[i] = f
+		}
+		counts[titles[i]]++
+	}
+	for i, f := range fields {
+		if counts[titles[i]] > 1 {
+			titles[i] = strings.Replace(f, ".", "_", -1)
+		}
+	}
+	return titles
+}
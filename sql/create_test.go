@@ -0,0 +1,73 @@
+package sql
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/cube2222/octosql"
+	"github.com/cube2222/octosql/execution"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCreateDataset(t *testing.T) {
+	cases := []struct {
+		query       string
+		ref, selQry string
+		ok          bool
+	}{
+		{"SELECT * FROM me/movies as m", "", "", false},
+		{"CREATE DATASET me/summary AS SELECT m.title FROM me/movies as m", "me/summary", "SELECT m.title FROM me/movies as m", true},
+		{"create table me/summary as\n  select m.title\n  from me/movies as m;\n", "me/summary", "select m.title\n  from me/movies as m", true},
+		{"CREATE DATASET me/summary SELECT * FROM me/movies as m", "", "", false},
+	}
+
+	for i, c := range cases {
+		ref, sel, ok := ParseCreateDataset(c.query)
+		if ok != c.ok || ref != c.ref || sel != c.selQry {
+			t.Errorf("case %d: expected (%q, %q, %t), got: (%q, %q, %t)", i, c.ref, c.selQry, c.ok, ref, sel, ok)
+		}
+	}
+}
+
+func TestDatasetOutput(t *testing.T) {
+	fields := []octosql.VariableName{"m.title", "m.duration", "o.title"}
+	out := &datasetOutput{}
+	rows := [][]octosql.Value{
+		{octosql.MakeString("Avatar"), octosql.MakeInt(178), octosql.MakeNull()},
+		{octosql.MakeString("Spectre"), octosql.MakeFloat(148.5), octosql.MakeString("Bond")},
+	}
+	for _, row := range rows {
+		if err := out.WriteRecord(execution.NewRecordFromSlice(fields, row)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ds, err := out.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "m_title", "type": "string"},
+				map[string]interface{}{"title": "duration", "type": "number"},
+				map[string]interface{}{"title": "o_title", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, ds.Structure.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBody := "m_title,duration,o_title\nAvatar,178,\nSpectre,148.5,Bond\n"
+	if diff := cmp.Diff(expectBody, string(data)); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -153,7 +153,7 @@ func (p *processor) processTableRefs() error {
 // adds a table alias if one does not exist
 func (p *processor) processTableRef(text string) error {
 	if text != "" {
-		name := toLegalName(text)
+		name := p.legalName(text)
 		p.mapping[name] = text
 		p.processed.WriteString(name)
 	}
@@ -190,9 +190,38 @@ func (p *processor) processTableRef(text string) error {
 	}
 }
 
+// legalName converts a reference string to a legal table name, adding a
+// numeric suffix if another reference already maps to the same name
+func (p *processor) legalName(refStr string) string {
+	name := toLegalName(refStr)
+	for i := 2; ; i++ {
+		if existing, ok := p.mapping[name]; !ok || existing == refStr {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", toLegalName(refStr), i)
+	}
+}
+
+// legalNameReplacer swaps characters used in references for sequences that
+// are legal in SQL table names. Revisions like "~2", "^", ":branch" &
+// "@{2020-01-01}" are named so self-joins across versions get distinct table
+// names
+var legalNameReplacer = strings.NewReplacer(
+	"/", "_",
+	"~", "_rev_",
+	"^", "_parent",
+	":", "_branch_",
+	"@", "_",
+	"{", "_",
+	"}", "",
+	"-", "_",
+	"+", "_",
+	".", "_",
+)
+
 func toLegalName(refStr string) string {
 	refStr = strings.Replace(refStr, "@", "_at_", 1)
-	return strings.Replace(refStr, "/", "_", -1)
+	return legalNameReplacer.Replace(refStr)
 }
 
 // scan reads one token from the input stream
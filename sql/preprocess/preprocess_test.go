@@ -88,6 +88,39 @@ func TestPrepropcess(t *testing.T) {
 				"b5_covid_19_recovered": "b5/covid_19_recovered",
 			},
 		},
+		{
+			"select a.id from me/ds as a left join me/ds~1 as b on a.id = b.id where b.id is null",
+			"select a.id from me_ds as a left join me_ds_rev_1 as b on a.id = b.id where b.id is null",
+			map[string]string{
+				"me_ds":       "me/ds",
+				"me_ds_rev_1": "me/ds~1",
+			},
+		},
+		{
+			"select a.id from me/ds@HEAD as a, me/ds@HEAD^ b",
+			"select a.id from me_ds_at_HEAD as a, me_ds_at_HEAD_parent b",
+			map[string]string{
+				"me_ds_at_HEAD":        "me/ds@HEAD",
+				"me_ds_at_HEAD_parent": "me/ds@HEAD^",
+			},
+		},
+		{
+			"select a.id from me/ds:cleanup as a, me/ds@{2020-01-01} b, me/ds@v1.0 c",
+			"select a.id from me_ds_branch_cleanup as a, me_ds_at__2020_01_01 b, me_ds_at_v1_0 c",
+			map[string]string{
+				"me_ds_branch_cleanup": "me/ds:cleanup",
+				"me_ds_at__2020_01_01": "me/ds@{2020-01-01}",
+				"me_ds_at_v1_0":        "me/ds@v1.0",
+			},
+		},
+		{
+			"select a.id from me/ds_rev_1 as a, me/ds~1 b",
+			"select a.id from me_ds_rev_1 as a, me_ds_rev_1_2 b",
+			map[string]string{
+				"me_ds_rev_1":   "me/ds_rev_1",
+				"me_ds_rev_1_2": "me/ds~1",
+			},
+		},
 	}
 
 	for _, c := range good {
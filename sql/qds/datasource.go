@@ -2,19 +2,25 @@ package qds
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/cube2222/octosql"
 	"github.com/cube2222/octosql/config"
 	"github.com/cube2222/octosql/execution"
 	"github.com/cube2222/octosql/physical"
 	"github.com/cube2222/octosql/physical/metadata"
+	"github.com/cube2222/octosql/physical/optimizer"
 	golog "github.com/ipfs/go-log"
 	perrors "github.com/pkg/errors"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/dsio/replacecr"
 	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
@@ -28,9 +34,21 @@ const CfgTypeString = "qri"
 
 var log = golog.Logger("qds")
 
+// availableFilters lists the relations octosql can push down into a qri data
+// source. pushed-down filters are evaluated while reading, before building
+// full records. Any column can be filtered, so all relations are secondary
 var availableFilters = map[physical.FieldType]map[physical.Relation]struct{}{
-	physical.Primary:   make(map[physical.Relation]struct{}),
-	physical.Secondary: make(map[physical.Relation]struct{}),
+	physical.Primary: make(map[physical.Relation]struct{}),
+	physical.Secondary: {
+		physical.Equal:        struct{}{},
+		physical.NotEqual:     struct{}{},
+		physical.MoreThan:     struct{}{},
+		physical.LessThan:     struct{}{},
+		physical.GreaterEqual: struct{}{},
+		physical.LessEqual:    struct{}{},
+		physical.In:           struct{}{},
+		physical.NotIn:        struct{}{},
+	},
 }
 
 // DataSource implements a qri dataset as an octosql.DataSource
@@ -39,6 +57,14 @@ type DataSource struct {
 	alias string
 	ref   dsref.Ref
 	ds    *dataset.Dataset
+
+	// filter is evaluated against each entry, entries that don't match are
+	// skipped
+	filter physical.Formula
+	matCtx *physical.MaterializationContext
+	// columns optionally limits the columns read to a set of column titles.
+	// nil columns reads all columns
+	columns []string
 }
 
 // NewDataSourceBuilderFactory is a factory function for qri data source
@@ -51,6 +77,11 @@ func NewDataSourceBuilderFactory(r repo.Repo, loadDataset dsref.ParseResolveLoad
 				return nil, perrors.Wrap(err, "couldn't get path")
 			}
 
+			columns, err := config.GetStringList(dbConfig, "columns", config.WithDefault([]string(nil)))
+			if err != nil {
+				return nil, perrors.Wrap(err, "couldn't get columns")
+			}
+
 			ds, err := loadDataset(ctx, refstr)
 			if err != nil {
 				return nil, err
@@ -66,9 +97,12 @@ func NewDataSourceBuilderFactory(r repo.Repo, loadDataset dsref.ParseResolveLoad
 			}
 
 			return &DataSource{
-				r:     r,
-				alias: alias,
-				ref:   ref,
+				r:       r,
+				alias:   alias,
+				ref:     ref,
+				filter:  filter,
+				matCtx:  matCtx,
+				columns: columns,
 			}, nil
 		},
 		nil,
@@ -99,44 +133,103 @@ func (qds *DataSource) Get(ctx context.Context, variables octosql.Variables) (ex
 		return nil, perrors.Wrap(err, "couldn't open ")
 	}
 
-	r, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+	cols, err := initializeColumns(qds.alias, qds.ref, ds.Structure)
 	if err != nil {
-		return nil, err
+		return nil, perrors.Wrap(err, "couldn't initialize columns for record stream")
 	}
 
-	aliasedFields, err := initializeColumns(qds.alias, qds.ref, ds.Structure)
+	filter, err := qds.filter.Materialize(ctx, qds.matCtx)
 	if err != nil {
-		return nil, perrors.Wrap(err, "couldn't initialize columns for record stream")
+		return nil, perrors.Wrap(err, "couldn't materialize filter")
 	}
 
-	return &RecordStream{
-		alias:         qds.alias,
-		ds:            ds,
-		r:             r,
-		isDone:        false,
-		aliasedFields: aliasedFields,
-	}, nil
+	// decode columns the filter uses before any others, so entries that can't
+	// match are skipped without decoding the rest of the entry
+	filterCols := map[octosql.VariableName]bool{}
+	for _, pred := range qds.filter.ExtractPredicates() {
+		for _, v := range append(optimizer.GetVariables(ctx, pred.Left), optimizer.GetVariables(ctx, pred.Right)...) {
+			filterCols[v] = true
+		}
+	}
+	projected := map[string]bool{}
+	for _, col := range qds.columns {
+		projected[col] = true
+	}
+
+	rs := &RecordStream{
+		alias:     qds.alias,
+		ds:        ds,
+		body:      ds.BodyFile(),
+		r:         newCSVReader(ds.Structure, ds.BodyFile()),
+		hasHeader: dsio.HasHeaderRow(ds.Structure),
+		columns:   cols,
+		filter:    filter,
+		variables: variables,
+	}
+	for i, col := range cols {
+		if filterCols[col.field] {
+			rs.filterCols = append(rs.filterCols, i)
+		}
+		if filterCols[col.field] || qds.columns == nil || projected[col.title] {
+			rs.cols = append(rs.cols, i)
+		}
+	}
+	return rs, nil
 }
 
 // RecordStream connects a qri dataset to an octosql.RecordStream interface
 type RecordStream struct {
-	ds            *dataset.Dataset
-	r             dsio.EntryReader
-	isDone        bool
-	alias         string
-	aliasedFields []octosql.VariableName
+	ds        *dataset.Dataset
+	body      io.Closer
+	r         *csv.Reader
+	hasHeader bool
+	isDone    bool
+	alias     string
+
+	// all columns in the dataset
+	columns []column
+	// indexes of columns the filter uses, decoded before all others
+	filterCols []int
+	// indexes of all columns a record holds, in schema order
+	cols []int
+
+	filter    execution.Formula
+	variables octosql.Variables
+}
+
+// column is a named, typed column of a dataset
+type column struct {
+	title string
+	typ   string
+	field octosql.VariableName
+}
+
+// newCSVReader creates a csv reader configured by the format config of st
+func newCSVReader(st *dataset.Structure, r io.Reader) *csv.Reader {
+	csvr := csv.NewReader(replacecr.ReaderWithSize(r, 256*1024))
+	csvr.ReuseRecord = true
+	if opts, err := dataset.NewCSVOptions(st.FormatConfig); err == nil && opts != nil {
+		csvr.LazyQuotes = opts.LazyQuotes
+		if opts.VariadicFields {
+			csvr.FieldsPerRecord = -1
+		}
+		if opts.Separator != rune(0) {
+			csvr.Comma = opts.Separator
+		}
+	}
+	return csvr
 }
 
 // Close finalizes the stream
 func (rs *RecordStream) Close() error {
-	if err := rs.r.Close(); err != nil {
-		return perrors.Wrap(err, "couldn't close dataset entry reader")
+	if err := rs.body.Close(); err != nil {
+		return perrors.Wrap(err, "couldn't close dataset body")
 	}
 
 	return nil
 }
 
-func initializeColumns(alias string, ref dsref.Ref, st *dataset.Structure) ([]octosql.VariableName, error) {
+func initializeColumns(alias string, ref dsref.Ref, st *dataset.Structure) ([]column, error) {
 	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
 	if err != nil {
 		// the tabular package emits nice errors we can use as user-facing messages
@@ -150,14 +243,19 @@ func initializeColumns(alias string, ref dsref.Ref, st *dataset.Structure) ([]oc
 		return nil, qrierr.New(err, err.Error())
 	}
 
-	titles := cols.Titles()
-
-	fields := make([]octosql.VariableName, len(titles))
-	for i, t := range titles {
-		fields[i] = octosql.NewVariableName(fmt.Sprintf("%s.%s", alias, t))
+	res := make([]column, len(cols))
+	for i, c := range cols {
+		res[i] = column{
+			title: c.Title,
+			typ:   "string",
+			field: octosql.NewVariableName(fmt.Sprintf("%s.%s", alias, c.Title)),
+		}
+		if c.Type != nil && len(*c.Type) > 0 {
+			res[i].typ = []string(*c.Type)[0]
+		}
 	}
 
-	return fields, nil
+	return res, nil
 }
 
 // Next reads the next execution record in a stream
@@ -166,39 +264,89 @@ func (rs *RecordStream) Next(ctx context.Context) (*execution.Record, error) {
 		return nil, execution.ErrEndOfStream
 	}
 
-	ent, err := rs.r.ReadEntry()
-	if err != nil {
-		if err.Error() == "EOF" {
-			rs.isDone = true
-			rs.r.Close()
-			return nil, execution.ErrEndOfStream
-		}
-		log.Debug(err)
-		return nil, err
-	}
-
-	aliasedRecord := make(map[octosql.VariableName]octosql.Value)
-	if rec, ok := ent.Value.([]interface{}); ok {
-		for i, x := range rec {
-			switch v := x.(type) {
-			case string:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeString(v)
-			case int:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeInt(v)
-			case int64:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeInt(int(v))
-			case float64:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeFloat(v)
-			case bool:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeBool(v)
-			default:
-				aliasedRecord[rs.aliasedFields[i]] = octosql.MakeNull()
+	if rs.hasHeader {
+		rs.hasHeader = false
+		if _, err := rs.r.Read(); err != nil && err != io.EOF {
+			log.Debug(err)
+			return nil, err
+		}
+	}
+
+	for {
+		row, err := rs.r.Read()
+		if err != nil {
+			if err == io.EOF {
+				rs.isDone = true
+				return nil, execution.ErrEndOfStream
+			}
+			log.Debug(err)
+			return nil, err
+		}
+
+		values := make(map[octosql.VariableName]octosql.Value, len(rs.cols))
+		for _, i := range rs.filterCols {
+			values[rs.columns[i].field] = rs.value(row, i)
+		}
+
+		if len(rs.filterCols) > 0 {
+			vars, err := rs.variables.MergeWith(values)
+			if err != nil {
+				return nil, perrors.Wrap(err, "couldn't merge given variables with entry variables")
+			}
+			ok, err := rs.filter.Evaluate(ctx, vars)
+			if err != nil {
+				return nil, perrors.Wrap(err, "couldn't evaluate filter")
+			}
+			if !ok {
+				continue
 			}
 		}
-	} else {
-		log.Debugf("returned record is not an array type. got: %q", ent)
-		return nil, fmt.Errorf("returned record is not an array type. got: %q", ent)
+
+		fields := make([]octosql.VariableName, len(rs.cols))
+		for j, i := range rs.cols {
+			field := rs.columns[i].field
+			fields[j] = field
+			if _, ok := values[field]; !ok {
+				values[field] = rs.value(row, i)
+			}
+		}
+
+		return execution.NewRecord(fields, values), nil
+	}
+}
+
+// value decodes the value of column i in a row. Values that can't be parsed as
+// the column type are left as strings
+func (rs *RecordStream) value(row []string, i int) octosql.Value {
+	if i >= len(row) {
+		return octosql.MakeNull()
 	}
+	str := row[i]
 
-	return execution.NewRecord(rs.aliasedFields, aliasedRecord), nil
+	switch rs.columns[i].typ {
+	case "number":
+		if num, err := vals.ParseNumber([]byte(str)); err == nil {
+			return octosql.MakeFloat(num)
+		}
+	case "integer":
+		if num, err := vals.ParseInteger([]byte(str)); err == nil {
+			return octosql.MakeInt(int(num))
+		}
+	case "boolean":
+		if b, err := vals.ParseBoolean([]byte(str)); err == nil {
+			return octosql.MakeBool(b)
+		}
+	case "object":
+		// structured values aren't supported in queries
+		if err := json.Unmarshal([]byte(str), &map[string]interface{}{}); err == nil {
+			return octosql.MakeNull()
+		}
+	case "array":
+		if err := json.Unmarshal([]byte(str), &[]interface{}{}); err == nil {
+			return octosql.MakeNull()
+		}
+	case "null":
+		return octosql.MakeNull()
+	}
+	return octosql.MakeString(str)
 }
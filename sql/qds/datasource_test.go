@@ -44,6 +44,59 @@ func TestQriDatasource(t *testing.T) {
 	}
 }
 
+func TestQriDatasourceFilterPushdown(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	cases := []struct {
+		description string
+		query       string
+		columns     []interface{}
+		expect      string
+	}{
+		{"equality filter",
+			"select t1.duration from me_movies t1 where t1.title = 'Gods and Generals '",
+			nil,
+			"t1.duration\n280\n",
+		},
+		{"range filter with projected columns",
+			"select t1.title, t1.duration from me_movies t1 where t1.title > 'Zoo' and t1.title < 'Zoom'",
+			[]interface{}{"title", "duration"},
+			"t1.title,t1.duration\n'Zookeeper ',102\n'Zoolander 2 ',102\n'Zoolander ',90\n",
+		},
+		{"star select keeps schema column order when filtering a later column",
+			"select * from me_cities t1 where t1.avg_age > 60.0",
+			nil,
+			"t1.city,t1.pop,t1.avg_age,t1.in_usa\n'chatham',35000,65.25,true\n",
+		},
+		{"projection without filter",
+			"select t1.duration from me_movies t1 limit 1",
+			[]interface{}{"duration"},
+			"t1.duration\n178\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			dsCfg := map[string]interface{}{"ref": "me/movies"}
+			if c.columns != nil {
+				dsCfg["columns"] = c.columns
+			}
+			cfg := &octocfg.Config{
+				DataSources: []octocfg.DataSourceConfig{
+					{Type: CfgTypeString, Name: "me_movies", Config: dsCfg},
+					{Type: CfgTypeString, Name: "me_cities", Config: map[string]interface{}{"ref": "me/cities"}},
+				},
+			}
+
+			res := tr.MustRun(t, c.query, cfg)
+			if diff := cmp.Diff(c.expect, res); diff != "" {
+				t.Errorf("result mismatch. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 type testRunner struct {
 	ctx  context.Context
 	repo repo.Repo
@@ -104,7 +157,9 @@ func (tr *testRunner) MustRun(t *testing.T, query string, cfg *octocfg.Config) s
 	}
 
 	// Run query
-	app.RunPlan(tr.ctx, plan)
+	if err := app.RunPlan(tr.ctx, plan); err != nil {
+		t.Fatal(err)
+	}
 	return out.String()
 }
 
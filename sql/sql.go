@@ -17,6 +17,7 @@ import (
 	"github.com/cube2222/octosql/physical"
 	golog "github.com/ipfs/go-log"
 	"github.com/pkg/errors"
+	"github.com/qri-io/qri/base/columnar"
 	"github.com/qri-io/qri/dsref"
 	qrierr "github.com/qri-io/qri/errors"
 	"github.com/qri-io/qri/repo"
@@ -30,38 +31,105 @@ var log = golog.Logger("sql")
 type Service struct {
 	r           repo.Repo
 	loadDataset dsref.ParseResolveLoad
+	cache       *ResultCache
 }
 
-// New creates an SQL service
-func New(r repo.Repo, loadDataset dsref.ParseResolveLoad) *Service {
+// Options configures a Service
+type Options struct {
+	// ResultCache stores query results for reuse. Results are only reused
+	// while all datasets in a query resolve to the same versions. nil
+	// disables caching
+	ResultCache *ResultCache
+}
+
+// New creates an SQL service. Table references in queries are loaded with
+// loadDataset, which selects any revisions like "me/ds~1" that references use
+func New(r repo.Repo, loadDataset dsref.ParseResolveLoad, opts ...func(o *Options)) *Service {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return &Service{
 		r:           r,
 		loadDataset: loadDataset,
+		cache:       o.ResultCache,
 	}
 }
 
 // Exec runs an SQL query against a given dataset mapping
 func (svc *Service) Exec(ctx context.Context, w io.Writer, outFormat, query string) error {
+	if svc.cache != nil {
+		return svc.cachedExec(ctx, w, outFormat, query)
+	}
+	return svc.uncachedExec(ctx, w, outFormat, query)
+}
+
+// newOutput creates an output that writes results in the named format
+func newOutput(w io.Writer, outFormat string) (output.Output, error) {
+	switch outFormat {
+	case "table":
+		return table.NewOutput(w, false), nil
+	case "table_row_separated":
+		return table.NewOutput(w, true), nil
+	case "json":
+		return jsonoutput.NewOutput(w), nil
+	case "csv":
+		return csvoutput.NewOutput(',', w), nil
+	case "tabbed":
+		return csvoutput.NewOutput('\t', w), nil
+	case columnar.ParquetFormat, columnar.ArrowFormat:
+		return &columnarOutput{format: outFormat, w: w}, nil
+	}
+	err := fmt.Errorf("invalid output type: %s", outFormat)
+	log.Error(err)
+	return nil, err
+}
+
+// exec runs a SELECT query, writing results to out. Datasets are loaded with
+// load
+func (svc *Service) exec(ctx context.Context, out output.Output, query string, load dsref.ParseResolveLoad) error {
 	processedQuery, sources, err := preprocess.Query(query)
 	if err != nil {
 		log.Errorf("mapping query: %s", err)
 		return err
 	}
 
+	// Parse query
+	stmt, err := sqlparser.Parse(processedQuery)
+	if err != nil {
+		log.Debugf("couldn't parse query: %s", err)
+		return qrierr.New(err, fmt.Sprintf("Parsing SQL:\n%s", err.Error()))
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	columns := referencedColumns(stmt, names)
+
 	// Configuration
 	cfg := &octosqlcfg.Config{}
 	for name, refStr := range sources {
+		dsCfg := map[string]interface{}{
+			"ref": refStr,
+		}
+		// only read columns the query uses
+		if cols, ok := columns[name]; ok {
+			list := make([]interface{}, len(cols))
+			for i, col := range cols {
+				list[i] = col
+			}
+			dsCfg["columns"] = list
+		}
 		cfg.DataSources = append(cfg.DataSources, octosqlcfg.DataSourceConfig{
-			Type: qds.CfgTypeString,
-			Name: name,
-			Config: map[string]interface{}{
-				"ref": refStr,
-			},
+			Type:   qds.CfgTypeString,
+			Name:   name,
+			Config: dsCfg,
 		})
 	}
 
 	ff := func(dbConfig map[string]interface{}) (physical.DataSourceBuilderFactory, error) {
-		return qds.NewDataSourceBuilderFactory(svc.r, svc.loadDataset), nil
+		return qds.NewDataSourceBuilderFactory(svc.r, load), nil
 	}
 
 	dataSourceRespository, err := physical.CreateDataSourceRepositoryFromConfig(
@@ -75,37 +143,13 @@ func (svc *Service) Exec(ctx context.Context, w io.Writer, outFormat, query stri
 		return err
 	}
 
-	var out output.Output
-	switch outFormat {
-	case "table":
-		out = table.NewOutput(w, false)
-	case "table_row_separated":
-		out = table.NewOutput(w, true)
-	case "json":
-		out = jsonoutput.NewOutput(w)
-	case "csv":
-		out = csvoutput.NewOutput(',', w)
-	case "tabbed":
-		out = csvoutput.NewOutput('\t', w)
-	default:
-		err = fmt.Errorf("invalid output type: %s", w)
-		log.Error(err)
-		return err
-	}
-
 	app := app.NewApp(cfg, dataSourceRespository, out, false)
 
-	// Parse query
-	stmt, err := sqlparser.Parse(processedQuery)
-	if err != nil {
-		log.Debugf("couldn't parse query: %s", err)
-		return qrierr.New(err, fmt.Sprintf("Parsing SQL:\n%s", err.Error()))
-	}
 	typed, ok := stmt.(sqlparser.SelectStatement)
 	if !ok {
 		log.Debugf("%v is not a select statement", reflect.TypeOf(stmt))
 		err := fmt.Errorf("invalid statement type, wanted sqlparser.SelectStatement got %v", reflect.TypeOf(stmt))
-		return qrierr.New(err, "only SELECT statements are supported")
+		return qrierr.New(err, "only SELECT and CREATE DATASET ... AS SELECT statements are supported")
 	}
 	plan, err := parser.ParseNode(typed)
 	if err != nil {
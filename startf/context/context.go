@@ -15,6 +15,19 @@ type Context struct {
 	values  starlark.StringDict
 	config  map[string]interface{}
 	secrets map[string]interface{}
+	// incremental reads upstream entries for incremental transforms
+	incremental Incremental
+}
+
+// Incremental loads the upstream entries incremental transforms read. Only
+// entries added since the previous run of the transform are returned
+type Incremental interface {
+	// ProcessedVersions maps each upstream dataset reference to the version
+	// the previous run of the transform processed
+	ProcessedVersions() map[string]string
+	// NewEntries returns the entries of an upstream dataset added since the
+	// processed version
+	NewEntries(refstr string) (starlark.Value, error)
 }
 
 // NewContext creates a new contex
@@ -34,6 +47,9 @@ func (c *Context) Struct() *starlarkstruct.Struct {
 		"get":        starlark.NewBuiltin("get", c.getValue),
 		"get_config": starlark.NewBuiltin("get_config", c.GetConfig),
 		"get_secret": starlark.NewBuiltin("get_secret", c.GetSecret),
+
+		"processed_versions": starlark.NewBuiltin("processed_versions", c.ProcessedVersions),
+		"new_entries":        starlark.NewBuiltin("new_entries", c.NewEntries),
 	}
 
 	for k, v := range c.results {
@@ -49,6 +65,11 @@ func (c *Context) SetResult(name string, value starlark.Value) {
 	c.results[name] = value
 }
 
+// SetIncremental enables the processed_versions & new_entries functions
+func (c *Context) SetIncremental(inc Incremental) {
+	c.incremental = inc
+}
+
 func (c *Context) setValue(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var (
 		key   starlark.String
@@ -101,3 +122,35 @@ func (c *Context) GetConfig(thread *starlark.Thread, _ *starlark.Builtin, args s
 
 	return util.Marshal(c.config[string(key)])
 }
+
+// ProcessedVersions returns a dict of upstream dataset references to the
+// version the previous run of this transform processed
+func (c *Context) ProcessedVersions(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("processed_versions", args, kwargs); err != nil {
+		return starlark.None, err
+	}
+	if c.incremental == nil {
+		return starlark.None, fmt.Errorf("processed_versions function is not enabled")
+	}
+
+	dict := &starlark.Dict{}
+	for ref, path := range c.incremental.ProcessedVersions() {
+		if err := dict.SetKey(starlark.String(ref), starlark.String(path)); err != nil {
+			return starlark.None, err
+		}
+	}
+	return dict, nil
+}
+
+// NewEntries returns the body entries of an upstream dataset added since the
+// version the previous run of this transform processed
+func (c *Context) NewEntries(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var refstr starlark.String
+	if err := starlark.UnpackArgs("new_entries", args, kwargs, "ref", &refstr); err != nil {
+		return starlark.None, err
+	}
+	if c.incremental == nil {
+		return starlark.None, fmt.Errorf("new_entries function is not enabled")
+	}
+	return c.incremental.NewEntries(refstr.GoString())
+}
@@ -71,6 +71,17 @@ func TestMissingSecrets(t *testing.T) {
 	}
 }
 
+func TestMissingIncremental(t *testing.T) {
+	thread := &starlark.Thread{}
+	ctx := NewContext(nil, nil)
+
+	_, err := ctx.NewEntries(thread, nil, starlark.Tuple{starlark.String("peer/upstream")}, nil)
+	expect := "new_entries function is not enabled"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error message mismatch. expected: %s, got: %v", expect, err)
+	}
+}
+
 // load implements the 'load' operation as used in the evaluator tests.
 func newLoader() func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
 	return testdata.NewLoader(nil, "context_is_global_no_module_name_exists")
@@ -0,0 +1,301 @@
+package ds
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/starlib/util"
+	"go.starlark.net/starlark"
+)
+
+// BodyEntries is a starlark iterable that reads body entries from a file on
+// disk one at a time, without holding the body in memory. Array bodies yield
+// entry values, object bodies yield (key, value) tuples. Each iteration
+// re-reads the file from the start
+type BodyEntries struct {
+	path string
+	st   *dataset.Structure
+	// err records the first error encountered reading entries. starlark
+	// iterators can't return errors, so iteration stops early & err is
+	// reported when the dataset is closed
+	err error
+}
+
+var _ starlark.Iterable = (*BodyEntries)(nil)
+
+// String implements the starlark.Value interface
+func (b *BodyEntries) String() string { return "<body_entries>" }
+
+// Type implements the starlark.Value interface
+func (b *BodyEntries) Type() string { return "body_entries" }
+
+// Freeze implements the starlark.Value interface. BodyEntries is immutable
+func (b *BodyEntries) Freeze() {}
+
+// Truth implements the starlark.Value interface
+func (b *BodyEntries) Truth() starlark.Bool { return starlark.True }
+
+// Hash implements the starlark.Value interface
+func (b *BodyEntries) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: body_entries")
+}
+
+// Err returns the first error encountered iterating entries
+func (b *BodyEntries) Err() error {
+	return b.err
+}
+
+// Iterate implements the starlark.Iterable interface
+func (b *BodyEntries) Iterate() starlark.Iterator {
+	it := &entryIterator{entries: b}
+	mode, err := schemaScanMode(b.st)
+	if err != nil {
+		it.setErr(err)
+		return it
+	}
+	it.obj = mode == smObject
+
+	if it.f, err = os.Open(b.path); err != nil {
+		it.setErr(err)
+		return it
+	}
+	if it.rr, err = dsio.NewEntryReader(b.st, it.f); err != nil {
+		it.setErr(fmt.Errorf("error allocating data reader: %s", err))
+	}
+	return it
+}
+
+// entryIterator is a single pass over a BodyEntries file
+type entryIterator struct {
+	entries *BodyEntries
+	f       *os.File
+	rr      dsio.EntryReader
+	obj     bool
+}
+
+// Next implements the starlark.Iterator interface
+func (it *entryIterator) Next(p *starlark.Value) bool {
+	if it.rr == nil {
+		return false
+	}
+	ent, err := it.rr.ReadEntry()
+	if err != nil {
+		if err.Error() != io.EOF.Error() {
+			it.setErr(err)
+		}
+		it.rr = nil
+		return false
+	}
+
+	val, err := util.Marshal(ent.Value)
+	if err != nil {
+		it.setErr(err)
+		it.rr = nil
+		return false
+	}
+	if it.obj {
+		*p = starlark.Tuple{starlark.String(ent.Key), val}
+	} else {
+		*p = val
+	}
+	return true
+}
+
+// Done implements the starlark.Iterator interface
+func (it *entryIterator) Done() {
+	if it.f != nil {
+		it.f.Close()
+		it.f = nil
+	}
+}
+
+func (it *entryIterator) setErr(err error) {
+	if it.entries.err == nil {
+		it.entries.err = err
+	}
+}
+
+// rowWriter writes rows added with append_rows to a temporary file
+type rowWriter struct {
+	f   *os.File
+	w   dsio.EntryWriter
+	obj bool
+	i   int
+}
+
+// newRowWriter creates a temporary file to write entries of structure st to
+func newRowWriter(st *dataset.Structure) (*rowWriter, error) {
+	mode, err := schemaScanMode(st)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile("", "qri_transform_body")
+	if err != nil {
+		return nil, err
+	}
+	w, err := dsio.NewEntryWriter(st, f)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &rowWriter{f: f, w: w, obj: mode == smObject}, nil
+}
+
+// copyFrom writes all entries of the body file at path to the row writer
+func (rw *rowWriter) copyFrom(st *dataset.Structure, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rr, err := dsio.NewEntryReader(st, f)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
+	}
+	return dsio.EachEntry(rr, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		return rw.write(ent.Key, ent.Value)
+	})
+}
+
+// append writes each row of rows, read from iter. Object bodies accept a dict
+// or an iterable of (key, value) pairs
+func (rw *rowWriter) append(rows starlark.Value, iter starlark.Iterator) error {
+	if dict, ok := rows.(*starlark.Dict); ok && rw.obj {
+		for _, item := range dict.Items() {
+			if err := rw.appendPair(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var row starlark.Value
+	for iter.Next(&row) {
+		if rw.obj {
+			pair, ok := row.(starlark.Indexable)
+			if !ok || pair.Len() != 2 {
+				return fmt.Errorf("expected rows of an object body to be (key, value) pairs, got: %s", row.Type())
+			}
+			if err := rw.appendPair(starlark.Tuple{pair.Index(0), pair.Index(1)}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, err := util.Unmarshal(row)
+		if err != nil {
+			return err
+		}
+		if err := rw.write("", val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rw *rowWriter) appendPair(pair starlark.Tuple) error {
+	key, ok := starlark.AsString(pair[0])
+	if !ok {
+		return fmt.Errorf("expected row key to be a string, got: %s", pair[0].Type())
+	}
+	val, err := util.Unmarshal(pair[1])
+	if err != nil {
+		return err
+	}
+	return rw.write(key, val)
+}
+
+func (rw *rowWriter) write(key string, val interface{}) error {
+	ent := dsio.Entry{Value: val}
+	if rw.obj {
+		ent.Key = key
+	} else {
+		ent.Index = rw.i
+		rw.i++
+	}
+	return rw.w.WriteEntry(ent)
+}
+
+// close finishes writing rows, returning the path of the written file
+func (rw *rowWriter) close() (string, error) {
+	err := rw.w.Close()
+	if cerr := rw.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(rw.f.Name())
+		return "", err
+	}
+	return rw.f.Name(), nil
+}
+
+// discard abandons written rows, removing the temporary file
+func (rw *rowWriter) discard() {
+	rw.f.Close()
+	os.Remove(rw.f.Name())
+}
+
+// spool copies the body of ds to a temporary file so it can be read more than
+// once, replacing the consumed body file with a reader of the copy
+func spool(ds *dataset.Dataset) (string, error) {
+	f, err := ioutil.TempFile("", "qri_transform_body")
+	if err != nil {
+		return "", err
+	}
+	body := ds.BodyFile()
+	_, err = io.Copy(f, body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = setBodyFile(ds, f.Name(), body.FileName(), false)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// setBodyFile closes any existing body file of ds & replaces it with a reader
+// of the file at path. When remove is true the file at path is deleted once
+// the body file is closed
+func setBodyFile(ds *dataset.Dataset, path, name string, remove bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if prev := ds.BodyFile(); prev != nil {
+		prev.Close()
+	}
+	var rc io.ReadCloser = f
+	if remove {
+		rc = removeOnClose{f}
+	}
+	ds.SetBodyFile(qfs.NewMemfileReader(name, rc))
+	return nil
+}
+
+// removeOnClose deletes a file when it's closed
+type removeOnClose struct {
+	*os.File
+}
+
+// Close closes & removes the file
+func (r removeOnClose) Close() error {
+	err := r.File.Close()
+	if rerr := os.Remove(r.File.Name()); err == nil && !os.IsNotExist(rerr) {
+		err = rerr
+	}
+	return err
+}
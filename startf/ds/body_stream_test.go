@@ -0,0 +1,136 @@
+package ds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"go.starlark.net/starlark"
+)
+
+func TestBodyEntriesObject(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "json",
+		Schema: dataset.BaseSchemaObject,
+	}
+	prev := &dataset.Dataset{Structure: st}
+	prev.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`{"a":1,"b":2}`)))
+	d := NewDataset(prev, nil)
+	d.SetMutable(&dataset.Dataset{Structure: st})
+	thread := &starlark.Thread{}
+
+	entries, err := d.BodyEntries(thread, nil, starlark.Tuple{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := starlark.NewList(nil)
+	iter := entries.(starlark.Iterable).Iterate()
+	var row starlark.Value
+	for iter.Next(&row) {
+		rows.Append(row)
+	}
+	iter.Done()
+	expect := `[("a", 1), ("b", 2)]`
+	if rows.String() != expect {
+		t.Errorf("entries mismatch. expected: %s, got: %s", expect, rows)
+	}
+
+	add := &starlark.Dict{}
+	add.SetKey(starlark.String("c"), starlark.MakeInt(3))
+	if _, err := d.AppendRows(thread, nil, starlark.Tuple{add}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !d.IsBodyModified() {
+		t.Errorf("expected body to have been modified")
+	}
+
+	readPath := d.readPath
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(readPath); !os.IsNotExist(err) {
+		t.Errorf("expected closing dataset to remove read body copy")
+	}
+
+	data, err := ioutil.ReadAll(d.write.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.write.BodyFile().Close(); err != nil {
+		t.Fatal(err)
+	}
+	expect = `{"a":1,"b":2,"c":3}`
+	if string(data) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, string(data))
+	}
+}
+
+func TestAppendRowsThenSetBody(t *testing.T) {
+	d := csvDataset()
+	thread := &starlark.Thread{}
+
+	row := starlark.NewList([]starlark.Value{
+		starlark.NewList([]starlark.Value{starlark.String("baz"), starlark.MakeInt(4), starlark.String("yes")}),
+	})
+	if _, err := d.AppendRows(thread, nil, starlark.Tuple{row}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := d.GetBody(thread, nil, starlark.Tuple{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `[["foo", 1, "true"], ["bar", 2, "false"], ["bat", 3, "meh"], ["baz", 4, "yes"]]`
+	if fmt.Sprintf("%s", body) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, body)
+	}
+
+	writePath := d.writePath
+	if _, err := d.SetBody(thread, nil, starlark.Tuple{row}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(writePath); !os.IsNotExist(err) {
+		t.Errorf("expected set_body to remove appended rows")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err = d.GetBody(thread, nil, starlark.Tuple{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `[["baz", 4, "yes"]]`
+	if fmt.Sprintf("%s", body) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, body)
+	}
+}
+
+func TestBodyEntriesReadError(t *testing.T) {
+	prev := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: dataset.BaseSchemaArray,
+		},
+	}
+	prev.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,oops`)))
+	d := NewDataset(prev, nil)
+	thread := &starlark.Thread{}
+
+	entries, err := d.BodyEntries(thread, nil, starlark.Tuple{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter := entries.(starlark.Iterable).Iterate()
+	var row starlark.Value
+	for iter.Next(&row) {
+	}
+	iter.Done()
+
+	if err := d.Close(); err == nil {
+		t.Errorf("expected closing dataset to report read error")
+	}
+}
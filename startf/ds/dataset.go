@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"sync"
 
 	"github.com/qri-io/dataset"
@@ -49,6 +50,15 @@ type Dataset struct {
 	bodyCache starlark.Iterable
 	check     MutateFieldCheck
 	modBody   bool
+
+	// temporary files holding copies of the read & write bodies, for
+	// streaming body entries
+	readPath  string
+	writePath string
+	// rows is an open writer for rows added with append_rows
+	rows *rowWriter
+	// entries tracks iterables created by body_entries
+	entries []*BodyEntries
 }
 
 // NewDataset creates a dataset object, intended to be called from go-land to prepare datasets
@@ -68,11 +78,40 @@ func (d *Dataset) SetMutable(ds *dataset.Dataset) {
 	d.write = ds
 }
 
-// IsBodyModified returns whether the body has been modified by set_body
+// IsBodyModified returns whether the body has been modified by set_body or
+// append_rows
 func (d *Dataset) IsBodyModified() bool {
 	return d.modBody
 }
 
+// Close finishes writing rows added with append_rows, making them the body of
+// the mutable dataset, and removes temporary copies of the read body. Close
+// returns the first error encountered iterating body entries. Datasets must
+// be closed once a transform is done with them
+func (d *Dataset) Close() error {
+	err := d.finishRows()
+	if d.writePath != "" {
+		// the body file deletes the temporary file once it's been read
+		if serr := setBodyFile(d.write, d.writePath, d.write.BodyFile().FileName(), true); err == nil {
+			err = serr
+		}
+		d.writePath = ""
+	}
+	if d.readPath != "" {
+		if d.read.BodyFile() != nil {
+			d.read.BodyFile().Close()
+		}
+		os.Remove(d.readPath)
+		d.readPath = ""
+	}
+	for _, e := range d.entries {
+		if e.Err() != nil && err == nil {
+			err = e.Err()
+		}
+	}
+	return err
+}
+
 // Methods exposes dataset methods as starlark values
 func (d *Dataset) Methods() *starlarkstruct.Struct {
 	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
@@ -82,6 +121,8 @@ func (d *Dataset) Methods() *starlarkstruct.Struct {
 		"set_structure": starlark.NewBuiltin("set_structure", d.SetStructure),
 		"get_body":      starlark.NewBuiltin("get_body", d.GetBody),
 		"set_body":      starlark.NewBuiltin("set_body", d.SetBody),
+		"body_entries":  starlark.NewBuiltin("body_entries", d.BodyEntries),
+		"append_rows":   starlark.NewBuiltin("append_rows", d.AppendRows),
 	})
 }
 
@@ -230,6 +271,9 @@ func (d *Dataset) GetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 	}
 	if d.modBody && d.write != nil {
 		provider = d.write
+		if err := d.finishRows(); err != nil {
+			return starlark.None, err
+		}
 	}
 
 	if provider.BodyFile() == nil {
@@ -310,6 +354,7 @@ func (d *Dataset) SetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 			return starlark.None, fmt.Errorf("expected data for '%s' format to be a string", df)
 		}
 
+		d.discardWriteBody()
 		d.write.SetBodyFile(qfs.NewMemfileBytes(fmt.Sprintf("body.%s", df), []byte(string(str))))
 		d.modBody = true
 		d.bodyCache = nil
@@ -336,6 +381,7 @@ func (d *Dataset) SetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 		return starlark.None, err
 	}
 
+	d.discardWriteBody()
 	d.write.SetBodyFile(qfs.NewMemfileBytes(fmt.Sprintf("body.%s", d.write.Structure.Format), w.Bytes()))
 	d.modBody = true
 	d.bodyCache = nil
@@ -343,6 +389,174 @@ func (d *Dataset) SetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 	return starlark.None, nil
 }
 
+// BodyEntries returns an iterable of body entries that reads the body from
+// disk as it's iterated, for working with bodies too large to hold in memory.
+// Like get_body, the read body is used until the body is modified
+func (d *Dataset) BodyEntries(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("body_entries", args, kwargs); err != nil {
+		return starlark.None, err
+	}
+
+	provider := d.read
+	if d.modBody && d.write != nil {
+		provider = d.write
+	}
+	if provider == nil || provider.BodyFile() == nil {
+		return starlark.Tuple{}, nil
+	}
+	if provider.Structure == nil {
+		return starlark.None, fmt.Errorf("error: no structure for dataset")
+	}
+
+	path, err := d.bodyPath(provider)
+	if err != nil {
+		return starlark.None, err
+	}
+	entries := &BodyEntries{path: path, st: provider.Structure}
+	d.entries = append(d.entries, entries)
+	return entries, nil
+}
+
+// AppendRows adds rows to the end of the dataset body, writing them to a
+// temporary file instead of holding the body in memory. Rows of array bodies
+// are any iterable, including body_entries. Rows of object bodies are a dict
+// or an iterable of (key, value) pairs
+func (d *Dataset) AppendRows(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rows starlark.Value
+	if err := starlark.UnpackArgs("append_rows", args, kwargs, "rows", &rows); err != nil {
+		return starlark.None, err
+	}
+
+	if d.write == nil {
+		return starlark.None, fmt.Errorf("cannot call append_rows on read-only dataset")
+	}
+
+	if err := d.checkField("body"); err != nil {
+		return starlark.None, err
+	}
+
+	if err := d.checkField("structure"); err != nil {
+		err = fmt.Errorf("cannot use a transform to set the body of a dataset and manually adjust structure at the same time")
+		return starlark.None, err
+	}
+
+	iter, ok := rows.(starlark.Iterable)
+	if !ok {
+		return starlark.None, fmt.Errorf("expected rows to be iterable")
+	}
+
+	// start iterating before opening the row writer, which replaces files
+	// rows may be read from
+	it := iter.Iterate()
+	defer it.Done()
+
+	if d.rows == nil {
+		if err := d.startRows(rows); err != nil {
+			return starlark.None, err
+		}
+	}
+	d.modBody = true
+	d.bodyCache = nil
+
+	if err := d.rows.append(rows, it); err != nil {
+		return starlark.None, err
+	}
+	if entries, ok := rows.(*BodyEntries); ok && entries.Err() != nil {
+		return starlark.None, entries.Err()
+	}
+	return starlark.None, nil
+}
+
+// startRows opens a writer for appended rows, beginning with the entries of
+// the current body
+func (d *Dataset) startRows(rows starlark.Value) error {
+	provider := d.read
+	if d.modBody {
+		provider = d.write
+	}
+	src := ""
+	if provider != nil && provider.BodyFile() != nil && provider.Structure != nil {
+		var err error
+		if src, err = d.bodyPath(provider); err != nil {
+			return err
+		}
+	}
+
+	st := d.writeStructure(rows)
+	rw, err := newRowWriter(st)
+	if err != nil {
+		return err
+	}
+	if src != "" {
+		if err := rw.copyFrom(provider.Structure, src); err != nil {
+			rw.discard()
+			return err
+		}
+	}
+
+	d.discardWriteBody()
+	d.write.Structure = st
+	d.rows = rw
+	return nil
+}
+
+// finishRows closes the writer for appended rows, making the written file the
+// write body
+func (d *Dataset) finishRows() error {
+	if d.rows == nil {
+		return nil
+	}
+	path, err := d.rows.close()
+	d.rows = nil
+	if err != nil {
+		return err
+	}
+	d.writePath = path
+	return setBodyFile(d.write, path, fmt.Sprintf("body.%s", d.write.Structure.Format), false)
+}
+
+// discardWriteBody drops appended rows & temporary copies of the write body,
+// ahead of replacing the write body
+func (d *Dataset) discardWriteBody() {
+	if d.rows != nil {
+		d.rows.discard()
+		d.rows = nil
+	}
+	if d.writePath != "" {
+		if d.write.BodyFile() != nil {
+			d.write.BodyFile().Close()
+		}
+		os.Remove(d.writePath)
+		d.writePath = ""
+	}
+}
+
+// bodyPath returns the path to a temporary file holding the body of provider,
+// copying the body to disk on first use
+func (d *Dataset) bodyPath(provider *dataset.Dataset) (string, error) {
+	if provider == d.write {
+		if err := d.finishRows(); err != nil {
+			return "", err
+		}
+		if d.writePath != "" {
+			return d.writePath, nil
+		}
+	} else if d.readPath != "" {
+		return d.readPath, nil
+	}
+
+	path, err := spool(provider)
+	if err != nil {
+		return "", err
+	}
+	if provider == d.write {
+		d.writePath = path
+	} else {
+		d.readPath = path
+	}
+	return path, nil
+}
+
 // writeStructure determines the destination data structure for writing a
 // dataset body, falling back to a default json structure based on input values
 // if no prior structure exists
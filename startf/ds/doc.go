@@ -24,5 +24,11 @@
             structure (tuple, set, list, dict). When parse_as is set, set_body assumes the provided body value will
             be a string of serialized structured data in the given format. valid parse_as values are "json", "csv",
             "cbor", "xlsx".
+          body_entries() iterable
+            get an iterable of dataset body entries that reads the body from disk as it's iterated, without holding
+            the body in memory. Array bodies yield entry values, object bodies yield (key, value) tuples
+          append_rows(rows iterable)
+            add rows to the end of the dataset body, writing them to a temporary file instead of holding the body in
+            memory. rows of an object body are a dict or an iterable of (key, value) pairs
 */
 package ds
@@ -0,0 +1,266 @@
+package startf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// FixtureMode determines how HTTP requests made during the download step use
+// an HTTP fixture
+type FixtureMode string
+
+const (
+	// FixtureOff makes requests over the network without a fixture
+	FixtureOff FixtureMode = ""
+	// FixtureRecord makes requests over the network, recording responses to a
+	// fixture file
+	FixtureRecord FixtureMode = "record"
+	// FixtureReplay serves responses from a fixture file without using the
+	// network
+	FixtureReplay FixtureMode = "replay"
+)
+
+// ErrNoFixtureResponse is returned when replaying a request the fixture
+// has no recorded response for
+var ErrNoFixtureResponse = errors.New("no recorded response in http fixture")
+
+// ParseFixtureMode reads a fixture mode from a string
+func ParseFixtureMode(s string) (FixtureMode, error) {
+	switch mode := FixtureMode(s); mode {
+	case FixtureOff, FixtureRecord, FixtureReplay:
+		return mode, nil
+	}
+	return FixtureOff, fmt.Errorf("invalid http fixture mode %q. must be one of [record,replay]", s)
+}
+
+// FixturePath returns the path of the HTTP fixture for a transform script,
+// stored next to the script. The fixture for "transform.star" is
+// "transform.fixture.json"
+func FixturePath(scriptPath string) string {
+	return strings.TrimSuffix(scriptPath, filepath.Ext(scriptPath)) + ".fixture.json"
+}
+
+// Fixture is a recording of HTTP exchanges made by a transform. Fixtures are
+// stored next to transform scripts & are likely to be shared, so they don't
+// hold credentials. Each exchange records:
+//
+//   - the request method
+//   - the request URL, without credentials & with the values of query
+//     parameters that look like credentials replaced by "REDACTED"
+//   - a SHA-256 hash of the request body, instead of the body itself
+//   - the response status code & body
+//   - response headers, except ones that look like credentials, such as
+//     Set-Cookie
+//
+// Request headers aren't recorded
+type Fixture struct {
+	Responses []*FixtureResponse `json:"responses"`
+
+	lk     sync.Mutex
+	served map[int]bool
+}
+
+// FixtureResponse is a recorded HTTP response, and the request that produced it
+type FixtureResponse struct {
+	Method string `json:"method"`
+	// URL is the redacted request URL
+	URL string `json:"url"`
+	// RequestBodyHash is the hex-encoded SHA-256 hash of the request body,
+	// empty for requests without a body
+	RequestBodyHash string      `json:"requestBodyHash,omitempty"`
+	StatusCode      int         `json:"statusCode"`
+	Header          http.Header `json:"header,omitempty"`
+	Body            []byte      `json:"body"`
+}
+
+// redacted replaces values that may be credentials in fixtures
+const redacted = "REDACTED"
+
+// sensitiveWords are words in the names of query parameters & headers that
+// hold credentials
+var sensitiveWords = map[string]bool{
+	"apikey":        true,
+	"auth":          true,
+	"authorization": true,
+	"bearer":        true,
+	"cookie":        true,
+	"credential":    true,
+	"credentials":   true,
+	"jwt":           true,
+	"key":           true,
+	"passwd":        true,
+	"password":      true,
+	"secret":        true,
+	"session":       true,
+	"sessionid":     true,
+	"sig":           true,
+	"signature":     true,
+	"token":         true,
+}
+
+// isSensitive reports whether a query parameter or header name looks like it
+// holds credentials, like "api_key", "X-Auth-Token" or "Set-Cookie"
+func isSensitive(name string) bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	for _, w := range words {
+		if sensitiveWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureURL is the form of a request URL recorded in fixtures, without
+// credentials & with sensitive query values redacted
+func fixtureURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	c.Fragment = ""
+	if c.RawQuery != "" {
+		q := c.Query()
+		for name, vals := range q {
+			if isSensitive(name) {
+				for i := range vals {
+					vals[i] = redacted
+				}
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// fixtureHeader copies response headers, dropping ones that look like they
+// hold credentials
+func fixtureHeader(h http.Header) http.Header {
+	res := http.Header{}
+	for name, vals := range h {
+		if !isSensitive(name) {
+			res[name] = append([]string(nil), vals...)
+		}
+	}
+	return res
+}
+
+// bodyHash hashes a request body for matching requests in fixtures
+func bodyHash(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadFixture loads a fixture from a JSON file
+func ReadFixture(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading http fixture: %w", err)
+	}
+	f := &Fixture{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing http fixture %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// WriteFile saves the fixture as a JSON file
+func (f *Fixture) WriteFile(path string) error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// record adds an exchange to the fixture, returning the response with a
+// re-readable body
+func (f *Fixture) record(req *http.Request, reqBody []byte, res *http.Response) (*http.Response, error) {
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.Responses = append(f.Responses, &FixtureResponse{
+		Method:          req.Method,
+		URL:             fixtureURL(req.URL),
+		RequestBodyHash: bodyHash(reqBody),
+		StatusCode:      res.StatusCode,
+		Header:          fixtureHeader(res.Header),
+		Body:            body,
+	})
+	return res, nil
+}
+
+// replay finds the recorded response to a request, matching requests by their
+// redacted URL. Identical requests are served recorded responses in order,
+// repeating the last one once all have been served
+func (f *Fixture) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	if f.served == nil {
+		f.served = map[int]bool{}
+	}
+
+	u, hash := fixtureURL(req.URL), bodyHash(reqBody)
+	match := -1
+	for i, r := range f.Responses {
+		if r.Method != req.Method || r.URL != u || r.RequestBodyHash != hash {
+			continue
+		}
+		match = i
+		if !f.served[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoFixtureResponse, req.Method, u)
+	}
+	f.served[match] = true
+
+	r := f.Responses[match]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
+
+// requestBody reads the body of a request, leaving it readable
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
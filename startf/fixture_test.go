@@ -0,0 +1,199 @@
+package startf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"go.starlark.net/starlark"
+)
+
+func TestHTTPFixture(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"foo":["bar","baz","bat"]}`))
+	}))
+
+	dir, err := ioutil.TempDir("", "http_fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := FixturePath(filepath.Join(dir, "transform.star"))
+	if expect := filepath.Join(dir, "transform.fixture.json"); path != expect {
+		t.Errorf("fixture path mismatch. expected: %s, got: %s", expect, path)
+	}
+
+	run := func(mode FixtureMode) (string, error) {
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(scriptFile(t, "testdata/fetch.star"))
+		err := ExecScript(ctx, ds, nil, SetHTTPFixture(path, mode), func(o *ExecOpts) {
+			o.Globals["test_server_url"] = starlark.String(s.URL)
+		})
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadAll(ds.BodyFile())
+		return string(data), err
+	}
+
+	if _, err := run(FixtureReplay); err == nil {
+		t.Errorf("expected replaying a missing fixture to error")
+	}
+
+	expect := `["bar","baz","bat"]`
+	body, err := run(FixtureRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != expect {
+		t.Errorf("recorded body mismatch. expected: %s, got: %s", expect, body)
+	}
+	if requests != 1 {
+		t.Errorf("expected recording to make 1 request, got: %d", requests)
+	}
+
+	// replay without a server
+	s.Close()
+	body, err = run(FixtureReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != expect {
+		t.Errorf("replayed body mismatch. expected: %s, got: %s", expect, body)
+	}
+	if requests != 1 {
+		t.Errorf("expected replaying to make no requests, got: %d", requests-1)
+	}
+
+	f, err := ReadFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Responses[0].URL = s.URL + "/other"
+	if err := f.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	// starlark flattens errors to strings
+	if _, err := run(FixtureReplay); err == nil || !strings.Contains(err.Error(), ErrNoFixtureResponse.Error()) {
+		t.Errorf("expected unrecorded request to error with ErrNoFixtureResponse, got: %v", err)
+	}
+}
+
+func TestFixtureRedaction(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+		w.Header().Set("X-Auth-Token", "s3cr3t")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer s.Close()
+
+	f := &Fixture{}
+	u := s.URL + "/data?page=2&api_key=s3cr3t"
+	req, err := http.NewRequest("POST", u, strings.NewReader("password=s3cr3t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := roundTrip(req, f, FixtureRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("expected fixture not to contain credentials, got: %s", data)
+	}
+	r := f.Responses[0]
+	if expect := s.URL + "/data?api_key=REDACTED&page=2"; r.URL != expect {
+		t.Errorf("url mismatch. expected: %q, got: %q", expect, r.URL)
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected non-sensitive headers to be recorded, got: %v", r.Header)
+	}
+
+	// replays match requests by their redacted form
+	req, _ = http.NewRequest("POST", s.URL+"/data?page=2&api_key=0th3r", strings.NewReader("password=s3cr3t"))
+	if _, err := roundTrip(req, f, FixtureReplay); err != nil {
+		t.Errorf("expected request with a different api key to replay, got: %s", err)
+	}
+	req, _ = http.NewRequest("POST", s.URL+"/data?page=3&api_key=s3cr3t", strings.NewReader("password=s3cr3t"))
+	if _, err := roundTrip(req, f, FixtureReplay); !errors.Is(err, ErrNoFixtureResponse) {
+		t.Errorf("expected request for another page not to replay, got: %v", err)
+	}
+	req, _ = http.NewRequest("POST", u, strings.NewReader("password=other"))
+	if _, err := roundTrip(req, f, FixtureReplay); !errors.Is(err, ErrNoFixtureResponse) {
+		t.Errorf("expected request with another body not to replay, got: %v", err)
+	}
+}
+
+func TestHTTPFixturesArePerTransform(t *testing.T) {
+	dir, err := ioutil.TempDir("", "http_fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const url = "http://fixture.test/data"
+	wg := sync.WaitGroup{}
+	bodies := make([]string, 8)
+	errs := make([]error, len(bodies))
+	for i := range bodies {
+		path := filepath.Join(dir, fmt.Sprintf("tf_%d.fixture.json", i))
+		f := &Fixture{Responses: []*FixtureResponse{
+			{Method: "GET", URL: url, StatusCode: 200, Body: []byte(fmt.Sprintf(`{"foo":[%d]}`, i))},
+		}}
+		if err := f.WriteFile(path); err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+			ds.Transform.SetScriptFile(scriptFile(t, "testdata/fetch.star"))
+			errs[i] = ExecScript(context.Background(), ds, nil, SetHTTPFixture(path, FixtureReplay), func(o *ExecOpts) {
+				o.Globals["test_server_url"] = starlark.String(url)
+			})
+			if errs[i] == nil {
+				data, _ := ioutil.ReadAll(ds.BodyFile())
+				bodies[i] = string(data)
+			}
+		}(i, path)
+	}
+	wg.Wait()
+
+	for i, body := range bodies {
+		if errs[i] != nil {
+			t.Errorf("transform %d: unexpected error: %s", i, errs[i])
+		}
+		if expect := fmt.Sprintf("[%d]", i); body != expect {
+			t.Errorf("transform %d: expected body replayed from its own fixture %s, got: %s", i, expect, body)
+		}
+	}
+}
+
+func TestParseFixtureMode(t *testing.T) {
+	for _, s := range []string{"", "record", "replay"} {
+		if _, err := ParseFixtureMode(s); err != nil {
+			t.Errorf("parsing %q: %s", s, err)
+		}
+	}
+	if _, err := ParseFixtureMode("rewind"); err == nil {
+		t.Errorf("expected invalid mode to error")
+	}
+}
@@ -0,0 +1,232 @@
+package startf
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	skyds "github.com/qri-io/qri/startf/ds"
+	"go.starlark.net/starlark"
+)
+
+// processedVersions maps the "peername/name" of each upstream dataset the
+// previous version of a transform loaded to the path of the version it loaded.
+// versions are read from the transform resources of the previous version
+func processedVersions(prev *dataset.Dataset) map[string]string {
+	versions := map[string]string{}
+	if prev == nil || prev.Transform == nil {
+		return versions
+	}
+	for _, res := range prev.Transform.Resources {
+		if res == nil || qfs.PathKind(res.Path) == "http" {
+			continue
+		}
+		if i := strings.Index(res.Path, "@"); i > 0 {
+			versions[res.Path[:i]] = res.Path[i+1:]
+		}
+	}
+	return versions
+}
+
+// ProcessedVersions implements skyctx.Incremental, listing the upstream
+// versions the previous run of this transform processed
+func (t *transform) ProcessedVersions() map[string]string {
+	return processedVersions(t.prev)
+}
+
+// NewEntries implements skyctx.Incremental. It loads the latest version of an
+// upstream dataset & returns only the body entries added since the version the
+// previous run of this transform processed, assuming the upstream dataset only
+// appends entries. The first run of a transform gets every entry. Loaded
+// versions are recorded as transform resources so the next run can pick up
+// where this one left off
+func (t *transform) NewEntries(refstr string) (starlark.Value, error) {
+	if t.loadDataset == nil {
+		return starlark.None, fmt.Errorf("new_entries function is not enabled")
+	}
+
+	ds, err := t.loadDataset(t.ctx, refstr)
+	if err != nil {
+		return starlark.None, err
+	}
+	t.addResource(ds)
+
+	if ds.Structure == nil {
+		return starlark.None, fmt.Errorf("error: no structure for dataset")
+	}
+	w, err := skyds.NewStarlarkEntryWriter(ds.Structure)
+	if err != nil {
+		return starlark.None, fmt.Errorf("error allocating starlark entry writer: %s", err)
+	}
+
+	processed := processedVersions(t.prev)[fmt.Sprintf("%s/%s", ds.Peername, ds.Name)]
+	if processed == ds.Path || ds.BodyFile() == nil {
+		return w.Value(), nil
+	}
+
+	var prev *dataset.Structure
+	if processed != "" {
+		pds, err := t.loadDataset(t.ctx, fmt.Sprintf("%s/%s@%s", ds.Peername, ds.Name, processed))
+		if err != nil {
+			return starlark.None, fmt.Errorf("loading processed version of %s/%s: %w", ds.Peername, ds.Name, err)
+		}
+		if pds.BodyFile() != nil {
+			pds.BodyFile().Close()
+		}
+		prev = pds.Structure
+	}
+
+	defer ds.BodyFile().Close()
+	st, body, skip, err := appendedEntries(ds.Structure, ds.BodyFile(), prev)
+	if errors.Is(err, errNotAppended) {
+		t.print(fmt.Sprintf("%s/%s doesn't extend the processed version, reading all entries\n", ds.Peername, ds.Name))
+		// the body has been partly read, load it again from the start
+		if ds, err = t.loadDataset(t.ctx, fmt.Sprintf("%s/%s@%s", ds.Peername, ds.Name, ds.Path)); err != nil {
+			return starlark.None, err
+		}
+		defer ds.BodyFile().Close()
+		st, body, skip = ds.Structure, ds.BodyFile(), 0
+	} else if err != nil {
+		return starlark.None, err
+	}
+
+	rr, err := dsio.NewEntryReader(st, body)
+	if err != nil {
+		return starlark.None, fmt.Errorf("error allocating data reader: %s", err)
+	}
+	err = dsio.EachEntry(rr, func(i int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		if i < skip {
+			return nil
+		}
+		return w.WriteEntry(ent)
+	})
+	if err != nil {
+		return starlark.None, err
+	}
+
+	return w.Value(), nil
+}
+
+// errNotAppended is returned by appendedEntries when a body doesn't begin with
+// the body of the processed version
+var errNotAppended = errors.New("body doesn't extend the processed version")
+
+// appendedEntries prepares body to read the entries appended since the
+// processed version with structure prev. Bytes of CSV & JSON array bodies up
+// to the length of the processed body are hashed without being decoded, and
+// must match the checksum of the processed body. A structure & reader of the
+// appended tail is returned. Other formats can't be read from an offset, and
+// have the number of processed entries to skip while reading returned instead
+func appendedEntries(st *dataset.Structure, body io.Reader, prev *dataset.Structure) (*dataset.Structure, io.Reader, int, error) {
+	if prev == nil {
+		return st, body, 0, nil
+	}
+	if prev.Length > st.Length || prev.Entries > st.Entries {
+		return nil, nil, 0, errNotAppended
+	}
+	tlt, err := dsio.GetTopLevelType(st)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if prev.Length == 0 || prev.Format != st.Format {
+		return st, body, prev.Entries, nil
+	}
+
+	switch {
+	case st.Format == dataset.CSVDataFormat.String():
+		// the processed body ends with the newline of its last record
+		r, h, err := readPrefix(body, int64(prev.Length-1))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if b, err := r.ReadByte(); err != nil || b != '\n' || !matchesChecksum(h, b, prev.Checksum) {
+			return nil, nil, 0, errNotAppended
+		}
+		tail := &dataset.Structure{}
+		tail.Assign(st)
+		tail.FormatConfig = map[string]interface{}{}
+		for k, v := range st.FormatConfig {
+			tail.FormatConfig[k] = v
+		}
+		tail.FormatConfig["headerRow"] = false
+		return tail, r, 0, nil
+
+	case st.Format == dataset.JSONDataFormat.String() && tlt == "array":
+		// the processed body ends with the "]" that closes its array, which
+		// the body replaces with a comma if entries were appended
+		r, h, err := readPrefix(body, int64(prev.Length-1))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if !matchesChecksum(h, ']', prev.Checksum) {
+			return nil, nil, 0, errNotAppended
+		}
+		b, err := nextNonSpace(r)
+		if err != nil {
+			return nil, nil, 0, errNotAppended
+		}
+		switch {
+		case b == ']' && prev.Entries == st.Entries:
+			return st, strings.NewReader("[]"), 0, nil
+		case b == ',' && prev.Entries > 0:
+			return st, io.MultiReader(strings.NewReader("["), r), 0, nil
+		case b != ']' && prev.Entries == 0:
+			// the processed body was an empty array
+			if err := r.UnreadByte(); err != nil {
+				return nil, nil, 0, err
+			}
+			return st, io.MultiReader(strings.NewReader("["), r), 0, nil
+		}
+		return nil, nil, 0, errNotAppended
+	}
+	return st, body, prev.Entries, nil
+}
+
+// readPrefix moves past the first n bytes of r, returning a reader of the
+// remaining bytes & a sha256 hash of the bytes read
+func readPrefix(r io.Reader, n int64) (*bufio.Reader, hash.Hash, error) {
+	h := sha256.New()
+	if _, err := io.CopyN(h, r, n); err == io.EOF {
+		return nil, nil, errNotAppended
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return bufio.NewReader(r), h, nil
+}
+
+// matchesChecksum reports whether the bytes hashed by h followed by last match
+// checksum, a base58 encoded sha256 multihash like Structure.Checksum
+func matchesChecksum(h hash.Hash, last byte, checksum string) bool {
+	h.Write([]byte{last})
+	mh, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return false
+	}
+	return multihash.Multihash(mh).B58String() == checksum
+}
+
+// nextNonSpace reads the next byte of r that isn't JSON whitespace
+func nextNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return b, nil
+	}
+}
@@ -0,0 +1,171 @@
+package startf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+)
+
+// ErrLimitExceeded is the error transforms fail with when they use more
+// resources than their limits allow
+var ErrLimitExceeded = errors.New("transform exceeded resource limit")
+
+// Limits caps the resources a transform can use. Zero values are unlimited.
+// The starlark interpreter doesn't meter memory, MaxSteps bounds the work,
+// including allocations, a script can do instead
+type Limits struct {
+	// Timeout is the longest a transform can run. Transforms also stop when
+	// the context they're executed with is done
+	Timeout time.Duration
+	// MaxSteps is the most starlark execution steps a transform can take
+	MaxSteps uint64
+	// MaxBodyEntries is the most body entries a transform can produce
+	MaxBodyEntries int
+	// MaxBodyBytes is the largest body a transform can produce, in bytes
+	MaxBodyBytes int64
+	// MaxHTTPRequests is the most HTTP requests a download function can make
+	MaxHTTPRequests int
+	// MaxHTTPResponseBytes is the largest HTTP response body a download
+	// function can read, in bytes
+	MaxHTTPResponseBytes int64
+}
+
+// SetLimits caps the resources a transform can use
+func SetLimits(l Limits) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Limits = l
+	}
+}
+
+// deadlineError describes why a transform stopped before finishing
+func (l Limits) deadlineError(ctx context.Context) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return fmt.Errorf("transform cancelled: %w", ctx.Err())
+	}
+	if l.Timeout > 0 {
+		return fmt.Errorf("%w: transform ran longer than the %s time limit", ErrLimitExceeded, l.Timeout)
+	}
+	return fmt.Errorf("%w: transform ran past its deadline", ErrLimitExceeded)
+}
+
+// limitBody replaces the body file of ds with one that fails once the body
+// passes the body limits. The body is checked as it's read, without holding
+// it in memory, so oversized bodies fail when they're saved
+func (l Limits) limitBody(ds *dataset.Dataset) {
+	f := ds.BodyFile()
+	if (l.MaxBodyEntries == 0 && l.MaxBodyBytes == 0) || f == nil {
+		return
+	}
+
+	b := &limitedBodyFile{f: f, maxBytes: l.MaxBodyBytes}
+	if l.MaxBodyEntries > 0 && ds.Structure != nil {
+		b.countEntries(ds.Structure, l.MaxBodyEntries)
+	}
+	ds.SetBodyFile(qfs.NewMemfileReader(f.FileName(), b))
+}
+
+// limitedBodyFile counts the bytes read from a body file, copying them to an
+// entry reader that counts body entries
+type limitedBodyFile struct {
+	f        qfs.File
+	maxBytes int64
+	read     int64
+	err      error
+
+	// entries is written the bytes read from f while counting entries
+	entries *io.PipeWriter
+	// counted receives the result of counting entries
+	counted chan error
+}
+
+// countEntries starts counting the entries of body data as it's read
+func (b *limitedBodyFile) countEntries(st *dataset.Structure, max int) {
+	pr, pw := io.Pipe()
+	b.entries = pw
+	b.counted = make(chan error, 1)
+	go func() {
+		rr, err := dsio.NewEntryReader(st, pr)
+		if err == nil {
+			n := 0
+			err = dsio.EachEntry(rr, func(_ int, _ dsio.Entry, err error) error {
+				if err != nil {
+					return err
+				}
+				if n++; n > max {
+					return fmt.Errorf("%w: transform body has more than the %d entry limit", ErrLimitExceeded, max)
+				}
+				return nil
+			})
+		}
+		// stop writes to the pipe, which would otherwise block forever
+		pr.CloseWithError(io.ErrClosedPipe)
+		b.counted <- err
+	}()
+}
+
+// Read implements the io.Reader interface
+func (b *limitedBodyFile) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.f.Read(p)
+	if b.read += int64(n); b.maxBytes > 0 && b.read > b.maxBytes {
+		b.fail(fmt.Errorf("%w: transform body is larger than the %d byte limit", ErrLimitExceeded, b.maxBytes))
+		return 0, b.err
+	}
+
+	if b.entries != nil {
+		if _, werr := b.write(p[:n]); werr != nil {
+			b.finishCount()
+		} else if err == io.EOF {
+			b.entries.Close()
+			b.finishCount()
+		}
+		if b.err != nil {
+			return 0, b.err
+		}
+	}
+	return n, err
+}
+
+// write copies body data to the entry counter
+func (b *limitedBodyFile) write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return b.entries.Write(p)
+}
+
+// finishCount waits for entry counting to stop, failing reads if the body has
+// too many entries. Body data that can't be read as entries is left for the
+// reader of the body to report
+func (b *limitedBodyFile) finishCount() {
+	b.entries = nil
+	if err := <-b.counted; errors.Is(err, ErrLimitExceeded) {
+		b.err = err
+	}
+}
+
+// fail stops reads of the body with an error
+func (b *limitedBodyFile) fail(err error) {
+	b.err = err
+	if b.entries != nil {
+		b.entries.CloseWithError(err)
+		b.entries = nil
+	}
+}
+
+// Close closes the body file, stopping entry counting
+func (b *limitedBodyFile) Close() error {
+	if b.entries != nil {
+		b.entries.CloseWithError(io.ErrClosedPipe)
+		b.entries = nil
+	}
+	return b.f.Close()
+}
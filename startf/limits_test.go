@@ -0,0 +1,184 @@
+package startf
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"go.starlark.net/starlark"
+)
+
+func TestExecScriptLimits(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"foo":["bar","baz","bat"]}`))
+	}))
+	defer s.Close()
+
+	slow := `
+def transform(ds, ctx):
+	total = 0
+	for i in range(1000000000):
+		total += i
+	ds.set_body([[total]])
+`
+	body := `
+def transform(ds, ctx):
+	ds.set_body([[1],[2],[3]])
+`
+	fetch := `
+load("http.star", "http")
+
+def download(ctx):
+	for i in range(3):
+		http.get(test_server_url)
+	return []
+
+def transform(ds, ctx):
+	ds.set_body([[1]])
+`
+	read := `
+load("http.star", "http")
+
+def download(ctx):
+	return http.get(test_server_url).text()
+
+def transform(ds, ctx):
+	ds.set_body([[1]])
+`
+
+	cases := []struct {
+		description string
+		script      string
+		limits      Limits
+		err         string
+	}{
+		{"timeout", slow, Limits{Timeout: time.Millisecond * 50}, "transform exceeded resource limit: transform ran longer than the 50ms time limit"},
+		{"max body entries", body, Limits{MaxBodyEntries: 2}, "transform exceeded resource limit: transform body has more than the 2 entry limit"},
+		{"max body bytes", body, Limits{MaxBodyBytes: 5}, "transform exceeded resource limit: transform body is larger than the 5 byte limit"},
+		{"max http requests", fetch, Limits{MaxHTTPRequests: 2}, "transform exceeded resource limit: download made more than the 2 http request limit"},
+		{"max http response size", read, Limits{MaxHTTPResponseBytes: 10}, "transform exceeded resource limit: http response is larger than the 10 byte limit"},
+		{"max steps", slow, Limits{MaxSteps: 1000}, "transform exceeded resource limit: transform ran more than the 1000 execution step limit"},
+
+		{"within body limits", body, Limits{MaxBodyEntries: 3, MaxBodyBytes: 100}, ""},
+		{"within http limits", fetch, Limits{MaxHTTPRequests: 3, MaxHTTPResponseBytes: 100}, ""},
+		{"within step limit", body, Limits{MaxSteps: 1000}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+			ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(c.script)))
+
+			err := ExecScript(context.Background(), ds, nil, SetLimits(c.limits), func(o *ExecOpts) {
+				o.Globals["test_server_url"] = starlark.String(s.URL)
+			})
+			if err == nil && ds.BodyFile() != nil {
+				// body limits are checked as the body is read
+				_, err = ioutil.ReadAll(ds.BodyFile())
+			}
+			if c.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), c.err) {
+				t.Errorf("error mismatch. expected to contain: %q, got: %q", c.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestExecScriptDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	script := `
+def transform(ds, ctx):
+	for i in range(1000000000):
+		pass
+`
+	ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+	ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+
+	err := ExecScript(ctx, ds, nil)
+	expect := "transform exceeded resource limit: transform ran past its deadline"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	}
+}
+
+func TestExecScriptCancelStopsScript(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	script := `
+def transform(ds, ctx):
+	for i in range(1000000000):
+		ds.set_meta("title", "spinning")
+`
+	ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+	ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+
+	if err := ExecScript(ctx, ds, nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	// the script must have stopped, writes to ds after ExecScript returns are
+	// reported by the race detector
+	ds.Meta = nil
+}
+
+func TestExecScriptHTTPLimitsArePerTransform(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer s.Close()
+
+	fetch := `
+load("http.star", "http")
+
+def download(ctx):
+	for i in range(requests):
+		http.get(test_server_url)
+	return []
+
+def transform(ds, ctx):
+	ds.set_body([[1]])
+`
+	wg := sync.WaitGroup{}
+	errs := make([]error, 6)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// even transforms stay within their limit, odd ones exceed it
+			requests := 2 + i%2
+			ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+			ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(strings.Replace(fetch, "range(requests)", fmt.Sprintf("range(%d)", requests), 1))))
+			errs[i] = ExecScript(context.Background(), ds, nil, SetLimits(Limits{MaxHTTPRequests: 2}), func(o *ExecOpts) {
+				o.Globals["test_server_url"] = starlark.String(s.URL)
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if i%2 == 0 && err != nil {
+			t.Errorf("transform %d: unexpected error: %s", i, err)
+		}
+		if i%2 == 1 && (err == nil || !strings.Contains(err.Error(), ErrLimitExceeded.Error())) {
+			t.Errorf("transform %d: expected a limit error, got: %v", i, err)
+		}
+	}
+}
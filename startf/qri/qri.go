@@ -1,11 +1,21 @@
 package qri
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/stats"
+	"github.com/qri-io/starlib/util"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
 )
@@ -14,20 +24,63 @@ import (
 // in starlark's load() function, eg: load('qri.star', 'qri')
 const ModuleName = "qri.star"
 
+// ContextKey is the starlark thread-local key module functions read a
+// context.Context from
+const ContextKey = "context"
+
 var (
 	once      sync.Once
 	qriModule starlark.StringDict
 )
 
+// LogFunc lists the version history of a dataset reference, newest first
+type LogFunc func(ctx context.Context, refstr string, limit, offset int) ([]logbook.DatasetLogItem, error)
+
+// QueryFunc runs an SQL query, returning result rows. Queries load the datasets
+// they read with load, which is nil if the module has no dataset loader
+type QueryFunc func(ctx context.Context, load dsref.ParseResolveLoad, query string) ([]map[string]interface{}, error)
+
+// Options configures the functions a qri module provides. Functions without
+// the resources they need error when called
+type Options struct {
+	// LoadDataset loads datasets by reference string, required by qri.diff &
+	// qri.stats
+	LoadDataset dsref.ParseResolveLoad
+	// Log lists dataset history, required by qri.log
+	Log LogFunc
+	// Query runs SQL queries, required by qri.sql
+	Query QueryFunc
+	// Stats calculates dataset statistics. defaults to an uncached stats
+	// service
+	Stats *stats.Stats
+}
+
 // NewModule creates a new qri module instance
-func NewModule(repo repo.Repo) *Module {
-	return &Module{repo: repo}
+func NewModule(repo repo.Repo, opts ...func(o *Options)) *Module {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stats == nil {
+		o.Stats = stats.New(nil)
+	}
+	return &Module{
+		repo:        repo,
+		loadDataset: o.LoadDataset,
+		log:         o.Log,
+		query:       o.Query,
+		stats:       o.Stats,
+	}
 }
 
 // Module encapsulates state for a qri starlark module
 type Module struct {
-	repo repo.Repo
-	ds   *dataset.Dataset
+	repo        repo.Repo
+	ds          *dataset.Dataset
+	loadDataset dsref.ParseResolveLoad
+	log         LogFunc
+	query       QueryFunc
+	stats       *stats.Stats
 }
 
 // Namespace produces this module's exported namespace
@@ -45,6 +98,10 @@ func (m *Module) Struct() *starlarkstruct.Struct {
 // AddAllMethods augments a starlark.StringDict with all qri builtins. Should really only be used during "transform" step
 func (m *Module) AddAllMethods(sd starlark.StringDict) starlark.StringDict {
 	sd["list_datasets"] = starlark.NewBuiltin("list_datasets", m.ListDatasets)
+	sd["log"] = starlark.NewBuiltin("log", m.Log)
+	sd["diff"] = starlark.NewBuiltin("diff", m.Diff)
+	sd["stats"] = starlark.NewBuiltin("stats", m.Stats)
+	sd["sql"] = starlark.NewBuiltin("sql", m.SQL)
 	return sd
 }
 
@@ -66,3 +123,220 @@ func (m *Module) ListDatasets(thread *starlark.Thread, _ *starlark.Builtin, args
 	}
 	return l, nil
 }
+
+// Log lists the version history of a dataset, newest first. Each version is a
+// dict of version details, including "path", "commitTitle" & "commitTime"
+func (m *Module) Log(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		refstr starlark.String
+		limit  = 25
+		offset = 0
+	)
+	if err := starlark.UnpackArgs("log", args, kwargs, "ref", &refstr, "limit?", &limit, "offset?", &offset); err != nil {
+		return starlark.None, err
+	}
+	if m.log == nil {
+		return starlark.None, fmt.Errorf("log function is not enabled")
+	}
+
+	items, err := m.log(threadContext(thread), refstr.GoString(), limit, offset)
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(items)
+}
+
+// Diff compares two dataset versions, returning a dict with a "stat" summary
+// of changes & a list of "deltas". Meta, structure & body are compared
+func (m *Module) Diff(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var left, right starlark.String
+	if err := starlark.UnpackArgs("diff", args, kwargs, "left", &left, "right", &right); err != nil {
+		return starlark.None, err
+	}
+	if m.loadDataset == nil {
+		return starlark.None, fmt.Errorf("diff function is not enabled")
+	}
+
+	ctx := threadContext(thread)
+	a, err := m.diffData(ctx, left.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	b, err := m.diffData(ctx, right.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+
+	deltas, stat, err := deepdiff.New().StatDiff(ctx, a, b)
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(map[string]interface{}{
+		"stat":   stat,
+		"deltas": deltas,
+	})
+}
+
+// Stats calculates statistics for the body of a dataset, returning a list
+// with a dict of stats for each column
+func (m *Module) Stats(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var refstr starlark.String
+	if err := starlark.UnpackArgs("stats", args, kwargs, "ref", &refstr); err != nil {
+		return starlark.None, err
+	}
+	if m.loadDataset == nil {
+		return starlark.None, fmt.Errorf("stats function is not enabled")
+	}
+
+	ctx := threadContext(thread)
+	ds, err := m.loadDataset(ctx, refstr.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	if ds.BodyFile() == nil || ds.Structure == nil {
+		return starlark.None, fmt.Errorf("dataset %s/%s has no body", ds.Peername, ds.Name)
+	}
+
+	r, err := m.stats.JSON(ctx, ds)
+	if err != nil {
+		return starlark.None, err
+	}
+	return decodeJSON(r)
+}
+
+// SQL runs an SQL query against qri datasets, returning a list of rows. Each
+// row is a dict of column names to values
+func (m *Module) SQL(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var query starlark.String
+	if err := starlark.UnpackArgs("sql", args, kwargs, "query", &query); err != nil {
+		return starlark.None, err
+	}
+	if m.query == nil {
+		return starlark.None, fmt.Errorf("sql function is not enabled")
+	}
+
+	rows, err := m.query(threadContext(thread), m.loadDataset, query.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(rows)
+}
+
+// diffData loads the components of a dataset version that diff compares into
+// a single document
+func (m *Module) diffData(ctx context.Context, refstr string) (interface{}, error) {
+	ds, err := m.loadDataset(ctx, refstr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := map[string]interface{}{}
+	if ds.Meta != nil {
+		d["meta"] = ds.Meta
+	}
+	if ds.Structure != nil {
+		d["structure"] = &dataset.Structure{
+			Format:       ds.Structure.Format,
+			FormatConfig: ds.Structure.FormatConfig,
+			Schema:       ds.Structure.Schema,
+		}
+	}
+	if ds.BodyFile() != nil && ds.Structure != nil {
+		defer ds.BodyFile().Close()
+		rr, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+		if err != nil {
+			return nil, err
+		}
+		if d["body"], err = readEntries(rr); err != nil {
+			return nil, err
+		}
+	}
+
+	// round trip through JSON so diffing sees plain maps & slices
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// readEntries reads all entries from an EntryReader into an array or object
+func readEntries(rr dsio.EntryReader) (interface{}, error) {
+	tlt, err := dsio.GetTopLevelType(rr.Structure())
+	if err != nil {
+		return nil, err
+	}
+
+	if tlt == "object" {
+		obj := map[string]interface{}{}
+		err = dsio.EachEntry(rr, func(_ int, ent dsio.Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			obj[ent.Key] = ent.Value
+			return nil
+		})
+		return obj, err
+	}
+
+	arr := []interface{}{}
+	err = dsio.EachEntry(rr, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		arr = append(arr, ent.Value)
+		return nil
+	})
+	return arr, err
+}
+
+// threadContext gets the context a thread is executing with
+func threadContext(thread *starlark.Thread) context.Context {
+	if ctx, ok := thread.Local(ContextKey).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// toStarlark converts a go value to starlark values by way of its JSON
+// encoding. Whole numbers become starlark ints
+func toStarlark(v interface{}) (starlark.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return starlark.None, err
+	}
+	return decodeJSON(bytes.NewReader(data))
+}
+
+func decodeJSON(r io.Reader) (starlark.Value, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return starlark.None, err
+	}
+	return util.Marshal(convertNumbers(v))
+}
+
+// convertNumbers replaces json.Number values with int64s or float64s
+func convertNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case []interface{}:
+		for i, val := range x {
+			x[i] = convertNumbers(val)
+		}
+	case map[string]interface{}:
+		for key, val := range x {
+			x[key] = convertNumbers(val)
+		}
+	}
+	return v
+}
@@ -1,10 +1,14 @@
 package qri
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"go.starlark.net/starlark"
 )
 
@@ -39,3 +43,125 @@ func newLoader(ds *dataset.Dataset) func(thread *starlark.Thread, module string)
 		return nil, fmt.Errorf("invalid module")
 	}
 }
+
+func TestModuleFunctions(t *testing.T) {
+	versions := map[string]string{
+		"peer/cities~1": `[["toronto",40],["new york",80]]`,
+		"peer/cities":   `[["toronto",40],["new york",85],["chicago",60]]`,
+	}
+	load := func(ctx context.Context, refstr string) (*dataset.Dataset, error) {
+		body, ok := versions[refstr]
+		if !ok {
+			return nil, dsref.ErrRefNotFound
+		}
+		ds := &dataset.Dataset{
+			Peername: "peer",
+			Name:     "cities",
+			Meta:     &dataset.Meta{Title: refstr},
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "array",
+						"items": []interface{}{
+							map[string]interface{}{"title": "city", "type": "string"},
+							map[string]interface{}{"title": "pop", "type": "integer"},
+						},
+					},
+				},
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		return ds, nil
+	}
+	log := func(ctx context.Context, refstr string, limit, offset int) ([]logbook.DatasetLogItem, error) {
+		items := []logbook.DatasetLogItem{
+			{VersionInfo: dsref.VersionInfo{Path: "/map/b", BodyRows: 3}, CommitTitle: "added chicago"},
+			{VersionInfo: dsref.VersionInfo{Path: "/map/a", BodyRows: 2}, CommitTitle: "created dataset"},
+		}
+		if limit < len(items) {
+			items = items[:limit]
+		}
+		return items, nil
+	}
+	query := func(ctx context.Context, _ dsref.ParseResolveLoad, q string) ([]map[string]interface{}, error) {
+		if q != "SELECT * FROM peer/cities" {
+			return nil, fmt.Errorf("unexpected query: %q", q)
+		}
+		return []map[string]interface{}{{"city": "toronto", "pop": 40}}, nil
+	}
+
+	m := NewModule(nil, func(o *Options) {
+		o.LoadDataset = load
+		o.Log = log
+		o.Query = query
+	})
+
+	script := `
+load('qri.star', 'qri')
+
+log = qri.log("peer/cities", limit=1)
+assert_eq(len(log), 1)
+assert_eq(log[0]["commitTitle"], "added chicago")
+assert_eq(log[0]["bodyRows"], 3)
+
+diff = qri.diff("peer/cities~1", "peer/cities")
+assert_eq(diff["stat"]["inserts"] > 0, True)
+assert_eq(len(diff["deltas"]) > 0, True)
+
+stats = qri.stats("peer/cities")
+assert_eq(len(stats), 2)
+assert_eq(stats[1]["type"], "numeric")
+assert_eq(stats[1]["max"], 85)
+
+rows = qri.sql("SELECT * FROM peer/cities")
+assert_eq(rows, [{"city": "toronto", "pop": 40}])
+`
+	thread := &starlark.Thread{
+		Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+			if module == ModuleName {
+				return m.Namespace(), nil
+			}
+			return nil, fmt.Errorf("invalid module")
+		},
+	}
+	thread.SetLocal(ContextKey, context.Background())
+	predeclared := starlark.StringDict{
+		"assert_eq": starlark.NewBuiltin("assert_eq", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var a, b starlark.Value
+			if err := starlark.UnpackPositionalArgs("assert_eq", args, kwargs, 2, &a, &b); err != nil {
+				return nil, err
+			}
+			if eq, err := starlark.Equal(a, b); err != nil || !eq {
+				return nil, fmt.Errorf("%s != %s", a, b)
+			}
+			return starlark.None, nil
+		}),
+	}
+	if _, err := starlark.ExecFile(thread, "test.star", script, predeclared); err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			t.Fatal(evalErr.Backtrace())
+		}
+		t.Fatal(err)
+	}
+}
+
+func TestModuleFunctionsDisabled(t *testing.T) {
+	m := NewModule(nil)
+	thread := &starlark.Thread{}
+	calls := map[string]starlark.Tuple{
+		"log":   {starlark.String("peer/cities")},
+		"diff":  {starlark.String("peer/a"), starlark.String("peer/b")},
+		"stats": {starlark.String("peer/cities")},
+		"sql":   {starlark.String("SELECT 1")},
+	}
+	methods := m.AddAllMethods(starlark.StringDict{})
+	for name, args := range calls {
+		_, err := starlark.Call(thread, methods[name], args, nil)
+		expect := fmt.Sprintf("%s function is not enabled", name)
+		if err == nil || err.Error() != expect {
+			t.Errorf("%s: expected error %q, got: %v", name, expect, err)
+		}
+	}
+}
@@ -1,42 +1,219 @@
 package startf
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sync"
 
 	starhttp "github.com/qri-io/starlib/http"
+	"go.starlark.net/starlark"
 )
 
 var (
-	httpGuard = &HTTPGuard{}
 	// ErrNtwkDisabled is returned whenever a network call is attempted but h.NetworkEnabled is false
 	ErrNtwkDisabled = fmt.Errorf("network use is disabled. http can only be used during download step")
+
+	// httpModuleLock serializes creating http modules, which read
+	// package-level settings of the starlib http package
+	httpModuleLock sync.Mutex
 )
 
-// HTTPGuard protects network requests, only allowing when network is enabled
+// httpGuardKey is the thread local key of the HTTPGuard of a transform
+const httpGuardKey = "__httpGuard"
+
+// HTTPGuard protects network requests, only allowing when network is enabled.
+// HTTPGuard also limits the number of requests made & the size of responses.
+// Each transform has its own guard, so limits, request counts & fixtures of
+// transforms that run at the same time don't mix
 type HTTPGuard struct {
 	NetworkEnabled bool
+
+	lk               sync.Mutex
+	ctx              context.Context
+	maxRequests      int
+	maxResponseBytes int64
+	requests         int
+	urls             []string
+	fixture          *Fixture
+	fixtureMode      FixtureMode
+}
+
+// NewHTTPGuard creates a guard with networking disabled. Requests are
+// cancelled when ctx is done
+func NewHTTPGuard(ctx context.Context) *HTTPGuard {
+	return &HTTPGuard{ctx: ctx}
+}
+
+// threadHTTPGuard returns the HTTPGuard of the transform a thread runs
+func threadHTTPGuard(thread *starlark.Thread) *HTTPGuard {
+	h, _ := thread.Local(httpGuardKey).(*HTTPGuard)
+	return h
 }
 
 // Allowed implements starlib/http RequestGuard
 func (h *HTTPGuard) Allowed(req *http.Request) error {
+	h.lk.Lock()
+	defer h.lk.Unlock()
 	if !h.NetworkEnabled {
 		return ErrNtwkDisabled
 	}
+	if h.maxRequests > 0 && h.requests >= h.maxRequests {
+		return fmt.Errorf("%w: download made more than the %d http request limit", ErrLimitExceeded, h.maxRequests)
+	}
+	h.requests++
+	h.urls = append(h.urls, resourceURL(req.URL))
 	return nil
 }
 
+// Requested lists the URLs of allowed requests since limits were last set,
+// without credentials, query strings or fragments
+func (h *HTTPGuard) Requested() []string {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	return append([]string(nil), h.urls...)
+}
+
+// resourceURL strips credentials, query strings & fragments from a URL, which
+// may hold secrets like API keys
+func resourceURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	c.RawQuery = ""
+	c.ForceQuery = false
+	c.Fragment = ""
+	return c.String()
+}
+
 // EnableNtwk allows network calls
 func (h *HTTPGuard) EnableNtwk() {
+	h.lk.Lock()
+	defer h.lk.Unlock()
 	h.NetworkEnabled = true
 }
 
 // DisableNtwk prevents network calls from succeeding
 func (h *HTTPGuard) DisableNtwk() {
+	h.lk.Lock()
+	defer h.lk.Unlock()
 	h.NetworkEnabled = false
 }
 
+// SetLimits caps the number of requests & the size of response bodies,
+// resetting the request count & requested URLs. Zero values are unlimited
+func (h *HTTPGuard) SetLimits(maxRequests int, maxResponseBytes int64) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	h.maxRequests = maxRequests
+	h.maxResponseBytes = maxResponseBytes
+	h.requests = 0
+	h.urls = nil
+}
+
+// SetFixture records responses to, or replays responses from an HTTP
+// fixture. A nil fixture or FixtureOff mode uses the network directly
+func (h *HTTPGuard) SetFixture(f *Fixture, mode FixtureMode) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	h.fixture = f
+	h.fixtureMode = mode
+}
+
+// RoundTrip implements http.RoundTripper, cutting off response bodies that
+// are larger than the response size limit
+func (h *HTTPGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.lk.Lock()
+	max := h.maxResponseBytes
+	fixture, mode := h.fixture, h.fixtureMode
+	ctx := h.ctx
+	h.lk.Unlock()
+
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	res, err := roundTrip(req, fixture, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if max > 0 {
+		if res.ContentLength > max {
+			res.Body.Close()
+			return nil, responseTooLarge(max)
+		}
+		res.Body = &limitedBody{ReadCloser: res.Body, remaining: max, max: max}
+	}
+	return res, nil
+}
+
+// module creates an http starlark module that makes requests through the
+// guard
+func (h *HTTPGuard) module() (starlark.StringDict, error) {
+	httpModuleLock.Lock()
+	defer httpModuleLock.Unlock()
+	guard, client := starhttp.Guard, starhttp.Client
+	defer func() {
+		starhttp.Guard, starhttp.Client = guard, client
+	}()
+	starhttp.Guard = h
+	starhttp.Client = &http.Client{Transport: h}
+	return starhttp.LoadModule()
+}
+
+// roundTrip makes a request, recording or replaying the response with a
+// fixture if one is set
+func roundTrip(req *http.Request, fixture *Fixture, mode FixtureMode) (*http.Response, error) {
+	if fixture == nil || mode == FixtureOff {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if mode == FixtureReplay {
+		return fixture.replay(req, reqBody)
+	}
+
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return fixture.record(req, reqBody, res)
+}
+
+func responseTooLarge(max int64) error {
+	return fmt.Errorf("%w: http response is larger than the %d byte limit", ErrLimitExceeded, max)
+}
+
+// limitedBody errors when reading more than max bytes
+type limitedBody struct {
+	io.ReadCloser
+	remaining, max int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, responseTooLarge(b.max)
+	}
+	// read one byte past the limit to detect oversized responses
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = -1
+	return n, responseTooLarge(b.max)
+}
+
 func init() {
-	// connect httpGuard instance to starlib http guard
-	starhttp.Guard = httpGuard
+	// http modules loaded outside of a transform can't use the network
+	starhttp.Guard = &HTTPGuard{}
+	starhttp.Client = &http.Client{Transport: starhttp.Guard.(*HTTPGuard)}
 }
@@ -16,8 +16,10 @@ import (
 	skyctx "github.com/qri-io/qri/startf/context"
 	skyds "github.com/qri-io/qri/startf/ds"
 	skyqri "github.com/qri-io/qri/startf/qri"
+	"github.com/qri-io/qri/stats"
 	"github.com/qri-io/qri/version"
 	"github.com/qri-io/starlib"
+	starhttp "github.com/qri-io/starlib/http"
 	"go.starlark.net/resolve"
 	"go.starlark.net/starlark"
 )
@@ -49,6 +51,19 @@ type ExecOpts struct {
 	ErrWriter io.Writer
 	// starlark module loader function
 	ModuleLoader ModuleLoader
+	// resources the transform is allowed to use
+	Limits Limits
+	// function for listing dataset history, enables qri.log
+	Log skyqri.LogFunc
+	// function for running SQL queries, enables qri.sql
+	Query skyqri.QueryFunc
+	// stats service for qri.stats, defaults to uncached stats
+	Stats *stats.Stats
+	// path to an HTTP fixture file, used to record or replay responses to
+	// requests made by the download step
+	HTTPFixturePath string
+	// how the download step uses the HTTP fixture
+	HTTPFixtureMode FixtureMode
 }
 
 // AddDatasetLoader is required to enable the load_dataset starlark builtin
@@ -58,6 +73,37 @@ func AddDatasetLoader(prl dsref.ParseResolveLoad) func(o *ExecOpts) {
 	}
 }
 
+// AddLogFunc is required to enable the qri.log starlark builtin
+func AddLogFunc(log skyqri.LogFunc) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Log = log
+	}
+}
+
+// AddQueryFunc is required to enable the qri.sql starlark builtin
+func AddQueryFunc(query skyqri.QueryFunc) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Query = query
+	}
+}
+
+// AddStats provides the stats service the qri.stats starlark builtin uses
+func AddStats(s *stats.Stats) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Stats = s
+	}
+}
+
+// SetHTTPFixture records responses to HTTP requests made by the download
+// step to the fixture file at path, or replays responses from it without using
+// the network
+func SetHTTPFixture(path string, mode FixtureMode) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.HTTPFixturePath = path
+		o.HTTPFixtureMode = mode
+	}
+}
+
 // AddQriRepo adds a qri repo to execution options, providing scripted access
 // to assets within the respoitory
 func AddQriRepo(repo repo.Repo) func(o *ExecOpts) {
@@ -117,9 +163,12 @@ type transform struct {
 	skyqri       *skyqri.Module
 	checkFunc    func(path ...string) error
 	globals      starlark.StringDict
+	predeclared  starlark.StringDict
 	bodyFile     qfs.File
 	stderr       io.Writer
 	moduleLoader ModuleLoader
+	// datasets created for the script, closed when execution finishes
+	datasets []*skyds.Dataset
 
 	download starlark.Iterable
 }
@@ -149,17 +198,13 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 		opt(o)
 	}
 
-	// hoist execution settings to resolve package settings
-	resolve.AllowFloat = o.AllowFloat
-	resolve.AllowSet = o.AllowSet
-	resolve.AllowLambda = o.AllowLambda
-	resolve.AllowNestedDef = o.AllowNestedDef
-
-	// add error func to starlark environment
-	starlark.Universe["error"] = starlark.NewBuiltin("error", Error)
-	for key, val := range o.Globals {
-		starlark.Universe[key] = val
-	}
+	// hoist execution settings to resolve package settings. settings are
+	// package-level, only write ones that change so transforms with the same
+	// settings can run at the same time
+	setResolveFlag(&resolve.AllowFloat, o.AllowFloat)
+	setResolveFlag(&resolve.AllowSet, o.AllowSet)
+	setResolveFlag(&resolve.AllowLambda, o.AllowLambda)
+	setResolveFlag(&resolve.AllowNestedDef, o.AllowNestedDef)
 
 	// set transform details
 	next.Transform.Syntax = "starlark"
@@ -172,19 +217,35 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 	tr := io.TeeReader(script, buf)
 	pipeScript := qfs.NewMemfileReader(script.FileName(), tr)
 
+	// resources record the inputs of this run, drop any carried over from a
+	// previous version
+	next.Transform.Resources = nil
+
 	t := &transform{
 		ctx:          ctx,
+		predeclared:  o.Globals,
 		loadDataset:  o.DatasetLoader,
 		repo:         o.Repo,
 		next:         next,
 		prev:         prev,
-		skyqri:       skyqri.NewModule(o.Repo),
 		checkFunc:    o.MutateFieldCheck,
 		stderr:       o.ErrWriter,
 		moduleLoader: o.ModuleLoader,
 	}
 
+	t.skyqri = skyqri.NewModule(o.Repo, func(mo *skyqri.Options) {
+		if o.DatasetLoader != nil {
+			mo.LoadDataset = t.loadResource
+		}
+		mo.Log = o.Log
+		mo.Query = o.Query
+		mo.Stats = o.Stats
+	})
+
 	skyCtx := skyctx.NewContext(next.Transform.Config, o.Secrets)
+	// incremental transforms use the context to read only upstream entries
+	// added since the previous run
+	skyCtx.SetIncremental(t)
 
 	thread := &starlark.Thread{
 		Load: t.ModuleLoader,
@@ -194,8 +255,87 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 		},
 	}
 
+	if o.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Limits.Timeout)
+		defer cancel()
+		t.ctx = ctx
+	}
+	stepsExceeded := false
+	if o.Limits.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(o.Limits.MaxSteps)
+		thread.OnMaxSteps = func(thread *starlark.Thread) {
+			stepsExceeded = true
+			thread.Cancel("too many steps")
+		}
+	}
+
+	guard := NewHTTPGuard(ctx)
+	guard.SetLimits(o.Limits.MaxHTTPRequests, o.Limits.MaxHTTPResponseBytes)
+	fixture, err := o.httpFixture()
+	if err != nil {
+		return err
+	}
+	guard.SetFixture(fixture, o.HTTPFixtureMode)
+	thread.SetLocal(httpGuardKey, guard)
+	thread.SetLocal(skyqri.ContextKey, ctx)
+
+	// run the script in the background, cancelling it when ctx is done. the
+	// interpreter stops at the next execution step, wait for it so nothing
+	// touches next once ExecScript returns
+	bodyFile := next.BodyFile()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- t.exec(thread, pipeScript, skyCtx)
+	}()
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		thread.Cancel(ctx.Err().Error())
+		<-errs
+		return o.Limits.deadlineError(ctx)
+	}
+	if stepsExceeded {
+		return fmt.Errorf("%w: transform ran more than the %d execution step limit", ErrLimitExceeded, o.Limits.MaxSteps)
+	}
+	for _, u := range guard.Requested() {
+		t.addURLResource(u)
+	}
+	if err == nil && next.BodyFile() != bodyFile {
+		o.Limits.limitBody(next)
+	}
+	if err == nil && o.HTTPFixtureMode == FixtureRecord {
+		err = fixture.WriteFile(o.HTTPFixturePath)
+	}
+
+	// restore consumed script file
+	next.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", buf.Bytes()))
+
+	return err
+}
+
+// httpFixture creates a fixture to record responses to, or reads the fixture
+// to replay responses from
+func (o *ExecOpts) httpFixture() (*Fixture, error) {
+	switch o.HTTPFixtureMode {
+	case FixtureRecord:
+		return &Fixture{}, nil
+	case FixtureReplay:
+		return ReadFixture(o.HTTPFixturePath)
+	}
+	return nil, nil
+}
+
+// exec runs a script, calling special functions & the transform function
+func (t *transform) exec(thread *starlark.Thread, script qfs.File, skyCtx *skyctx.Context) (err error) {
+	defer func() {
+		if cerr := t.closeDatasets(); err == nil {
+			err = cerr
+		}
+	}()
+
 	// execute the transformation
-	t.globals, err = starlark.ExecFile(thread, pipeScript.FileName(), pipeScript, t.locals())
+	t.globals, err = starlark.ExecFile(thread, script.FileName(), script, t.locals())
 	if err != nil {
 		if evalErr, ok := err.(*starlark.EvalError); ok {
 			return fmt.Errorf(evalErr.Backtrace())
@@ -225,10 +365,6 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 	if evalErr, ok := err.(*starlark.EvalError); ok {
 		return fmt.Errorf(evalErr.Backtrace())
 	}
-
-	// restore consumed script file
-	next.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", buf.Bytes()))
-
 	return err
 }
 
@@ -281,8 +417,10 @@ func (t *transform) specialFuncs() (defined map[string]specialFunc, err error) {
 type specialFunc func(t *transform, thread *starlark.Thread, ctx *skyctx.Context) (result starlark.Value, err error)
 
 func callDownloadFunc(t *transform, thread *starlark.Thread, ctx *skyctx.Context) (result starlark.Value, err error) {
-	httpGuard.EnableNtwk()
-	defer httpGuard.DisableNtwk()
+	if guard := threadHTTPGuard(thread); guard != nil {
+		guard.EnableNtwk()
+		defer guard.DisableNtwk()
+	}
 	t.print("📡 running download...\n")
 
 	var download *starlark.Function
@@ -306,7 +444,7 @@ func callTransformFunc(t *transform, thread *starlark.Thread, ctx *skyctx.Contex
 	}
 	t.print("🤖  running transform...\n")
 
-	d := skyds.NewDataset(t.prev, t.checkFunc)
+	d := t.newDataset(t.prev, t.checkFunc)
 	d.SetMutable(t.next)
 	if _, err = starlark.Call(thread, transform, starlark.Tuple{d.Methods(), ctx.Struct()}, nil); err != nil {
 		return err
@@ -314,15 +452,47 @@ func callTransformFunc(t *transform, thread *starlark.Thread, ctx *skyctx.Contex
 	return nil
 }
 
+// newDataset creates a starlark dataset that's closed when script execution
+// finishes
+func (t *transform) newDataset(ds *dataset.Dataset, check skyds.MutateFieldCheck) *skyds.Dataset {
+	d := skyds.NewDataset(ds, check)
+	t.datasets = append(t.datasets, d)
+	return d
+}
+
+// closeDatasets closes all datasets created for the script, finishing
+// streamed body writes
+func (t *transform) closeDatasets() (err error) {
+	for _, d := range t.datasets {
+		if cerr := d.Close(); err == nil {
+			err = cerr
+		}
+	}
+	t.datasets = nil
+	return err
+}
+
 // print writes output only if a node is specified
 func (t *transform) print(msg string) {
 	t.stderr.Write([]byte(msg))
 }
 
 func (t *transform) locals() starlark.StringDict {
-	return starlark.StringDict{
+	locals := starlark.StringDict{
+		"error":        starlark.NewBuiltin("error", Error),
 		"load_dataset": starlark.NewBuiltin("load_dataset", t.LoadDataset),
 	}
+	for key, val := range t.predeclared {
+		locals[key] = val
+	}
+	return locals
+}
+
+// setResolveFlag sets a resolve package flag if it doesn't already hold val
+func setResolveFlag(flag *bool, val bool) {
+	if *flag != val {
+		*flag = val
+	}
 }
 
 // ModuleLoader sums all loading assets to resolve a module name during transform execution
@@ -330,6 +500,11 @@ func (t *transform) ModuleLoader(thread *starlark.Thread, module string) (dict s
 	if module == skyqri.ModuleName && t.skyqri != nil {
 		return t.skyqri.Namespace(), nil
 	}
+	if module == starhttp.ModuleName {
+		if guard := threadHTTPGuard(thread); guard != nil {
+			return guard.module()
+		}
+	}
 
 	if t.moduleLoader == nil {
 		return nil, fmt.Errorf("couldn't load module: %s", module)
@@ -349,11 +524,16 @@ func (t *transform) LoadDataset(thread *starlark.Thread, _ *starlark.Builtin, ar
 		return nil, fmt.Errorf("load_dataset function is not enabled")
 	}
 
-	ds, err := t.loadDataset(t.ctx, refstr.GoString())
+	ds, err := t.loadResource(t.ctx, refstr.GoString())
 	if err != nil {
 		return starlark.None, err
 	}
 
+	return t.newDataset(ds, nil).Methods(), nil
+}
+
+// addResource records a loaded dataset version as a resource of the transform
+func (t *transform) addResource(ds *dataset.Dataset) {
 	if t.next.Transform.Resources == nil {
 		t.next.Transform.Resources = map[string]*dataset.TransformResource{}
 	}
@@ -365,8 +545,26 @@ func (t *transform) LoadDataset(thread *starlark.Thread, _ *starlark.Builtin, ar
 		// same data structure as dsref.Ref
 		Path: fmt.Sprintf("%s/%s@%s", ds.Peername, ds.Name, ds.Path),
 	}
+}
 
-	return skyds.NewDataset(ds, nil).Methods(), nil
+// addURLResource records a URL requested by the download step as a resource
+// of the transform
+func (t *transform) addURLResource(u string) {
+	if t.next.Transform.Resources == nil {
+		t.next.Transform.Resources = map[string]*dataset.TransformResource{}
+	}
+	t.next.Transform.Resources[u] = &dataset.TransformResource{Path: u}
+}
+
+// loadResource loads a dataset, recording the loaded version as a resource of
+// the transform
+func (t *transform) loadResource(ctx context.Context, refstr string) (*dataset.Dataset, error) {
+	ds, err := t.loadDataset(ctx, refstr)
+	if err != nil {
+		return nil, err
+	}
+	t.addResource(ds)
+	return ds, nil
 }
 
 // MutatedComponentsFunc returns a function for checking if a field has been
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/multiformats/go-multihash"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
@@ -252,6 +253,98 @@ func TestGetMetaWithPrev(t *testing.T) {
 	}
 }
 
+func TestStreamBody(t *testing.T) {
+	ctx := context.Background()
+	ds := &dataset.Dataset{
+		Transform: &dataset.Transform{},
+	}
+	ds.Transform.SetScriptFile(scriptFile(t, "testdata/stream_body.star"))
+	prev := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: dataset.BaseSchemaArray,
+		},
+	}
+	prev.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["a",1],["b",2],["c",3]]`)))
+
+	err := ExecScript(ctx, ds, prev, func(o *ExecOpts) {
+		o.ModuleLoader = testModuleLoader(t)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.BodyFile().Close(); err != nil {
+		t.Fatal(err)
+	}
+	expect := `[["a",1],["b",2],["c",3],["d",4],["e",6]]`
+	if string(data) != expect {
+		t.Errorf("expected: %s, actual: %s", expect, string(data))
+	}
+}
+
+func TestTransformResources(t *testing.T) {
+	ctx := context.Background()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"foo":["bar","baz","bat"]}`))
+	}))
+	defer s.Close()
+
+	loader := func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+		ds := &dataset.Dataset{
+			Peername:  "peer",
+			Name:      "upstream",
+			Path:      "/map/" + refStr[len("peer/"):],
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,3]`)))
+		return ds, nil
+	}
+	script := `
+load("http.star", "http")
+load("qri.star", "qri")
+
+def download(ctx):
+	return http.get(test_server_url + "/data?key=secret").json()["foo"]
+
+def transform(ds, ctx):
+	load_dataset("peer/a")
+	qri.stats("peer/b")
+	ds.set_body(ctx.download)
+`
+	ds := &dataset.Dataset{
+		Transform: &dataset.Transform{
+			Resources: map[string]*dataset.TransformResource{
+				"/map/stale": {Path: "peer/upstream@/map/stale"},
+			},
+		},
+	}
+	ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+	err := ExecScript(ctx, ds, nil, AddDatasetLoader(loader), func(o *ExecOpts) {
+		o.Globals["test_server_url"] = starlark.String(s.URL)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		"/map/a":        "peer/upstream@/map/a",
+		"/map/b":        "peer/upstream@/map/b",
+		s.URL + "/data": s.URL + "/data",
+	}
+	if len(ds.Transform.Resources) != len(expect) {
+		t.Errorf("expected %d resources, got: %d", len(expect), len(ds.Transform.Resources))
+	}
+	for key, path := range expect {
+		if res := ds.Transform.Resources[key]; res == nil || res.Path != path {
+			t.Errorf("resource %q mismatch. expected path: %q, got: %v", key, path, res)
+		}
+	}
+}
+
 func TestMutatedComponentsFunc(t *testing.T) {
 	ds := &dataset.Dataset{
 		Commit:    &dataset.Commit{},
@@ -305,3 +398,136 @@ func testModuleLoader(t *testing.T) func(thread *starlark.Thread, module string)
 		return starlib.Loader(thread, module)
 	}
 }
+
+func TestIncrementalTransform(t *testing.T) {
+	ctx := context.Background()
+	versions := map[string]*dataset.Dataset{
+		"peer/upstream@/map/v1": {
+			Path:      "/map/v1",
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, Entries: 2},
+		},
+		"peer/upstream": {
+			Path:      "/map/v2",
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, Entries: 3},
+		},
+	}
+	loader := func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+		v, ok := versions[refStr]
+		if !ok {
+			return nil, dsref.ErrRefNotFound
+		}
+		ds := &dataset.Dataset{Peername: "peer", Name: "upstream", Path: v.Path, Structure: v.Structure}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`["a","b","c"]`)))
+		return ds, nil
+	}
+	processed := func(path string) *dataset.Dataset {
+		return &dataset.Dataset{Transform: &dataset.Transform{
+			Resources: map[string]*dataset.TransformResource{
+				path: {Path: "peer/upstream@" + path},
+			},
+		}}
+	}
+
+	cases := []struct {
+		description string
+		prev        *dataset.Dataset
+		expectMeta  string
+		expectBody  string
+	}{
+		{"first run reads every entry", nil, "", `["a","b","c"]`},
+		{"reads entries added since processed version", processed("/map/v1"), "/map/v1", `["c"]`},
+		{"processed latest version", processed("/map/v2"), "/map/v2", `[]`},
+	}
+
+	for _, c := range cases {
+		ds := &dataset.Dataset{
+			Transform: &dataset.Transform{},
+		}
+		ds.Transform.SetScriptFile(scriptFile(t, "testdata/incremental.star"))
+		if err := ExecScript(ctx, ds, c.prev, AddDatasetLoader(loader)); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.description, err)
+			continue
+		}
+		if got := ds.Meta.Meta()["processed"]; got != c.expectMeta {
+			t.Errorf("%s: processed version mismatch. expected: %q, got: %q", c.description, c.expectMeta, got)
+		}
+		data, _ := ioutil.ReadAll(ds.BodyFile())
+		if string(data) != c.expectBody {
+			t.Errorf("%s: body mismatch. expected: %s, got: %s", c.description, c.expectBody, string(data))
+		}
+		if res := ds.Transform.Resources["/map/v2"]; res == nil || res.Path != "peer/upstream@/map/v2" {
+			t.Errorf("%s: expected loaded upstream version to be recorded as a resource, got: %v", c.description, res)
+		}
+	}
+}
+
+func TestNewEntriesSkipsProcessedBytes(t *testing.T) {
+	ctx := context.Background()
+	csvSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": []interface{}{map[string]interface{}{"title": "letter", "type": "string"}},
+		},
+	}
+	csvConfig := map[string]interface{}{"headerRow": true}
+
+	// processed prefixes are invalid, so decoding them would fail the
+	// transform
+	cases := []struct {
+		description  string
+		prev, body   string
+		prevN, bodyN int
+		st           dataset.Structure
+		expectBody   string
+	}{
+		{"json", `[{{{{{{{]`, `[{{{{{{{,"c"]`, 2, 3, dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `["c"]`},
+		{"json without appended entries", `[{{{{{{{]`, `[{{{{{{{]`, 2, 2, dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `[]`},
+		{"json from empty", `[]`, `["c"]`, 0, 1, dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `["c"]`},
+		{"csv", "letter\na\"b\n", "letter\na\"b\nc\n", 2, 3, dataset.Structure{Format: "csv", Schema: csvSchema, FormatConfig: csvConfig}, `[["c"]]`},
+		{"not appended", `["a","b"]`, `["abc","d"]`, 2, 2, dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `["abc","d"]`},
+		// rows edited in place keep the processed length, but not its checksum
+		{"json row edited", `["a","b"]`, `["x","b","c"]`, 2, 3, dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `["x","b","c"]`},
+		{"csv row edited", "letter\na\nb\n", "letter\nz\nb\nc\n", 2, 3, dataset.Structure{Format: "csv", Schema: csvSchema, FormatConfig: csvConfig}, `[["z"],["b"],["c"]]`},
+	}
+
+	for _, c := range cases {
+		loader := func(ctx context.Context, refStr string) (*dataset.Dataset, error) {
+			ds := &dataset.Dataset{Peername: "peer", Name: "upstream", Path: "/map/v2", Structure: &dataset.Structure{}}
+			ds.Structure.Assign(&c.st)
+			body := c.body
+			ds.Structure.Entries = c.bodyN
+			switch refStr {
+			case "peer/upstream", "peer/upstream@/map/v2":
+			case "peer/upstream@/map/v1":
+				ds.Path, body, ds.Structure.Entries = "/map/v1", c.prev, c.prevN
+			default:
+				return nil, dsref.ErrRefNotFound
+			}
+			ds.Structure.Length = len(body)
+			sum, err := multihash.Sum([]byte(body), multihash.SHA2_256, -1)
+			if err != nil {
+				return nil, err
+			}
+			ds.Structure.Checksum = sum.B58String()
+			ds.SetBodyFile(qfs.NewMemfileBytes("body."+c.st.Format, []byte(body)))
+			return ds, nil
+		}
+		prev := &dataset.Dataset{Transform: &dataset.Transform{
+			Resources: map[string]*dataset.TransformResource{
+				"/map/v1": {Path: "peer/upstream@/map/v1"},
+			},
+		}}
+
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(scriptFile(t, "testdata/incremental.star"))
+		if err := ExecScript(ctx, ds, prev, AddDatasetLoader(loader)); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.description, err)
+			continue
+		}
+		data, _ := ioutil.ReadAll(ds.BodyFile())
+		if string(data) != c.expectBody {
+			t.Errorf("%s: body mismatch. expected: %s, got: %s", c.description, c.expectBody, string(data))
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package stats
 
 import (
+	"bytes"
 	"context"
 	"encoding/base32"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 var (
@@ -14,6 +20,8 @@ var (
 	ErrCacheMiss = fmt.Errorf("stats: cache miss")
 	// ErrNoCache indicates there is no cache
 	ErrNoCache = fmt.Errorf("stats: no cache")
+	// ErrCacheEntryTooLarge indicates data is larger than the cache can hold
+	ErrCacheEntryTooLarge = fmt.Errorf("stats: data exceeds cache max size")
 )
 
 // Cache is a store of JSON-formated stats data, keyed by path
@@ -30,36 +38,181 @@ type Cache interface {
 }
 
 // osCache is a stats cache stored in a directory on the local operating system
+// each entry is stored as a single file. entries are evicted least-recently-used
+// first when the total size of the cache exceeds maxSize. Last-used times are
+// tracked with file modification times, which allows cache state to persist
+// across restarts
 type osCache struct {
 	root    string
 	maxSize uint64
+
+	lk      sync.Mutex
+	size    uint64
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is the in-memory record of a file in the cache
+type cacheEntry struct {
+	filename string
+	size     uint64
+	used     time.Time
 }
 
 var _ Cache = (*osCache)(nil)
 
-// NewOSCache creates a cache in a local direcory
+// NewOSCache creates a cache in a local direcory. Any existing entries in
+// rootDir are loaded into the cache. A maxSize of 0 means the cache size is
+// unbounded
 func NewOSCache(rootDir string, maxSize uint64) Cache {
-	if err := os.MkdirAll(rootDir, os.ModePerm); err != nil {
-		// log.Errorf("stat: %s", args ...interface{})
-	}
-	return osCache{
+	c := &osCache{
 		root:    rootDir,
 		maxSize: maxSize,
+		entries: map[string]*cacheEntry{},
 	}
+
+	if err := os.MkdirAll(rootDir, os.ModePerm); err != nil {
+		log.Errorf("creating stats cache directory: %s", err)
+		return c
+	}
+	if err := c.load(); err != nil {
+		log.Errorf("loading stats cache: %s", err)
+	}
+	return c
 }
 
-// Put places stats in the cache, keyed by path
-func (c osCache) PutJSON(ctx context.Context, path string, r io.Reader) error {
-	filename := fmt.Sprintf("%s.json", b32Enc.EncodeToString([]byte(path)))
-	// TODO (b5) - use this
-	_ = filepath.Join(c.root, filename)
+// load reads cache state from the root directory
+func (c *osCache) load() error {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	infos, err := ioutil.ReadDir(c.root)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range infos {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		path, err := b32Enc.DecodeString(strings.TrimSuffix(fi.Name(), ".json"))
+		if err != nil {
+			log.Debugf("ignoring unrecognized stats cache file %q", fi.Name())
+			continue
+		}
+		c.entries[string(path)] = &cacheEntry{
+			filename: fi.Name(),
+			size:     uint64(fi.Size()),
+			used:     fi.ModTime(),
+		}
+		c.size += uint64(fi.Size())
+	}
 
-	return fmt.Errorf("not finished")
+	return c.evict(0)
+}
+
+// PutJSON places stats in the cache, keyed by path
+func (c *osCache) PutJSON(ctx context.Context, path string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	size := uint64(len(data))
+	if c.maxSize != 0 && size > c.maxSize {
+		return ErrCacheEntryTooLarge
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.remove(path)
+	if err := c.evict(size); err != nil {
+		return err
+	}
+
+	filename := cacheFilename(path)
+	if err := ioutil.WriteFile(filepath.Join(c.root, filename), data, 0644); err != nil {
+		return err
+	}
+	c.entries[path] = &cacheEntry{
+		filename: filename,
+		size:     size,
+		used:     time.Now(),
+	}
+	c.size += size
+	return nil
 }
 
 // JSON gets cached byte data for a path
-func (c osCache) JSON(ctx context.Context, path string) (r io.Reader, err error) {
-	return nil, ErrCacheMiss
+func (c *osCache) JSON(ctx context.Context, path string) (r io.Reader, err error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ent, ok := c.entries[path]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	fp := filepath.Join(c.root, ent.filename)
+	data, err := ioutil.ReadFile(fp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// file was removed out from under us, forget it
+			c.size -= ent.size
+			delete(c.entries, path)
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	ent.used = time.Now()
+	if err := os.Chtimes(fp, ent.used, ent.used); err != nil {
+		log.Debugf("updating stats cache access time: %s", err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// evict removes least-recently-used entries until there is room to add size
+// bytes to the cache. callers must hold the lock
+func (c *osCache) evict(size uint64) error {
+	if c.maxSize == 0 || c.size+size <= c.maxSize {
+		return nil
+	}
+
+	paths := make([]string, 0, len(c.entries))
+	for path := range c.entries {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return c.entries[paths[i]].used.Before(c.entries[paths[j]].used)
+	})
+
+	for _, path := range paths {
+		if c.size+size <= c.maxSize {
+			break
+		}
+		if err := c.remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove drops an entry from the cache. callers must hold the lock
+func (c *osCache) remove(path string) error {
+	ent, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(c.root, ent.filename)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	c.size -= ent.size
+	delete(c.entries, path)
+	return nil
+}
+
+func cacheFilename(path string) string {
+	return fmt.Sprintf("%s.json", b32Enc.EncodeToString([]byte(path)))
 }
 
 var b32Enc = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
@@ -6,11 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestOSCache(t *testing.T) {
-	t.Skip("skipping caching for now")
-
 	tmp, err := ioutil.TempDir("", "test_os_cache")
 	if err != nil {
 		t.Fatal(err)
@@ -33,7 +32,7 @@ func TestOSCache(t *testing.T) {
 
 	// overwrite data at path "statsA"
 	statsA2 := bytes.Repeat([]byte{'p'}, 50)
-	if err = cache.PutJSON(ctx, "statsA", bytes.NewReader(statsA)); err != nil {
+	if err = cache.PutJSON(ctx, "statsA", bytes.NewReader(statsA2)); err != nil {
 		t.Errorf("expected putting json data to not fail. got: %s", err)
 	}
 	got = cacheBytes(t, cache, "statsA")
@@ -52,6 +51,53 @@ func TestOSCache(t *testing.T) {
 	if getAErr != ErrCacheMiss && getBErr != ErrCacheMiss {
 		t.Errorf("expected at least one cache in an overflow state to ErrCacheMiss. got:\n\tstatA: %v\n\tstatB: %v", getAErr, getBErr)
 	}
+
+	// data larger than the entire cache is rejected
+	tooBig := bytes.Repeat([]byte{'o'}, 101)
+	if err = cache.PutJSON(ctx, "tooBig", bytes.NewReader(tooBig)); err != ErrCacheEntryTooLarge {
+		t.Errorf("expected putting data larger than max size to return ErrCacheEntryTooLarge. got: %v", err)
+	}
+}
+
+func TestOSCacheLRU(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "test_os_cache_lru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ctx := context.Background()
+	cache := NewOSCache(tmp, 100)
+
+	for _, path := range []string{"a", "b", "c"} {
+		if err := cache.PutJSON(ctx, path, bytes.NewReader(bytes.Repeat([]byte{'x'}, 30))); err != nil {
+			t.Fatal(err)
+		}
+		// ensure distinct access times
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// read "a", making "b" the least-recently used entry
+	cacheBytes(t, cache, "a")
+	time.Sleep(time.Millisecond * 10)
+
+	if err := cache.PutJSON(ctx, "d", bytes.NewReader(bytes.Repeat([]byte{'x'}, 30))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.JSON(ctx, "b"); err != ErrCacheMiss {
+		t.Errorf("expected least-recently used entry to be evicted. got: %v", err)
+	}
+
+	// cache state must survive re-opening the directory
+	reopened := NewOSCache(tmp, 100)
+	for _, path := range []string{"a", "c", "d"} {
+		if got := cacheBytes(t, reopened, path); len(got) != 30 {
+			t.Errorf("reopened cache path %q: expected 30 bytes, got %d", path, len(got))
+		}
+	}
+	if _, err := reopened.JSON(ctx, "b"); err != ErrCacheMiss {
+		t.Errorf("expected evicted entry to remain missing after reopening. got: %v", err)
+	}
 }
 
 func cacheBytes(t *testing.T, c Cache, path string) []byte {
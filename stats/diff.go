@@ -0,0 +1,299 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/qri-io/dataset/dsio"
+)
+
+// Diff describes the change in statistical profile between two versions of
+// a dataset
+type Diff struct {
+	LeftRowCount  int           `json:"leftRowCount"`
+	RightRowCount int           `json:"rightRowCount"`
+	RowCountDelta int           `json:"rowCountDelta"`
+	Columns       []*ColumnDiff `json:"columns"`
+}
+
+// Column diff status values
+const (
+	// ColumnAdded indicates a column only exists in the right side of a diff
+	ColumnAdded = "added"
+	// ColumnRemoved indicates a column only exists in the left side of a diff
+	ColumnRemoved = "removed"
+	// ColumnTypeChanged indicates the type of stat reported for a column differs
+	// between versions
+	ColumnTypeChanged = "typeChanged"
+	// ColumnChanged indicates column stats differ between versions
+	ColumnChanged = "changed"
+	// ColumnUnchanged indicates column stats are the same in both versions
+	ColumnUnchanged = "unchanged"
+)
+
+// ColumnDiff describes drift in the stats of a single column
+type ColumnDiff struct {
+	// Column is the title, key, or index of the column
+	Column    string `json:"column"`
+	Status    string `json:"status"`
+	LeftType  string `json:"leftType,omitempty"`
+	RightType string `json:"rightType,omitempty"`
+
+	LeftCount  int `json:"leftCount"`
+	RightCount int `json:"rightCount"`
+	// Null counts are the number of rows that have no value of the column's
+	// stat type, either because the value is null or missing
+	LeftNullCount  int `json:"leftNullCount"`
+	RightNullCount int `json:"rightNullCount"`
+	NullCountDelta int `json:"nullCountDelta"`
+
+	// mean values are only reported for numeric columns
+	LeftMean  *float64 `json:"leftMean,omitempty"`
+	RightMean *float64 `json:"rightMean,omitempty"`
+	MeanShift *float64 `json:"meanShift,omitempty"`
+
+	// categorical values are only reported for string columns with a
+	// complete set of distinct values on both sides
+	AddedValues   []string `json:"addedValues,omitempty"`
+	RemovedValues []string `json:"removedValues,omitempty"`
+	// ValuesTruncated is true when a side of a string column has no complete
+	// set of distinct values, and added & removed values weren't calculated
+	ValuesTruncated bool `json:"valuesTruncated,omitempty"`
+}
+
+// DiffInput is one side of a stats diff
+type DiffInput struct {
+	// Stats is stats output as produced by ToMap, or decoded from Stats.JSON
+	Stats []map[string]interface{}
+	// RowCount is the number of entries in the dataset body
+	RowCount int
+	// Columns optionally names the columns of a tabular dataset, in order
+	Columns []string
+	// Values holds the distinct string values of each column, as produced by
+	// ColumnValues. Frequency tables drop values that appear once, so string
+	// columns without values here are marked truncated
+	Values map[string]*ValueSet
+}
+
+// ValueSet is the set of distinct string values in a column
+type ValueSet struct {
+	Values map[string]bool
+	// Truncated is true when the column held more than the maximum number of
+	// distinct values, and Values is incomplete
+	Truncated bool
+}
+
+// ColumnValues reads all entries from r, collecting up to max distinct string
+// values for each column. Columns are keyed by object key, or by index for
+// array entries
+func ColumnValues(r dsio.EntryReader, max int) (map[string]*ValueSet, error) {
+	cols := map[string]*ValueSet{}
+	add := func(key string, v interface{}) {
+		str, ok := v.(string)
+		if !ok {
+			return
+		}
+		set, ok := cols[key]
+		if !ok {
+			set = &ValueSet{Values: map[string]bool{}}
+			cols[key] = set
+		}
+		if set.Truncated || set.Values[str] {
+			return
+		}
+		if len(set.Values) >= max {
+			set.Truncated = true
+			return
+		}
+		set.Values[str] = true
+	}
+
+	for {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				return cols, nil
+			}
+			return nil, err
+		}
+		switch row := ent.Value.(type) {
+		case map[string]interface{}:
+			for key, v := range row {
+				add(key, v)
+			}
+		case []interface{}:
+			for i, v := range row {
+				add(fmt.Sprintf("%d", i), v)
+			}
+		}
+	}
+}
+
+// CalcDiff lines up the stats for each column on both sides, and describes
+// the changes between them. Columns are matched by key for datasets with
+// object entries, and by column title or index otherwise
+func CalcDiff(left, right DiffInput) *Diff {
+	d := &Diff{
+		LeftRowCount:  left.RowCount,
+		RightRowCount: right.RowCount,
+		RowCountDelta: right.RowCount - left.RowCount,
+		Columns:       []*ColumnDiff{},
+	}
+
+	lCols := keyColumns(left)
+	rCols := keyColumns(right)
+	lVals := keyValues(left)
+	rVals := keyValues(right)
+
+	for _, name := range columnOrder(left, right, lCols, rCols) {
+		cd := diffColumn(name, lCols[name], rCols[name], left.RowCount, right.RowCount)
+		if cd.Status != ColumnAdded && cd.Status != ColumnRemoved && cd.LeftType == "string" {
+			diffValues(cd, lVals[name], rVals[name])
+		}
+		d.Columns = append(d.Columns, cd)
+	}
+
+	return d
+}
+
+// keyColumns maps column names to their stats
+func keyColumns(in DiffInput) map[string]map[string]interface{} {
+	cols := make(map[string]map[string]interface{}, len(in.Stats))
+	for i, st := range in.Stats {
+		cols[columnName(in, i)] = st
+	}
+	return cols
+}
+
+// keyValues maps column names to their distinct values
+func keyValues(in DiffInput) map[string]*ValueSet {
+	vals := make(map[string]*ValueSet, len(in.Stats))
+	for i, st := range in.Stats {
+		key, ok := st["key"].(string)
+		if !ok {
+			key = fmt.Sprintf("%d", i)
+		}
+		if set, ok := in.Values[key]; ok {
+			vals[columnName(in, i)] = set
+		}
+	}
+	return vals
+}
+
+func columnName(in DiffInput, i int) string {
+	if key, ok := in.Stats[i]["key"].(string); ok {
+		return key
+	}
+	if i < len(in.Columns) && in.Columns[i] != "" {
+		return in.Columns[i]
+	}
+	return fmt.Sprintf("%d", i)
+}
+
+// columnOrder lists column names in right-side order, followed by any columns
+// that only exist on the left
+func columnOrder(left, right DiffInput, lCols, rCols map[string]map[string]interface{}) []string {
+	names := make([]string, 0, len(rCols))
+	for i := range right.Stats {
+		names = append(names, columnName(right, i))
+	}
+	for i := range left.Stats {
+		name := columnName(left, i)
+		if _, ok := rCols[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func diffColumn(name string, l, r map[string]interface{}, lRows, rRows int) *ColumnDiff {
+	cd := &ColumnDiff{Column: name}
+
+	if l != nil {
+		cd.LeftType, _ = l["type"].(string)
+		cd.LeftCount = toInt(l["count"])
+		cd.LeftNullCount = nullCount(cd.LeftType, cd.LeftCount, lRows)
+	}
+	if r != nil {
+		cd.RightType, _ = r["type"].(string)
+		cd.RightCount = toInt(r["count"])
+		cd.RightNullCount = nullCount(cd.RightType, cd.RightCount, rRows)
+	}
+	cd.NullCountDelta = cd.RightNullCount - cd.LeftNullCount
+
+	switch {
+	case l == nil:
+		cd.Status = ColumnAdded
+		return cd
+	case r == nil:
+		cd.Status = ColumnRemoved
+		return cd
+	case cd.LeftType != cd.RightType:
+		cd.Status = ColumnTypeChanged
+		return cd
+	}
+
+	changed := cd.LeftCount != cd.RightCount || cd.NullCountDelta != 0
+
+	switch cd.LeftType {
+	case "numeric":
+		lMean, lok := l["mean"].(float64)
+		rMean, rok := r["mean"].(float64)
+		if lok && rok {
+			shift := rMean - lMean
+			cd.LeftMean, cd.RightMean, cd.MeanShift = &lMean, &rMean, &shift
+			changed = changed || shift != 0
+		}
+	}
+
+	if changed {
+		cd.Status = ColumnChanged
+	} else {
+		cd.Status = ColumnUnchanged
+	}
+	return cd
+}
+
+// diffValues lists the values added to & removed from a string column, marking
+// the column changed if any are. Incomplete value sets aren't compared
+func diffValues(cd *ColumnDiff, l, r *ValueSet) {
+	if l == nil || r == nil || l.Truncated || r.Truncated {
+		cd.ValuesTruncated = true
+		return
+	}
+	for val := range r.Values {
+		if !l.Values[val] {
+			cd.AddedValues = append(cd.AddedValues, val)
+		}
+	}
+	for val := range l.Values {
+		if !r.Values[val] {
+			cd.RemovedValues = append(cd.RemovedValues, val)
+		}
+	}
+	sort.Strings(cd.AddedValues)
+	sort.Strings(cd.RemovedValues)
+	if len(cd.AddedValues) > 0 || len(cd.RemovedValues) > 0 {
+		cd.Status = ColumnChanged
+	}
+}
+
+func nullCount(typ string, count, rows int) int {
+	if typ == "null" {
+		return count
+	}
+	if rows < count {
+		return 0
+	}
+	return rows - count
+}
+
+func toInt(v interface{}) int {
+	switch x := v.(type) {
+	case int:
+		return x
+	case float64:
+		return int(x)
+	}
+	return 0
+}
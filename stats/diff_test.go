@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestCalcDiff(t *testing.T) {
+	left, lVals := tabularStats(t, `[
+		["a", 1, "red"],
+		["b", 2, "red"],
+		["c", 3, "blue"],
+		["d", 4, "blue"]
+	]`)
+	right, rVals := tabularStats(t, `[
+		["a", 1, "red", true],
+		["b", 2, "red", false],
+		["c", 3, "green", true],
+		["d", null, "green", true],
+		["e", 10, "red", false]
+	]`)
+
+	got := CalcDiff(
+		DiffInput{Stats: left, RowCount: 4, Columns: []string{"name", "value", "color"}, Values: lVals},
+		DiffInput{Stats: right, RowCount: 5, Columns: []string{"name", "value", "color", "flag"}, Values: rVals},
+	)
+
+	lMean, rMean, shift := 2.5, 4.0, 1.5
+	expect := &Diff{
+		LeftRowCount:  4,
+		RightRowCount: 5,
+		RowCountDelta: 1,
+		Columns: []*ColumnDiff{
+			{Column: "name", Status: ColumnChanged, LeftType: "string", RightType: "string", LeftCount: 4, RightCount: 5, AddedValues: []string{"e"}},
+			{Column: "value", Status: ColumnChanged, LeftType: "numeric", RightType: "numeric", LeftCount: 4, RightCount: 4, RightNullCount: 1, NullCountDelta: 1, LeftMean: &lMean, RightMean: &rMean, MeanShift: &shift},
+			{Column: "color", Status: ColumnChanged, LeftType: "string", RightType: "string", LeftCount: 4, RightCount: 5, AddedValues: []string{"green"}, RemovedValues: []string{"blue"}},
+			{Column: "flag", Status: ColumnAdded, RightType: "boolean", RightCount: 5},
+		},
+	}
+
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	unchanged := CalcDiff(DiffInput{Stats: left, RowCount: 4, Values: lVals}, DiffInput{Stats: left, RowCount: 4, Values: lVals})
+	for _, col := range unchanged.Columns {
+		if col.Status != ColumnUnchanged {
+			t.Errorf("column %q: expected diffing identical stats to be unchanged, got: %q", col.Column, col.Status)
+		}
+	}
+
+	removed := CalcDiff(DiffInput{Stats: right, RowCount: 5}, DiffInput{Stats: left, RowCount: 4})
+	if last := removed.Columns[len(removed.Columns)-1]; last.Column != "3" || last.Status != ColumnRemoved {
+		t.Errorf("expected unnamed column missing from the right side to be removed. got: %q %q", last.Column, last.Status)
+	}
+}
+
+func TestCalcDiffSingletonValues(t *testing.T) {
+	// frequency tables drop values that appear once, value sets must not
+	left, lVals := tabularStats(t, `[["red"],["red"],["blue"],["gone"]]`)
+	right, rVals := tabularStats(t, `[["red"],["blue"],["blue"],["new"]]`)
+
+	got := CalcDiff(DiffInput{Stats: left, RowCount: 4, Values: lVals}, DiffInput{Stats: right, RowCount: 4, Values: rVals})
+	col := got.Columns[0]
+	if col.Status != ColumnChanged {
+		t.Errorf("expected column to change, got: %q", col.Status)
+	}
+	if diff := cmp.Diff([]string{"new"}, col.AddedValues); diff != "" {
+		t.Errorf("added values mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"gone"}, col.RemovedValues); diff != "" {
+		t.Errorf("removed values mismatch (-want +got):\n%s", diff)
+	}
+
+	// value sets that hit the limit aren't compared
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(`[["red"],["blue"],["green"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncVals, err := ColumnValues(r, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncVals["0"].Truncated {
+		t.Errorf("expected value set over the limit to be truncated")
+	}
+	got = CalcDiff(DiffInput{Stats: left, RowCount: 4, Values: truncVals}, DiffInput{Stats: right, RowCount: 4, Values: rVals})
+	if col := got.Columns[0]; !col.ValuesTruncated || col.AddedValues != nil || col.RemovedValues != nil {
+		t.Errorf("expected truncated values to be skipped. got: %#v", col)
+	}
+	got = CalcDiff(DiffInput{Stats: left, RowCount: 4}, DiffInput{Stats: right, RowCount: 4})
+	if !got.Columns[0].ValuesTruncated {
+		t.Errorf("expected missing values to be marked truncated")
+	}
+}
+
+func TestCalcDiffKeyedColumns(t *testing.T) {
+	left := []map[string]interface{}{
+		{"key": "a", "type": "numeric", "count": 2, "mean": float64(1)},
+		{"key": "b", "type": "string", "count": 2},
+	}
+	right := []map[string]interface{}{
+		{"key": "b", "type": "numeric", "count": 2, "mean": float64(1)},
+	}
+
+	got := CalcDiff(DiffInput{Stats: left, RowCount: 2}, DiffInput{Stats: right, RowCount: 2})
+	if len(got.Columns) != 2 {
+		t.Fatalf("expected 2 columns, got: %d", len(got.Columns))
+	}
+	if got.Columns[0].Column != "b" || got.Columns[0].Status != ColumnTypeChanged {
+		t.Errorf("expected column b to change type. got: %q %q", got.Columns[0].Column, got.Columns[0].Status)
+	}
+	if got.Columns[1].Column != "a" || got.Columns[1].Status != ColumnRemoved {
+		t.Errorf("expected column a to be removed. got: %q %q", got.Columns[1].Column, got.Columns[1].Status)
+	}
+}
+
+func tabularStats(t *testing.T, body string) ([]map[string]interface{}, map[string]*ValueSet) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vr, err := dsio.NewJSONReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := ColumnValues(vr, StopFreqCountThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc := NewAccumulator(r)
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+	// round trip through JSON to match stats read from a cache
+	data, err := json.Marshal(ToMap(acc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := []map[string]interface{}{}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats, vals
+}
@@ -0,0 +1,239 @@
+package stats
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// quantileSketch approximates the distribution of a stream of values in
+// bounded memory. It's an implementation of the "merging" t-digest, which
+// keeps a small set of weighted centroids that are more precise toward the
+// tails of the distribution. Memory use is bounded by compression
+type quantileSketch struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// defaultSketchCompression balances accuracy against memory use, keeping a
+// few hundred centroids at most
+const defaultSketchCompression = 100
+
+func newQuantileSketch(compression float64) *quantileSketch {
+	return &quantileSketch{
+		compression: compression,
+		buffer:      make([]centroid, 0, int(compression)*5),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add places a value in the sketch
+func (s *quantileSketch) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	s.buffer = append(s.buffer, centroid{mean: v, weight: 1})
+	s.count++
+	if v < s.min {
+		s.min = v
+	}
+	if v > s.max {
+		s.max = v
+	}
+	if len(s.buffer) == cap(s.buffer) {
+		s.compress()
+	}
+}
+
+// Count gives the number of values added to the sketch
+func (s *quantileSketch) Count() int {
+	return int(s.count)
+}
+
+// compress merges buffered values into centroids
+func (s *quantileSketch) compress() {
+	if len(s.buffer) == 0 {
+		return
+	}
+	all := append(s.centroids, s.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(s.centroids)+1)
+	cur := all[0]
+	weightSoFar := 0.0
+	k0 := s.scale(0)
+	for _, c := range all[1:] {
+		proposed := cur.weight + c.weight
+		if s.scale((weightSoFar+proposed)/s.count)-k0 <= 1 {
+			cur.mean += (c.mean - cur.mean) * c.weight / proposed
+			cur.weight = proposed
+		} else {
+			weightSoFar += cur.weight
+			k0 = s.scale(weightSoFar / s.count)
+			merged = append(merged, cur)
+			cur = c
+		}
+	}
+	s.centroids = append(merged, cur)
+	s.buffer = s.buffer[:0]
+}
+
+// scale maps quantile q onto the t-digest k1 scale. A centroid may span at
+// most one unit on this scale, which keeps centroids small near the tails
+func (s *quantileSketch) scale(q float64) float64 {
+	return s.compression / (2 * math.Pi) * math.Asin(2*math.Min(q, 1)-1)
+}
+
+// Quantile estimates the value at quantile q, where 0 <= q <= 1
+func (s *quantileSketch) Quantile(q float64) float64 {
+	s.compress()
+	switch {
+	case len(s.centroids) == 0:
+		return math.NaN()
+	case q <= 0:
+		return s.min
+	case q >= 1:
+		return s.max
+	}
+
+	target := q * s.count
+	first := s.centroids[0]
+	if target < first.weight/2 {
+		return s.min + (first.mean-s.min)*target/(first.weight/2)
+	}
+
+	cum := 0.0
+	for i := 0; i < len(s.centroids)-1; i++ {
+		a, b := s.centroids[i], s.centroids[i+1]
+		left := cum + a.weight/2
+		right := cum + a.weight + b.weight/2
+		if target <= right {
+			return a.mean + (b.mean-a.mean)*(target-left)/(right-left)
+		}
+		cum += a.weight
+	}
+
+	last := s.centroids[len(s.centroids)-1]
+	left := s.count - last.weight/2
+	return last.mean + (s.max-last.mean)*(target-left)/(last.weight/2)
+}
+
+// CDF estimates the fraction of values that are less than or equal to x
+func (s *quantileSketch) CDF(x float64) float64 {
+	s.compress()
+	switch {
+	case len(s.centroids) == 0:
+		return math.NaN()
+	case x < s.min:
+		return 0
+	case x >= s.max:
+		return 1
+	}
+
+	first := s.centroids[0]
+	if x < first.mean {
+		return (first.weight / 2) * (x - s.min) / (first.mean - s.min) / s.count
+	}
+
+	cum := 0.0
+	for i := 0; i < len(s.centroids)-1; i++ {
+		a, b := s.centroids[i], s.centroids[i+1]
+		if x < b.mean {
+			left := cum + a.weight/2
+			right := cum + a.weight + b.weight/2
+			return (left + (right-left)*(x-a.mean)/(b.mean-a.mean)) / s.count
+		}
+		cum += a.weight
+	}
+
+	last := s.centroids[len(s.centroids)-1]
+	left := s.count - last.weight/2
+	return (left + (last.weight/2)*(x-last.mean)/(s.max-last.mean)) / s.count
+}
+
+// hyperLogLog estimates the number of distinct values in a stream using a
+// fixed number of registers
+type hyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// hllPrecision sets the number of registers to 2^12, for a standard error
+// of roughly 1.6% using 4KiB of memory
+const hllPrecision = 12
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{
+		precision: hllPrecision,
+		registers: make([]uint8, 1<<hllPrecision),
+	}
+}
+
+// Add places a value in the estimator
+func (h *hyperLogLog) Add(data []byte) {
+	x := hash64(data)
+	idx := x >> (64 - h.precision)
+	w := x<<h.precision | 1<<(h.precision-1)
+	rho := uint8(bits.LeadingZeros64(w)) + 1
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+// AddString places a string value in the estimator
+func (h *hyperLogLog) AddString(str string) {
+	h.Add([]byte(str))
+}
+
+// AddFloat places a float value in the estimator
+func (h *hyperLogLog) AddFloat(v float64) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+	h.Add(buf)
+}
+
+// Count estimates the number of distinct values added
+func (h *hyperLogLog) Count() int {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	est := (0.7213 / (1 + 1.079/m)) * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		// small range correction
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int(est + 0.5)
+}
+
+// hash64 hashes data with FNV-1a, mixing the result to spread bits evenly
+// across the output
+func hash64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestQuantileSketch(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	s := newQuantileSketch(defaultSketchCompression)
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()*100 + 500
+		s.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	if s.Count() != len(vals) {
+		t.Errorf("count mismatch. expected: %d, got: %d", len(vals), s.Count())
+	}
+	if len(s.centroids) > int(defaultSketchCompression)*2 {
+		t.Errorf("expected sketch to stay bounded. got %d centroids", len(s.centroids))
+	}
+
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+		expect := vals[int(q*float64(len(vals)))]
+		got := s.Quantile(q)
+		// allow 2% error relative to the standard deviation
+		if math.Abs(expect-got) > 2 {
+			t.Errorf("quantile %v: expected ~%f, got: %f", q, expect, got)
+		}
+		if cdf := s.CDF(expect); math.Abs(cdf-q) > 0.005 {
+			t.Errorf("cdf of quantile %v: expected ~%f, got: %f", q, q, cdf)
+		}
+	}
+
+	if s.Quantile(0) != vals[0] || s.Quantile(1) != vals[len(vals)-1] {
+		t.Errorf("expected extreme quantiles to equal min & max")
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	h := newHyperLogLog()
+	if h.Count() != 0 {
+		t.Errorf("expected empty estimator to count zero, got: %d", h.Count())
+	}
+
+	for i := 0; i < 10; i++ {
+		h.AddString(fmt.Sprintf("value_%d", i%5))
+	}
+	if h.Count() != 5 {
+		t.Errorf("expected small cardinalities to be exact. expected: 5, got: %d", h.Count())
+	}
+
+	h = newHyperLogLog()
+	n := 200000
+	for i := 0; i < n; i++ {
+		h.AddFloat(float64(i))
+		h.AddFloat(float64(i))
+	}
+	if errRate := math.Abs(float64(h.Count()-n)) / float64(n); errRate > 0.05 {
+		t.Errorf("expected estimate within 5%% of %d, got: %d", n, h.Count())
+	}
+}
+
+func TestApproximateStatsPastThreshold(t *testing.T) {
+	prev := StopFreqCountThreshold
+	StopFreqCountThreshold = 10
+	defer func() { StopFreqCountThreshold = prev }()
+
+	num := newNumericAcc("integer")
+	str := newStringAcc()
+	for i := 0; i < 10000; i++ {
+		num.Write(dsio.Entry{Value: i % 5000})
+		str.Write(dsio.Entry{Value: fmt.Sprintf("%d", i%5000)})
+	}
+	num.Close()
+	str.Close()
+
+	if num.values != nil || num.sketch == nil {
+		t.Fatal("expected numeric accumulator to switch to sketches past the threshold")
+	}
+	if math.Abs(num.median-2500) > 50 {
+		t.Errorf("median: expected ~2500, got: %f", num.median)
+	}
+	if math.Abs(num.quantiles["p99"]-4950) > 50 {
+		t.Errorf("p99: expected ~4950, got: %f", num.quantiles["p99"])
+	}
+	total := 0.0
+	for _, f := range num.histogram {
+		if math.Abs(f-1000) > 50 {
+			t.Errorf("expected evenly distributed histogram bucket frequencies. got: %v", num.histogram)
+			break
+		}
+		total += f
+	}
+	if math.Abs(total-10000) > 10 {
+		t.Errorf("expected histogram frequencies to sum to ~10000, got: %f", total)
+	}
+	if math.Abs(float64(num.distinct-5000)) > 250 {
+		t.Errorf("numeric distinct: expected ~5000, got: %d", num.distinct)
+	}
+
+	if str.frequencies != nil {
+		t.Error("expected string accumulator to drop frequencies past the threshold")
+	}
+	if math.Abs(float64(str.distinct-5000)) > 250 {
+		t.Errorf("string distinct: expected ~5000, got: %d", str.distinct)
+	}
+}
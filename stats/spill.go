@@ -0,0 +1,301 @@
+package stats
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// freqTable counts the frequency of string values in bounded memory. Once the
+// number of distinct values held in memory reaches max, counts are written to
+// a temporary file as a sorted "run" & the in-memory table is reset. Runs are
+// merged when the table is read
+type freqTable struct {
+	dir    string
+	max    int
+	counts map[string]int
+	runs   []string
+	err    error
+}
+
+func newFreqTable(dir string, max int) *freqTable {
+	return &freqTable{
+		dir:    dir,
+		max:    max,
+		counts: map[string]int{},
+	}
+}
+
+// Add increments the count for a value
+func (t *freqTable) Add(val string) {
+	if t.err != nil {
+		return
+	}
+	t.counts[val]++
+	if len(t.counts) >= t.max {
+		t.err = t.spill()
+	}
+}
+
+// maxRuns caps the number of spilled runs a table keeps before compacting
+// them into a single run, bounding the number of open files during a merge
+const maxRuns = 64
+
+// spill writes in-memory counts to a temp file, sorted by value
+func (t *freqTable) spill() error {
+	f, err := ioutil.TempFile(t.dir, "qri_stats_freq_")
+	if err != nil {
+		return err
+	}
+	t.runs = append(t.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, val := range sortedKeys(t.counts) {
+		if err := writeFreq(w, val, t.counts[val]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	t.counts = map[string]int{}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if len(t.runs) >= maxRuns {
+		return t.compact()
+	}
+	return nil
+}
+
+// compact merges all spilled runs into a single run
+func (t *freqTable) compact() error {
+	f, err := ioutil.TempFile(t.dir, "qri_stats_freq_")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := mergeRuns(t.runs, nil, func(val string, count int) error {
+		return writeFreq(w, val, count)
+	}); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	for _, path := range t.runs {
+		os.Remove(path)
+	}
+	t.runs = []string{f.Name()}
+	return nil
+}
+
+// Each calls fn once for each distinct value in sorted order, with the total
+// count for that value. Each removes any spilled runs, and must only be
+// called once
+func (t *freqTable) Each(fn func(val string, count int)) error {
+	defer t.cleanup()
+	if t.err != nil {
+		return t.err
+	}
+	return mergeRuns(t.runs, t.counts, func(val string, count int) error {
+		fn(val, count)
+		return nil
+	})
+}
+
+// mergeRuns combines sorted runs stored at paths with an in-memory table of
+// counts, calling fn once per distinct value in sorted order
+func mergeRuns(paths []string, counts map[string]int, fn func(val string, count int) error) error {
+	var readErr error
+	mem := sortedKeys(counts)
+	memIdx := 0
+
+	readers := make([]func() (string, int, bool), 0, len(paths)+1)
+	readers = append(readers, func() (string, int, bool) {
+		if memIdx == len(mem) {
+			return "", 0, false
+		}
+		val := mem[memIdx]
+		memIdx++
+		return val, counts[val], true
+	})
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r := bufio.NewReader(f)
+		readers = append(readers, func() (string, int, bool) {
+			val, count, err := readFreq(r)
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return "", 0, false
+			}
+			return val, count, true
+		})
+	}
+
+	h := &runHeap{}
+	for i, next := range readers {
+		if val, count, ok := next(); ok {
+			heap.Push(h, runHead{val: val, count: count, src: i})
+		}
+	}
+
+	var (
+		cur      string
+		curCount int
+		started  bool
+	)
+	for h.Len() > 0 {
+		head := heap.Pop(h).(runHead)
+		if started && head.val != cur {
+			if err := fn(cur, curCount); err != nil {
+				return err
+			}
+			curCount = 0
+		}
+		cur = head.val
+		curCount += head.count
+		started = true
+		if val, count, ok := readers[head.src](); ok {
+			heap.Push(h, runHead{val: val, count: count, src: head.src})
+		}
+	}
+	if started {
+		if err := fn(cur, curCount); err != nil {
+			return err
+		}
+	}
+
+	return readErr
+}
+
+func (t *freqTable) cleanup() {
+	for _, path := range t.runs {
+		os.Remove(path)
+	}
+	t.runs = nil
+	t.counts = map[string]int{}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeFreq(w *bufio.Writer, val string, count int) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(val)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(val); err != nil {
+		return err
+	}
+	n = binary.PutUvarint(buf, uint64(count))
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+func readFreq(r *bufio.Reader) (string, int, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", 0, err
+	}
+	val := make([]byte, length)
+	if _, err := io.ReadFull(r, val); err != nil {
+		return "", 0, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(val), int(count), nil
+}
+
+// runHead is the next value from a sorted run
+type runHead struct {
+	val   string
+	count int
+	src   int
+}
+
+// runHeap orders run heads by value
+type runHeap []runHead
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return h[i].val < h[j].val }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(runHead)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// topFreqs tracks the n most frequent values seen
+type topFreqs struct {
+	n    int
+	vals freqHeap
+}
+
+func (t *topFreqs) Add(val string, count int) {
+	if len(t.vals) < t.n {
+		heap.Push(&t.vals, freq{val, count})
+	} else if t.n > 0 && count > t.vals[0].count {
+		t.vals[0] = freq{val, count}
+		heap.Fix(&t.vals, 0)
+	}
+}
+
+// Map returns tracked values as a map of value to count
+func (t *topFreqs) Map() map[string]int {
+	m := make(map[string]int, len(t.vals))
+	for _, f := range t.vals {
+		m[f.val] = f.count
+	}
+	return m
+}
+
+type freq struct {
+	val   string
+	count int
+}
+
+// freqHeap is a min-heap of frequencies
+type freqHeap []freq
+
+func (h freqHeap) Len() int            { return len(h) }
+func (h freqHeap) Less(i, j int) bool  { return h[i].count < h[j].count }
+func (h freqHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *freqHeap) Push(x interface{}) { *h = append(*h, x.(freq)) }
+func (h *freqHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestFreqTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_stats_spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a tiny table forces many spills & at least one compaction
+	tbl := newFreqTable(dir, 3)
+	expect := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		val := fmt.Sprintf("val_%d", (i*7)%150)
+		tbl.Add(val)
+		expect[val]++
+	}
+
+	got := map[string]int{}
+	prev := ""
+	if err := tbl.Each(func(val string, count int) {
+		if val <= prev {
+			t.Errorf("expected values in ascending order. %q came after %q", val, prev)
+		}
+		prev = val
+		got[val] = count
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("frequency mismatch (-want +got):\n%s", diff)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected spilled runs to be removed, found %d files", len(files))
+	}
+}
+
+func TestTopFreqs(t *testing.T) {
+	top := &topFreqs{n: 2}
+	top.Add("a", 3)
+	top.Add("b", 10)
+	top.Add("c", 1)
+	top.Add("d", 5)
+
+	expect := map[string]int{"b": 10, "d": 5}
+	if diff := cmp.Diff(expect, top.Map()); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBoundedMemoryAccumulator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_stats_bounded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rows := make([]string, 0, 300)
+	for i := 0; i < 300; i++ {
+		rows = append(rows, fmt.Sprintf(`["color_%d", %d]`, i%20, i))
+	}
+	rows = append(rows, `["lonely", 300]`)
+	body := "[" + strings.Join(rows, ",") + "]"
+
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r, func(o *Options) {
+		o.BoundedMemory = true
+		o.MaxFrequencies = 5
+		o.SpillDir = dir
+	})
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+	got := ToMap(acc)
+
+	str := got[0]
+	if str["count"] != 301 || str["distinct"] != 21 || str["unique"] != 1 {
+		t.Errorf("string stats mismatch. got count: %v, distinct: %v, unique: %v", str["count"], str["distinct"], str["unique"])
+	}
+	if freqs, ok := str["frequencies"].(map[string]int); !ok || len(freqs) != 5 {
+		t.Errorf("expected frequencies to be capped at 5 values, got: %v", str["frequencies"])
+	}
+
+	num := got[1]
+	if num["count"] != 301 || num["min"] != float64(0) || num["max"] != float64(300) {
+		t.Errorf("numeric stats mismatch. got count: %v, min: %v, max: %v", num["count"], num["min"], num["max"])
+	}
+	if median, ok := num["median"].(float64); !ok || median < 145 || median > 155 {
+		t.Errorf("median: expected ~150, got: %v", num["median"])
+	}
+	if distinct, ok := num["distinct"].(int); !ok || distinct < 290 || distinct > 310 {
+		t.Errorf("distinct: expected ~301, got: %v", num["distinct"])
+	}
+}
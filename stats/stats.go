@@ -4,9 +4,12 @@ package stats
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"os"
 	"sort"
 
 	logger "github.com/ipfs/go-log"
@@ -29,31 +32,58 @@ var (
 // Stats can generate an array of statistical info for a dataset
 type Stats struct {
 	cache Cache
+	opts  *Options
+}
+
+// Options configures how stats are calculated
+type Options struct {
+	// BoundedMemory calculates stats in a fixed amount of memory per field,
+	// regardless of dataset size. Numeric medians, quantiles and distinct
+	// counts are always approximated, and string frequency tables are spilled
+	// to disk
+	BoundedMemory bool
+	// MaxFrequencies is the number of distinct values a frequency table holds
+	// in memory before spilling to disk, and the number of frequencies reported
+	// in bounded memory mode. defaults to StopFreqCountThreshold
+	MaxFrequencies int
+	// SpillDir is the directory frequency tables are spilled to. defaults to
+	// the OS temp directory
+	SpillDir string
+}
+
+func newOptions(opts []func(*Options)) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.MaxFrequencies <= 0 {
+		o.MaxFrequencies = StopFreqCountThreshold
+	}
+	if o.SpillDir == "" {
+		o.SpillDir = os.TempDir()
+	}
+	return o
 }
 
 // New allocates a Stats service
-func New(cache Cache) *Stats {
+func New(cache Cache, opts ...func(o *Options)) *Stats {
 	if cache == nil {
-		return &Stats{
-			cache: nilCache(false),
-		}
+		cache = nilCache(false)
 	}
 	return &Stats{
 		cache: cache,
+		opts:  newOptions(opts),
 	}
 }
 
 // JSON gets stats data as reader of JSON-formatted bytes
 func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err error) {
-	// check cache if there is a Path
-	// TODO (ramfox): when we are calculating stats on fsi linked
-	// datasets, we need a different metric other the `dataset.Path` to
-	// identify and store stats, since FSI datasets will not have
-	// a `dataset.Path`. This metric should perhaps come out of the
-	// `dataset.BodyFile()` since we must have a bodyFile in order to
-	// calculate the stats
-	if ds.Path != "" {
-		if r, err := s.cache.JSON(ctx, ds.Path); err == nil {
+	key, err := cacheKey(ds)
+	if err != nil {
+		log.Debugf("determining stats cache key: %s", err)
+	}
+	if key != "" {
+		if r, err := s.cache.JSON(ctx, key); err == nil {
 			return r, nil
 		}
 	}
@@ -71,7 +101,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 		return nil, err
 	}
 
-	acc := NewAccumulator(rdr)
+	acc := newAccumulatorReader(rdr, s.opts)
 	for {
 		if _, err := acc.ReadEntry(); err != nil {
 			if err.Error() == "EOF" {
@@ -87,9 +117,9 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 		return nil, err
 	}
 
-	if ds.Path != "" {
+	if key != "" {
 		go func() {
-			if err := s.cache.PutJSON(context.Background(), ds.Path, bytes.NewReader(data)); err != nil {
+			if err := s.cache.PutJSON(context.Background(), key, bytes.NewReader(data)); err != nil {
 				log.Debugf("putting stats in cache: %v", err.Error())
 			}
 		}()
@@ -98,6 +128,56 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 	return bytes.NewReader(data), nil
 }
 
+// cacheKey determines the key stats for a dataset are stored under. Datasets
+// with a path are keyed by that path. Datasets without a path, like those
+// read from an FSI-linked working directory, are keyed by a checksum of the
+// body file's contents and the structure used to read it, which invalidates
+// cached stats whenever the body changes on disk. cacheKey returns the empty
+// string if no key can be determined
+func cacheKey(ds *dataset.Dataset) (string, error) {
+	if ds.Path != "" {
+		return ds.Path, nil
+	}
+	if ds.Structure == nil {
+		return "", nil
+	}
+
+	bodyPath := ds.BodyPath
+	if bodyPath == "" && ds.BodyFile() != nil {
+		bodyPath = ds.BodyFile().FullPath()
+	}
+	if bodyPath == "" {
+		return "", nil
+	}
+	f, err := os.Open(bodyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// body isn't stored on the local filesystem
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	// only the fields that affect how the body is read contribute to the key
+	st, err := json.Marshal(map[string]interface{}{
+		"format":       ds.Structure.Format,
+		"formatConfig": ds.Structure.FormatConfig,
+		"schema":       ds.Structure.Schema,
+	})
+	if err != nil {
+		return "", err
+	}
+	h.Write(st)
+
+	return fmt.Sprintf("/body/%x", h.Sum(nil)), nil
+}
+
 // Statser produces a slice of Stat objects
 type Statser interface {
 	Stats() []Stat
@@ -132,6 +212,7 @@ type Stat interface {
 // after a call to Close
 type Accumulator struct {
 	r     dsio.EntryReader
+	opts  *Options
 	stats accumulator
 }
 
@@ -142,8 +223,12 @@ var (
 )
 
 // NewAccumulator wraps an entry reader to create a stat accumulator
-func NewAccumulator(r dsio.EntryReader) *Accumulator {
-	return &Accumulator{r: r}
+func NewAccumulator(r dsio.EntryReader, opts ...func(o *Options)) *Accumulator {
+	return newAccumulatorReader(r, newOptions(opts))
+}
+
+func newAccumulatorReader(r dsio.EntryReader, opts *Options) *Accumulator {
+	return &Accumulator{r: r, opts: opts}
 }
 
 // Stats gets the statistics created by the accumulator
@@ -169,7 +254,7 @@ func (r *Accumulator) ReadEntry() (dsio.Entry, error) {
 		return ent, err
 	}
 	if r.stats == nil {
-		r.stats = newAccumulator(ent.Value)
+		r.stats = newAccumulator(ent.Value, r.opts)
 	}
 	r.stats.Write(ent)
 	return ent, nil
@@ -190,26 +275,36 @@ type accumulator interface {
 	Close()
 }
 
-func newAccumulator(val interface{}) accumulator {
+func newAccumulator(val interface{}, opts *Options) accumulator {
 	switch val.(type) {
 	default:
 		return &nullAcc{}
 	case float64, float32:
+		if opts.BoundedMemory {
+			return newBoundedNumericAcc("number")
+		}
 		return newNumericAcc("number")
 	case int, int32, int64:
+		if opts.BoundedMemory {
+			return newBoundedNumericAcc("integer")
+		}
 		return newNumericAcc("integer")
 	case string:
+		if opts.BoundedMemory {
+			return newBoundedStringAcc(opts.SpillDir, opts.MaxFrequencies)
+		}
 		return newStringAcc()
 	case bool:
 		return &boolAcc{}
 	case map[string]interface{}:
-		return &objectAcc{children: map[string]accumulator{}}
+		return &objectAcc{opts: opts, children: map[string]accumulator{}}
 	case []interface{}:
-		return &arrayAcc{}
+		return &arrayAcc{opts: opts}
 	}
 }
 
 type objectAcc struct {
+	opts     *Options
 	children map[string]accumulator
 }
 
@@ -242,7 +337,7 @@ func (acc *objectAcc) Write(e dsio.Entry) {
 	if mapEntry, ok := e.Value.(map[string]interface{}); ok {
 		for key, val := range mapEntry {
 			if _, ok := acc.children[key]; !ok {
-				acc.children[key] = newAccumulator(val)
+				acc.children[key] = newAccumulator(val, acc.opts)
 			}
 			acc.children[key].Write(dsio.Entry{Key: key, Value: val})
 		}
@@ -266,6 +361,7 @@ func (acc *objectAcc) Close() {
 }
 
 type arrayAcc struct {
+	opts     *Options
 	children []accumulator
 }
 
@@ -291,7 +387,7 @@ func (acc *arrayAcc) Write(e dsio.Entry) {
 	if arrayEntry, ok := e.Value.([]interface{}); ok {
 		for i, val := range arrayEntry {
 			if len(acc.children) == i {
-				acc.children = append(acc.children, newAccumulator(val))
+				acc.children = append(acc.children, newAccumulator(val, acc.opts))
 			}
 			acc.children[i].Write(dsio.Entry{Index: i, Value: val})
 		}
@@ -329,20 +425,55 @@ type numericAcc struct {
 	max       float64
 	mean      float64
 	median    float64
+	distinct  int
+	quantiles map[string]float64
 	dividers  []float64
 	histogram []float64
+
+	// values accumulates every value written, up to a threshold. while values
+	// is non-nil stats are calculated exactly
+	values []float64
+	// once the number of values passes the threshold, values is dropped &
+	// stats are approximated with sketches
+	sketch         *quantileSketch
+	distinctSketch *hyperLogLog
 }
 
 var _ accumulator = (*numericAcc)(nil)
 
+// reportedQuantiles lists the quantiles numeric accumulators report, keyed by
+// their name in stat output
+var reportedQuantiles = []struct {
+	key string
+	p   float64
+}{
+	{"p1", 0.01},
+	{"p25", 0.25},
+	{"p75", 0.75},
+	{"p99", 0.99},
+}
+
 func newNumericAcc(typ string) *numericAcc {
 	return &numericAcc{
 		typ:    typ,
 		max:    float64(minInt),
 		min:    float64(maxInt),
 		median: maxFloat,
-		// use histogram to accumulate values
-		histogram: make([]float64, 0, StopFreqCountThreshold*100),
+		// use values to accumulate values
+		values: make([]float64, 0, StopFreqCountThreshold*100),
+	}
+}
+
+// newBoundedNumericAcc creates a numeric accumulator that never retains
+// values, approximating stats with sketches from the first value written
+func newBoundedNumericAcc(typ string) *numericAcc {
+	return &numericAcc{
+		typ:            typ,
+		max:            float64(minInt),
+		min:            float64(maxInt),
+		median:         maxFloat,
+		sketch:         newQuantileSketch(defaultSketchCompression),
+		distinctSketch: newHyperLogLog(),
 	}
 }
 
@@ -367,11 +498,22 @@ func (acc *numericAcc) Write(e dsio.Entry) {
 		return
 	}
 
-	if acc.histogram != nil {
-		acc.histogram = append(acc.histogram, v)
-		if len(acc.histogram) == StopFreqCountThreshold*100 {
-			acc.histogram = nil
+	if acc.values != nil {
+		acc.values = append(acc.values, v)
+		if len(acc.values) == StopFreqCountThreshold*100 {
+			// switch to approximating stats, seeding sketches with all values
+			// seen so far
+			acc.sketch = newQuantileSketch(defaultSketchCompression)
+			acc.distinctSketch = newHyperLogLog()
+			for _, val := range acc.values {
+				acc.sketch.Add(val)
+				acc.distinctSketch.AddFloat(val)
+			}
+			acc.values = nil
 		}
+	} else {
+		acc.sketch.Add(v)
+		acc.distinctSketch.AddFloat(v)
 	}
 
 	acc.mean += v
@@ -392,16 +534,21 @@ func (acc *numericAcc) Map() map[string]interface{} {
 		return map[string]interface{}{"count": 0}
 	}
 	m := map[string]interface{}{
-		"mean":  acc.mean,
-		"count": acc.count,
-		"min":   acc.min,
-		"max":   acc.max,
+		"mean":     acc.mean,
+		"count":    acc.count,
+		"min":      acc.min,
+		"max":      acc.max,
+		"distinct": acc.distinct,
 	}
 
 	if acc.median != maxFloat {
 		m["median"] = acc.median
 	}
 
+	if acc.quantiles != nil {
+		m["quantiles"] = acc.quantiles
+	}
+
 	if acc.histogram != nil {
 		m["histogram"] = map[string][]float64{
 			"bins":        acc.dividers,
@@ -414,27 +561,57 @@ func (acc *numericAcc) Map() map[string]interface{} {
 
 // Close finalizes the accumulator
 func (acc *numericAcc) Close() {
+	if acc.count == 0 {
+		return
+	}
+
 	// finalize avg
 	acc.mean = acc.mean / float64(acc.count)
 
-	if len(acc.histogram) > 0 {
-		sort.Float64Slice(acc.histogram).Sort()
+	// turn values into a histogram
+	nBins := 10
+	acc.dividers = make([]float64, nBins+1)
+	// Increase the maximum divider so that the maximum value of x is contained
+	// within the last bucket.
+	gonumfloats.Span(acc.dividers, acc.min, acc.max+1)
+	acc.quantiles = make(map[string]float64, len(reportedQuantiles))
 
-		if len(acc.histogram)%2 == 0 && len(acc.histogram) > 1 {
-			acc.median = (acc.histogram[len(acc.histogram)/2-1] + acc.histogram[len(acc.histogram)/2]) / float64(2)
-			// acc.median = (acc.histogram[len(acc.histogram)/2] + acc.histogram[len(acc.histogram)/2+1]) / float64(2)
+	if acc.values != nil {
+		sort.Float64Slice(acc.values).Sort()
+
+		if len(acc.values)%2 == 0 && len(acc.values) > 1 {
+			acc.median = (acc.values[len(acc.values)/2-1] + acc.values[len(acc.values)/2]) / float64(2)
 		} else {
-			acc.median = acc.histogram[len(acc.histogram)/2]
+			acc.median = acc.values[len(acc.values)/2]
+		}
+
+		for _, q := range reportedQuantiles {
+			acc.quantiles[q.key] = gonumstat.Quantile(q.p, gonumstat.Empirical, acc.values, nil)
+		}
+
+		for i, v := range acc.values {
+			if i == 0 || v != acc.values[i-1] {
+				acc.distinct++
+			}
 		}
 
-		// turn values into a histogram
-		nBins := 10
-		acc.dividers = make([]float64, nBins+1)
-		// Increase the maximum divider so that the maximum value of x is contained
-		// within the last bucket.
-		gonumfloats.Span(acc.dividers, acc.min, acc.max+1)
 		// Span includes the min and the max. Trim the dividers to create 10 buckets
-		acc.histogram = gonumstat.Histogram(nil, acc.dividers, acc.histogram, nil)
+		acc.histogram = gonumstat.Histogram(nil, acc.dividers, acc.values, nil)
+		acc.values = nil
+		return
+	}
+
+	acc.median = acc.sketch.Quantile(0.5)
+	for _, q := range reportedQuantiles {
+		acc.quantiles[q.key] = acc.sketch.Quantile(q.p)
+	}
+	acc.distinct = acc.distinctSketch.Count()
+
+	// estimate bucket frequencies from the cumulative distribution
+	acc.histogram = make([]float64, nBins)
+	for i := range acc.histogram {
+		frac := acc.sketch.CDF(acc.dividers[i+1]) - acc.sketch.CDF(acc.dividers[i])
+		acc.histogram[i] = math.Round(frac * float64(acc.count))
 	}
 }
 
@@ -443,7 +620,15 @@ type stringAcc struct {
 	minLength   int
 	maxLength   int
 	unique      int
+	distinct    int
 	frequencies map[string]int
+	// distinctSketch approximates the number of distinct values once
+	// frequencies are no longer being tracked
+	distinctSketch *hyperLogLog
+	// in bounded memory mode values are counted in a table that spills to
+	// disk, and only the top maxFrequencies values are reported
+	spill          *freqTable
+	maxFrequencies int
 }
 
 var _ accumulator = (*stringAcc)(nil)
@@ -456,6 +641,17 @@ func newStringAcc() *stringAcc {
 	}
 }
 
+// newBoundedStringAcc creates a string accumulator that counts frequencies
+// in a table that spills to dir once it holds max distinct values
+func newBoundedStringAcc(dir string, max int) *stringAcc {
+	return &stringAcc{
+		maxLength:      minInt,
+		minLength:      maxInt,
+		spill:          newFreqTable(dir, max),
+		maxFrequencies: max,
+	}
+}
+
 // Type indicates this stat accumulator kind
 func (acc *stringAcc) Type() string { return "string" }
 
@@ -464,11 +660,19 @@ func (acc *stringAcc) Write(e dsio.Entry) {
 	if str, ok := e.Value.(string); ok {
 		acc.count++
 
-		if acc.frequencies != nil {
+		if acc.spill != nil {
+			acc.spill.Add(str)
+		} else if acc.frequencies != nil {
 			acc.frequencies[str]++
 			if len(acc.frequencies) >= StopFreqCountThreshold {
+				acc.distinctSketch = newHyperLogLog()
+				for key := range acc.frequencies {
+					acc.distinctSketch.AddString(key)
+				}
 				acc.frequencies = nil
 			}
+		} else {
+			acc.distinctSketch.AddString(str)
 		}
 
 		if len(str) < acc.minLength {
@@ -492,6 +696,7 @@ func (acc *stringAcc) Map() map[string]interface{} {
 		"count":     acc.count,
 		"minLength": acc.minLength,
 		"maxLength": acc.maxLength,
+		"distinct":  acc.distinct,
 	}
 
 	if acc.unique != 0 {
@@ -506,7 +711,12 @@ func (acc *stringAcc) Map() map[string]interface{} {
 
 // Close finalizes the accumulator
 func (acc *stringAcc) Close() {
+	if acc.spill != nil {
+		acc.closeSpill()
+		return
+	}
 	if acc.frequencies != nil {
+		acc.distinct = len(acc.frequencies)
 		// determine unique values
 		for key, freq := range acc.frequencies {
 			if freq == 1 {
@@ -517,6 +727,30 @@ func (acc *stringAcc) Close() {
 		if len(acc.frequencies) == 0 {
 			acc.frequencies = nil
 		}
+	} else if acc.distinctSketch != nil {
+		acc.distinct = acc.distinctSketch.Count()
+	}
+}
+
+// closeSpill merges spilled frequency counts, calculating exact distinct &
+// unique counts, and keeping the most frequent non-unique values
+func (acc *stringAcc) closeSpill() {
+	top := &topFreqs{n: acc.maxFrequencies}
+	err := acc.spill.Each(func(val string, count int) {
+		acc.distinct++
+		if count == 1 {
+			acc.unique++
+			return
+		}
+		top.Add(val, count)
+	})
+	acc.spill = nil
+	if err != nil {
+		log.Errorf("reading spilled frequencies: %s", err)
+		return
+	}
+	if freqs := top.Map(); len(freqs) > 0 {
+		acc.frequencies = freqs
 	}
 }
 
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -31,6 +33,7 @@ func TestStrings(t *testing.T) {
 				"count":       5,
 				"minLength":   1,
 				"maxLength":   4,
+				"distinct":    4,
 				"unique":      3,
 				"frequencies": map[string]int{"a": 2},
 			},
@@ -60,26 +63,30 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"type":       "boolean",
 			},
 			{
-				"key":    "float",
-				"count":  5,
-				"min":    float64(1.1),
-				"max":    float64(5.5),
-				"mean":   float64(3.08),
-				"median": float64(3.3),
-				"type":   "numeric",
+				"key":       "float",
+				"count":     5,
+				"min":       float64(1.1),
+				"max":       float64(5.5),
+				"mean":      float64(3.08),
+				"median":    float64(3.3),
+				"distinct":  4,
+				"quantiles": map[string]float64{"p1": 1.1, "p25": 1.1, "p75": 4.4, "p99": 5.5},
+				"type":      "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
 				},
 			},
 			{
-				"key":    "int",
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(2.8),
-				"median": float64(3),
-				"type":   "numeric",
+				"key":       "int",
+				"count":     5,
+				"min":       float64(1),
+				"max":       float64(5),
+				"mean":      float64(2.8),
+				"median":    float64(3),
+				"distinct":  4,
+				"quantiles": map[string]float64{"p1": 1, "p25": 1, "p75": 4, "p99": 5},
+				"type":      "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
@@ -95,6 +102,7 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"count":       5,
 				"minLength":   1,
 				"maxLength":   5,
+				"distinct":    4,
 				"type":        "string",
 				"unique":      3,
 				"frequencies": map[string]int{"aaa": 2},
@@ -111,12 +119,14 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 		]`,
 		[]map[string]interface{}{
 			{
-				"count":  2,
-				"min":    float64(1),
-				"max":    float64(2),
-				"mean":   float64(1.5),
-				"median": float64(1.5),
-				"type":   "numeric",
+				"count":     2,
+				"min":       float64(1),
+				"max":       float64(2),
+				"mean":      float64(1.5),
+				"median":    float64(1.5),
+				"distinct":  2,
+				"quantiles": map[string]float64{"p1": 1, "p25": 1, "p75": 2, "p99": 2},
+				"type":      "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.2, 1.4, 1.6, 1.8, 2, 2.2, 2.4000000000000004, 2.6, 2.8, 3},
 					"frequencies": {1, 0, 0, 0, 0, 1, 0, 0, 0, 0},
@@ -141,24 +151,28 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 		}`,
 		[]map[string]interface{}{
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(2.8),
-				"median": float64(3),
-				"type":   "numeric",
+				"count":     5,
+				"min":       float64(1),
+				"max":       float64(5),
+				"mean":      float64(2.8),
+				"median":    float64(3),
+				"distinct":  4,
+				"quantiles": map[string]float64{"p1": 1, "p25": 1, "p75": 4, "p99": 5},
+				"type":      "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
 				},
 			},
 			{
-				"count":  5,
-				"min":    float64(1.1),
-				"max":    float64(5.5),
-				"mean":   float64(3.08),
-				"median": float64(2.2),
-				"type":   "numeric",
+				"count":     5,
+				"min":       float64(1.1),
+				"max":       float64(5.5),
+				"mean":      float64(3.08),
+				"median":    float64(2.2),
+				"distinct":  4,
+				"quantiles": map[string]float64{"p1": 1.1, "p25": 2.2, "p75": 4.4, "p99": 5.5},
+				"type":      "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {1, 0, 2, 0, 0, 0, 1, 0, 1, 0},
@@ -178,6 +192,7 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 				"count":       5,
 				"minLength":   1,
 				"maxLength":   5,
+				"distinct":    4,
 				"type":        "string",
 				"unique":      3,
 				"frequencies": map[string]int{"aaa": 2},
@@ -208,15 +223,18 @@ func TestFreqThreshold(t *testing.T) {
 				"count":       5,
 				"minLength":   11,
 				"maxLength":   11,
+				"distinct":    1,
 				"type":        "string",
 				"frequencies": map[string]int{"abcdefghijk": 5},
 			},
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(1),
-				"mean":   float64(1),
-				"median": float64(1),
+				"count":     5,
+				"min":       float64(1),
+				"max":       float64(1),
+				"mean":      float64(1),
+				"median":    float64(1),
+				"distinct":  1,
+				"quantiles": map[string]float64{"p1": 1, "p25": 1, "p75": 1, "p99": 1},
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7000000000000002, 1.8, 1.9, 2},
@@ -242,14 +260,17 @@ func TestFreqThreshold(t *testing.T) {
 				"count":     5,
 				"minLength": 1,
 				"maxLength": 1,
+				"distinct":  5,
 				"type":      "string",
 			},
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(3),
-				"median": float64(3),
+				"count":     5,
+				"min":       float64(1),
+				"max":       float64(5),
+				"mean":      float64(3),
+				"median":    float64(3),
+				"distinct":  5,
+				"quantiles": map[string]float64{"p1": 1, "p25": 2, "p75": 4, "p99": 5},
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
@@ -376,7 +397,7 @@ func TestJSON(t *testing.T) {
 			"json",
 			`{"type":"array"}`,
 			`["a","a","bb","ccc","dddd"]`,
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema array of object entries",
 			"json",
@@ -388,13 +409,13 @@ func TestJSON(t *testing.T) {
 				{"int": 4, "float": 4.4, "nil": null, "bool": true, "string": "aaa"},
 				{"int": 5, "float": 5.5, "nil": null, "bool": false, "string": "aaaaa"}
 			]`,
-			[]byte(`[{"count":5,"falseCount":3,"key":"bool","trueCount":2,"type":"boolean"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"frequencies":{"aaa":2},"key":"string","maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"falseCount":3,"key":"bool","trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"quantiles":{"p1":1.1,"p25":1.1,"p75":4.4,"p99":5.5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"quantiles":{"p1":1,"p25":1,"p75":4,"p99":5},"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"key":"string","maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"csv: an array of strings",
 			"csv",
 			`{"type":"array", "items": { "type": "array", "items": [{ "title": "str_col", "type": "string" }] }}`,
 			"a\na\nbb\nccc\ndddd",
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"csv: all types identity schema array of object entries",
 			"csv",
@@ -427,7 +448,7 @@ func TestJSON(t *testing.T) {
 				"type": "array"
 			 }`,
 			"1,1.1,,false,a\n1,1.1,,true,aa\n3,3.3,,false,aaa\n4,4.4,,true,aaa\n5,5.5,,false,aaaaa",
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"quantiles":{"p1":1,"p25":1,"p75":4,"p99":5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"quantiles":{"p1":1.1,"p25":1.1,"p75":4.4,"p99":5.5},"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema object of array entries",
 			"json",
@@ -439,7 +460,7 @@ func TestJSON(t *testing.T) {
 					"d" : [4,4.4,null,true,"aaa"],
 					"e" : [5,5.5,null,false,"aaaaa"]
 				}`,
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"quantiles":{"p1":1,"p25":1,"p75":4,"p99":5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"quantiles":{"p1":1.1,"p25":2.2,"p75":4.4,"p99":5.5},"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: array of object of array of strings",
 			"json",
@@ -449,7 +470,7 @@ func TestJSON(t *testing.T) {
 					{"ids": [1,2,3,4,5,6] },
 					{"ids": ["b",20,"c"] }
 				]`,
-			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"maxLength":1,"minLength":1,"unique":2},{"count":1,"maxLength":1,"minLength":1,"unique":1},{"count":2,"frequencies":{"c":2},"maxLength":1,"minLength":1},{"count":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4},{"count":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5},{"count":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6}]},{"count":1,"falseCount":0,"key":"is_great","trueCount":1,"type":"boolean"}]`),
+			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"distinct":2,"maxLength":1,"minLength":1,"unique":2},{"count":1,"distinct":1,"maxLength":1,"minLength":1,"unique":1},{"count":2,"distinct":1,"frequencies":{"c":2},"maxLength":1,"minLength":1},{"count":1,"distinct":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4,"quantiles":{"p1":4,"p25":4,"p75":4,"p99":4}},{"count":1,"distinct":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5,"quantiles":{"p1":5,"p25":5,"p75":5,"p99":5}},{"count":1,"distinct":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6,"quantiles":{"p1":6,"p25":6,"p75":6,"p99":6}}]},{"count":1,"falseCount":0,"key":"is_great","trueCount":1,"type":"boolean"}]`),
 		},
 	}
 	for i, c := range goodCases {
@@ -480,3 +501,56 @@ func TestJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheKey(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "test_stats_cache_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	bodyPath := filepath.Join(tmp, "body.csv")
+	if err := ioutil.WriteFile(bodyPath, []byte("a,1\nb,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	csvStructure := func() *dataset.Structure {
+		return &dataset.Structure{Format: "csv", Schema: dataset.BaseSchemaArray}
+	}
+
+	if key, _ := cacheKey(&dataset.Dataset{Path: "/ipfs/QmFoo", BodyPath: bodyPath, Structure: csvStructure()}); key != "/ipfs/QmFoo" {
+		t.Errorf("expected dataset with a path to be keyed by path. got: %q", key)
+	}
+	if key, _ := cacheKey(&dataset.Dataset{BodyPath: "/not/a/local/file.csv", Structure: csvStructure()}); key != "" {
+		t.Errorf("expected dataset without a local body file to have no key. got: %q", key)
+	}
+
+	a, err := cacheKey(&dataset.Dataset{BodyPath: bodyPath, Structure: csvStructure()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == "" {
+		t.Fatal("expected a working directory dataset to have a cache key")
+	}
+
+	b, err := cacheKey(&dataset.Dataset{BodyPath: bodyPath, Structure: csvStructure()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("expected unchanged body to produce the same key. %q != %q", a, b)
+	}
+
+	st := csvStructure()
+	st.FormatConfig = map[string]interface{}{"headerRow": true}
+	if c, _ := cacheKey(&dataset.Dataset{BodyPath: bodyPath, Structure: st}); c == a {
+		t.Error("expected changing structure to change the cache key")
+	}
+
+	if err := ioutil.WriteFile(bodyPath, []byte("a,1\nb,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := cacheKey(&dataset.Dataset{BodyPath: bodyPath, Structure: csvStructure()}); c == a {
+		t.Error("expected changing body file contents to change the cache key")
+	}
+}